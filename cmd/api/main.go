@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/JuanCS-Dev/typecraft/internal/api/handlers"
 	"github.com/JuanCS-Dev/typecraft/internal/config"
-	"github.com/JuanCS-Dev/typecraft/internal/database"
+	"github.com/JuanCS-Dev/typecraft/internal/repository"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+	"github.com/JuanCS-Dev/typecraft/internal/worker"
+	"github.com/JuanCS-Dev/typecraft/pkg/converter"
 	"github.com/gin-gonic/gin"
 )
 
@@ -39,16 +46,29 @@ func main() {
 	}
 	log.Println("✅ Configurações carregadas")
 
-	// Conectar ao banco de dados
-	log.Println("🔌 Conectando ao banco de dados...")
-	if err := database.Connect(cfg.DatabaseURL); err != nil {
-		log.Fatalf("❌ Erro ao conectar ao banco: %v", err)
+	// Subcomando: api migrate up|down [n]|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("❌ Erro nas migrations: %v", err)
+		}
+		return
+	}
+
+	// JWT_SECRET assina os access tokens e os links de download: sem um
+	// segredo próprio a API não sobe
+	if err := cfg.CheckJWTSecret(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if cfg.JWTSecret == config.ExampleJWTSecret {
+		log.Println("⚠️  Warning: ALLOW_INSECURE_JWT_SECRET ativo, usando o JWT_SECRET de exemplo (apenas desenvolvimento)")
 	}
 
-	// Executar migrations
-	log.Println("🔄 Executando migrations...")
-	if err := database.Migrate(); err != nil {
-		log.Fatalf("❌ Erro nas migrations: %v", err)
+	// Conectar ao banco de dados (DATABASE_DRIVER: postgres, sqlite ou memory)
+	// e executar migrations
+	log.Printf("🔌 Conectando ao banco de dados (%s)...", cfg.DatabaseDriver)
+	repos, err := repository.Open(cfg)
+	if err != nil {
+		log.Fatalf("❌ Erro ao conectar ao banco: %v", err)
 	}
 
 	// Configurar Gin
@@ -64,7 +84,7 @@ func main() {
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
-		if err := database.Health(); err != nil {
+		if err := repos.Health(); err != nil {
 			c.JSON(500, gin.H{"status": "unhealthy", "error": err.Error()})
 			return
 		}
@@ -80,8 +100,112 @@ func main() {
 		})
 	})
 
+	// Eventos e progresso das gerações, compartilhados com o worker via Redis
+	// (REDIS_URL); com DATABASE_DRIVER=memory ficam na memória da API
+	if !cfg.UseRedis() && cfg.DatabaseDriver != repository.DriverMemory {
+		log.Println("⚠️  Warning: REDIS_URL não configurado, progresso e eventos na memória da API (invisíveis para outro worker)")
+	}
+	events, err := service.NewEventStore(cfg)
+	if err != nil {
+		log.Fatalf("❌ Erro ao configurar eventos: %v", err)
+	}
+
+	// Webhooks recebem os mesmos eventos; o worker faz as entregas
+	webhooks := service.NewWebhookService(repos.Webhooks, repos.Projects, repos.Jobs)
+	publisher := service.EventPublishers{events, webhooks}
+
+	// Manuscritos enviados vão para o storage compartilhado com o worker
+	store, err := storage.New(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("❌ Erro ao configurar storage: %v", err)
+	}
+
+	// Autenticação: JWTs assinados com JWT_SECRET
+	auth := service.NewAuthService(
+		repos.Users,
+		cfg.JWTSecret,
+		service.WithAccessTokenTTL(time.Duration(cfg.AccessTokenTTLMinutes)*time.Minute),
+		service.WithRefreshTokenTTL(time.Duration(cfg.RefreshTokenTTLHours)*time.Hour),
+	)
+	authHandler := handlers.NewAuthHandler(auth)
+
 	// Inicializar handlers
-	projectHandler := handlers.NewProjectHandler()
+	projects := service.NewProjectService(
+		repos.Projects,
+		repos.Jobs,
+		service.WithProjectEvents(publisher),
+		service.WithProjectStorage(store),
+		service.WithProjectRevisions(repos.Revisions),
+		service.WithTrashRetention(time.Duration(cfg.TrashRetentionDays)*24*time.Hour),
+	)
+	projectHandler := handlers.NewProjectHandler(projects)
+	jobs := service.NewJobService(
+		repos.Jobs,
+		repos.JobLogs,
+		repos.Projects,
+		service.WithJobEvents(publisher),
+	)
+	jobHandler := handlers.NewJobHandler(jobs)
+	webhookHandler := handlers.NewWebhookHandler(webhooks)
+	artifactHandler := handlers.NewArtifactHandler(service.NewArtifactService(
+		repos.Artifacts,
+		repos.Projects,
+		store,
+		cfg.JWTSecret,
+		service.WithDownloadTTL(time.Duration(cfg.DownloadURLTTLSeconds)*time.Second),
+	))
+	eventsHandler := handlers.NewEventsHandler(projects, events)
+
+	// Gerações de livros: a API só enfileira os jobs de exportação; quem os
+	// executa é o worker (cmd/worker ou o embutido). O progresso vem do
+	// store compartilhado com o worker
+	progress, err := service.NewProgressStore(cfg)
+	if err != nil {
+		log.Fatalf("❌ Erro ao configurar progresso: %v", err)
+	}
+	outputDir := filepath.Join(cfg.TempDir, "output")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		log.Fatalf("❌ Erro ao criar diretório de saída: %v", err)
+	}
+	orchestrator := service.NewBookOrchestrator(
+		repos.ProjectStore(),
+		service.NewLocalAnalysisClient(),
+		outputDir,
+		service.WithProgressStore(progress),
+		service.WithEventPublisher(publisher),
+		service.WithStorage(store),
+		service.WithArtifactStore(repos.Artifacts),
+	)
+	generationHandler := handlers.NewBookGenerationHandler(service.NewGenerationJobs(
+		orchestrator,
+		repos.Jobs,
+		service.WithGenerationRevisions(repos.Revisions),
+	), jobs)
+
+	// Cada upload vira uma revisão numerada do manuscrito; as revisões podem
+	// ser comparadas capítulo a capítulo
+	revisionHandler := handlers.NewRevisionHandler(service.NewRevisionService(
+		repos.Revisions,
+		repos.Projects,
+		store,
+		cfg.TempDir,
+	))
+
+	// Uploads de manuscrito (inteiros ou em partes retomáveis), validados
+	// contra os formatos de entrada do pandoc quando disponível
+	uploadOpts := []service.UploadServiceOption{
+		service.WithMaxUploadSize(int64(cfg.MaxFileSizeMB) << 20),
+	}
+	if pandoc, err := converter.NewPandocConverter(); err == nil {
+		uploadOpts = append(uploadOpts, service.WithInputFormats(pandoc.ListInputFormats))
+	}
+	uploadHandler := handlers.NewUploadHandler(service.NewUploadService(
+		repos.Uploads,
+		repos.Projects,
+		projects,
+		filepath.Join(cfg.TempDir, "uploads"),
+		uploadOpts...,
+	))
 	
 	processingHandler, err := handlers.NewProcessingHandler()
 	if err != nil {
@@ -89,7 +213,7 @@ func main() {
 	}
 	
 	// Analysis handler (requires AI setup)
-	analysisHandler, err := handlers.NewAnalysisHandlerWithDeps()
+	analysisHandler, err := handlers.NewAnalysisHandlerWithDeps(repos, projects)
 	if err != nil {
 		log.Printf("⚠️  Warning: Analysis handler não disponível: %v", err)
 	}
@@ -101,22 +225,56 @@ func main() {
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
+		// Rotas públicas: cadastro, login e renovação de tokens, e os
+		// downloads de links assinados
+		authHandler.RegisterRoutes(v1)
+		artifactHandler.RegisterDownloadRoutes(v1)
+
+		// Todas as demais exigem um access token (Authorization: Bearer) e
+		// só enxergam os projetos do usuário
+		api := v1.Group("", handlers.RequireAuth(auth))
+		authHandler.RegisterAuthenticatedRoutes(api)
+
 		// Projects
-		projects := v1.Group("/projects")
+		projects := api.Group("/projects")
 		{
 			projects.POST("", projectHandler.CreateProject)
 			projects.GET("", projectHandler.ListProjects)
+			projects.GET("/search", projectHandler.SearchProjects)
+			projects.GET("/trash", projectHandler.ListTrash)
 			projects.GET("/:id", projectHandler.GetProject)
 			projects.PATCH("/:id", projectHandler.UpdateProject)
 			projects.DELETE("/:id", projectHandler.DeleteProject)
-			projects.POST("/:id/upload", projectHandler.UploadManuscript)
+			projects.POST("/:id/restore", projectHandler.RestoreProject)
 			projects.POST("/:id/process", projectHandler.ProcessProject)
 			projects.GET("/:id/jobs", projectHandler.GetProjectJobs)
 		}
+
+		// Upload de manuscritos (multipart ou retomável em partes)
+		uploadHandler.RegisterRoutes(api)
+
+		// Revisões do manuscrito e diff entre elas
+		revisionHandler.RegisterRoutes(api)
+
+		// Eventos (SSE: status, jobs, progresso e validação). EventSource não
+		// envia headers: só aqui o token também vale no ?access_token=
+		eventsHandler.RegisterRoutes(v1.Group("", handlers.RequireStreamAuth(auth)))
+
+		// Webhooks (assinaturas e log de entregas)
+		webhookHandler.RegisterRoutes(api)
+
+		// Arquivos gerados e downloads assinados
+		artifactHandler.RegisterRoutes(api)
+
+		// Geração de livros (202 + job) e progresso
+		generationHandler.RegisterRoutes(api)
+
+		// Jobs (administração: listagem, retry, cancelamento, dead-letter)
+		jobHandler.RegisterRoutes(api)
 		
 		// Processing (conversão e renderização direta)
 		if processingHandler != nil {
-			processing := v1.Group("/processing")
+			processing := api.Group("/processing")
 			{
 				processing.POST("/convert", processingHandler.ConvertFile)
 				processing.POST("/pdf", processingHandler.GeneratePDF)
@@ -126,20 +284,31 @@ func main() {
 		
 		// Analysis (AI-powered content analysis)
 		if analysisHandler != nil {
-			v1.POST("/projects/:id/analyze", analysisHandler.AnalyzeProject)
-			v1.GET("/projects/:id/analyses", analysisHandler.GetAnalysisHistory)
-			v1.GET("/projects/:id/metrics", analysisHandler.GetProjectMetrics)
-			v1.GET("/genres", analysisHandler.ListGenres)
+			api.POST("/projects/:id/analyze", analysisHandler.AnalyzeProject)
+			api.GET("/projects/:id/analyses", analysisHandler.GetAnalysisHistory)
+			api.GET("/projects/:id/metrics", analysisHandler.GetProjectMetrics)
+			api.GET("/genres", analysisHandler.ListGenres)
 		}
 		
 		// Design (Sprint 5-6: AI-powered design generation)
-		v1.POST("/projects/:id/design/generate", designHandler.GenerateDesign)
-		v1.GET("/fonts", designHandler.ListFonts)
+		api.POST("/projects/:id/design/generate", designHandler.GenerateDesign)
+		api.GET("/fonts", designHandler.ListFonts)
 		
 		// Render (Sprint 5-6: HTML/CSS and PDF rendering)
-		v1.POST("/projects/:id/render/html", renderHandler.RenderHTML)
-		v1.POST("/projects/:id/render/pdf", renderHandler.RenderPDF)
-		v1.GET("/projects/:id/render/status", renderHandler.GetRenderStatus)
+		api.POST("/projects/:id/render/html", renderHandler.RenderHTML)
+		api.POST("/projects/:id/render/pdf", renderHandler.RenderPDF)
+		api.GET("/projects/:id/render/status", renderHandler.GetRenderStatus)
+	}
+
+	// Sem banco externo nenhum worker enxerga a fila: os jobs rodam aqui
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if cfg.DatabaseDriver == repository.DriverMemory {
+		go func() {
+			if err := runEmbeddedWorker(ctx, cfg, repos, orchestrator, events, publisher, store); err != nil {
+				log.Printf("⚠️  Erro no worker embutido: %v", err)
+			}
+		}()
 	}
 
 	// Iniciar servidor
@@ -161,12 +330,48 @@ func main() {
 	<-quit
 
 	log.Println("🛑 Desligando servidor...")
-	if err := database.Close(); err != nil {
+	cancel()
+	if err := repos.Close(); err != nil {
 		log.Printf("⚠️  Erro ao fechar banco: %v", err)
 	}
 	log.Println("✅ Servidor desligado")
 }
 
+// runEmbeddedWorker processa a fila de jobs no processo da API, como o
+// cmd/worker faria, até ctx ser cancelado. O orquestrador é o mesmo da API,
+// que compartilha com ele o progresso das gerações
+func runEmbeddedWorker(ctx context.Context, cfg *config.Config, repos *repository.Repositories, orchestrator *service.BookOrchestrator, events service.EventStore, publisher service.EventPublisher, store storage.Storage) error {
+	pipeline := service.NewProjectPipeline(orchestrator, cfg.TempDir, service.WithSearchIndex(repos.Projects))
+	generations := service.NewGenerationJobs(orchestrator, repos.Jobs)
+
+	lease := time.Duration(cfg.JobLeaseSeconds) * time.Second
+	w := worker.New(repos.Jobs, repos.Projects, cfg.WorkerConcurrency, worker.WithLease(lease), worker.WithJobLogs(repos.JobLogs), worker.WithEvents(publisher), worker.WithCancellations(events))
+	worker.RegisterServices(w, pipeline, generations)
+
+	go service.NewWebhookDispatcher(repos.Webhooks).Run(ctx)
+
+	// Projetos na lixeira há mais de TRASH_RETENTION_DAYS são expurgados
+	if cfg.TrashRetentionDays > 0 {
+		go newTrashPurger(cfg, repos, store).Run(ctx)
+	}
+
+	log.Printf("⚙️  Worker embutido %s iniciado (concorrência: %d)", w.ID(), cfg.WorkerConcurrency)
+	return w.Run(ctx)
+}
+
+// newTrashPurger cria o expurgo da lixeira sobre os repositórios, apagando
+// também os arquivos do projeto no storage
+func newTrashPurger(cfg *config.Config, repos *repository.Repositories, store storage.Storage) *service.TrashPurger {
+	return service.NewTrashPurger(repos.Projects, service.ProjectData{
+		Jobs:      repos.Jobs,
+		JobLogs:   repos.JobLogs,
+		Analyses:  repos.Analyses,
+		Revisions: repos.Revisions,
+		Artifacts: repos.Artifacts,
+		Uploads:   repos.Uploads,
+	}, store, cfg.TempDir, time.Duration(cfg.TrashRetentionDays)*24*time.Hour)
+}
+
 // corsMiddleware adiciona headers CORS
 func corsMiddleware(allowedOrigins []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -186,8 +391,9 @@ func corsMiddleware(allowedOrigins []string) gin.HandlerFunc {
 		}
 		
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Authorization")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Authorization, Last-Event-ID, Tus-Resumable, Upload-Length, Upload-Offset, Upload-Metadata")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Location, Upload-Offset, Upload-Length, Tus-Resumable, X-Checksum-Sha256")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
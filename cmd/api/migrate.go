@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/config"
+	"github.com/JuanCS-Dev/typecraft/internal/database"
+	"github.com/JuanCS-Dev/typecraft/internal/repository"
+	"gorm.io/gorm"
+)
+
+// runMigrate executa o subcomando migrate:
+//
+//	migrate up        aplica as migrations pendentes
+//	migrate down [n]  desfaz as últimas n migrations (padrão: 1)
+//	migrate status    lista as migrations e quando foram aplicadas
+//
+// Todos falham se o banco estiver à frente do binário.
+func runMigrate(cfg *config.Config, args []string) error {
+	if cfg.DatabaseDriver == repository.DriverMemory {
+		return fmt.Errorf("DATABASE_DRIVER=memory não persiste o schema: use postgres ou sqlite")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("uso: migrate up|down [n]|status")
+	}
+
+	db, err := repository.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	switch args[0] {
+	case "up":
+		return database.Migrate(db)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("número de migrations inválido: %q", args[1])
+			}
+		}
+		return database.Rollback(db, steps)
+	case "status":
+		return printMigrationStatus(db)
+	default:
+		return fmt.Errorf("comando desconhecido: %q (use up, down ou status)", args[0])
+	}
+}
+
+// printMigrationStatus imprime a situação de cada migration e falha se
+// houver pendentes ou se o banco estiver à frente do binário
+func printMigrationStatus(db *gorm.DB) error {
+	statuses, err := database.Status(db)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSÃO\tNOME\tAPLICADA EM")
+	for _, status := range statuses {
+		applied := "pendente"
+		switch {
+		case status.Unknown:
+			applied = status.AppliedAt.Format(time.RFC3339) + " (desconhecida deste binário)"
+		case status.AppliedAt != nil:
+			applied = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, applied)
+	}
+	w.Flush()
+
+	return database.CheckSchema(db)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/config"
+	"github.com/JuanCS-Dev/typecraft/internal/repository"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+	"github.com/JuanCS-Dev/typecraft/internal/worker"
+)
+
+func main() {
+	log.Println("⚙️  Typecraft Worker v0.1.0")
+
+	// Carregar configurações
+	log.Println("📋 Carregando configurações...")
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Erro ao carregar configurações: %v", err)
+	}
+
+	// A fila precisa ser compartilhada com a API: sem banco externo
+	// (DATABASE_DRIVER=memory) a própria API processa os jobs
+	if cfg.DatabaseDriver == repository.DriverMemory {
+		log.Fatalf("❌ DATABASE_DRIVER=memory não é compartilhado entre processos: use postgres ou sqlite")
+	}
+
+	// Conectar ao banco de dados e executar migrations
+	log.Printf("🔌 Conectando ao banco de dados (%s)...", cfg.DatabaseDriver)
+	repos, err := repository.Open(cfg)
+	if err != nil {
+		log.Fatalf("❌ Erro ao conectar ao banco: %v", err)
+	}
+	defer repos.Close()
+
+	// Diretório dos livros gerados
+	outputDir := filepath.Join(cfg.TempDir, "output")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		log.Fatalf("❌ Erro ao criar diretório de saída: %v", err)
+	}
+
+	// Progresso das gerações e eventos, lidos pela API via Redis (REDIS_URL)
+	if !cfg.UseRedis() {
+		log.Println("⚠️  Warning: REDIS_URL não configurado, o progresso e os eventos não chegam à API")
+	}
+	progress, err := service.NewProgressStore(cfg)
+	if err != nil {
+		log.Fatalf("❌ Erro ao configurar progresso: %v", err)
+	}
+
+	// Eventos dos projetos (GET /projects/:id/events na API)
+	events, err := service.NewEventStore(cfg)
+	if err != nil {
+		log.Fatalf("❌ Erro ao configurar eventos: %v", err)
+	}
+
+	// Manuscritos, Markdown intermediário e livros gerados (STORAGE_BACKEND)
+	store, err := storage.New(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("❌ Erro ao configurar storage: %v", err)
+	}
+
+	// Serviços
+	projectRepo := repos.Projects
+	jobRepo := repos.Jobs
+	jobLogRepo := repos.JobLogs
+	webhookRepo := repos.Webhooks
+
+	// Eventos vão para o stream SSE e para as entregas de webhooks
+	publisher := service.EventPublishers{events, service.NewWebhookService(webhookRepo, projectRepo, jobRepo)}
+
+	orchestrator := service.NewBookOrchestrator(
+		repos.ProjectStore(),
+		service.NewLocalAnalysisClient(),
+		outputDir,
+		service.WithProgressStore(progress),
+		service.WithEventPublisher(publisher),
+		service.WithStorage(store),
+		service.WithArtifactStore(repos.Artifacts),
+	)
+	pipeline := service.NewProjectPipeline(orchestrator, cfg.TempDir, service.WithSearchIndex(projectRepo))
+	generations := service.NewGenerationJobs(orchestrator, jobRepo)
+
+	// Jobs de um worker que parar de enviar heartbeats voltam para a fila
+	lease := time.Duration(cfg.JobLeaseSeconds) * time.Second
+	w := worker.New(jobRepo, projectRepo, cfg.WorkerConcurrency, worker.WithLease(lease), worker.WithJobLogs(jobLogRepo), worker.WithEvents(publisher), worker.WithCancellations(events))
+	worker.RegisterServices(w, pipeline, generations)
+
+	// Graceful shutdown: jobs em andamento voltam para a fila
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Entregas de webhooks pendentes, com retry e backoff
+	go service.NewWebhookDispatcher(webhookRepo).Run(ctx)
+
+	// Projetos na lixeira há mais de TRASH_RETENTION_DAYS são expurgados
+	// com jobs, análises, revisões e arquivos
+	if cfg.TrashRetentionDays > 0 {
+		purger := service.NewTrashPurger(projectRepo, service.ProjectData{
+			Jobs:      jobRepo,
+			JobLogs:   jobLogRepo,
+			Analyses:  repos.Analyses,
+			Revisions: repos.Revisions,
+			Artifacts: repos.Artifacts,
+			Uploads:   repos.Uploads,
+		}, store, cfg.TempDir, time.Duration(cfg.TrashRetentionDays)*24*time.Hour)
+		go purger.Run(ctx)
+	}
+
+	log.Printf("🚀 Worker %s iniciado (concorrência: %d, lease: %s)", w.ID(), cfg.WorkerConcurrency, lease)
+	if err := w.Run(ctx); err != nil {
+		log.Fatalf("❌ Erro no worker: %v", err)
+	}
+	log.Println("✅ Worker desligado")
+}
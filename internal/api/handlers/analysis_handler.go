@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/JuanCS-Dev/typecraft/internal/ai"
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
 	"github.com/JuanCS-Dev/typecraft/internal/repository"
 	"github.com/JuanCS-Dev/typecraft/internal/service"
 	"github.com/gin-gonic/gin"
@@ -28,7 +29,7 @@ func NewAnalysisHandler(analysisService *service.AnalysisService, projectService
 }
 
 // NewAnalysisHandlerWithDeps creates a new analysis handler with all dependencies initialized
-func NewAnalysisHandlerWithDeps() (*AnalysisHandler, error) {
+func NewAnalysisHandlerWithDeps(repos *repository.Repositories, projectService *service.ProjectService) (*AnalysisHandler, error) {
 	// Get OpenAI API key
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey == "" {
@@ -39,12 +40,8 @@ func NewAnalysisHandlerWithDeps() (*AnalysisHandler, error) {
 	model := getEnvOrDefault("OPENAI_MODEL", "gpt-4o")
 	analyzer := ai.NewAnalyzer(apiKey, model)
 	
-	// Create repositories (they get DB from internal package)
-	projectRepo := repository.NewProjectRepository()
-	
-	// Create services
-	analysisService := service.NewAnalysisService(analyzer, projectRepo)
-	projectService := service.NewProjectService()
+	// Create services over the configured repositories
+	analysisService := service.NewAnalysisService(analyzer, repos.Projects, repos.Analyses)
 	
 	return NewAnalysisHandler(analysisService, projectService), nil
 }
@@ -102,9 +99,8 @@ func (h *AnalysisHandler) AnalyzeProject(c *gin.Context) {
 	}
 	
 	// Get project
-	project, err := h.projectService.GetProject(projectID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+	project, ok := h.ownedProject(c, projectID)
+	if !ok {
 		return
 	}
 	
@@ -234,6 +230,9 @@ func (h *AnalysisHandler) ListGenres(c *gin.Context) {
 // GET /api/v1/projects/:id/analyses
 func (h *AnalysisHandler) GetAnalysisHistory(c *gin.Context) {
 	projectID := c.Param("id")
+	if _, ok := h.ownedProject(c, projectID); !ok {
+		return
+	}
 	
 	// Get limit from query param (default 10)
 	limit := 10
@@ -259,6 +258,9 @@ func (h *AnalysisHandler) GetAnalysisHistory(c *gin.Context) {
 // GET /api/v1/projects/:id/metrics
 func (h *AnalysisHandler) GetProjectMetrics(c *gin.Context) {
 	projectID := c.Param("id")
+	if _, ok := h.ownedProject(c, projectID); !ok {
+		return
+	}
 	
 	// Get metrics from service
 	metrics, err := h.analysisService.GetProjectMetrics(projectID)
@@ -269,3 +271,14 @@ func (h *AnalysisHandler) GetProjectMetrics(c *gin.Context) {
 	
 	c.JSON(http.StatusOK, metrics)
 }
+
+// ownedProject loads a project of the caller, responding with 404 when it is
+// missing or belongs to another user
+func (h *AnalysisHandler) ownedProject(c *gin.Context, projectID string) (*domain.Project, bool) {
+	project, err := h.projectService.GetProject(CurrentUserID(c), projectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return nil, false
+	}
+	return project, true
+}
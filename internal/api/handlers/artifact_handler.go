@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ArtifactHandler lists generated files and serves their signed downloads
+type ArtifactHandler struct {
+	service *service.ArtifactService
+}
+
+// NewArtifactHandler creates the artifact handler
+func NewArtifactHandler(artifacts *service.ArtifactService) *ArtifactHandler {
+	return &ArtifactHandler{service: artifacts}
+}
+
+// ListArtifacts handles GET /api/v1/projects/:id/artifacts
+// @Summary List generated files
+// @Description Lists every generated file of the project (newest first) with format, size, SHA-256 checksum and the job of the generation that produced it.
+// @Description Each entry carries a signed download_url valid until expires_at.
+// @Tags artifacts
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {array} service.ArtifactFile
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/projects/{id}/artifacts [get]
+func (h *ArtifactHandler) ListArtifacts(c *gin.Context) {
+	userID := CurrentUserID(c)
+
+	files, err := h.service.List(userID, c.Param("id"))
+	if err != nil {
+		respondArtifactError(c, "Failed to list artifacts", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, files)
+}
+
+// GetArtifact handles GET /api/v1/projects/:id/artifacts/:artifactId
+// @Summary Get a generated file
+// @Description Returns the file with a fresh signed download_url
+// @Tags artifacts
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param artifactId path string true "Artifact ID"
+// @Success 200 {object} service.ArtifactFile
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/projects/{id}/artifacts/{artifactId} [get]
+func (h *ArtifactHandler) GetArtifact(c *gin.Context) {
+	userID := CurrentUserID(c)
+
+	file, err := h.service.Get(userID, c.Param("id"), c.Param("artifactId"))
+	if err != nil {
+		respondArtifactError(c, "Failed to get artifact", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, file)
+}
+
+// DownloadArtifact handles GET /api/v1/artifacts/:artifactId/download
+// @Summary Download a generated file
+// @Description Serves the file of a signed link issued by the artifact listing. Supports Range and conditional requests.
+// @Tags artifacts
+// @Produce octet-stream
+// @Param artifactId path string true "Artifact ID"
+// @Param expires query int true "Link expiry (Unix seconds)"
+// @Param signature query string true "Link signature"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/artifacts/{artifactId}/download [get]
+func (h *ArtifactHandler) DownloadArtifact(c *gin.Context) {
+	artifact, reader, err := h.service.Open(c.Request.Context(), c.Param("artifactId"), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		respondArtifactError(c, "Failed to download artifact", err)
+		return
+	}
+	defer reader.Close()
+
+	name := fmt.Sprintf("project_%d.%s", artifact.ProjectID, artifact.Format)
+	c.Header("Content-Type", artifact.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Header("ETag", `"`+artifact.Checksum+`"`)
+	c.Header("Cache-Control", "private, no-transform")
+	http.ServeContent(c.Writer, c.Request, name, artifact.CreatedAt, reader)
+}
+
+// RegisterRoutes registers the artifact listing routes; router must require
+// authentication
+func (h *ArtifactHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/projects/:id/artifacts", h.ListArtifacts)
+	router.GET("/projects/:id/artifacts/:artifactId", h.GetArtifact)
+}
+
+// RegisterDownloadRoutes registers the download route. The signed link is the
+// credential, so it stays reachable without an access token.
+func (h *ArtifactHandler) RegisterDownloadRoutes(router *gin.RouterGroup) {
+	router.GET("/artifacts/:artifactId/download", h.DownloadArtifact)
+}
+
+// respondArtifactError maps artifact errors to HTTP statuses
+func respondArtifactError(c *gin.Context, message string, err error) {
+	err = notOwnedAsMissing(err, domain.ErrProjectNotFound)
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrProjectNotFound), errors.Is(err, domain.ErrArtifactNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidDownloadLink):
+		status = http.StatusForbidden
+	}
+
+	c.JSON(status, ErrorResponse{Error: message, Message: err.Error()})
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Gin context keys RequireAuth stores the caller under
+const (
+	userIDKey = "user_id"
+	roleKey   = "user_role"
+)
+
+// TokenVerifier verifies access tokens (implemented by service.AuthService)
+type TokenVerifier interface {
+	Authenticate(accessToken string) (service.Caller, error)
+}
+
+// RequireAuth rejects requests without a valid access token and injects the
+// caller into the gin context (see CurrentUserID and CurrentCaller). The token
+// goes in the Authorization header as a Bearer token.
+func RequireAuth(tokens TokenVerifier) gin.HandlerFunc {
+	return requireAuth(tokens, false)
+}
+
+// RequireStreamAuth is RequireAuth for event streams: the access_token query
+// parameter is accepted as well, for EventSource clients, which cannot set
+// headers. Tokens in URLs end up in logs and browser history, so use it on
+// the stream routes only.
+func RequireStreamAuth(tokens TokenVerifier) gin.HandlerFunc {
+	return requireAuth(tokens, true)
+}
+
+func requireAuth(tokens TokenVerifier, allowQuery bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var token string
+		if allowQuery {
+			token = c.Query("access_token")
+		}
+		if header := c.GetHeader("Authorization"); header != "" {
+			scheme, credentials, _ := strings.Cut(header, " ")
+			if !strings.EqualFold(scheme, "Bearer") {
+				unauthorized(c, "Authorization header must be a Bearer token")
+				return
+			}
+			token = strings.TrimSpace(credentials)
+		}
+		if token == "" {
+			unauthorized(c, "missing access token")
+			return
+		}
+
+		caller, err := tokens.Authenticate(token)
+		if err != nil {
+			unauthorized(c, err.Error())
+			return
+		}
+
+		c.Set(userIDKey, caller.UserID)
+		c.Set(roleKey, caller.Role)
+		c.Next()
+	}
+}
+
+// CurrentUserID returns the authenticated caller set by RequireAuth
+func CurrentUserID(c *gin.Context) string {
+	return c.GetString(userIDKey)
+}
+
+// CurrentCaller returns the authenticated caller and its role, set by
+// RequireAuth
+func CurrentCaller(c *gin.Context) service.Caller {
+	return service.Caller{UserID: c.GetString(userIDKey), Role: c.GetString(roleKey)}
+}
+
+// unauthorized aborts with 401 and the Bearer challenge
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="typecraft"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized", Message: message})
+}
+
+// AuthHandler registers users and issues their tokens
+type AuthHandler struct {
+	service *service.AuthService
+}
+
+// NewAuthHandler creates the authentication handler
+func NewAuthHandler(auth *service.AuthService) *AuthHandler {
+	return &AuthHandler{service: auth}
+}
+
+// LoginRequest signs a user in
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest exchanges a refresh token for a new token pair
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Register handles POST /api/v1/auth/register
+// @Summary Create an account
+// @Description Creates an account and signs it in. Passwords need at least 8 characters.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body service.RegisterRequest true "Account"
+// @Success 201 {object} service.TokenPair
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/auth/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req service.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+
+	tokens, err := h.service.Register(req)
+	if err != nil {
+		respondAuthError(c, "Failed to register", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tokens)
+}
+
+// Login handles POST /api/v1/auth/login
+// @Summary Sign in
+// @Description Returns an access token (send it as "Authorization: Bearer <token>") and a refresh token to renew it
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginRequest true "Credentials"
+// @Success 200 {object} service.TokenPair
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+
+	tokens, err := h.service.Login(req.Email, req.Password)
+	if err != nil {
+		respondAuthError(c, "Failed to sign in", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Refresh handles POST /api/v1/auth/refresh
+// @Summary Renew tokens
+// @Description Exchanges a refresh token for a new access and refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} service.TokenPair
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+
+	tokens, err := h.service.Refresh(req.RefreshToken)
+	if err != nil {
+		respondAuthError(c, "Failed to refresh tokens", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout handles POST /api/v1/auth/logout
+// @Summary Sign out everywhere
+// @Description Revokes every refresh token of the caller. Access tokens stay valid until they expire.
+// @Tags auth
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.service.Logout(CurrentUserID(c)); err != nil {
+		respondAuthError(c, "Failed to sign out", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Me handles GET /api/v1/auth/me
+// @Summary Current user
+// @Tags auth
+// @Produce json
+// @Success 200 {object} domain.User
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/auth/me [get]
+func (h *AuthHandler) Me(c *gin.Context) {
+	user, err := h.service.User(CurrentUserID(c))
+	if err != nil {
+		respondAuthError(c, "Failed to get user", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// RegisterRoutes registers the public authentication routes
+func (h *AuthHandler) RegisterRoutes(router *gin.RouterGroup) {
+	auth := router.Group("/auth")
+	auth.POST("/register", h.Register)
+	auth.POST("/login", h.Login)
+	auth.POST("/refresh", h.Refresh)
+}
+
+// RegisterAuthenticatedRoutes registers the routes that act on the caller's
+// account; router must require authentication
+func (h *AuthHandler) RegisterAuthenticatedRoutes(router *gin.RouterGroup) {
+	auth := router.Group("/auth")
+	auth.POST("/logout", h.Logout)
+	auth.GET("/me", h.Me)
+}
+
+// respondAuthError maps authentication errors to HTTP statuses
+func respondAuthError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidRegistration):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrEmailTaken):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrInvalidToken):
+		status = http.StatusUnauthorized
+	case errors.Is(err, domain.ErrUserNotFound):
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, ErrorResponse{Error: message, Message: err.Error()})
+}
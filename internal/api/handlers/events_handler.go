@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// eventsKeepAlive is how long the stream waits for an event before sending a
+// comment, so proxies do not close an idle connection
+const eventsKeepAlive = 15 * time.Second
+
+// ProjectFinder loads a project of a user (implemented by service.ProjectService)
+type ProjectFinder interface {
+	GetProject(userID, id string) (*domain.Project, error)
+}
+
+// EventsHandler streams project events over Server-Sent Events
+type EventsHandler struct {
+	projects ProjectFinder
+	events   service.EventStore
+}
+
+// NewEventsHandler creates the project events handler
+func NewEventsHandler(projects ProjectFinder, events service.EventStore) *EventsHandler {
+	return &EventsHandler{projects: projects, events: events}
+}
+
+// StreamProjectEvents handles GET /api/v1/projects/:id/events
+// @Summary Stream project events
+// @Description Server-Sent Events stream of project status transitions (project.status), job state changes (job.status), per-stage generation progress (generation.progress), finished generations (generation.completed) and validation issues (validation.issue).
+// @Description EventSource cannot send an Authorization header: pass the access token in the access_token query parameter instead.
+// @Description Each event carries an id; reconnecting with the Last-Event-ID header (or the last_event_id query parameter) replays what was missed. Without either, only new events are sent.
+// @Tags projects
+// @Produce text/event-stream
+// @Param id path string true "Project ID"
+// @Param Last-Event-ID header string false "Resume after this event"
+// @Param last_event_id query string false "Resume after this event"
+// @Success 200 {object} service.ProjectEvent
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/projects/{id}/events [get]
+func (h *EventsHandler) StreamProjectEvents(c *gin.Context) {
+	projectID := c.Param("id")
+	if _, err := h.projects.GetProject(CurrentUserID(c), projectID); err != nil {
+		err = notOwnedAsMissing(err, domain.ErrProjectNotFound)
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, ErrorResponse{Error: "Project not found", Message: err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	if lastID == "" {
+		id, err := h.events.LastID(ctx, projectID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to read events", Message: err.Error()})
+			return
+		}
+		lastID = id
+	}
+
+	// Validate the resume point before committing to a stream
+	pending, err := h.events.Read(ctx, projectID, lastID, 0)
+	if errors.Is(err, service.ErrInvalidEventID) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid Last-Event-ID", Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to read events", Message: err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	c.Stream(func(w io.Writer) bool {
+		events := pending
+		pending = nil
+		if events == nil {
+			events, err = h.events.Read(ctx, projectID, lastID, eventsKeepAlive)
+			if ctx.Err() != nil {
+				return false
+			}
+			if err != nil {
+				c.SSEvent("error", ErrorResponse{Error: "Failed to read events", Message: err.Error()})
+				return false
+			}
+		}
+
+		if len(events) == 0 {
+			_, _ = io.WriteString(w, ": keep-alive\n\n")
+			return true
+		}
+		for _, event := range events {
+			c.Render(-1, sse.Event{Id: event.ID, Event: event.Type, Data: event})
+			lastID = event.ID
+		}
+		return true
+	})
+}
+
+// RegisterRoutes registers the project events route
+func (h *EventsHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/projects/:id/events", h.StreamProjectEvents)
+}
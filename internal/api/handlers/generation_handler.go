@@ -3,29 +3,38 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
 	"github.com/JuanCS-Dev/typecraft/internal/service"
 )
 
 // BookGenerationHandler handles book generation endpoints
 type BookGenerationHandler struct {
-	orchestrator *service.BookOrchestrator
+	jobs     *service.GenerationJobs
+	jobAdmin *service.JobService
 }
 
-// NewBookGenerationHandler creates a new handler
-func NewBookGenerationHandler(orchestrator *service.BookOrchestrator) *BookGenerationHandler {
+// NewBookGenerationHandler creates a new handler.
+// Generations are queued as export jobs for the worker; cancelling goes
+// through the jobs (jobAdmin). Every route is scoped to the caller's projects.
+func NewBookGenerationHandler(jobs *service.GenerationJobs, jobAdmin *service.JobService) *BookGenerationHandler {
 	return &BookGenerationHandler{
-		orchestrator: orchestrator,
+		jobs:     jobs,
+		jobAdmin: jobAdmin,
 	}
 }
 
-// GenerateBookRequest is the HTTP request body
+// GenerateBookRequest is the HTTP request body.
+// Revision generates from a numbered manuscript revision (file or bundle) of
+// the project; without it, the project's current manuscript is used.
 type GenerateBookRequest struct {
-	ContentPath      string                   `json:"content_path" binding:"required"`
+	Revision         int                      `json:"revision,omitempty" binding:"omitempty,min=1"`
 	OutputFormats    []string                 `json:"output_formats" binding:"required,dive,oneof=pdf epub"`
 	OverridePipeline string                   `json:"override_pipeline,omitempty" binding:"omitempty,oneof=latex html"`
 	CustomDesign     *CustomDesignRequest     `json:"custom_design,omitempty"`
@@ -39,60 +48,26 @@ type CustomDesignRequest struct {
 	MarginPreset  string   `json:"margin_preset,omitempty"`
 }
 
-// GenerateBookResponse is the HTTP response
+// GenerateBookResponse is returned when a generation is accepted
 type GenerateBookResponse struct {
-	Success        bool                  `json:"success"`
-	Message        string                `json:"message,omitempty"`
-	ProjectID      uint                  `json:"project_id"`
-	Pipeline       string                `json:"pipeline"`
-	OutputFiles    map[string]string     `json:"output_files"`
-	DesignMetadata *DesignMetadataResponse `json:"design_metadata,omitempty"`
-	Metrics        *MetricsResponse      `json:"metrics,omitempty"`
-	Error          string                `json:"error,omitempty"`
-}
-
-// DesignMetadataResponse contains design information
-type DesignMetadataResponse struct {
-	Fonts   FontsResponse   `json:"fonts"`
-	Colors  []string        `json:"colors"`
-	Margins MarginsResponse `json:"margins"`
-}
-
-// FontsResponse contains font information
-type FontsResponse struct {
-	Body    string `json:"body"`
-	Heading string `json:"heading"`
-}
-
-// MarginsResponse contains margin information
-type MarginsResponse struct {
-	Top    float64 `json:"top"`
-	Bottom float64 `json:"bottom"`
-	Left   float64 `json:"left"`
-	Right  float64 `json:"right"`
-}
-
-// MetricsResponse contains generation metrics
-type MetricsResponse struct {
-	DurationMs         int64   `json:"duration_ms"`
-	ContentAnalysisMs  int64   `json:"content_analysis_ms"`
-	DesignGenerationMs int64   `json:"design_generation_ms"`
-	RenderingMs        int64   `json:"rendering_ms"`
-	ValidationMs       int64   `json:"validation_ms"`
-	TotalPages         int     `json:"total_pages"`
-	FileSize           int64   `json:"file_size"`
-	QualityScore       float64 `json:"quality_score"`
+	Message   string           `json:"message"`
+	ProjectID uint             `json:"project_id"`
+	JobID     string           `json:"job_id"`
+	Status    domain.JobStatus `json:"status"`
+	StatusURL string           `json:"status_url"`
 }
 
 // Generate handles POST /api/v1/projects/:id/generate
 // @Summary Generate book in multiple formats
-// @Description Orchestrates the complete book generation pipeline
+// @Description Queues the complete book generation pipeline as an export job.
+// @Description The outputs, metrics and design metadata are stored in the job result.
+// @Description The job and its artifacts record the manuscript revision they were built from.
 // @Tags generation
 // @Accept json
 // @Produce json
 // @Param id path int true "Project ID"
 // @Param request body GenerateBookRequest true "Generation parameters"
-// @Success 200 {object} GenerateBookResponse
+// @Success 202 {object} GenerateBookResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
@@ -121,7 +96,7 @@ func (h *BookGenerationHandler) Generate(c *gin.Context) {
 	// Convert to service request
 	serviceReq := &service.GenerationRequest{
 		ProjectID:        uint(projectID),
-		ContentPath:      req.ContentPath,
+		Revision:         req.Revision,
 		OutputFormats:    req.OutputFormats,
 		OverridePipeline: req.OverridePipeline,
 	}
@@ -136,58 +111,67 @@ func (h *BookGenerationHandler) Generate(c *gin.Context) {
 		}
 	}
 
-	// Execute generation
-	result, err := h.orchestrator.Generate(c.Request.Context(), serviceReq)
-	
-	// Build response
-	response := &GenerateBookResponse{
-		Success:     result.Success,
-		ProjectID:   result.ProjectID,
-		Pipeline:    result.Pipeline,
-		OutputFiles: result.OutputFiles,
+	// Queue generation
+	job, err := h.jobs.Enqueue(c.Request.Context(), CurrentUserID(c), serviceReq)
+	if err = notOwnedAsMissing(err, domain.ErrProjectNotFound); errors.Is(err, domain.ErrProjectNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Project not found",
+			Message: err.Error(),
+		})
+		return
 	}
-
-	// Add design metadata if available
-	if result.DesignMetadata != nil {
-		response.DesignMetadata = &DesignMetadataResponse{
-			Fonts: FontsResponse{
-				Body:    result.DesignMetadata.Fonts.Body,
-				Heading: result.DesignMetadata.Fonts.Heading,
-			},
-			Colors: result.DesignMetadata.Colors,
-			Margins: MarginsResponse{
-				Top:    result.DesignMetadata.Margins.Top,
-				Bottom: result.DesignMetadata.Margins.Bottom,
-				Left:   result.DesignMetadata.Margins.Left,
-				Right:  result.DesignMetadata.Margins.Right,
-			},
-		}
+	if errors.Is(err, domain.ErrRevisionNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Revision not found",
+			Message: err.Error(),
+		})
+		return
 	}
-
-	// Add metrics if available
-	if result.Metrics != nil {
-		response.Metrics = &MetricsResponse{
-			DurationMs:         result.Metrics.Duration.Milliseconds(),
-			ContentAnalysisMs:  result.Metrics.ContentAnalysisMs,
-			DesignGenerationMs: result.Metrics.DesignGenerationMs,
-			RenderingMs:        result.Metrics.RenderingMs,
-			ValidationMs:       result.Metrics.ValidationMs,
-			TotalPages:         result.Metrics.TotalPages,
-			FileSize:           result.Metrics.FileSize,
-			QualityScore:       result.Metrics.QualityScore,
-		}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to queue generation",
+			Message: err.Error(),
+		})
+		return
 	}
 
-	// Handle errors
+	c.JSON(http.StatusAccepted, GenerateBookResponse{
+		Message:   "Book generation queued",
+		ProjectID: uint(projectID),
+		JobID:     job.ID,
+		Status:    job.Status,
+		StatusURL: fmt.Sprintf("/api/v1/jobs/%s", job.ID),
+	})
+}
+
+// GetJob handles GET /api/v1/jobs/:jobId
+// @Summary Get generation job
+// @Description Returns a generation job of one of the caller's projects; completed jobs carry the outputs in result
+// @Tags generation
+// @Produce json
+// @Param jobId path string true "Job ID"
+// @Success 200 {object} domain.Job
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/jobs/{jobId} [get]
+func (h *BookGenerationHandler) GetJob(c *gin.Context) {
+	job, err := h.jobs.Get(c.Request.Context(), CurrentUserID(c), c.Param("jobId"))
+	if err = notOwnedAsMissing(err, domain.ErrJobNotFound); errors.Is(err, domain.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Job not found",
+			Message: err.Error(),
+		})
+		return
+	}
 	if err != nil {
-		response.Error = err.Error()
-		response.Message = "Book generation failed"
-		c.JSON(http.StatusInternalServerError, response)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to load job",
+			Message: err.Error(),
+		})
 		return
 	}
 
-	response.Message = "Book generated successfully"
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, job)
 }
 
 // GetProgress handles GET /api/v1/projects/:id/generation/progress
@@ -210,14 +194,28 @@ func (h *BookGenerationHandler) GetProgress(c *gin.Context) {
 		return
 	}
 
-	progress, err := h.orchestrator.GetProgress(c.Request.Context(), uint(projectID))
-	if err != nil {
+	progress, err := h.jobs.Progress(c.Request.Context(), CurrentUserID(c), uint(projectID))
+	if err = notOwnedAsMissing(err, domain.ErrProjectNotFound); errors.Is(err, domain.ErrProjectNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Project not found",
+			Message: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, service.ErrProgressNotFound) {
 		c.JSON(http.StatusNotFound, ErrorResponse{
 			Error:   "Progress not found",
 			Message: err.Error(),
 		})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "Failed to load progress",
+			Message: err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, ProgressResponse{
 		ProjectID:    progress.ProjectID,
@@ -225,18 +223,22 @@ func (h *BookGenerationHandler) GetProgress(c *gin.Context) {
 		CurrentStage: progress.CurrentStage,
 		Progress:     progress.Progress,
 		Message:      progress.Message,
+		StartedAt:    progress.StartedAt,
+		UpdatedAt:    progress.UpdatedAt,
+		ETASeconds:   int64(progress.ETA.Seconds()),
 	})
 }
 
 // CancelGeneration handles DELETE /api/v1/projects/:id/generation
 // @Summary Cancel generation
-// @Description Cancels an in-progress book generation job
+// @Description Cancels the pending and running book generation jobs of a project. The worker stops a running generation on its next heartbeat.
 // @Tags generation
 // @Produce json
 // @Param id path int true "Project ID"
 // @Success 200 {object} MessageResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /api/v1/projects/{id}/generation [delete]
 func (h *BookGenerationHandler) CancelGeneration(c *gin.Context) {
 	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -248,7 +250,22 @@ func (h *BookGenerationHandler) CancelGeneration(c *gin.Context) {
 		return
 	}
 
-	if err := h.orchestrator.CancelGeneration(c.Request.Context(), uint(projectID)); err != nil {
+	_, err = h.jobAdmin.CancelGeneration(CurrentCaller(c), strconv.FormatUint(projectID, 10))
+	if err = notOwnedAsMissing(err, domain.ErrProjectNotFound); errors.Is(err, domain.ErrProjectNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "Project not found",
+			Message: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, service.ErrGenerationNotRunning) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "No generation in progress",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "Failed to cancel generation",
 			Message: err.Error(),
@@ -263,11 +280,14 @@ func (h *BookGenerationHandler) CancelGeneration(c *gin.Context) {
 
 // ProgressResponse contains progress information
 type ProgressResponse struct {
-	ProjectID    uint   `json:"project_id"`
-	Status       string `json:"status"`
-	CurrentStage string `json:"current_stage"`
-	Progress     int    `json:"progress"`
-	Message      string `json:"message,omitempty"`
+	ProjectID    uint      `json:"project_id"`
+	Status       string    `json:"status"`
+	CurrentStage string    `json:"current_stage"`
+	Progress     int       `json:"progress"`
+	Message      string    `json:"message,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	ETASeconds   int64     `json:"eta_seconds"` // Estimated time remaining, 0 when finished
 }
 
 // ErrorResponse is a standard error response
@@ -281,6 +301,20 @@ type MessageResponse struct {
 	Message string `json:"message"`
 }
 
+// notOwnedAsMissing reports a resource of another user exactly like a missing
+// one, status and message alike, so IDs of other users cannot be probed:
+// access denied, and the missing project behind a resource, become missing
+func notOwnedAsMissing(err, missing error) error {
+	switch {
+	case errors.Is(err, service.ErrProjectAccessDenied),
+		errors.Is(err, service.ErrWebhookAccessDenied),
+		errors.Is(err, domain.ErrProjectNotFound),
+		errors.Is(err, missing):
+		return missing
+	}
+	return err
+}
+
 // RegisterRoutes registers all generation routes
 func (h *BookGenerationHandler) RegisterRoutes(router *gin.RouterGroup) {
 	generation := router.Group("/projects/:id/generation")
@@ -289,4 +323,5 @@ func (h *BookGenerationHandler) RegisterRoutes(router *gin.RouterGroup) {
 		generation.GET("/progress", h.GetProgress)
 		generation.DELETE("", h.CancelGeneration)
 	}
+	router.GET("/jobs/:jobId", h.GetJob)
 }
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler exposes job administration across the caller's projects, or
+// across every project for operators
+type JobHandler struct {
+	service *service.JobService
+}
+
+// NewJobHandler creates the job administration handler
+func NewJobHandler(jobs *service.JobService) *JobHandler {
+	return &JobHandler{service: jobs}
+}
+
+// CancelJobRequest is the optional body of a cancellation
+type CancelJobRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ListJobs handles GET /api/v1/jobs
+// @Summary List jobs
+// @Description Lists jobs of all projects of the caller (of every project for operators), newest first
+// @Tags jobs
+// @Produce json
+// @Param status query string false "Job status"
+// @Param type query string false "Job type"
+// @Param project_id query string false "Project ID"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} service.JobList
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/jobs [get]
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	filter, ok := jobFilter(c)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.service.List(CurrentCaller(c), filter)
+	if err != nil {
+		respondJobError(c, "Failed to list jobs", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// ListDeadLetters handles GET /api/v1/jobs/dead-letter
+// @Summary List dead-letter jobs
+// @Description Lists failed jobs that exhausted their attempts, with their error and captured output
+// @Tags jobs
+// @Produce json
+// @Param type query string false "Job type"
+// @Param project_id query string false "Project ID"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} service.DeadLetterList
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/jobs/dead-letter [get]
+func (h *JobHandler) ListDeadLetters(c *gin.Context) {
+	filter, ok := jobFilter(c)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.service.DeadLetters(CurrentCaller(c), filter)
+	if err != nil {
+		respondJobError(c, "Failed to list dead-letter jobs", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// RetryJob handles POST /api/v1/jobs/:jobId/retry
+// @Summary Retry job
+// @Description Requeues a failed or cancelled job with its attempts and error reset
+// @Tags jobs
+// @Produce json
+// @Param jobId path string true "Job ID"
+// @Success 200 {object} domain.Job
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/jobs/{jobId}/retry [post]
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	job, err := h.service.Retry(CurrentCaller(c), c.Param("jobId"))
+	if err != nil {
+		respondJobError(c, "Failed to retry job", notOwnedAsMissing(err, domain.ErrJobNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob handles POST /api/v1/jobs/:jobId/cancel
+// @Summary Cancel job
+// @Description Cancels a pending or running job and the jobs depending on it
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param jobId path string true "Job ID"
+// @Param request body CancelJobRequest false "Cancellation reason"
+// @Success 200 {object} domain.Job
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/jobs/{jobId}/cancel [post]
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	var req CancelJobRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+			return
+		}
+	}
+
+	job, err := h.service.Cancel(CurrentCaller(c), c.Param("jobId"), req.Reason)
+	if err != nil {
+		respondJobError(c, "Failed to cancel job", notOwnedAsMissing(err, domain.ErrJobNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// GetJobLogs handles GET /api/v1/jobs/:jobId/logs
+// @Summary List job tool runs
+// @Description Lists the external tool runs (pandoc, lualatex, pagedjs-cli, ...) of a job with command line, duration, exit code and fetchable artifacts
+// @Tags jobs
+// @Produce json
+// @Param jobId path string true "Job ID"
+// @Success 200 {array} service.JobLogView
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/jobs/{jobId}/logs [get]
+func (h *JobHandler) GetJobLogs(c *gin.Context) {
+	logs, err := h.service.Logs(CurrentCaller(c), c.Param("jobId"))
+	if err != nil {
+		respondJobError(c, "Failed to list job logs", notOwnedAsMissing(err, domain.ErrJobNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
+// GetJobLogArtifact handles GET /api/v1/jobs/:jobId/logs/:logId/:artifact
+// @Summary Fetch tool run output
+// @Description Returns stdout, stderr or a log file written by the tool (e.g. document.log) as plain text
+// @Tags jobs
+// @Produce plain
+// @Param jobId path string true "Job ID"
+// @Param logId path string true "Log ID"
+// @Param artifact path string true "stdout, stderr or a file name"
+// @Success 200 {string} string
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/jobs/{jobId}/logs/{logId}/{artifact} [get]
+func (h *JobHandler) GetJobLogArtifact(c *gin.Context) {
+	content, err := h.service.LogArtifact(CurrentCaller(c), c.Param("jobId"), c.Param("logId"), c.Param("artifact"))
+	if err != nil {
+		respondJobError(c, "Failed to fetch job log", notOwnedAsMissing(err, domain.ErrJobNotFound))
+		return
+	}
+
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(content))
+}
+
+// RegisterRoutes registers the job administration routes
+func (h *JobHandler) RegisterRoutes(router *gin.RouterGroup) {
+	jobs := router.Group("/jobs")
+	jobs.GET("", h.ListJobs)
+	jobs.GET("/dead-letter", h.ListDeadLetters)
+	jobs.POST("/:jobId/retry", h.RetryJob)
+	jobs.POST("/:jobId/cancel", h.CancelJob)
+	jobs.GET("/:jobId/logs", h.GetJobLogs)
+	jobs.GET("/:jobId/logs/:logId/:artifact", h.GetJobLogArtifact)
+}
+
+// jobFilter reads the list filters from the query string
+func jobFilter(c *gin.Context) (domain.JobFilter, bool) {
+	filter := domain.JobFilter{
+		Status:    domain.JobStatus(c.Query("status")),
+		Type:      domain.JobType(c.Query("type")),
+		ProjectID: c.Query("project_id"),
+	}
+
+	for name, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid " + name, Message: err.Error()})
+			return filter, false
+		}
+		*target = n
+	}
+
+	return filter, true
+}
+
+// respondJobError maps job administration errors to HTTP statuses. Jobs and
+// projects of other users are reported as missing (see notOwnedAsMissing).
+func respondJobError(c *gin.Context, message string, err error) {
+	err = notOwnedAsMissing(err, domain.ErrProjectNotFound)
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrJobNotFound),
+		errors.Is(err, domain.ErrJobLogNotFound),
+		errors.Is(err, domain.ErrProjectNotFound),
+		errors.Is(err, service.ErrJobLogArtifactNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrJobNotRetryable),
+		errors.Is(err, service.ErrJobNotCancellable),
+		errors.Is(err, domain.ErrJobConflict):
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, ErrorResponse{Error: message, Message: err.Error()})
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
 	"github.com/JuanCS-Dev/typecraft/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -15,9 +18,9 @@ type ProjectHandler struct {
 }
 
 // NewProjectHandler cria uma nova instância do handler
-func NewProjectHandler() *ProjectHandler {
+func NewProjectHandler(projects *service.ProjectService) *ProjectHandler {
 	return &ProjectHandler{
-		service: service.NewProjectService(),
+		service: projects,
 	}
 }
 
@@ -37,8 +40,7 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 		return
 	}
 	
-	// UserID padrão até implementação de autenticação (Sprint 3-4)
-	userID := "default_user"
+	userID := CurrentUserID(c)
 	
 	project, err := h.service.CreateProject(userID, req)
 	if err != nil {
@@ -59,9 +61,9 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 func (h *ProjectHandler) GetProject(c *gin.Context) {
 	id := c.Param("id")
 	
-	project, err := h.service.GetProject(id)
+	project, err := h.service.GetProject(CurrentUserID(c), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondProjectError(c, err)
 		return
 	}
 	
@@ -80,8 +82,7 @@ func (h *ProjectHandler) ListProjects(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	
-	// UserID padrão até implementação de autenticação (Sprint 3-4)
-	userID := "default_user"
+	userID := CurrentUserID(c)
 	
 	projects, total, err := h.service.ListProjects(userID, page, pageSize)
 	if err != nil {
@@ -116,9 +117,9 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 		return
 	}
 	
-	project, err := h.service.UpdateProject(id, updates)
+	project, err := h.service.UpdateProject(CurrentUserID(c), id, updates)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondProjectError(c, err)
 		return
 	}
 	
@@ -126,50 +127,111 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 }
 
 // DeleteProject godoc
-// @Summary Deletar projeto
+// @Summary Mover projeto para a lixeira
+// @Description O projeto pode ser restaurado até o fim do período de retenção (TRASH_RETENTION_DAYS),
+// @Description quando é expurgado junto com jobs, análises, revisões e arquivos.
 // @Tags projects
 // @Param id path string true "Project ID"
 // @Success 204
+// @Failure 404 {object} map[string]interface{}
 // @Router /api/v1/projects/{id} [delete]
 func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 	id := c.Param("id")
 	
-	if err := h.service.DeleteProject(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.DeleteProject(CurrentUserID(c), id); err != nil {
+		respondProjectError(c, err)
 		return
 	}
 	
 	c.Status(http.StatusNoContent)
 }
 
-// UploadManuscript godoc
-// @Summary Upload do manuscrito
+// ListTrash godoc
+// @Summary Listar projetos na lixeira
 // @Tags projects
-// @Accept multipart/form-data
 // @Produce json
-// @Param id path string true "Project ID"
-// @Param file formData file true "Arquivo do manuscrito"
-// @Success 200 {object} domain.Project
-// @Router /api/v1/projects/{id}/upload [post]
-func (h *ProjectHandler) UploadManuscript(c *gin.Context) {
-	id := c.Param("id")
+// @Param page query int false "Número da página" default(1)
+// @Param page_size query int false "Itens por página" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/projects/trash [get]
+func (h *ProjectHandler) ListTrash(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	
+	userID := CurrentUserID(c)
 	
-	file, err := c.FormFile("file")
+	projects, total, err := h.service.ListTrash(userID, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "arquivo é obrigatório"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	
-	// Upload para MinIO/S3 (Sprint 3-4)
-	// Por enquanto, apenas simular
-	manuscriptURL := fmt.Sprintf("s3://typecraft-files/manuscripts/%s/%s", id, file.Filename)
+	c.JSON(http.StatusOK, gin.H{
+		"data":      projects,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+		"pages":     (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// SearchProjects godoc
+// @Summary Buscar projetos
+// @Description Busca por texto em título, autor, descrição e texto do manuscrito convertido (full-text search no Postgres; "frase exata" e -excluir são aceitos).
+// @Description Datas em RFC 3339 ou AAAA-MM-DD; os limites "from" são inclusivos e os "to" exclusivos (uma data sem hora inclui o dia inteiro).
+// @Tags projects
+// @Produce json
+// @Param q query string false "Texto buscado"
+// @Param genre query string false "Gênero"
+// @Param status query string false "Status do projeto"
+// @Param language query string false "Idioma"
+// @Param page_format query string false "Formato da página"
+// @Param created_from query string false "Criados a partir de"
+// @Param created_to query string false "Criados até"
+// @Param updated_from query string false "Atualizados a partir de"
+// @Param updated_to query string false "Atualizados até"
+// @Param sort query string false "relevance, created_at, updated_at, title ou author"
+// @Param order query string false "asc ou desc"
+// @Param limit query int false "Itens por página" default(20)
+// @Param offset query int false "Itens pulados" default(0)
+// @Success 200 {object} service.ProjectSearchResult
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/projects/search [get]
+func (h *ProjectHandler) SearchProjects(c *gin.Context) {
+	filter, ascending, err := projectFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter.UserID = CurrentUserID(c)
+
+	result, err := h.service.SearchProjects(filter, ascending)
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// RestoreProject godoc
+// @Summary Restaurar projeto da lixeira
+// @Tags projects
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} domain.Project
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/projects/{id}/restore [post]
+func (h *ProjectHandler) RestoreProject(c *gin.Context) {
+	id := c.Param("id")
 	
-	if err := h.service.SetManuscriptURL(id, manuscriptURL); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	project, err := h.service.RestoreProject(CurrentUserID(c), id)
+	if err != nil {
+		respondProjectError(c, err)
 		return
 	}
 	
-	project, _ := h.service.GetProject(id)
 	c.JSON(http.StatusOK, project)
 }
 
@@ -183,8 +245,14 @@ func (h *ProjectHandler) UploadManuscript(c *gin.Context) {
 func (h *ProjectHandler) ProcessProject(c *gin.Context) {
 	id := c.Param("id")
 	
-	if err := h.service.StartProcessing(id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := h.service.StartProcessing(CurrentUserID(c), id); err != nil {
+		// Os demais erros são de validação (status ou manuscrito)
+		err = notOwnedAsMissing(err, domain.ErrProjectNotFound)
+		status := http.StatusBadRequest
+		if errors.Is(err, domain.ErrProjectNotFound) {
+			status = projectErrorStatus(err)
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -199,16 +267,109 @@ func (h *ProjectHandler) ProcessProject(c *gin.Context) {
 // @Tags projects
 // @Produce json
 // @Param id path string true "Project ID"
-// @Success 200 {array} domain.Job
+// @Success 200 {object} domain.JobGraph
 // @Router /api/v1/projects/{id}/jobs [get]
 func (h *ProjectHandler) GetProjectJobs(c *gin.Context) {
 	id := c.Param("id")
 	
-	jobs, err := h.service.GetProjectJobs(id)
+	graph, err := h.service.GetProjectJobs(CurrentUserID(c), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondProjectError(c, err)
 		return
 	}
 	
-	c.JSON(http.StatusOK, jobs)
+	c.JSON(http.StatusOK, graph)
+}
+
+// respondProjectError responde com o status de projectErrorStatus. Um projeto
+// de outro usuário aparece como inexistente (veja notOwnedAsMissing).
+func respondProjectError(c *gin.Context, err error) {
+	err = notOwnedAsMissing(err, domain.ErrProjectNotFound)
+	c.JSON(projectErrorStatus(err), gin.H{"error": err.Error()})
+}
+
+// projectErrorStatus mapeia erros de projeto para status HTTP
+func projectErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrProjectNotFound), errors.Is(err, service.ErrProjectAccessDenied):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidProjectSort):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// projectFilter lê os filtros da busca de projetos da query string. A ordem
+// é nil quando não informada, para o serviço usar a padrão da ordenação.
+func projectFilter(c *gin.Context) (domain.ProjectFilter, *bool, error) {
+	filter := domain.ProjectFilter{
+		Query:      c.Query("q"),
+		Genre:      c.Query("genre"),
+		Status:     domain.ProjectStatus(c.Query("status")),
+		Language:   c.Query("language"),
+		PageFormat: c.Query("page_format"),
+		Sort:       domain.ProjectSort(c.Query("sort")),
+	}
+
+	for name, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, nil, fmt.Errorf("%s inválido: %q", name, value)
+		}
+		*target = n
+	}
+
+	dates := []struct {
+		name   string
+		target **time.Time
+		end    bool
+	}{
+		{"created_from", &filter.CreatedFrom, false},
+		{"created_to", &filter.CreatedTo, true},
+		{"updated_from", &filter.UpdatedFrom, false},
+		{"updated_to", &filter.UpdatedTo, true},
+	}
+	for _, date := range dates {
+		value := c.Query(date.name)
+		if value == "" {
+			continue
+		}
+		t, err := parseFilterDate(value, date.end)
+		if err != nil {
+			return filter, nil, fmt.Errorf("%s inválido: %q (use RFC 3339 ou AAAA-MM-DD)", date.name, value)
+		}
+		*date.target = &t
+	}
+
+	var ascending *bool
+	switch order := c.Query("order"); order {
+	case "":
+	case "asc", "desc":
+		value := order == "asc"
+		ascending = &value
+	default:
+		return filter, nil, fmt.Errorf("order inválido: %q (use asc ou desc)", order)
+	}
+
+	return filter, ascending, nil
+}
+
+// parseFilterDate lê uma data em RFC 3339 ou AAAA-MM-DD. Como limite final
+// (exclusivo), uma data sem hora vale até o fim do dia.
+func parseFilterDate(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
 }
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// RevisionHandler lists the manuscript revisions of a project and compares them
+type RevisionHandler struct {
+	service *service.RevisionService
+}
+
+// NewRevisionHandler creates the revision handler
+func NewRevisionHandler(revisions *service.RevisionService) *RevisionHandler {
+	return &RevisionHandler{service: revisions}
+}
+
+// ListRevisions handles GET /api/v1/projects/:id/revisions
+// @Summary List manuscript revisions
+// @Description Lists every uploaded version of the manuscript (newest first) with its number, SHA-256 checksum, uploader and note.
+// @Description Jobs, artifacts and AI analyses carry the revision_id they were built from.
+// @Tags revisions
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {array} domain.Revision
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/projects/{id}/revisions [get]
+func (h *RevisionHandler) ListRevisions(c *gin.Context) {
+	userID := CurrentUserID(c)
+
+	revisions, err := h.service.List(userID, c.Param("id"))
+	if err != nil {
+		respondRevisionError(c, "Failed to list revisions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetRevision handles GET /api/v1/projects/:id/revisions/:number
+// @Summary Get a manuscript revision
+// @Tags revisions
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param number path int true "Revision number"
+// @Success 200 {object} domain.Revision
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/projects/{id}/revisions/{number} [get]
+func (h *RevisionHandler) GetRevision(c *gin.Context) {
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: "invalid revision number"})
+		return
+	}
+
+	userID := CurrentUserID(c)
+
+	revision, err := h.service.Get(userID, c.Param("id"), number)
+	if err != nil {
+		respondRevisionError(c, "Failed to get revision", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffRevisions handles GET /api/v1/projects/:id/revisions/diff
+// @Summary Compare two manuscript revisions
+// @Description Converts both revisions to text and compares them chapter by chapter.
+// @Description Chapters are matched by title (renamed chapters by content) and marked added, removed, modified or unchanged; changed chapters carry unified-diff style hunks.
+// @Tags revisions
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param from query int true "Older revision number"
+// @Param to query int true "Newer revision number"
+// @Success 200 {object} service.RevisionDiff
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/projects/{id}/revisions/diff [get]
+func (h *RevisionHandler) DiffRevisions(c *gin.Context) {
+	from, err := revisionQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+	to, err := revisionQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+
+	userID := CurrentUserID(c)
+
+	diff, err := h.service.Diff(c.Request.Context(), userID, c.Param("id"), from, to)
+	if err != nil {
+		respondRevisionError(c, "Failed to compare revisions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// RegisterRoutes registers the revision routes
+func (h *RevisionHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/projects/:id/revisions", h.ListRevisions)
+	router.GET("/projects/:id/revisions/diff", h.DiffRevisions)
+	router.GET("/projects/:id/revisions/:number", h.GetRevision)
+}
+
+// revisionQuery reads a revision number from the query string
+func revisionQuery(c *gin.Context, name string) (int, error) {
+	number, err := strconv.Atoi(c.Query(name))
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("%s must be a revision number", name)
+	}
+	return number, nil
+}
+
+// respondRevisionError maps revision errors to HTTP statuses
+func respondRevisionError(c *gin.Context, message string, err error) {
+	err = notOwnedAsMissing(err, domain.ErrProjectNotFound)
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrProjectNotFound), errors.Is(err, domain.ErrRevisionNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidBundle):
+		status = http.StatusUnprocessableEntity
+	}
+
+	c.JSON(status, ErrorResponse{Error: message, Message: err.Error()})
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// tusVersion is the resumable upload protocol version spoken by the chunked
+// upload endpoints
+const tusVersion = "1.0.0"
+
+// UploadHandler receives manuscripts, whole or in resumable chunks
+type UploadHandler struct {
+	service *service.UploadService
+}
+
+// NewUploadHandler creates the upload handler
+func NewUploadHandler(uploads *service.UploadService) *UploadHandler {
+	return &UploadHandler{service: uploads}
+}
+
+// UploadManuscript handles POST /api/v1/projects/:id/upload
+// @Summary Upload the manuscript
+// @Description Uploads the whole manuscript in one request. Files over the size limit, of unsupported formats, or whose content does not match the extension are rejected before reaching storage.
+// @Description The SHA-256 of the file is returned in the X-Checksum-Sha256 header and in the upload.
+// @Description A ZIP is a bundle: chapter files, images and an optional manifest.json ({"chapters": [...], "assets": [...], "cover": "..."}) setting chapter order, assets and cover.
+// @Tags uploads
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param file formData file true "Manuscript (md, txt, docx, odt, rtf, html, epub) or bundle (zip)"
+// @Param note formData string false "Note kept on the new manuscript revision"
+// @Success 200 {object} domain.Upload
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Router /api/v1/projects/{id}/upload [post]
+func (h *UploadHandler) UploadManuscript(c *gin.Context) {
+	userID := CurrentUserID(c)
+
+	// Room for the multipart envelope around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxSize()+1<<20)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondUploadError(c, "Failed to upload manuscript", service.ErrUploadTooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: "file is required"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+	defer src.Close()
+
+	req := service.CreateUploadRequest{Filename: file.Filename, Size: file.Size, Note: c.PostForm("note")}
+	upload, err := h.service.Upload(c.Request.Context(), userID, c.Param("id"), req, src)
+	if err != nil {
+		respondUploadError(c, "Failed to upload manuscript", err)
+		return
+	}
+
+	c.Header("X-Checksum-Sha256", upload.Checksum)
+	c.JSON(http.StatusOK, upload)
+}
+
+// CreateUpload handles POST /api/v1/projects/:id/uploads
+// @Summary Start a resumable upload
+// @Description Starts a chunked upload, from a JSON body or tus headers (Upload-Length, and Upload-Metadata with a base64 "filename" and optional "note").
+// @Description The size limit and the format are checked here; the upload URL is returned in the Location header.
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param request body service.CreateUploadRequest false "File name, size and revision note"
+// @Success 201 {object} domain.Upload
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Router /api/v1/projects/{id}/uploads [post]
+func (h *UploadHandler) CreateUpload(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+
+	req, err := createUploadRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+
+	userID := CurrentUserID(c)
+
+	upload, err := h.service.Create(userID, c.Param("id"), req)
+	if err != nil {
+		respondUploadError(c, "Failed to create upload", err)
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+upload.ID)
+	c.Header("Upload-Offset", "0")
+	c.JSON(http.StatusCreated, upload)
+}
+
+// GetUpload handles GET /api/v1/projects/:id/uploads/:uploadId
+// @Summary Get an upload
+// @Description Returns the progress of an upload; completed uploads carry the SHA-256 checksum and the manuscript URL
+// @Tags uploads
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param uploadId path string true "Upload ID"
+// @Success 200 {object} domain.Upload
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/projects/{id}/uploads/{uploadId} [get]
+func (h *UploadHandler) GetUpload(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+
+	userID := CurrentUserID(c)
+
+	upload, err := h.service.Get(userID, c.Param("id"), c.Param("uploadId"))
+	if err != nil {
+		respondUploadError(c, "Failed to get upload", err)
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.JSON(http.StatusOK, upload)
+}
+
+// HeadUpload handles HEAD /api/v1/projects/:id/uploads/:uploadId
+// @Summary Get the offset of an upload
+// @Description Returns Upload-Offset and Upload-Length, so an interrupted upload resumes from the right byte
+// @Tags uploads
+// @Param id path string true "Project ID"
+// @Param uploadId path string true "Upload ID"
+// @Success 200
+// @Failure 404
+// @Router /api/v1/projects/{id}/uploads/{uploadId} [head]
+func (h *UploadHandler) HeadUpload(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Cache-Control", "no-store")
+
+	userID := CurrentUserID(c)
+
+	upload, err := h.service.Get(userID, c.Param("id"), c.Param("uploadId"))
+	if err != nil {
+		c.Status(uploadErrorStatus(err))
+		return
+	}
+
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload handles PATCH /api/v1/projects/:id/uploads/:uploadId
+// @Summary Send a chunk
+// @Description Appends the body at Upload-Offset, which must match the current offset (409 otherwise: ask HEAD and resume).
+// @Description Returns 204 with the new Upload-Offset; the last chunk returns 200 with the completed upload and its X-Checksum-Sha256.
+// @Tags uploads
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param uploadId path string true "Upload ID"
+// @Param Upload-Offset header int true "Offset of the chunk"
+// @Success 200 {object} domain.Upload
+// @Success 204
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Router /api/v1/projects/{id}/uploads/{uploadId} [patch]
+func (h *UploadHandler) PatchUpload(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+			Error:   "Invalid request",
+			Message: "Content-Type must be application/offset+octet-stream",
+		})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: "Upload-Offset header is required"})
+		return
+	}
+
+	userID := CurrentUserID(c)
+
+	upload, err := h.service.WriteChunk(c.Request.Context(), userID, c.Param("id"), c.Param("uploadId"), offset, c.Request.Body)
+	if upload != nil {
+		setUploadHeaders(c, upload)
+	}
+	if err != nil {
+		respondUploadError(c, "Failed to write chunk", err)
+		return
+	}
+
+	if upload.Status == domain.UploadStatusCompleted {
+		c.Header("X-Checksum-Sha256", upload.Checksum)
+		c.JSON(http.StatusOK, upload)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RegisterRoutes registers the upload routes
+func (h *UploadHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/projects/:id/upload", h.UploadManuscript)
+	router.POST("/projects/:id/uploads", h.CreateUpload)
+	router.GET("/projects/:id/uploads/:uploadId", h.GetUpload)
+	router.HEAD("/projects/:id/uploads/:uploadId", h.HeadUpload)
+	router.PATCH("/projects/:id/uploads/:uploadId", h.PatchUpload)
+}
+
+// createUploadRequest reads the file name and size from the JSON body or,
+// for tus clients, from the Upload-Length and Upload-Metadata headers
+func createUploadRequest(c *gin.Context) (service.CreateUploadRequest, error) {
+	var req service.CreateUploadRequest
+
+	length := c.GetHeader("Upload-Length")
+	if length == "" {
+		err := c.ShouldBindJSON(&req)
+		return req, err
+	}
+
+	size, err := strconv.ParseInt(length, 10, 64)
+	if err != nil {
+		return req, fmt.Errorf("invalid Upload-Length %q", length)
+	}
+	req.Size = size
+
+	// Upload-Metadata: "key base64value,key base64value"
+	for _, pair := range strings.Split(c.GetHeader("Upload-Metadata"), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key != "filename" && key != "note" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return req, fmt.Errorf("invalid %s metadata: %w", key, err)
+		}
+		if key == "filename" {
+			req.Filename = string(decoded)
+		} else {
+			req.Note = string(decoded)
+		}
+	}
+	if req.Filename == "" {
+		return req, errors.New("filename metadata is required")
+	}
+	return req, nil
+}
+
+// setUploadHeaders reports the progress of an upload in tus headers
+func setUploadHeaders(c *gin.Context, upload *domain.Upload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Size, 10))
+}
+
+// respondUploadError maps upload errors to HTTP statuses
+func respondUploadError(c *gin.Context, message string, err error) {
+	err = notOwnedAsMissing(err, domain.ErrProjectNotFound)
+	c.JSON(uploadErrorStatus(err), ErrorResponse{Error: message, Message: err.Error()})
+}
+
+func uploadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrProjectNotFound), errors.Is(err, domain.ErrUploadNotFound),
+		errors.Is(err, service.ErrProjectAccessDenied):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrUploadOffsetMismatch), errors.Is(err, service.ErrUploadClosed):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrUploadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedFormat):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrInvalidUpload), errors.Is(err, service.ErrInvalidBundle), errors.Is(err, io.ErrUnexpectedEOF):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler manages outbound webhook subscriptions and their delivery log
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+// NewWebhookHandler creates the webhook handler
+func NewWebhookHandler(webhooks *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: webhooks}
+}
+
+// CreateWebhook handles POST /api/v1/webhooks
+// @Summary Subscribe a webhook
+// @Description Subscribes a URL to lifecycle events of a project, or of every project of the user when project_id is omitted.
+// @Description Events: project.status_changed, generation.completed, job.failed, validation.warnings (empty for all).
+// @Description Deliveries are JSON POSTs signed with X-Typecraft-Signature: sha256=HMAC-SHA256(secret, "<X-Typecraft-Timestamp>.<body>"), retried with exponential backoff.
+// @Description The URL must resolve to public addresses only (no loopback, private or link-local ranges); redirects are not followed.
+// @Description The secret is only returned in this response.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body service.CreateWebhookRequest true "Subscription"
+// @Success 201 {object} service.WebhookCreated
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req service.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Message: err.Error()})
+		return
+	}
+
+	userID := CurrentUserID(c)
+
+	hook, err := h.service.Create(userID, req)
+	if err != nil {
+		respondWebhookError(c, "Failed to create webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hook)
+}
+
+// ListWebhooks handles GET /api/v1/webhooks
+// @Summary List webhooks
+// @Tags webhooks
+// @Produce json
+// @Param project_id query string false "Only subscriptions of this project"
+// @Success 200 {array} domain.Webhook
+// @Router /api/v1/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	filter := domain.WebhookFilter{UserID: CurrentUserID(c), ProjectID: c.Query("project_id")}
+
+	hooks, err := h.service.List(filter)
+	if err != nil {
+		respondWebhookError(c, "Failed to list webhooks", err)
+		return
+	}
+	if hooks == nil {
+		hooks = []*domain.Webhook{}
+	}
+
+	c.JSON(http.StatusOK, hooks)
+}
+
+// GetWebhook handles GET /api/v1/webhooks/:webhookId
+// @Summary Get webhook
+// @Tags webhooks
+// @Produce json
+// @Param webhookId path string true "Webhook ID"
+// @Success 200 {object} domain.Webhook
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/webhooks/{webhookId} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	hook, err := h.service.Get(CurrentUserID(c), c.Param("webhookId"))
+	if err != nil {
+		respondWebhookError(c, "Failed to get webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteWebhook handles DELETE /api/v1/webhooks/:webhookId
+// @Summary Delete webhook
+// @Description Removes the subscription and its delivery log; pending deliveries are dropped
+// @Tags webhooks
+// @Param webhookId path string true "Webhook ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/webhooks/{webhookId} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.service.Delete(CurrentUserID(c), c.Param("webhookId")); err != nil {
+		respondWebhookError(c, "Failed to delete webhook", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /api/v1/webhooks/:webhookId/deliveries
+// @Summary Webhook delivery log
+// @Description Lists the deliveries of a webhook, newest first, with payload, attempts, response status and error
+// @Tags webhooks
+// @Produce json
+// @Param webhookId path string true "Webhook ID"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} service.WebhookDeliveryList
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/webhooks/{webhookId}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	var limit, offset int
+	for name, target := range map[string]*int{"limit": &limit, "offset": &offset} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid " + name, Message: err.Error()})
+			return
+		}
+		*target = n
+	}
+
+	deliveries, err := h.service.Deliveries(CurrentUserID(c), c.Param("webhookId"), limit, offset)
+	if err != nil {
+		respondWebhookError(c, "Failed to list webhook deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RegisterRoutes registers the webhook routes
+func (h *WebhookHandler) RegisterRoutes(router *gin.RouterGroup) {
+	webhooks := router.Group("/webhooks")
+	webhooks.POST("", h.CreateWebhook)
+	webhooks.GET("", h.ListWebhooks)
+	webhooks.GET("/:webhookId", h.GetWebhook)
+	webhooks.DELETE("/:webhookId", h.DeleteWebhook)
+	webhooks.GET("/:webhookId/deliveries", h.ListDeliveries)
+}
+
+// respondWebhookError maps webhook errors to HTTP statuses
+func respondWebhookError(c *gin.Context, message string, err error) {
+	err = notOwnedAsMissing(err, domain.ErrWebhookNotFound)
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrWebhookNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidWebhook):
+		status = http.StatusBadRequest
+	}
+
+	c.JSON(status, ErrorResponse{Error: message, Message: err.Error()})
+}
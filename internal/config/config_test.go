@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestLoad_RedisOptional(t *testing.T) {
+	t.Setenv("REDIS_URL", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.UseRedis() {
+		t.Error("Expected no Redis without REDIS_URL")
+	}
+
+	t.Setenv("REDIS_URL", "redis://localhost:6379/0")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.UseRedis() {
+		t.Error("Expected Redis with REDIS_URL")
+	}
+
+	// The memory driver never depends on an external Redis
+	t.Setenv("DATABASE_DRIVER", "memory")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.UseRedis() {
+		t.Error("Expected no Redis with DATABASE_DRIVER=memory")
+	}
+}
@@ -4,80 +4,85 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/JuanCS-Dev/typecraft/internal/domain"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-// DB é a instância global do banco de dados
-var DB *gorm.DB
+// Drivers de banco suportados
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Open estabelece conexão com o banco de dados do driver: dsn é a URL do
+// Postgres ou o caminho do arquivo SQLite (":memory:" para um banco em memória)
+func Open(driver, dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case DriverPostgres, "":
+		dialector = postgres.Open(dsn)
+	case DriverSQLite:
+		dialector = sqlite.Open(sqliteDSN(dsn))
+	default:
+		return nil, fmt.Errorf("driver de banco desconhecido: %q", driver)
+	}
 
-// Connect estabelece conexão com o banco de dados
-func Connect(databaseURL string) error {
-	var err error
-	
 	// Configurar logger do GORM
-	DB, err = gorm.Open(postgres.Open(databaseURL), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
-	
 	if err != nil {
-		return fmt.Errorf("falha ao conectar ao banco: %w", err)
+		return nil, fmt.Errorf("falha ao conectar ao banco: %w", err)
 	}
-	
-	log.Println("✅ Conexão com banco de dados estabelecida")
-	
-	return nil
-}
 
-// Migrate executa as migrations automáticas
-func Migrate() error {
-	if DB == nil {
-		return fmt.Errorf("banco de dados não inicializado")
-	}
-	
-	log.Println("🔄 Executando migrations...")
-	
-	err := DB.AutoMigrate(
-		&domain.Project{},
-		&domain.Job{},
-		&domain.AIAnalysis{},
-	)
-	
-	if err != nil {
-		return fmt.Errorf("falha nas migrations: %w", err)
+	if driver == DriverSQLite {
+		// O SQLite aceita um escritor por vez: uma conexão evita "database is
+		// locked" entre goroutines e mantém um banco ":memory:" único
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
 	}
-	
-	log.Println("✅ Migrations concluídas")
-	
-	return nil
+
+	log.Printf("✅ Conexão com banco de dados (%s) estabelecida", db.Dialector.Name())
+
+	return db, nil
+}
+
+// sqliteDSN liga as chaves estrangeiras e espera locks de outros processos
+// (API e worker no mesmo arquivo) em vez de falhar na hora. Transações
+// começam com o lock de escrita, serializando os Claims de vários workers.
+func sqliteDSN(path string) string {
+	return path + "?_foreign_keys=on&_busy_timeout=5000&_txlock=immediate"
 }
 
 // Close fecha a conexão com o banco de dados
-func Close() error {
-	if DB == nil {
+func Close(db *gorm.DB) error {
+	if db == nil {
 		return nil
 	}
-	
-	sqlDB, err := DB.DB()
+
+	sqlDB, err := db.DB()
 	if err != nil {
 		return err
 	}
-	
+
 	return sqlDB.Close()
 }
 
 // Health verifica se o banco está respondendo
-func Health() error {
-	if DB == nil {
+func Health(db *gorm.DB) error {
+	if db == nil {
 		return fmt.Errorf("banco de dados não inicializado")
 	}
-	
-	sqlDB, err := DB.DB()
+
+	sqlDB, err := db.DB()
 	if err != nil {
 		return err
 	}
-	
+
 	return sqlDB.Ping()
 }
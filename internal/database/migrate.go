@@ -0,0 +1,256 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Erros de versão do schema
+var (
+	// ErrSchemaAhead indica migrations aplicadas que este binário não conhece:
+	// o banco foi migrado por uma versão mais nova e não deve ser usado
+	ErrSchemaAhead = errors.New("o banco está à frente deste binário")
+	// ErrSchemaPending indica migrations conhecidas ainda não aplicadas
+	ErrSchemaPending = errors.New("há migrations pendentes")
+)
+
+// migrationLockKey identifica o advisory lock do Postgres que serializa
+// API, worker e `migrate` aplicando migrations ao mesmo tempo
+const migrationLockKey = 7_346_529_001
+
+// Migration é uma alteração versionada do schema. Up e Down rodam numa
+// transação junto com o registro em schema_migrations; Down nil torna a
+// migration irreversível.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration registra uma migration aplicada
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName especifica o nome da tabela no banco
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus é a situação de uma migration no banco
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // nil se pendente
+	Unknown   bool       // aplicada no banco, mas ausente deste binário
+}
+
+// Migrate aplica em ordem as migrations pendentes. Falha sem alterar nada
+// se o banco estiver à frente do binário (ErrSchemaAhead).
+func Migrate(db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("banco de dados não inicializado")
+	}
+
+	statuses, err := Status(db)
+	if err != nil {
+		return err
+	}
+	if err := checkAhead(statuses); err != nil {
+		return err
+	}
+
+	log.Println("🔄 Executando migrations...")
+
+	applied := 0
+	for _, m := range migrations {
+		if isApplied(statuses, m.Version) {
+			continue
+		}
+		log.Printf("⬆️  %04d_%s", m.Version, m.Name)
+		if err := apply(db, m); err != nil {
+			return fmt.Errorf("falha na migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		applied++
+	}
+
+	log.Printf("✅ Migrations concluídas (%d aplicadas, versão %d)", applied, LatestVersion())
+
+	return nil
+}
+
+// Rollback desfaz as últimas steps migrations aplicadas, da mais recente
+// para a mais antiga
+func Rollback(db *gorm.DB, steps int) error {
+	if db == nil {
+		return fmt.Errorf("banco de dados não inicializado")
+	}
+
+	statuses, err := Status(db)
+	if err != nil {
+		return err
+	}
+	if err := checkAhead(statuses); err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		m := migrations[i]
+		if !isApplied(statuses, m.Version) {
+			continue
+		}
+		if m.Down == nil {
+			return fmt.Errorf("migration %04d_%s é irreversível", m.Version, m.Name)
+		}
+		log.Printf("⬇️  %04d_%s", m.Version, m.Name)
+		if err := revert(db, m); err != nil {
+			return fmt.Errorf("falha ao desfazer a migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		steps--
+	}
+
+	return nil
+}
+
+// Status lista as migrations do binário, aplicadas ou não, seguidas das
+// aplicadas no banco que o binário não conhece
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	if db == nil {
+		return nil, fmt.Errorf("banco de dados não inicializado")
+	}
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("falha ao criar schema_migrations: %w", err)
+	}
+
+	var rows []SchemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("falha ao ler schema_migrations: %w", err)
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	var unknown []MigrationStatus
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		unknown = append(unknown, MigrationStatus{Version: row.Version, Name: row.Name, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Version < unknown[j].Version })
+
+	return append(statuses, unknown...), nil
+}
+
+// CheckSchema verifica se o banco está exatamente na versão do binário,
+// retornando ErrSchemaAhead ou ErrSchemaPending caso contrário
+func CheckSchema(db *gorm.DB) error {
+	statuses, err := Status(db)
+	if err != nil {
+		return err
+	}
+	if err := checkAhead(statuses); err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			return fmt.Errorf("%w: %04d_%s (execute `migrate up`)", ErrSchemaPending, status.Version, status.Name)
+		}
+	}
+	return nil
+}
+
+// LatestVersion é a versão da última migration conhecida pelo binário
+func LatestVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// apply executa o Up de uma migration e a registra na mesma transação
+func apply(db *gorm.DB, m Migration) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := lockMigrations(tx); err != nil {
+			return err
+		}
+		// Outro processo pode tê-la aplicado enquanto esperávamos o lock
+		var count int64
+		if err := tx.Model(&SchemaMigration{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if err := m.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+	})
+}
+
+// revert executa o Down de uma migration e apaga seu registro
+func revert(db *gorm.DB, m Migration) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := lockMigrations(tx); err != nil {
+			return err
+		}
+		result := tx.Where("version = ?", m.Version).Delete(&SchemaMigration{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return m.Down(tx)
+	})
+}
+
+// lockMigrations segura, até o fim da transação, o lock que impede dois
+// processos de migrarem juntos. No SQLite a transação já é exclusiva.
+func lockMigrations(tx *gorm.DB) error {
+	if tx.Dialector.Name() != DriverPostgres {
+		return nil
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey).Error
+}
+
+// checkAhead falha se o banco tiver migrations que o binário não conhece
+func checkAhead(statuses []MigrationStatus) error {
+	for _, status := range statuses {
+		if status.Unknown {
+			return fmt.Errorf("%w: migration %04d_%s aplicada, mas este binário vai até %04d",
+				ErrSchemaAhead, status.Version, status.Name, LatestVersion())
+		}
+	}
+	return nil
+}
+
+// isApplied verifica se a migration version já foi aplicada
+func isApplied(statuses []MigrationStatus, version int) bool {
+	for _, status := range statuses {
+		if status.Version == version {
+			return status.AppliedAt != nil
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := Open(DriverSQLite, filepath.Join(t.TempDir(), "typecraft.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { Close(db) })
+	return db
+}
+
+func TestMigrate_UpDownStatus(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := CheckSchema(db); !errors.Is(err, ErrSchemaPending) {
+		t.Fatalf("expected ErrSchemaPending on an empty database, got %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if err := CheckSchema(db); err != nil {
+		t.Fatalf("CheckSchema after Migrate: %v", err)
+	}
+	// Aplicar de novo não faz nada
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second Migrate failed: %v", err)
+	}
+
+	project := &domain.Project{UserID: "user-1", Title: "Livro", Author: "Autor"}
+	if err := db.Create(project).Error; err != nil {
+		t.Fatalf("expected the migrated schema to accept projects: %v", err)
+	}
+
+	user := &domain.User{ID: "user-1", Email: "autor@example.com", PasswordHash: "hash"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("expected the migrated schema to accept users: %v", err)
+	}
+	var stored domain.User
+	if err := db.First(&stored, "id = ?", "user-1").Error; err != nil || stored.Role != domain.RoleUser {
+		t.Errorf("expected new accounts to default to the user role, got %q (%v)", stored.Role, err)
+	}
+
+	if err := Rollback(db, 1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if db.Migrator().HasColumn("users", "role") {
+		t.Error("expected the roles rollback to drop users.role")
+	}
+
+	if err := Rollback(db, 1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("expected the users rollback to drop users")
+	}
+
+	if err := Rollback(db, 1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if db.Migrator().HasTable("manuscript_texts") {
+		t.Error("expected the search rollback to drop manuscript_texts")
+	}
+
+	if err := Rollback(db, 1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if db.Migrator().HasColumn("projects", "deleted_at") {
+		t.Error("expected the trash rollback to drop projects.deleted_at")
+	}
+
+	if err := Rollback(db, 1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if db.Migrator().HasTable("revisions") || db.Migrator().HasColumn("projects", "revision_id") {
+		t.Error("expected the revisions rollback to drop the table and its columns")
+	}
+
+	if err := Rollback(db, 1); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	statuses, err := Status(db)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil || statuses[2].AppliedAt != nil || statuses[3].AppliedAt != nil || statuses[4].AppliedAt != nil || statuses[5].AppliedAt != nil || statuses[6].AppliedAt != nil {
+		t.Errorf("expected only the baseline to remain applied, got %+v", statuses)
+	}
+	if db.Migrator().HasTable("ai_analyses") {
+		t.Error("expected ai_analyses to be dropped by the rollback")
+	}
+	if !db.Migrator().HasTable("projects") {
+		t.Error("expected projects to survive a one-step rollback")
+	}
+
+	if err := Rollback(db, 6); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if db.Migrator().HasTable("projects") {
+		t.Error("expected the baseline rollback to drop projects")
+	}
+}
+
+func TestMigrate_FailsWhenDatabaseIsAhead(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	future := SchemaMigration{Version: LatestVersion() + 1, Name: "future", AppliedAt: time.Now()}
+	if err := db.Create(&future).Error; err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if err := Migrate(db); !errors.Is(err, ErrSchemaAhead) {
+		t.Errorf("Migrate: expected ErrSchemaAhead, got %v", err)
+	}
+	if err := Rollback(db, 1); !errors.Is(err, ErrSchemaAhead) {
+		t.Errorf("Rollback: expected ErrSchemaAhead, got %v", err)
+	}
+	if err := CheckSchema(db); !errors.Is(err, ErrSchemaAhead) {
+		t.Errorf("CheckSchema: expected ErrSchemaAhead, got %v", err)
+	}
+}
+
+func TestMigrate_ReconcilesLegacyAnalyses(t *testing.T) {
+	db := openTestDB(t)
+
+	// Tabela criada pelo antigo script SQL 003_create_ai_analyses
+	legacy := `CREATE TABLE ai_analyses (
+		id TEXT PRIMARY KEY,
+		manuscript_id TEXT NOT NULL,
+		genre VARCHAR(100) NOT NULL,
+		tone VARCHAR(100) NOT NULL,
+		recommended_pipeline VARCHAR(50) NOT NULL,
+		pipeline_reason TEXT,
+		analyzed_at TIMESTAMP NOT NULL,
+		tokens_used INTEGER DEFAULT 0,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	)`
+	if err := db.Exec(legacy).Error; err != nil {
+		t.Fatalf("creating legacy table: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO ai_analyses VALUES ('a1', 'p1', 'fiction', 'formal', 'html', '', ?, 0, ?, ?)`,
+		time.Now(), time.Now(), time.Now()).Error; err != nil {
+		t.Fatalf("inserting legacy row: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	m := db.Migrator()
+	for _, column := range []string{"manuscript_id", "tone", "pipeline_reason"} {
+		if m.HasColumn("ai_analyses", column) {
+			t.Errorf("expected legacy column %s to be dropped", column)
+		}
+	}
+
+	var existing domain.AIAnalysis
+	if err := db.First(&existing, "id = ?", "a1").Error; err != nil {
+		t.Fatalf("expected the legacy row to survive: %v", err)
+	}
+	if existing.ProjectID != "p1" {
+		t.Errorf("expected manuscript_id to become project_id, got %q", existing.ProjectID)
+	}
+
+	analysis := &domain.AIAnalysis{
+		ProjectID:           "p2",
+		Genre:               "technical",
+		RecommendedPipeline: "latex",
+		WordCount:           100,
+		EstimatedPages:      1,
+		AnalyzedAt:          time.Now(),
+	}
+	if err := analysis.BeforeCreate(); err != nil {
+		t.Fatalf("BeforeCreate failed: %v", err)
+	}
+	if err := db.Create(analysis).Error; err != nil {
+		t.Errorf("expected inserts to work after reconciling: %v", err)
+	}
+}
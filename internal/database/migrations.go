@@ -0,0 +1,561 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrations é a história do schema, em ordem de versão. Uma migration
+// aplicada nunca muda: alterações novas entram como uma nova versão.
+//
+// As migrations usam structs próprias, cópias das entidades de domain na
+// época em que foram escritas, para que mudanças futuras nas entidades não
+// alterem o que uma versão antiga cria.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		Up:      baselineUp,
+		Down:    baselineDown,
+	},
+	{
+		Version: 2,
+		Name:    "ai_analyses",
+		Up:      aiAnalysesUp,
+		Down:    aiAnalysesDown,
+	},
+	{
+		Version: 3,
+		Name:    "revisions",
+		Up:      revisionsUp,
+		Down:    revisionsDown,
+	},
+	{
+		Version: 4,
+		Name:    "project_trash",
+		Up:      projectTrashUp,
+		Down:    projectTrashDown,
+	},
+	{
+		Version: 5,
+		Name:    "project_search",
+		Up:      projectSearchUp,
+		Down:    projectSearchDown,
+	},
+	{
+		Version: 6,
+		Name:    "users",
+		Up:      usersUp,
+		Down:    usersDown,
+	},
+	{
+		Version: 7,
+		Name:    "user_roles",
+		Up:      userRolesUp,
+		Down:    userRolesDown,
+	},
+}
+
+// 0001_baseline: o schema que o AutoMigrate criava. Em bancos que já o têm,
+// apenas cria o que faltar.
+
+type projectV1 struct {
+	ID                   uint   `gorm:"primaryKey;autoIncrement"`
+	UserID               string `gorm:"index;not null"`
+	Title                string `gorm:"not null"`
+	Author               string `gorm:"not null"`
+	Genre                string
+	Language             string `gorm:"default:'pt'"`
+	ISBN                 string
+	Description          string
+	PageFormat           string `gorm:"default:'6x9'"`
+	DistributionChannels string `gorm:"type:jsonb"`
+	Status               string `gorm:"default:'created';index"`
+	Progress             int    `gorm:"default:0"`
+	Analysis             string `gorm:"type:jsonb"`
+	DesignConfig         string `gorm:"type:jsonb"`
+	ManuscriptURL        string
+	PDFKdpURL            string
+	PDFIngramSparkURL    string
+	EpubURL              string
+	CoverURL             string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	CompletedAt          *time.Time
+	ErrorLog             string `gorm:"type:jsonb"`
+}
+
+func (projectV1) TableName() string { return "projects" }
+
+type jobV1 struct {
+	ID             string `gorm:"primaryKey"`
+	ProjectID      string `gorm:"index;not null"`
+	Type           string `gorm:"not null"`
+	Status         string `gorm:"default:'pending';index"`
+	Priority       int    `gorm:"default:5"`
+	DependsOn      string `gorm:"type:jsonb"`
+	Payload        string `gorm:"type:jsonb"`
+	Result         string `gorm:"type:jsonb"`
+	ErrorMsg       string
+	Attempts       int `gorm:"default:0"`
+	MaxAttempts    int `gorm:"default:3"`
+	CreatedAt      time.Time
+	RunAfter       *time.Time `gorm:"index"`
+	StartedAt      *time.Time
+	LeaseOwner     string     `gorm:"index"`
+	LeaseExpiresAt *time.Time `gorm:"index"`
+	CompletedAt    *time.Time
+}
+
+func (jobV1) TableName() string { return "jobs" }
+
+type jobLogV1 struct {
+	ID         string `gorm:"primaryKey"`
+	JobID      string `gorm:"index;not null"`
+	Attempt    int
+	Tool       string
+	Command    string `gorm:"type:jsonb"`
+	Dir        string
+	ExitCode   int
+	DurationMs int64
+	Error      string
+	Stdout     string `gorm:"type:text"`
+	Stderr     string `gorm:"type:text"`
+	Files      string `gorm:"type:jsonb"`
+	Truncated  bool
+	StartedAt  time.Time
+	CreatedAt  time.Time
+}
+
+func (jobLogV1) TableName() string { return "job_logs" }
+
+type webhookV1 struct {
+	ID        string `gorm:"primaryKey"`
+	UserID    string `gorm:"index;not null"`
+	ProjectID string `gorm:"index"`
+	URL       string `gorm:"not null"`
+	Secret    string `gorm:"not null"`
+	Events    string `gorm:"type:jsonb"`
+	Active    bool   `gorm:"default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (webhookV1) TableName() string { return "webhooks" }
+
+type webhookDeliveryV1 struct {
+	ID             string `gorm:"primaryKey"`
+	WebhookID      string `gorm:"index;not null"`
+	Event          string `gorm:"not null"`
+	Payload        string `gorm:"type:text"`
+	Status         string `gorm:"default:'pending';index"`
+	Attempts       int
+	MaxAttempts    int
+	NextAttemptAt  *time.Time `gorm:"index"`
+	ResponseStatus int
+	ResponseBody   string `gorm:"type:text"`
+	Error          string
+	DurationMs     int64
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+func (webhookDeliveryV1) TableName() string { return "webhook_deliveries" }
+
+type artifactV1 struct {
+	ID          string `gorm:"primaryKey"`
+	ProjectID   uint   `gorm:"index;not null"`
+	JobID       string `gorm:"index"`
+	Format      string
+	Key         string `gorm:"not null"`
+	Size        int64
+	Checksum    string
+	ContentType string
+	CreatedAt   time.Time
+}
+
+func (artifactV1) TableName() string { return "artifacts" }
+
+type uploadV1 struct {
+	ID            string `gorm:"primaryKey"`
+	ProjectID     uint   `gorm:"index;not null"`
+	UserID        string
+	Filename      string
+	Format        string
+	ContentType   string
+	Size          int64
+	Offset        int64 `gorm:"column:upload_offset"`
+	Checksum      string
+	Status        string
+	Error         string
+	ManuscriptURL string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (uploadV1) TableName() string { return "uploads" }
+
+// baselineTables são as tabelas da 0001, na ordem de criação
+func baselineTables() []interface{} {
+	return []interface{}{
+		&projectV1{},
+		&jobV1{},
+		&jobLogV1{},
+		&webhookV1{},
+		&webhookDeliveryV1{},
+		&artifactV1{},
+		&uploadV1{},
+	}
+}
+
+func baselineUp(tx *gorm.DB) error {
+	return tx.AutoMigrate(baselineTables()...)
+}
+
+func baselineDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(baselineTables()...)
+}
+
+// 0002_ai_analyses: a tabela de análises de IA. Alguns bancos a receberam
+// de um script SQL antigo, com manuscript_id no lugar de project_id, uma
+// chave estrangeira para a inexistente tabela manuscripts e colunas que
+// o código nunca usou (algumas NOT NULL, que impediam os inserts).
+
+type aiAnalysisV2 struct {
+	ID                           string    `gorm:"type:uuid;primaryKey"`
+	ProjectID                    string    `gorm:"type:uuid;not null;index"`
+	Genre                        string    `gorm:"type:varchar(100);not null"`
+	GenreConfidence              float64   `gorm:"type:decimal(5,4)"`
+	SubGenres                    string    `gorm:"type:text"`
+	TonePrimary                  string    `gorm:"type:varchar(100)"`
+	ToneFormality                float64   `gorm:"type:decimal(5,4)"`
+	ToneEmotion                  string    `gorm:"type:varchar(100)"`
+	ToneConfidence               float64   `gorm:"type:decimal(5,4)"`
+	ComplexityAvgSentenceLength  float64   `gorm:"type:decimal(7,2)"`
+	ComplexityVocabularyRichness float64   `gorm:"type:decimal(5,4)"`
+	ComplexitySyntaxComplexity   float64   `gorm:"type:decimal(5,4)"`
+	ComplexityTechnicalDensity   float64   `gorm:"type:decimal(5,4)"`
+	ComplexityReadingLevel       string    `gorm:"type:varchar(50)"`
+	EmotionalKeywords            string    `gorm:"type:text"`
+	Sentiments                   string    `gorm:"type:text"`
+	HasMath                      bool      `gorm:"type:boolean;default:false"`
+	HasCode                      bool      `gorm:"type:boolean;default:false"`
+	HasImages                    bool      `gorm:"type:boolean;default:false"`
+	WordCount                    int       `gorm:"type:integer;not null"`
+	EstimatedPages               int       `gorm:"type:integer;not null"`
+	RecommendedPipeline          string    `gorm:"type:varchar(50);not null"`
+	AnalyzedAt                   time.Time `gorm:"not null"`
+	TokensUsed                   int       `gorm:"type:integer;default:0"`
+	CreatedAt                    time.Time
+	UpdatedAt                    time.Time
+}
+
+func (aiAnalysisV2) TableName() string { return "ai_analyses" }
+
+// legacyAnalysisColumns são as colunas do script antigo sem uso no código
+var legacyAnalysisColumns = []string{
+	"sub_genre", "tone", "tone_score",
+	"equation_percentage", "code_percentage", "table_count", "image_count",
+	"pipeline_confidence", "pipeline_reason",
+	"recommended_body_font", "recommended_title_font", "recommended_mono_font", "font_rationale",
+}
+
+func aiAnalysesUp(tx *gorm.DB) error {
+	m := tx.Migrator()
+	analysis := &aiAnalysisV2{}
+
+	if m.HasTable(analysis) && m.HasColumn(analysis, "manuscript_id") {
+		if m.HasConstraint(analysis, "fk_ai_analyses_manuscript") {
+			if err := m.DropConstraint(analysis, "fk_ai_analyses_manuscript"); err != nil {
+				return err
+			}
+		}
+		if m.HasIndex(analysis, "idx_ai_analyses_manuscript_id") {
+			if err := m.DropIndex(analysis, "idx_ai_analyses_manuscript_id"); err != nil {
+				return err
+			}
+		}
+		legacy := legacyAnalysisColumns
+		if m.HasColumn(analysis, "project_id") {
+			legacy = append([]string{"manuscript_id"}, legacy...)
+		} else if err := m.RenameColumn(analysis, "manuscript_id", "project_id"); err != nil {
+			return err
+		}
+		for _, column := range legacy {
+			if !m.HasColumn(analysis, column) {
+				continue
+			}
+			// DROP COLUMN direto: o Migrator do SQLite reescreve o CREATE TABLE
+			// e não reconhece colunas sem aspas, como as do script antigo
+			if err := tx.Exec("ALTER TABLE ai_analyses DROP COLUMN " + column).Error; err != nil {
+				return err
+			}
+		}
+		// Colunas NOT NULL novas precisam de um valor para as linhas existentes
+		for _, column := range []string{"word_count", "estimated_pages"} {
+			if m.HasColumn(analysis, column) {
+				continue
+			}
+			if err := tx.Exec("ALTER TABLE ai_analyses ADD COLUMN " + column + " integer NOT NULL DEFAULT 0").Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.AutoMigrate(analysis)
+}
+
+func aiAnalysesDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&aiAnalysisV2{})
+}
+
+// 0003_revisions: cada upload vira uma revisão numerada do manuscrito, e
+// projetos, jobs, artefatos, análises e uploads passam a apontar para ela.
+
+type revisionV3 struct {
+	ID            string `gorm:"primaryKey"`
+	ProjectID     uint   `gorm:"not null;uniqueIndex:idx_revisions_project_number"`
+	Number        int    `gorm:"not null;uniqueIndex:idx_revisions_project_number"`
+	Filename      string
+	ManuscriptURL string
+	Size          int64
+	Checksum      string
+	UploadedBy    string
+	Note          string
+	CreatedAt     time.Time
+}
+
+func (revisionV3) TableName() string { return "revisions" }
+
+// As colunas novas das tabelas existentes, uma struct por tabela
+type projectRevisionV3 struct {
+	RevisionID string
+}
+
+func (projectRevisionV3) TableName() string { return "projects" }
+
+type jobRevisionV3 struct {
+	RevisionID string `gorm:"index"`
+}
+
+func (jobRevisionV3) TableName() string { return "jobs" }
+
+type artifactRevisionV3 struct {
+	RevisionID string `gorm:"index"`
+}
+
+func (artifactRevisionV3) TableName() string { return "artifacts" }
+
+type aiAnalysisRevisionV3 struct {
+	RevisionID string `gorm:"index"`
+}
+
+func (aiAnalysisRevisionV3) TableName() string { return "ai_analyses" }
+
+type uploadRevisionV3 struct {
+	Note       string
+	RevisionID string
+}
+
+func (uploadRevisionV3) TableName() string { return "uploads" }
+
+// revisionColumns lista, por tabela, as colunas adicionadas pela 0003
+func revisionColumns() []struct {
+	model   interface{}
+	columns []string
+	indexed bool
+} {
+	return []struct {
+		model   interface{}
+		columns []string
+		indexed bool
+	}{
+		{&projectRevisionV3{}, []string{"RevisionID"}, false},
+		{&jobRevisionV3{}, []string{"RevisionID"}, true},
+		{&artifactRevisionV3{}, []string{"RevisionID"}, true},
+		{&aiAnalysisRevisionV3{}, []string{"RevisionID"}, true},
+		{&uploadRevisionV3{}, []string{"Note", "RevisionID"}, false},
+	}
+}
+
+func revisionsUp(tx *gorm.DB) error {
+	m := tx.Migrator()
+	if err := m.AutoMigrate(&revisionV3{}); err != nil {
+		return err
+	}
+
+	for _, table := range revisionColumns() {
+		for _, column := range table.columns {
+			if m.HasColumn(table.model, column) {
+				continue
+			}
+			if err := m.AddColumn(table.model, column); err != nil {
+				return err
+			}
+		}
+		if table.indexed && !m.HasIndex(table.model, "RevisionID") {
+			if err := m.CreateIndex(table.model, "RevisionID"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func revisionsDown(tx *gorm.DB) error {
+	m := tx.Migrator()
+	for _, table := range revisionColumns() {
+		// No SQLite a coluna só pode ser removida depois do índice
+		if table.indexed && m.HasIndex(table.model, "RevisionID") {
+			if err := m.DropIndex(table.model, "RevisionID"); err != nil {
+				return err
+			}
+		}
+		for _, column := range table.columns {
+			if !m.HasColumn(table.model, column) {
+				continue
+			}
+			if err := m.DropColumn(table.model, column); err != nil {
+				return err
+			}
+		}
+	}
+	return m.DropTable(&revisionV3{})
+}
+
+// 0004_project_trash: projetos excluídos vão para a lixeira (deleted_at) e
+// só são apagados quando expurgados.
+
+type projectTrashV4 struct {
+	DeletedAt *time.Time `gorm:"index"`
+}
+
+func (projectTrashV4) TableName() string { return "projects" }
+
+func projectTrashUp(tx *gorm.DB) error {
+	m := tx.Migrator()
+	project := &projectTrashV4{}
+	if !m.HasColumn(project, "DeletedAt") {
+		if err := m.AddColumn(project, "DeletedAt"); err != nil {
+			return err
+		}
+	}
+	if !m.HasIndex(project, "DeletedAt") {
+		return m.CreateIndex(project, "DeletedAt")
+	}
+	return nil
+}
+
+func projectTrashDown(tx *gorm.DB) error {
+	m := tx.Migrator()
+	project := &projectTrashV4{}
+	// No SQLite a coluna só pode ser removida depois do índice
+	if m.HasIndex(project, "DeletedAt") {
+		if err := m.DropIndex(project, "DeletedAt"); err != nil {
+			return err
+		}
+	}
+	if m.HasColumn(project, "DeletedAt") {
+		return m.DropColumn(project, "DeletedAt")
+	}
+	return nil
+}
+
+// 0005_project_search: o texto extraído dos manuscritos, para a busca de
+// projetos. No Postgres, índices de full-text search (configuração 'simple':
+// o catálogo mistura idiomas) sobre o texto e sobre título, autor e
+// descrição; nos demais bancos a busca usa LIKE e dispensa índices.
+
+type manuscriptTextV5 struct {
+	ProjectID  uint `gorm:"primaryKey;autoIncrement:false"`
+	RevisionID string
+	Content    string `gorm:"type:text"`
+	UpdatedAt  time.Time
+}
+
+func (manuscriptTextV5) TableName() string { return "manuscript_texts" }
+
+// projectSearchPostgres cria a coluna tsvector e os índices GIN. A expressão
+// do índice de projects precisa ser a mesma usada nas consultas
+// (repository.projectDocument).
+var projectSearchPostgres = []string{
+	`ALTER TABLE manuscript_texts ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('simple', coalesce(content, ''))) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_manuscript_texts_search ON manuscript_texts USING GIN (search_vector)`,
+	`CREATE INDEX IF NOT EXISTS idx_projects_search ON projects USING GIN (
+		to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(author, '') || ' ' || coalesce(description, '')))`,
+}
+
+func projectSearchUp(tx *gorm.DB) error {
+	if err := tx.Migrator().AutoMigrate(&manuscriptTextV5{}); err != nil {
+		return err
+	}
+	if tx.Dialector.Name() != DriverPostgres {
+		return nil
+	}
+	for _, statement := range projectSearchPostgres {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func projectSearchDown(tx *gorm.DB) error {
+	if tx.Dialector.Name() == DriverPostgres {
+		if err := tx.Exec("DROP INDEX IF EXISTS idx_projects_search").Error; err != nil {
+			return err
+		}
+	}
+	return tx.Migrator().DropTable(&manuscriptTextV5{})
+}
+
+// 0006_users: as contas da API. Projetos existentes continuam com o UserID
+// que tinham (até aqui, "default_user").
+
+type userV6 struct {
+	ID           string `gorm:"primaryKey"`
+	Email        string `gorm:"uniqueIndex;not null"`
+	Name         string
+	PasswordHash string `gorm:"not null"`
+	TokenVersion int    `gorm:"not null;default:0"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (userV6) TableName() string { return "users" }
+
+func usersUp(tx *gorm.DB) error {
+	return tx.Migrator().AutoMigrate(&userV6{})
+}
+
+func usersDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&userV6{})
+}
+
+// 0007_user_roles: o papel de cada conta. Todas começam como "user"; um
+// operador é promovido direto no banco
+// (UPDATE users SET role = 'operator' WHERE email = ...).
+
+type userRoleV7 struct {
+	Role string `gorm:"not null;default:'user'"`
+}
+
+func (userRoleV7) TableName() string { return "users" }
+
+func userRolesUp(tx *gorm.DB) error {
+	m := tx.Migrator()
+	if m.HasColumn(&userRoleV7{}, "Role") {
+		return nil
+	}
+	return m.AddColumn(&userRoleV7{}, "Role")
+}
+
+func userRolesDown(tx *gorm.DB) error {
+	m := tx.Migrator()
+	if !m.HasColumn(&userRoleV7{}, "Role") {
+		return nil
+	}
+	return m.DropColumn(&userRoleV7{}, "Role")
+}
@@ -11,6 +11,7 @@ import (
 type AIAnalysis struct {
 	ID              string             `gorm:"type:uuid;primaryKey" json:"id"`
 	ProjectID       string             `gorm:"type:uuid;not null;index" json:"project_id"`
+	RevisionID      string             `gorm:"index" json:"revision_id,omitempty"` // Revisão do manuscrito analisada
 	
 	// Classificação de gênero
 	Genre           string             `gorm:"type:varchar(100);not null" json:"genre"`
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrArtifactNotFound é retornado para um arquivo gerado inexistente
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+// Artifact é um arquivo gerado (PDF, ePub) guardado no storage. Cada geração
+// grava seus arquivos sob uma chave própria, então artefatos de gerações
+// anteriores continuam disponíveis para download.
+type Artifact struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	ProjectID   uint      `json:"project_id" gorm:"index;not null"`
+	JobID       string    `json:"job_id" gorm:"index"`                // geração (job render ou export) que o produziu
+	RevisionID  string    `json:"revision_id,omitempty" gorm:"index"` // revisão do manuscrito usada na geração
+	Format      string    `json:"format"`
+	Key         string    `json:"-" gorm:"not null"` // chave no storage
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"` // SHA-256 em hexadecimal
+	ContentType string    `json:"content_type"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -1,21 +1,30 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"time"
+)
 
 // Job representa uma tarefa assíncrona de processamento
 type Job struct {
 	ID          string    `json:"id" gorm:"primaryKey"`
 	ProjectID   string    `json:"project_id" gorm:"index;not null"`
+	RevisionID  string    `json:"revision_id,omitempty" gorm:"index"` // Revisão do manuscrito processada
 	Type        JobType   `json:"type" gorm:"not null"`
 	Status      JobStatus `json:"status" gorm:"default:'pending';index"`
 	Priority    int       `json:"priority" gorm:"default:5"` // 1-10, maior = mais prioritário
-	Payload     *map[string]interface{} `json:"payload,omitempty" gorm:"type:jsonb"`
-	Result      *map[string]interface{} `json:"result,omitempty" gorm:"type:jsonb"`
+	DependsOn   *[]string `json:"depends_on,omitempty" gorm:"type:jsonb;serializer:json"` // IDs dos jobs pais (DAG)
+	Payload     *map[string]interface{} `json:"payload,omitempty" gorm:"type:jsonb;serializer:json"`
+	Result      *map[string]interface{} `json:"result,omitempty" gorm:"type:jsonb;serializer:json"`
 	ErrorMsg    string    `json:"error_msg,omitempty"`
 	Attempts    int       `json:"attempts" gorm:"default:0"`
 	MaxAttempts int       `json:"max_attempts" gorm:"default:3"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	RunAfter    *time.Time `json:"run_after,omitempty" gorm:"index"` // Próxima tentativa (backoff)
 	StartedAt   *time.Time `json:"started_at,omitempty"`
+	LeaseOwner     string     `json:"lease_owner,omitempty" gorm:"index"`      // Worker que detém o job
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty" gorm:"index"` // Renovado pelos heartbeats
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
 
@@ -59,6 +68,29 @@ func (j *Job) CanRetry() bool {
 	return j.Status == JobStatusFailed && j.Attempts < j.MaxAttempts
 }
 
+// CanCancel verifica se o job ainda não terminou e pode ser cancelado
+func (j *Job) CanCancel() bool {
+	return j.Status == JobStatusPending || j.Status == JobStatusRunning
+}
+
+// IsDeadLetter verifica se o job falhou após esgotar suas tentativas
+func (j *Job) IsDeadLetter() bool {
+	return j.Status == JobStatusFailed && j.Attempts >= j.MaxAttempts
+}
+
+// Requeue devolve um job falho ou cancelado à fila com as tentativas zeradas,
+// limpando o erro e o resultado anteriores
+func (j *Job) Requeue() {
+	j.Status = JobStatusPending
+	j.ErrorMsg = ""
+	j.Result = nil
+	j.Attempts = 0
+	j.RunAfter = nil
+	j.StartedAt = nil
+	j.CompletedAt = nil
+	j.ReleaseLease()
+}
+
 // MarkStarted marca o job como iniciado
 func (j *Job) MarkStarted() {
 	j.Status = JobStatusRunning
@@ -82,3 +114,209 @@ func (j *Job) MarkFailed(err error) {
 	now := time.Now()
 	j.CompletedAt = &now
 }
+
+// MarkCancelled marca o job como cancelado, registrando o motivo
+func (j *Job) MarkCancelled(reason string) {
+	j.Status = JobStatusCancelled
+	j.ErrorMsg = reason
+	now := time.Now()
+	j.CompletedAt = &now
+}
+
+// ScheduleRetry devolve o job à fila para uma nova tentativa a partir de at
+func (j *Job) ScheduleRetry(err error, at time.Time) {
+	j.Status = JobStatusPending
+	j.ErrorMsg = err.Error()
+	j.RunAfter = &at
+}
+
+// JobFilter filtra a listagem de jobs; campos vazios não filtram
+type JobFilter struct {
+	Status     JobStatus
+	Type       JobType
+	ProjectID  string
+	ProjectIDs []string // apenas jobs destes projetos (nil não filtra; vazio não casa nenhum)
+	DeadLetter bool     // apenas jobs que esgotaram MaxAttempts
+	Limit      int
+	Offset     int
+}
+
+// Matches verifica se o job passa pelo filtro (ignora Limit e Offset)
+func (f JobFilter) Matches(j *Job) bool {
+	switch {
+	case f.Status != "" && j.Status != f.Status:
+		return false
+	case f.Type != "" && j.Type != f.Type:
+		return false
+	case f.ProjectID != "" && j.ProjectID != f.ProjectID:
+		return false
+	case f.ProjectIDs != nil && !slices.Contains(f.ProjectIDs, j.ProjectID):
+		return false
+	case f.DeadLetter && !j.IsDeadLetter():
+		return false
+	}
+	return true
+}
+
+// Lease reserva o job para o worker owner até until
+func (j *Job) Lease(owner string, until time.Time) {
+	j.LeaseOwner = owner
+	j.LeaseExpiresAt = &until
+}
+
+// ReleaseLease libera o job do worker que o detinha
+func (j *Job) ReleaseLease() {
+	j.LeaseOwner = ""
+	j.LeaseExpiresAt = nil
+}
+
+// LeaseExpired verifica se o job está em execução com o lease vencido,
+// ou seja, o worker parou de enviar heartbeats
+func (j *Job) LeaseExpired(now time.Time) bool {
+	return j.Status == JobStatusRunning && j.LeaseExpiresAt != nil && !j.LeaseExpiresAt.After(now)
+}
+
+// RecoverExpiredLease trata um job abandonado por um worker: volta para a
+// fila se CanRetry permitir, senão permanece falho
+func (j *Job) RecoverExpiredLease(now time.Time) {
+	err := fmt.Errorf("lease do worker %s expirou", j.LeaseOwner)
+	j.ReleaseLease()
+	j.MarkFailed(err)
+	if j.CanRetry() {
+		j.CompletedAt = nil
+		j.ScheduleRetry(err, now)
+	}
+}
+
+// Parents retorna os IDs dos jobs dos quais este depende
+func (j *Job) Parents() []string {
+	if j.DependsOn == nil {
+		return nil
+	}
+	return *j.DependsOn
+}
+
+// AddDependency faz o job aguardar a conclusão de parent
+func (j *Job) AddDependency(parent *Job) {
+	parents := append(slices.Clone(j.Parents()), parent.ID)
+	j.DependsOn = &parents
+}
+
+// DependenciesMet indica se todos os pais foram concluídos com sucesso.
+// statuses mapeia o ID de cada job do projeto para seu status atual.
+func (j *Job) DependenciesMet(statuses map[string]JobStatus) bool {
+	for _, parent := range j.Parents() {
+		if statuses[parent] != JobStatusCompleted {
+			return false
+		}
+	}
+	return true
+}
+
+// Descendants retorna, em ordem de visita, os jobs que dependem direta ou
+// indiretamente do job id
+func Descendants(jobs []*Job, id string) []*Job {
+	children := make(map[string][]*Job)
+	for _, job := range jobs {
+		for _, parent := range job.Parents() {
+			children[parent] = append(children[parent], job)
+		}
+	}
+
+	var result []*Job
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current] {
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			result = append(result, child)
+			queue = append(queue, child.ID)
+		}
+	}
+	return result
+}
+
+// ValidateJobGraph verifica se as dependências apontam para jobs conhecidos e
+// não formam ciclos
+func ValidateJobGraph(jobs []*Job) error {
+	byID := make(map[string]*Job, len(jobs))
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+
+	var visit func(job *Job) error
+	visit = func(job *Job) error {
+		switch state[job.ID] {
+		case visiting:
+			return fmt.Errorf("ciclo de dependências no job %s", job.ID)
+		case done:
+			return nil
+		}
+		state[job.ID] = visiting
+		for _, parentID := range job.Parents() {
+			parent, ok := byID[parentID]
+			if !ok {
+				return fmt.Errorf("job %s depende de job desconhecido %s", job.ID, parentID)
+			}
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		state[job.ID] = done
+		return nil
+	}
+
+	for _, job := range jobs {
+		if err := visit(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JobEdge liga um job pai (From) a um job que depende dele (To)
+type JobEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// JobGraph é a visão em DAG dos jobs de um projeto
+type JobGraph struct {
+	Jobs     []*Job    `json:"jobs"`
+	Edges    []JobEdge `json:"edges"`
+	Runnable []string  `json:"runnable"` // jobs pendentes com todos os pais concluídos
+}
+
+// NewJobGraph monta o grafo a partir dos jobs de um projeto
+func NewJobGraph(jobs []*Job) *JobGraph {
+	statuses := make(map[string]JobStatus, len(jobs))
+	for _, job := range jobs {
+		statuses[job.ID] = job.Status
+	}
+
+	graph := &JobGraph{
+		Jobs:     jobs,
+		Edges:    []JobEdge{},
+		Runnable: []string{},
+	}
+	for _, job := range jobs {
+		for _, parent := range job.Parents() {
+			graph.Edges = append(graph.Edges, JobEdge{From: parent, To: job.ID})
+		}
+		if job.Status == JobStatusPending && job.DependenciesMet(statuses) {
+			graph.Runnable = append(graph.Runnable, job.ID)
+		}
+	}
+	return graph
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// Artefatos sempre disponíveis em um JobLog, além dos arquivos em Files
+const (
+	JobLogStdout = "stdout"
+	JobLogStderr = "stderr"
+)
+
+// JobLog registra uma execução de ferramenta externa (pandoc, lualatex,
+// pagedjs-cli, pyftsubset) feita por um job: linha de comando, duração,
+// exit code e as saídas, que ficam disponíveis mesmo após os arquivos
+// temporários serem removidos
+type JobLog struct {
+	ID         string             `json:"id" gorm:"primaryKey"`
+	JobID      string             `json:"job_id" gorm:"index;not null"`
+	Attempt    int                `json:"attempt"`
+	Tool       string             `json:"tool"`
+	Command    []string           `json:"command" gorm:"type:jsonb;serializer:json"`
+	Dir        string             `json:"dir,omitempty"`
+	ExitCode   int                `json:"exit_code"` // -1 se o processo não terminou sozinho
+	DurationMs int64              `json:"duration_ms"`
+	Error      string             `json:"error,omitempty"`
+	Stdout     string             `json:"-" gorm:"type:text"`
+	Stderr     string             `json:"-" gorm:"type:text"`
+	Files      *map[string]string `json:"-" gorm:"type:jsonb;serializer:json"` // ex.: document.log do LaTeX
+	Truncated  bool               `json:"truncated,omitempty"`
+	StartedAt  time.Time          `json:"started_at"`
+	CreatedAt  time.Time          `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Failed verifica se a ferramenta terminou com erro
+func (l *JobLog) Failed() bool {
+	return l.ExitCode != 0 || l.Error != ""
+}
+
+// Artifacts lista os nomes dos artefatos que podem ser lidos com Artifact
+func (l *JobLog) Artifacts() []string {
+	names := []string{JobLogStdout, JobLogStderr}
+	if l.Files != nil {
+		files := make([]string, 0, len(*l.Files))
+		for name := range *l.Files {
+			files = append(files, name)
+		}
+		sort.Strings(files)
+		names = append(names, files...)
+	}
+	return names
+}
+
+// Artifact retorna o conteúdo de um artefato pelo nome
+func (l *JobLog) Artifact(name string) (string, bool) {
+	switch name {
+	case JobLogStdout:
+		return l.Stdout, true
+	case JobLogStderr:
+		return l.Stderr, true
+	}
+	if l.Files == nil {
+		return "", false
+	}
+	content, ok := (*l.Files)[name]
+	return content, ok
+}
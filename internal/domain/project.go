@@ -1,18 +1,19 @@
 package domain
 
 import (
+	"strings"
 	"time"
 )
 
 // Analysis represents simplified content analysis results
 type Analysis struct {
-	Genre      string
-	Tone       string
-	Complexity float64
-	HasMath    bool
-	ImageCount int
-	TableCount int
-	CodeBlocks int
+	Genre      string  `json:"genre"`
+	Tone       string  `json:"tone"`
+	Complexity float64 `json:"complexity"`
+	HasMath    bool    `json:"has_math"`
+	ImageCount int     `json:"image_count"`
+	TableCount int     `json:"table_count"`
+	CodeBlocks int     `json:"code_blocks"`
 }
 
 // Document represents analyzed document structure
@@ -53,17 +54,17 @@ type Project struct {
 	
 	// Configurações
 	PageFormat           string    `json:"page_format" gorm:"default:'6x9'"`
-	DistributionChannels *[]string `json:"distribution_channels,omitempty" gorm:"type:jsonb"`
+	DistributionChannels *[]string `json:"distribution_channels,omitempty" gorm:"type:jsonb;serializer:json"`
 	
 	// Status do processamento
 	Status   ProjectStatus `json:"status" gorm:"default:'created';index"`
 	Progress int           `json:"progress" gorm:"default:0"` // 0-100
 	
 	// Análise de conteúdo (JSON gerado pela IA)
-	Analysis *map[string]interface{} `json:"analysis,omitempty" gorm:"type:jsonb"`
+	Analysis *map[string]interface{} `json:"analysis,omitempty" gorm:"type:jsonb;serializer:json"`
 	
 	// Design gerado (JSON com fontes, cores, layout)
-	DesignConfig *map[string]interface{} `json:"design_config,omitempty" gorm:"type:jsonb"`
+	DesignConfig *map[string]interface{} `json:"design_config,omitempty" gorm:"type:jsonb;serializer:json"`
 	
 	// URLs dos arquivos (MinIO/S3)
 	ManuscriptURL      string `json:"manuscript_url,omitempty"`
@@ -72,13 +73,20 @@ type Project struct {
 	EpubURL            string `json:"epub_url,omitempty"`
 	CoverURL           string `json:"cover_url,omitempty"`
 	
+	// Revisão atual do manuscrito (a que ManuscriptURL aponta)
+	RevisionID string `json:"revision_id,omitempty"`
+	
 	// Timestamps
 	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 	
+	// Lixeira: um projeto excluído some das listagens e pode ser restaurado
+	// até ser expurgado, ao fim do período de retenção
+	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"`
+	
 	// Logs de erro (JSON array)
-	ErrorLog *[]string `json:"error_log,omitempty" gorm:"type:jsonb"`
+	ErrorLog *[]string `json:"error_log,omitempty" gorm:"type:jsonb;serializer:json"`
 }
 
 // TableName especifica o nome da tabela no banco
@@ -86,6 +94,86 @@ func (Project) TableName() string {
 	return "projects"
 }
 
+// ManuscriptText é o texto extraído do manuscrito de um projeto (o Markdown
+// da última conversão), usado na busca textual
+type ManuscriptText struct {
+	ProjectID  uint      `gorm:"primaryKey;autoIncrement:false"`
+	RevisionID string    // revisão convertida
+	Content    string    `gorm:"type:text"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName especifica o nome da tabela no banco
+func (ManuscriptText) TableName() string {
+	return "manuscript_texts"
+}
+
+// ProjectSort é o campo de ordenação da busca de projetos
+type ProjectSort string
+
+const (
+	ProjectSortRelevance ProjectSort = "relevance" // apenas com texto buscado
+	ProjectSortCreated   ProjectSort = "created_at"
+	ProjectSortUpdated   ProjectSort = "updated_at"
+	ProjectSortTitle     ProjectSort = "title"
+	ProjectSortAuthor    ProjectSort = "author"
+)
+
+// Valid verifica se a ordenação é conhecida
+func (s ProjectSort) Valid() bool {
+	switch s {
+	case ProjectSortRelevance, ProjectSortCreated, ProjectSortUpdated, ProjectSortTitle, ProjectSortAuthor:
+		return true
+	}
+	return false
+}
+
+// ProjectFilter filtra a busca de projetos; campos vazios não filtram.
+// Projetos na lixeira nunca aparecem.
+type ProjectFilter struct {
+	UserID      string
+	Query       string // texto buscado em título, autor, descrição e manuscrito
+	Genre       string // sem diferenciar maiúsculas
+	Status      ProjectStatus
+	Language    string
+	PageFormat  string
+	CreatedFrom *time.Time // inclusive
+	CreatedTo   *time.Time // exclusive
+	UpdatedFrom *time.Time // inclusive
+	UpdatedTo   *time.Time // exclusive
+	Sort        ProjectSort
+	Ascending   bool
+	Limit       int
+	Offset      int
+}
+
+// Matches verifica se o projeto passa pelos filtros de campo (ignora Query,
+// ordenação e paginação)
+func (f ProjectFilter) Matches(p *Project) bool {
+	switch {
+	case p.IsTrashed():
+		return false
+	case f.UserID != "" && p.UserID != f.UserID:
+		return false
+	case f.Genre != "" && !strings.EqualFold(p.Genre, f.Genre):
+		return false
+	case f.Status != "" && p.Status != f.Status:
+		return false
+	case f.Language != "" && p.Language != f.Language:
+		return false
+	case f.PageFormat != "" && p.PageFormat != f.PageFormat:
+		return false
+	case f.CreatedFrom != nil && p.CreatedAt.Before(*f.CreatedFrom):
+		return false
+	case f.CreatedTo != nil && !p.CreatedAt.Before(*f.CreatedTo):
+		return false
+	case f.UpdatedFrom != nil && p.UpdatedAt.Before(*f.UpdatedFrom):
+		return false
+	case f.UpdatedTo != nil && !p.UpdatedAt.Before(*f.UpdatedTo):
+		return false
+	}
+	return true
+}
 // IsCompleted verifica se o projeto foi concluído
 func (p *Project) IsCompleted() bool {
 	return p.Status == StatusCompleted
@@ -96,6 +184,21 @@ func (p *Project) IsFailed() bool {
 	return p.Status == StatusFailed
 }
 
+// IsTrashed verifica se o projeto está na lixeira
+func (p *Project) IsTrashed() bool {
+	return p.DeletedAt != nil
+}
+
+// PurgeAt retorna quando um projeto na lixeira será expurgado, dado o
+// período de retenção
+func (p *Project) PurgeAt(retention time.Duration) *time.Time {
+	if p.DeletedAt == nil {
+		return nil
+	}
+	purgeAt := p.DeletedAt.Add(retention)
+	return &purgeAt
+}
+
 // CanBeProcessed verifica se o projeto pode ser processado
 func (p *Project) CanBeProcessed() bool {
 	return p.Status == StatusCreated && p.ManuscriptURL != ""
@@ -9,6 +9,10 @@ import (
 var (
 	ErrProjectNotFound = errors.New("project not found")
 	ErrInvalidInput    = errors.New("invalid input")
+	ErrJobLeaseLost    = errors.New("job lease lost")
+	ErrJobNotFound     = errors.New("job not found")
+	ErrJobConflict     = errors.New("job changed concurrently")
+	ErrJobLogNotFound  = errors.New("job log not found")
 )
 
 // ProjectRepository defines the interface for project persistence
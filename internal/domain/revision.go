@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRevisionNotFound é retornado para uma revisão inexistente
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Revision é uma versão enviada do manuscrito de um projeto. Cada envio vira
+// uma nova revisão numerada (1, 2, ...) e os arquivos das anteriores são
+// mantidos, para comparar versões e saber de qual rascunho veio cada prova
+// (jobs, artefatos e análises guardam o RevisionID).
+type Revision struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	ProjectID     uint      `json:"project_id" gorm:"not null;uniqueIndex:idx_revisions_project_number"`
+	Number        int       `json:"number" gorm:"not null;uniqueIndex:idx_revisions_project_number"`
+	Filename      string    `json:"filename"`
+	ManuscriptURL string    `json:"manuscript_url"`
+	Size          int64     `json:"size"`
+	Checksum      string    `json:"checksum"` // SHA-256 em hexadecimal
+	UploadedBy    string    `json:"uploaded_by"`
+	Note          string    `json:"note,omitempty"` // comentário do autor sobre a versão
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUploadNotFound é retornado para um upload inexistente
+var ErrUploadNotFound = errors.New("upload not found")
+
+// UploadStatus representa o estado de um upload de manuscrito
+type UploadStatus string
+
+const (
+	UploadStatusUploading UploadStatus = "uploading"
+	UploadStatusCompleted UploadStatus = "completed"
+	UploadStatusFailed    UploadStatus = "failed"
+)
+
+// Upload é o envio de um manuscrito em partes, retomável a partir de Offset
+// (no estilo do protocolo tus). Ao receber o último byte o arquivo é
+// validado, armazenado e vira o manuscrito do projeto.
+type Upload struct {
+	ID            string       `json:"id" gorm:"primaryKey"`
+	ProjectID     uint         `json:"project_id" gorm:"index;not null"`
+	UserID        string       `json:"user_id"`
+	Filename      string       `json:"filename"`
+	Format        string       `json:"format"`                 // extensão sem ponto: docx, md, ...
+	ContentType   string       `json:"content_type,omitempty"` // tipo detectado pelo conteúdo
+	Size          int64        `json:"size"`
+	Offset        int64        `json:"offset" gorm:"column:upload_offset"` // bytes já recebidos
+	Checksum      string       `json:"checksum,omitempty"`                 // SHA-256 do arquivo completo
+	Status        UploadStatus `json:"status"`
+	Error         string       `json:"error,omitempty"`
+	ManuscriptURL string       `json:"manuscript_url,omitempty"`
+	Note          string       `json:"note,omitempty"`        // nota da revisão criada pelo upload
+	RevisionID    string       `json:"revision_id,omitempty"` // revisão criada ao completar
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// Remaining retorna quantos bytes ainda faltam
+func (u *Upload) Remaining() int64 {
+	return u.Size - u.Offset
+}
+
+// IsOpen verifica se o upload ainda aceita partes
+func (u *Upload) IsOpen() bool {
+	return u.Status == UploadStatusUploading
+}
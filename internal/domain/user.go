@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrUserNotFound é retornado para um usuário inexistente
+	ErrUserNotFound = errors.New("user not found")
+	// ErrEmailTaken é retornado ao cadastrar um e-mail já usado
+	ErrEmailTaken = errors.New("email already registered")
+)
+
+// Papéis das contas
+const (
+	RoleUser     = "user"     // vê apenas os próprios projetos
+	RoleOperator = "operator" // administra os jobs de todos os projetos
+)
+
+// User é uma conta da API. É dona dos projetos (Project.UserID) e, por eles,
+// dos jobs, análises, revisões e arquivos gerados.
+type User struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	Email        string    `json:"email" gorm:"uniqueIndex;not null"` // sempre minúsculo
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"-" gorm:"not null"`                   // bcrypt
+	TokenVersion int       `json:"-" gorm:"not null;default:0"`         // incrementada no logout: invalida os refresh tokens emitidos
+	Role         string    `json:"role" gorm:"not null;default:'user'"` // RoleUser ou RoleOperator; promovido direto no banco
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// IsOperator indica se a conta administra os jobs de todos os usuários
+func (u *User) IsOperator() bool {
+	return u.Role == RoleOperator
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// WebhookEvent é um evento do ciclo de vida notificado a assinantes externos
+type WebhookEvent string
+
+const (
+	WebhookEventStatusChanged       WebhookEvent = "project.status_changed"
+	WebhookEventGenerationCompleted WebhookEvent = "generation.completed"
+	WebhookEventJobFailed           WebhookEvent = "job.failed"
+	WebhookEventValidationWarnings  WebhookEvent = "validation.warnings"
+)
+
+// WebhookEvents lista os eventos que podem ser assinados
+var WebhookEvents = []WebhookEvent{
+	WebhookEventStatusChanged,
+	WebhookEventGenerationCompleted,
+	WebhookEventJobFailed,
+	WebhookEventValidationWarnings,
+}
+
+// IsValid verifica se o evento existe
+func (e WebhookEvent) IsValid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrWebhookNotFound é retornado para uma assinatura inexistente
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Webhook é uma assinatura de eventos de um projeto ou, sem ProjectID, de
+// todos os projetos de um usuário. Events vazio assina todos os eventos.
+type Webhook struct {
+	ID        string         `json:"id" gorm:"primaryKey"`
+	UserID    string         `json:"user_id" gorm:"index;not null"`
+	ProjectID string         `json:"project_id,omitempty" gorm:"index"`
+	URL       string         `json:"url" gorm:"not null"`
+	Secret    string         `json:"-" gorm:"not null"` // chave do HMAC das entregas
+	Events    []WebhookEvent `json:"events" gorm:"type:jsonb;serializer:json"`
+	Active    bool           `json:"active" gorm:"default:true"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Subscribes verifica se a assinatura recebe o evento de um projeto do usuário
+func (w *Webhook) Subscribes(event WebhookEvent, project *Project) bool {
+	if !w.Active || project == nil {
+		return false
+	}
+	if w.ProjectID != "" {
+		if w.ProjectID != strconv.FormatUint(uint64(project.ID), 10) {
+			return false
+		}
+	} else if w.UserID != project.UserID {
+		return false
+	}
+
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, subscribed := range w.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus é o estado de uma entrega
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery registra o envio de um evento a um webhook, com o payload
+// exato que foi assinado e o resultado de cada tentativa
+type WebhookDelivery struct {
+	ID             string                `json:"id" gorm:"primaryKey"`
+	WebhookID      string                `json:"webhook_id" gorm:"index;not null"`
+	Event          WebhookEvent          `json:"event" gorm:"not null"`
+	Payload        string                `json:"payload" gorm:"type:text"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"default:'pending';index"`
+	Attempts       int                   `json:"attempts"`
+	MaxAttempts    int                   `json:"max_attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty" gorm:"index"`
+	ResponseStatus int                   `json:"response_status,omitempty"`
+	ResponseBody   string                `json:"response_body,omitempty" gorm:"type:text"`
+	Error          string                `json:"error,omitempty"`
+	DurationMs     int64                 `json:"duration_ms"`
+	CreatedAt      time.Time             `json:"created_at" gorm:"autoCreateTime"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
+
+// MarkSucceeded registra a resposta 2xx do assinante
+func (d *WebhookDelivery) MarkSucceeded(status int, body string, at time.Time) {
+	d.Status = WebhookDeliverySucceeded
+	d.ResponseStatus = status
+	d.ResponseBody = body
+	d.Error = ""
+	d.NextAttemptAt = nil
+	d.DeliveredAt = &at
+}
+
+// MarkAttemptFailed registra uma tentativa sem sucesso: agenda a próxima em
+// retryAt ou, esgotadas as tentativas, marca a entrega como falha
+func (d *WebhookDelivery) MarkAttemptFailed(status int, body string, err error, retryAt time.Time) {
+	d.ResponseStatus = status
+	d.ResponseBody = body
+	d.Error = err.Error()
+	if d.Attempts >= d.MaxAttempts {
+		d.Status = WebhookDeliveryFailed
+		d.NextAttemptAt = nil
+		return
+	}
+	d.Status = WebhookDeliveryPending
+	d.NextAttemptAt = &retryAt
+}
+
+// WebhookFilter restringe a listagem de webhooks
+type WebhookFilter struct {
+	UserID    string
+	ProjectID string
+}
@@ -2,9 +2,12 @@ package html
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
 )
 
 // PandocConverter gerencia a conversão de Markdown para HTML via Pandoc
@@ -55,6 +58,12 @@ func DefaultHTMLOptions() ConvertOptions {
 // "O Pandoc lerá o arquivo Markdown com seu cabeçalho YAML e o transformará
 // no formato intermediário necessário para a etapa de renderização final"
 func (pc *PandocConverter) Convert(markdown string, opts ConvertOptions) (string, error) {
+	return pc.ConvertContext(context.Background(), markdown, opts)
+}
+
+// ConvertContext converte Markdown para HTML, matando o pandoc se o contexto
+// for cancelado
+func (pc *PandocConverter) ConvertContext(ctx context.Context, markdown string, opts ConvertOptions) (string, error) {
 	// Construir argumentos do Pandoc
 	args := []string{
 		"--from", opts.InputFormat,
@@ -91,7 +100,7 @@ func (pc *PandocConverter) Convert(markdown string, opts ConvertOptions) (string
 	}
 
 	// Criar comando
-	cmd := exec.Command(pc.PandocPath, args...)
+	cmd := process.CommandContext(ctx, pc.PandocPath, args...)
 
 	// Input via stdin
 	cmd.Stdin = bytes.NewBufferString(markdown)
@@ -102,8 +111,11 @@ func (pc *PandocConverter) Convert(markdown string, opts ConvertOptions) (string
 	cmd.Stderr = &stderr
 
 	// Executar
-	err := cmd.Run()
+	err := process.Exec(ctx, cmd)
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("conversão cancelada: %w", ctx.Err())
+		}
 		return "", fmt.Errorf("pandoc falhou: %w\nstderr: %s", err, stderr.String())
 	}
 
@@ -4,8 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
 )
 
 // FontSubsetter handles font subsetting using Python fonttools
@@ -62,9 +63,9 @@ func (fs *FontSubsetter) SubsetFont(ctx context.Context, opts SubsetOptions) err
 		args = append(args, fmt.Sprintf("--text=%s", opts.Text))
 	}
 
-	cmd := exec.CommandContext(ctx, "pyftsubset", args...)
+	cmd := process.CommandContext(ctx, "pyftsubset", args...)
 
-	output, err := cmd.CombinedOutput()
+	output, err := process.CombinedOutput(ctx, cmd)
 	if err != nil {
 		return fmt.Errorf("pyftsubset failed: %w\nOutput: %s", err, string(output))
 	}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
 	"github.com/rs/zerolog/log"
 )
 
@@ -109,16 +110,23 @@ func (e *Engine) ConvertToPDF(ctx context.Context, htmlContent string, options P
 		return nil, fmt.Errorf("falha obter info PDF: %w", err)
 	}
 
+	pageCount, err := countPDFPages(outputPDF)
+	if err != nil {
+		return nil, fmt.Errorf("falha contar páginas do PDF: %w", err)
+	}
+
 	result := &PagedOutput{
-		PDFPath:  outputPDF,
-		HTMLPath: htmlFile,
-		FileSize: stat.Size(),
-		Warnings: []string{},
+		PDFPath:   outputPDF,
+		HTMLPath:  htmlFile,
+		PageCount: pageCount,
+		FileSize:  stat.Size(),
+		Warnings:  []string{},
 	}
 
 	log.Info().
 		Str("pdf", outputPDF).
 		Int64("size", result.FileSize).
+		Int("pages", result.PageCount).
 		Msg("PDF gerado com sucesso")
 
 	return result, nil
@@ -145,11 +153,12 @@ func (e *Engine) runPagedJS(ctx context.Context, inputHTML, outputPDF string, op
 		args = append(args, "--timeout", fmt.Sprintf("%d", options.Timeout))
 	}
 
-	cmd := exec.CommandContext(ctx, "npx", args...)
+	// Grupo de processos próprio: cancelar mata npx, node e o Chromium
+	cmd := process.CommandContext(ctx, "npx", args...)
 	cmd.Dir = filepath.Dir(e.nodeModulesPath)
 
 	// Capturar output
-	output, err := cmd.CombinedOutput()
+	output, err := process.CombinedOutput(ctx, cmd)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -165,6 +174,18 @@ func (e *Engine) runPagedJS(ctx context.Context, inputHTML, outputPDF string, op
 	return nil
 }
 
+// pdfPageObject casa objetos de página (/Type /Page, sem o /Pages da árvore)
+var pdfPageObject = regexp.MustCompile(`/Type\s*/Page[^s]`)
+
+// countPDFPages conta as páginas do PDF gerado pelo Chromium
+func countPDFPages(pdfPath string) (int, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return 0, err
+	}
+	return len(pdfPageObject.FindAll(data, -1)), nil
+}
+
 // detectNodeModules tenta encontrar node_modules no projeto
 func detectNodeModules() (string, error) {
 	// Procurar node_modules a partir do diretório atual
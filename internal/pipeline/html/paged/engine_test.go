@@ -3,6 +3,7 @@ package paged
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -55,6 +56,29 @@ func TestPagedEngine(t *testing.T) {
 	t.Logf("PDF gerado: %s (%d bytes)", result.PDFPath, result.FileSize)
 }
 
+// TestCountPDFPages testa a contagem de páginas do PDF
+func TestCountPDFPages(t *testing.T) {
+	pdf := "%PDF-1.4\n" +
+		"1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+		"2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >> endobj\n" +
+		"3 0 obj << /Type /Page /Parent 2 0 R >> endobj\n" +
+		"4 0 obj << /Type/Page /Parent 2 0 R >> endobj\n" +
+		"%%EOF"
+
+	path := filepath.Join(t.TempDir(), "test.pdf")
+	if err := os.WriteFile(path, []byte(pdf), 0644); err != nil {
+		t.Fatalf("Falha escrever PDF: %v", err)
+	}
+
+	pages, err := countPDFPages(path)
+	if err != nil {
+		t.Fatalf("Falha contar páginas: %v", err)
+	}
+	if pages != 2 {
+		t.Errorf("Esperado 2 páginas, obtido %d", pages)
+	}
+}
+
 // TestBookTemplate testa renderização de template
 func TestBookTemplate(t *testing.T) {
 	tmpl := BookTemplate{
@@ -4,9 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"time"
+
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
 )
 
 // PagedJSRenderer renders HTML to PDF using Paged.js CLI
@@ -53,13 +54,13 @@ func (r *PagedJSRenderer) RenderToPDF(ctx context.Context, opts RenderOptions) e
 
 	// Build pagedjs-cli command
 	// pagedjs-cli converts HTML to PDF with CSS Paged Media support
-	cmd := exec.CommandContext(timeoutCtx, "pagedjs-cli",
+	cmd := process.CommandContext(timeoutCtx, "pagedjs-cli",
 		opts.HTMLPath,
 		"-o", opts.OutputPath,
 	)
 
 	// Capture output for debugging
-	output, err := cmd.CombinedOutput()
+	output, err := process.CombinedOutput(timeoutCtx, cmd)
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			return fmt.Errorf("PDF rendering timed out after %v: %w", timeout, err)
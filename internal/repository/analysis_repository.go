@@ -5,7 +5,6 @@ package repository
 import (
 	"time"
 
-	"github.com/JuanCS-Dev/typecraft/internal/database"
 	"github.com/JuanCS-Dev/typecraft/internal/domain"
 	"gorm.io/gorm"
 )
@@ -16,9 +15,9 @@ type AnalysisRepository struct {
 }
 
 // NewAnalysisRepository creates a new analysis repository
-func NewAnalysisRepository() *AnalysisRepository {
+func NewAnalysisRepository(db *gorm.DB) *AnalysisRepository {
 	return &AnalysisRepository{
-		db: database.DB,
+		db: db,
 	}
 }
 
@@ -154,3 +153,8 @@ func (r *AnalysisRepository) DeleteOldAnalyses(maxAge time.Duration) (int64, err
 		Delete(&domain.AIAnalysis{})
 	return result.RowsAffected, result.Error
 }
+
+// DeleteByProjectID deletes every analysis of a project
+func (r *AnalysisRepository) DeleteByProjectID(projectID string) error {
+	return r.db.Where("project_id = ?", projectID).Delete(&domain.AIAnalysis{}).Error
+}
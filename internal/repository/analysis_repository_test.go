@@ -10,16 +10,14 @@ import (
 )
 
 func TestAnalysisRepository_Cache(t *testing.T) {
-	// Skip if DB not available
-	if testing.Short() {
-		t.Skip("Skipping repository test in short mode")
+	for name, repo := range analysisRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			testAnalysisCache(t, repo)
+		})
 	}
-	
-	repo := NewAnalysisRepository()
-	if repo.db == nil {
-		t.Skip("Database not available")
-	}
-	
+}
+
+func testAnalysisCache(t *testing.T, repo Analyses) {
 	// Create test analysis
 	projectID := uuid.New().String()
 	analysis := &domain.AIAnalysis{
@@ -84,15 +82,14 @@ func TestAnalysisRepository_Cache(t *testing.T) {
 }
 
 func TestAnalysisRepository_ListByProject(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping repository test in short mode")
-	}
-	
-	repo := NewAnalysisRepository()
-	if repo.db == nil {
-		t.Skip("Database not available")
+	for name, repo := range analysisRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			testAnalysisListByProject(t, repo)
+		})
 	}
-	
+}
+
+func testAnalysisListByProject(t *testing.T, repo Analyses) {
 	projectID := uuid.New().String()
 	
 	// Create multiple analyses
@@ -131,3 +128,12 @@ func TestAnalysisRepository_ListByProject(t *testing.T) {
 		repo.Delete(a.ID)
 	}
 }
+
+// analysisRepositories returns the GORM (over SQLite) and in-memory
+// implementations, which must behave the same
+func analysisRepositories(t *testing.T) map[string]Analyses {
+	return map[string]Analyses{
+		"sqlite": NewAnalysisRepository(openTestDB(t)),
+		"memory": NewMemoryAnalysisRepository(),
+	}
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+)
+
+// ArtifactRepository lida com operações de banco de dados para os arquivos
+// gerados dos projetos
+type ArtifactRepository struct {
+	db *gorm.DB
+}
+
+// NewArtifactRepository cria uma nova instância do repositório
+func NewArtifactRepository(db *gorm.DB) *ArtifactRepository {
+	return &ArtifactRepository{
+		db: db,
+	}
+}
+
+// Create registra um arquivo gerado
+func (r *ArtifactRepository) Create(artifact *domain.Artifact) error {
+	if err := r.db.Create(artifact).Error; err != nil {
+		return fmt.Errorf("erro ao registrar artefato: %w", err)
+	}
+	return nil
+}
+
+// GetByID busca um artefato por ID
+func (r *ArtifactRepository) GetByID(id string) (*domain.Artifact, error) {
+	var artifact domain.Artifact
+	if err := r.db.First(&artifact, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrArtifactNotFound
+		}
+		return nil, fmt.Errorf("erro ao buscar artefato: %w", err)
+	}
+	return &artifact, nil
+}
+
+// ListByProject lista os artefatos de um projeto, mais recentes primeiro
+func (r *ArtifactRepository) ListByProject(projectID uint) ([]*domain.Artifact, error) {
+	var artifacts []*domain.Artifact
+	if err := r.db.Where("project_id = ?", projectID).
+		Order("created_at DESC").
+		Find(&artifacts).Error; err != nil {
+		return nil, fmt.Errorf("erro ao listar artefatos: %w", err)
+	}
+	return artifacts, nil
+}
+
+// DeleteByProjectID apaga os registros dos artefatos de um projeto (os
+// arquivos no storage são removidos à parte)
+func (r *ArtifactRepository) DeleteByProjectID(projectID uint) error {
+	if err := r.db.Where("project_id = ?", projectID).Delete(&domain.Artifact{}).Error; err != nil {
+		return fmt.Errorf("erro ao deletar artefatos do projeto: %w", err)
+	}
+	return nil
+}
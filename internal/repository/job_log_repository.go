@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+)
+
+// JobLogRepository lida com operações de banco de dados para JobLogs
+type JobLogRepository struct {
+	db *gorm.DB
+}
+
+// NewJobLogRepository cria uma nova instância do repositório
+func NewJobLogRepository(db *gorm.DB) *JobLogRepository {
+	return &JobLogRepository{
+		db: db,
+	}
+}
+
+// Create registra a execução de uma ferramenta
+func (r *JobLogRepository) Create(log *domain.JobLog) error {
+	if err := r.db.Create(log).Error; err != nil {
+		return fmt.Errorf("erro ao criar log do job: %w", err)
+	}
+	return nil
+}
+
+// GetByJobID busca as execuções de um job em ordem cronológica
+func (r *JobLogRepository) GetByJobID(jobID string) ([]*domain.JobLog, error) {
+	var logs []*domain.JobLog
+
+	if err := r.db.Where("job_id = ?", jobID).
+		Order("started_at ASC").
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar logs do job: %w", err)
+	}
+
+	return logs, nil
+}
+
+// GetByID busca uma execução de um job
+func (r *JobLogRepository) GetByID(jobID, id string) (*domain.JobLog, error) {
+	var log domain.JobLog
+	if err := r.db.First(&log, "id = ? AND job_id = ?", id, jobID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrJobLogNotFound
+		}
+		return nil, fmt.Errorf("erro ao buscar log do job: %w", err)
+	}
+	return &log, nil
+}
+
+// DeleteByJobIDs apaga as execuções dos jobs informados
+func (r *JobLogRepository) DeleteByJobIDs(jobIDs []string) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	if err := r.db.Where("job_id IN ?", jobIDs).Delete(&domain.JobLog{}).Error; err != nil {
+		return fmt.Errorf("erro ao deletar logs dos jobs: %w", err)
+	}
+	return nil
+}
@@ -2,10 +2,11 @@ package repository
 
 import (
 	"fmt"
+	"time"
 
-	"github.com/JuanCS-Dev/typecraft/internal/database"
 	"github.com/JuanCS-Dev/typecraft/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // JobRepository lida com operações de banco de dados para Jobs
@@ -14,9 +15,9 @@ type JobRepository struct {
 }
 
 // NewJobRepository cria uma nova instância do repositório
-func NewJobRepository() *JobRepository {
+func NewJobRepository(db *gorm.DB) *JobRepository {
 	return &JobRepository{
-		db: database.DB,
+		db: db,
 	}
 }
 
@@ -33,7 +34,7 @@ func (r *JobRepository) GetByID(id string) (*domain.Job, error) {
 	var job domain.Job
 	if err := r.db.First(&job, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("job não encontrado")
+			return nil, domain.ErrJobNotFound
 		}
 		return nil, fmt.Errorf("erro ao buscar job: %w", err)
 	}
@@ -61,7 +62,62 @@ func (r *JobRepository) Update(job *domain.Job) error {
 	return nil
 }
 
-// GetPending busca jobs pendentes para processar
+// UpdateFrom salva o job apenas se seu status no banco ainda for from,
+// evitando sobrescrever um job que o worker terminou nesse meio tempo.
+// Retorna domain.ErrJobConflict caso contrário.
+func (r *JobRepository) UpdateFrom(job *domain.Job, from domain.JobStatus) error {
+	result := r.db.Model(&domain.Job{}).
+		Where("id = ? AND status = ?", job.ID, from).
+		Select("*").
+		Updates(job)
+	if result.Error != nil {
+		return fmt.Errorf("erro ao atualizar job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrJobConflict
+	}
+	return nil
+}
+
+// List busca jobs de todos os projetos que passam pelo filtro, mais recentes
+// primeiro, junto com o total sem paginação
+func (r *JobRepository) List(filter domain.JobFilter) ([]*domain.Job, int64, error) {
+	query := r.db.Model(&domain.Job{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.ProjectID != "" {
+		query = query.Where("project_id = ?", filter.ProjectID)
+	}
+	if filter.ProjectIDs != nil {
+		// Lista vazia vira IN (NULL), que não casa nenhum job
+		query = query.Where("project_id IN ?", filter.ProjectIDs)
+	}
+	if filter.DeadLetter {
+		query = query.Where("status = ? AND attempts >= max_attempts", domain.JobStatusFailed)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao contar jobs: %w", err)
+	}
+
+	var jobs []*domain.Job
+	if err := query.Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&jobs).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar jobs: %w", err)
+	}
+
+	return jobs, total, nil
+}
+
+// GetPending busca jobs pendentes para processar.
+// Apenas lista: para executar um job use Claim, que o reserva atomicamente.
 func (r *JobRepository) GetPending(limit int) ([]*domain.Job, error) {
 	var jobs []*domain.Job
 	
@@ -75,6 +131,124 @@ func (r *JobRepository) GetPending(limit int) ([]*domain.Job, error) {
 	return jobs, nil
 }
 
+// pendingParents casa jobs com algum pai (depends_on) ainda não concluído
+const pendingParents = `EXISTS (
+	SELECT 1 FROM jobs AS parent
+	WHERE parent.id IN (SELECT jsonb_array_elements_text(COALESCE(jobs.depends_on, '[]'::jsonb)))
+	AND parent.status <> ?)`
+
+// pendingParentsSQLite é pendingParents com as funções JSON do SQLite
+const pendingParentsSQLite = `EXISTS (
+	SELECT 1 FROM jobs AS parent
+	WHERE parent.id IN (SELECT value FROM json_each(COALESCE(jobs.depends_on, '[]')))
+	AND parent.status <> ?)`
+
+// Claim reserva o próximo job pendente de maior prioridade e o marca como
+// running com um lease de owner válido por ttl. Só são elegíveis jobs cujos
+// pais (depends_on) já foram concluídos e que não aguardam backoff (run_after
+// no futuro). FOR UPDATE SKIP LOCKED permite vários workers em paralelo (no
+// SQLite, que não tem locks de linha, a transação já é exclusiva).
+// Retorna nil, nil quando não há job disponível.
+func (r *JobRepository) Claim(owner string, ttl time.Duration) (*domain.Job, error) {
+	var claimed *domain.Job
+
+	parents := pendingParents
+	if r.db.Dialector.Name() == "sqlite" {
+		parents = pendingParentsSQLite
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var job domain.Job
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", domain.JobStatusPending).
+			Where("run_after IS NULL OR run_after <= ?", time.Now()).
+			Where("NOT "+parents, domain.JobStatusCompleted).
+			Order("priority DESC, created_at ASC").
+			First(&job).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		job.MarkStarted()
+		job.Lease(owner, time.Now().Add(ttl))
+		if err := tx.Save(&job).Error; err != nil {
+			return err
+		}
+		claimed = &job
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao reservar job: %w", err)
+	}
+
+	return claimed, nil
+}
+
+// Heartbeat renova por ttl o lease de um job que owner ainda detém.
+// Retorna domain.ErrJobLeaseLost se o lease expirou e o job foi recuperado.
+func (r *JobRepository) Heartbeat(id, owner string, ttl time.Duration) error {
+	result := r.db.Model(&domain.Job{}).
+		Where("id = ? AND lease_owner = ? AND status = ?", id, owner, domain.JobStatusRunning).
+		Update("lease_expires_at", time.Now().Add(ttl))
+	if result.Error != nil {
+		return fmt.Errorf("erro ao renovar lease: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrJobLeaseLost
+	}
+	return nil
+}
+
+// Release salva o resultado de um job e libera seu lease, desde que owner
+// ainda o detenha (compare-and-swap em status e owner). Retorna
+// domain.ErrJobLeaseLost se outro worker ou o reaper assumiu o job.
+func (r *JobRepository) Release(job *domain.Job, owner string) error {
+	job.ReleaseLease()
+	result := r.db.Model(&domain.Job{}).
+		Where("id = ? AND lease_owner = ? AND status = ?", job.ID, owner, domain.JobStatusRunning).
+		Select("*").
+		Updates(job)
+	if result.Error != nil {
+		return fmt.Errorf("erro ao liberar job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrJobLeaseLost
+	}
+	return nil
+}
+
+// ReapExpired recupera os jobs running cujo lease venceu antes de now
+// (worker caiu ou travou): voltam para pending ou ficam failed conforme
+// CanRetry. Retorna os jobs recuperados.
+func (r *JobRepository) ReapExpired(now time.Time) ([]*domain.Job, error) {
+	var jobs []*domain.Job
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND lease_expires_at <= ?", domain.JobStatusRunning, now).
+			Find(&jobs).Error
+		if err != nil {
+			return err
+		}
+
+		for _, job := range jobs {
+			job.RecoverExpiredLease(now)
+			if err := tx.Save(job).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao recuperar jobs expirados: %w", err)
+	}
+
+	return jobs, nil
+}
+
 // GetByStatus busca jobs por status
 func (r *JobRepository) GetByStatus(status domain.JobStatus, limit, offset int) ([]*domain.Job, error) {
 	var jobs []*domain.Job
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+)
+
+// MemoryAnalysisRepository keeps AI analyses in memory, for development and
+// tests without a database. Lookups that find nothing return
+// gorm.ErrRecordNotFound, like AnalysisRepository.
+type MemoryAnalysisRepository struct {
+	mu       sync.RWMutex
+	analyses map[string]*domain.AIAnalysis
+}
+
+// NewMemoryAnalysisRepository creates an empty analysis repository
+func NewMemoryAnalysisRepository() *MemoryAnalysisRepository {
+	return &MemoryAnalysisRepository{analyses: make(map[string]*domain.AIAnalysis)}
+}
+
+// Save saves an AI analysis
+func (r *MemoryAnalysisRepository) Save(analysis *domain.AIAnalysis) error {
+	if err := analysis.BeforeCreate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.analyses[analysis.ID]; exists {
+		return errors.New("analysis already exists: " + analysis.ID)
+	}
+	if analysis.CreatedAt.IsZero() {
+		analysis.CreatedAt = time.Now()
+	}
+	r.store(analysis)
+	return nil
+}
+
+// GetByProjectID retrieves the most recent analysis for a project
+func (r *MemoryAnalysisRepository) GetByProjectID(projectID string) (*domain.AIAnalysis, error) {
+	analyses := r.find(func(a *domain.AIAnalysis) bool { return a.ProjectID == projectID })
+	if len(analyses) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return analyses[0], nil
+}
+
+// GetByID retrieves an analysis by its ID
+func (r *MemoryAnalysisRepository) GetByID(id string) (*domain.AIAnalysis, error) {
+	analyses := r.find(func(a *domain.AIAnalysis) bool { return a.ID == id })
+	if len(analyses) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return analyses[0], nil
+}
+
+// GetCachedAnalysis retrieves a cached analysis if it exists and is still valid
+// Returns nil if no valid cache exists
+func (r *MemoryAnalysisRepository) GetCachedAnalysis(projectID string, maxAge time.Duration) (*domain.AIAnalysis, error) {
+	cutoff := time.Now().Add(-maxAge)
+	analyses := r.find(func(a *domain.AIAnalysis) bool {
+		return a.ProjectID == projectID && a.AnalyzedAt.After(cutoff)
+	})
+	if len(analyses) == 0 {
+		return nil, nil
+	}
+	return analyses[0], nil
+}
+
+// Update updates an existing analysis (or saves it, like GORM's Save)
+func (r *MemoryAnalysisRepository) Update(analysis *domain.AIAnalysis) error {
+	if err := analysis.BeforeCreate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	analysis.UpdatedAt = time.Now()
+	r.store(analysis)
+	return nil
+}
+
+// Delete deletes an analysis by ID
+func (r *MemoryAnalysisRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.analyses, id)
+	return nil
+}
+
+// ListByProject lists all analyses for a project, ordered by most recent
+func (r *MemoryAnalysisRepository) ListByProject(projectID string, limit int) ([]*domain.AIAnalysis, error) {
+	analyses := r.find(func(a *domain.AIAnalysis) bool { return a.ProjectID == projectID })
+	if limit > 0 {
+		analyses = paginate(analyses, limit, 0)
+	}
+	return analyses, nil
+}
+
+// CountByProject counts total analyses for a project
+func (r *MemoryAnalysisRepository) CountByProject(projectID string) (int64, error) {
+	analyses := r.find(func(a *domain.AIAnalysis) bool { return a.ProjectID == projectID })
+	return int64(len(analyses)), nil
+}
+
+// GetTotalTokensUsed calculates total tokens used for a project
+func (r *MemoryAnalysisRepository) GetTotalTokensUsed(projectID string) (int, error) {
+	total := 0
+	for _, analysis := range r.find(func(a *domain.AIAnalysis) bool { return a.ProjectID == projectID }) {
+		total += analysis.TokensUsed
+	}
+	return total, nil
+}
+
+// DeleteOldAnalyses deletes analyses older than the specified duration
+func (r *MemoryAnalysisRepository) DeleteOldAnalyses(maxAge time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-maxAge)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, analysis := range r.analyses {
+		if analysis.AnalyzedAt.Before(cutoff) {
+			delete(r.analyses, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteByProjectID deletes every analysis of a project
+func (r *MemoryAnalysisRepository) DeleteByProjectID(projectID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, analysis := range r.analyses {
+		if analysis.ProjectID == projectID {
+			delete(r.analyses, id)
+		}
+	}
+	return nil
+}
+
+// store keeps a copy of an analysis; the caller holds the lock
+func (r *MemoryAnalysisRepository) store(analysis *domain.AIAnalysis) {
+	stored := *analysis
+	r.analyses[analysis.ID] = &stored
+}
+
+// find copies the analyses that pass keep, most recent first
+func (r *MemoryAnalysisRepository) find(keep func(*domain.AIAnalysis) bool) []*domain.AIAnalysis {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var analyses []*domain.AIAnalysis
+	for _, analysis := range r.analyses {
+		if keep(analysis) {
+			found := *analysis
+			analyses = append(analyses, &found)
+		}
+	}
+	sort.Slice(analyses, func(i, j int) bool {
+		return analyses[i].AnalyzedAt.After(analyses[j].AnalyzedAt)
+	})
+	return analyses
+}
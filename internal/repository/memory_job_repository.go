@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+// MemoryJobRepository guarda jobs em memória, para desenvolvimento e testes
+// sem banco de dados. Claim segue as mesmas regras do JobRepository:
+// prioridade, backoff (run_after), dependências concluídas e lease.
+type MemoryJobRepository struct {
+	mu   sync.Mutex
+	jobs map[string]*domain.Job
+}
+
+// NewMemoryJobRepository cria um repositório de jobs vazio
+func NewMemoryJobRepository() *MemoryJobRepository {
+	return &MemoryJobRepository{jobs: make(map[string]*domain.Job)}
+}
+
+// Create cria um novo job
+func (r *MemoryJobRepository) Create(job *domain.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.jobs[job.ID]; exists {
+		return fmt.Errorf("erro ao criar job: ID %s já existe", job.ID)
+	}
+
+	// Defaults das colunas (tags default do GORM)
+	if job.Status == "" {
+		job.Status = domain.JobStatusPending
+	}
+	if job.Priority == 0 {
+		job.Priority = 5
+	}
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = 3
+	}
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+
+	r.jobs[job.ID] = cloneJob(job)
+	return nil
+}
+
+// GetByID busca um job por ID
+func (r *MemoryJobRepository) GetByID(id string) (*domain.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, domain.ErrJobNotFound
+	}
+	return cloneJob(job), nil
+}
+
+// GetByProjectID busca todos os jobs de um projeto
+func (r *MemoryJobRepository) GetByProjectID(projectID string) ([]*domain.Job, error) {
+	return r.find(func(j *domain.Job) bool { return j.ProjectID == projectID }, oldestFirst), nil
+}
+
+// Update atualiza um job (ou o cria, como o Save do GORM)
+func (r *MemoryJobRepository) Update(job *domain.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[job.ID] = cloneJob(job)
+	return nil
+}
+
+// UpdateFrom salva o job apenas se seu status ainda for from.
+// Retorna domain.ErrJobConflict caso contrário.
+func (r *MemoryJobRepository) UpdateFrom(job *domain.Job, from domain.JobStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.jobs[job.ID]
+	if !ok || stored.Status != from {
+		return domain.ErrJobConflict
+	}
+	r.jobs[job.ID] = cloneJob(job)
+	return nil
+}
+
+// List busca jobs que passam pelo filtro, mais recentes primeiro, junto com
+// o total sem paginação
+func (r *MemoryJobRepository) List(filter domain.JobFilter) ([]*domain.Job, int64, error) {
+	jobs := r.find(filter.Matches, newestJobFirst)
+	return paginate(jobs, filter.Limit, filter.Offset), int64(len(jobs)), nil
+}
+
+// GetPending busca jobs pendentes para processar.
+// Apenas lista: para executar um job use Claim, que o reserva atomicamente.
+func (r *MemoryJobRepository) GetPending(limit int) ([]*domain.Job, error) {
+	jobs := r.find(func(j *domain.Job) bool { return j.Status == domain.JobStatusPending }, byPriority)
+	return paginate(jobs, limit, 0), nil
+}
+
+// Claim reserva o próximo job pendente de maior prioridade cujos pais já
+// foram concluídos e que não aguarda backoff, com um lease de owner válido
+// por ttl. Retorna nil, nil quando não há job disponível.
+func (r *MemoryJobRepository) Claim(owner string, ttl time.Duration) (*domain.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	statuses := make(map[string]domain.JobStatus, len(r.jobs))
+	for id, job := range r.jobs {
+		statuses[id] = job.Status
+	}
+
+	var next *domain.Job
+	for _, job := range r.jobs {
+		if job.Status != domain.JobStatusPending || (job.RunAfter != nil && job.RunAfter.After(now)) {
+			continue
+		}
+		if !job.DependenciesMet(statuses) {
+			continue
+		}
+		if next == nil || byPriority(job, next) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.MarkStarted()
+	next.Lease(owner, now.Add(ttl))
+	return cloneJob(next), nil
+}
+
+// Heartbeat renova por ttl o lease de um job que owner ainda detém.
+// Retorna domain.ErrJobLeaseLost se o lease expirou e o job foi recuperado.
+func (r *MemoryJobRepository) Heartbeat(id, owner string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok || !leasedTo(job, owner) {
+		return domain.ErrJobLeaseLost
+	}
+	expires := time.Now().Add(ttl)
+	job.LeaseExpiresAt = &expires
+	return nil
+}
+
+// Release salva o resultado de um job e libera seu lease, desde que owner
+// ainda o detenha. Retorna domain.ErrJobLeaseLost caso contrário.
+func (r *MemoryJobRepository) Release(job *domain.Job, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job.ReleaseLease()
+	stored, ok := r.jobs[job.ID]
+	if !ok || !leasedTo(stored, owner) {
+		return domain.ErrJobLeaseLost
+	}
+	r.jobs[job.ID] = cloneJob(job)
+	return nil
+}
+
+// ReapExpired recupera os jobs running cujo lease venceu antes de now,
+// devolvendo-os à fila ou deixando-os failed conforme CanRetry
+func (r *MemoryJobRepository) ReapExpired(now time.Time) ([]*domain.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var reaped []*domain.Job
+	for _, job := range r.jobs {
+		if job.LeaseExpired(now) {
+			job.RecoverExpiredLease(now)
+			reaped = append(reaped, cloneJob(job))
+		}
+	}
+	return reaped, nil
+}
+
+// GetByStatus busca jobs por status
+func (r *MemoryJobRepository) GetByStatus(status domain.JobStatus, limit, offset int) ([]*domain.Job, error) {
+	jobs := r.find(func(j *domain.Job) bool { return j.Status == status }, newestJobFirst)
+	return paginate(jobs, limit, offset), nil
+}
+
+// GetFailed busca jobs que falharam e podem ser retentados
+func (r *MemoryJobRepository) GetFailed(limit int) ([]*domain.Job, error) {
+	jobs := r.find((*domain.Job).CanRetry, byPriority)
+	return paginate(jobs, limit, 0), nil
+}
+
+// Delete deleta um job
+func (r *MemoryJobRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.jobs, id)
+	return nil
+}
+
+// DeleteByProjectID deleta todos os jobs de um projeto
+func (r *MemoryJobRepository) DeleteByProjectID(projectID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, job := range r.jobs {
+		if job.ProjectID == projectID {
+			delete(r.jobs, id)
+		}
+	}
+	return nil
+}
+
+// CountByProject conta quantos jobs um projeto tem
+func (r *MemoryJobRepository) CountByProject(projectID string) (int64, error) {
+	jobs := r.find(func(j *domain.Job) bool { return j.ProjectID == projectID }, oldestFirst)
+	return int64(len(jobs)), nil
+}
+
+// CountByStatus conta jobs por status
+func (r *MemoryJobRepository) CountByStatus(status domain.JobStatus) (int64, error) {
+	jobs := r.find(func(j *domain.Job) bool { return j.Status == status }, oldestFirst)
+	return int64(len(jobs)), nil
+}
+
+// find copia os jobs que passam por keep, ordenados por less
+func (r *MemoryJobRepository) find(keep func(*domain.Job) bool, less func(a, b *domain.Job) bool) []*domain.Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var jobs []*domain.Job
+	for _, job := range r.jobs {
+		if keep(job) {
+			jobs = append(jobs, cloneJob(job))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return less(jobs[i], jobs[j])
+	})
+	return jobs
+}
+
+// leasedTo verifica se o job está em execução com o lease de owner
+func leasedTo(job *domain.Job, owner string) bool {
+	return job.Status == domain.JobStatusRunning && job.LeaseOwner == owner
+}
+
+// byPriority ordena jobs como ORDER BY priority DESC, created_at ASC
+func byPriority(a, b *domain.Job) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return oldestFirst(a, b)
+}
+
+// oldestFirst ordena jobs como ORDER BY created_at ASC (e ID, no empate)
+func oldestFirst(a, b *domain.Job) bool {
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return a.ID < b.ID
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// newestJobFirst ordena jobs como ORDER BY created_at DESC
+func newestJobFirst(a, b *domain.Job) bool {
+	return oldestFirst(b, a)
+}
+
+// cloneJob copia um job, inclusive a lista de dependências, para que quem o
+// recebe não altere o job guardado
+func cloneJob(job *domain.Job) *domain.Job {
+	clone := *job
+	if job.DependsOn != nil {
+		parents := append([]string(nil), *job.DependsOn...)
+		clone.DependsOn = &parents
+	}
+	return &clone
+}
@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+// MemoryProjectRepository guarda projetos em memória, para desenvolvimento e
+// testes sem banco de dados. Devolve cópias, como o repositório GORM.
+type MemoryProjectRepository struct {
+	mu       sync.RWMutex
+	projects map[uint]*domain.Project
+	texts    map[uint]string // texto dos manuscritos, para a busca
+	nextID   uint
+}
+
+// NewMemoryProjectRepository cria um repositório de projetos vazio
+func NewMemoryProjectRepository() *MemoryProjectRepository {
+	return &MemoryProjectRepository{
+		projects: make(map[uint]*domain.Project),
+		texts:    make(map[uint]string),
+	}
+}
+
+// Create cria um novo projeto, atribuindo o próximo ID
+func (r *MemoryProjectRepository) Create(project *domain.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if project.ID == 0 {
+		r.nextID++
+		project.ID = r.nextID
+	} else if _, exists := r.projects[project.ID]; exists {
+		return fmt.Errorf("erro ao criar projeto: ID %d já existe", project.ID)
+	} else if project.ID > r.nextID {
+		r.nextID = project.ID
+	}
+
+	now := time.Now()
+	if project.CreatedAt.IsZero() {
+		project.CreatedAt = now
+	}
+	project.UpdatedAt = now
+	// Defaults das colunas (tags default do GORM)
+	if project.Status == "" {
+		project.Status = domain.StatusCreated
+	}
+	if project.PageFormat == "" {
+		project.PageFormat = "6x9"
+	}
+	if project.Language == "" {
+		project.Language = "pt"
+	}
+
+	stored := *project
+	r.projects[project.ID] = &stored
+	return nil
+}
+
+// GetByID busca um projeto por ID
+func (r *MemoryProjectRepository) GetByID(id string) (*domain.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.lookup(id)
+	if !ok || project.IsTrashed() {
+		return nil, fmt.Errorf("projeto não encontrado: %w", domain.ErrProjectNotFound)
+	}
+	found := *project
+	return &found, nil
+}
+
+// GetAll lista todos os projetos com paginação (limit negativo: sem limite)
+func (r *MemoryProjectRepository) GetAll(userID string, limit, offset int) ([]*domain.Project, int64, error) {
+	projects := r.filter(func(p *domain.Project) bool {
+		return !p.IsTrashed() && (userID == "" || p.UserID == userID)
+	}, newestFirst)
+	return paginate(projects, limit, offset), int64(len(projects)), nil
+}
+
+// Update atualiza um projeto fora da lixeira; nunca o cria (veja
+// ProjectRepository.Update)
+func (r *MemoryProjectRepository) Update(project *domain.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.projects[project.ID]
+	if !ok || existing.IsTrashed() {
+		return fmt.Errorf("projeto não encontrado ou na lixeira: %w", domain.ErrProjectNotFound)
+	}
+	if project.CreatedAt.IsZero() {
+		project.CreatedAt = existing.CreatedAt
+	}
+	project.DeletedAt = nil
+	project.UpdatedAt = time.Now()
+	stored := *project
+	r.projects[project.ID] = &stored
+	return nil
+}
+
+// Delete apaga um projeto definitivamente (esteja ou não na lixeira)
+func (r *MemoryProjectRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if project, ok := r.lookup(id); ok {
+		delete(r.projects, project.ID)
+		delete(r.texts, project.ID)
+	}
+	return nil
+}
+
+// GetByStatus busca projetos por status
+func (r *MemoryProjectRepository) GetByStatus(status domain.ProjectStatus, limit, offset int) ([]*domain.Project, error) {
+	projects := r.filter(func(p *domain.Project) bool {
+		return !p.IsTrashed() && p.Status == status
+	}, newestFirst)
+	return paginate(projects, limit, offset), nil
+}
+
+// GetProcessable busca projetos prontos para processar
+func (r *MemoryProjectRepository) GetProcessable(limit int) ([]*domain.Project, error) {
+	projects := r.filter(func(p *domain.Project) bool {
+		return !p.IsTrashed() && p.Status == domain.StatusCreated && p.ManuscriptURL != ""
+	}, func(a, b *domain.Project) bool {
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return paginate(projects, limit, 0), nil
+}
+
+// UpdateStatus atualiza apenas o status de um projeto
+func (r *MemoryProjectRepository) UpdateStatus(id string, status domain.ProjectStatus, progress int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if project, ok := r.lookup(id); ok && !project.IsTrashed() {
+		project.Status = status
+		project.Progress = progress
+		project.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// Trash move um projeto para a lixeira
+func (r *MemoryProjectRepository) Trash(id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.lookup(id)
+	if !ok || project.IsTrashed() {
+		return fmt.Errorf("projeto não encontrado: %w", domain.ErrProjectNotFound)
+	}
+	project.DeletedAt = &at
+	return nil
+}
+
+// Restore tira um projeto da lixeira
+func (r *MemoryProjectRepository) Restore(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.lookup(id)
+	if !ok || !project.IsTrashed() {
+		return fmt.Errorf("projeto não está na lixeira: %w", domain.ErrProjectNotFound)
+	}
+	project.DeletedAt = nil
+	return nil
+}
+
+// GetTrashed busca um projeto na lixeira por ID
+func (r *MemoryProjectRepository) GetTrashed(id string) (*domain.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.lookup(id)
+	if !ok || !project.IsTrashed() {
+		return nil, fmt.Errorf("projeto não está na lixeira: %w", domain.ErrProjectNotFound)
+	}
+	found := *project
+	return &found, nil
+}
+
+// ListTrash lista os projetos na lixeira, excluídos mais recentemente primeiro
+func (r *MemoryProjectRepository) ListTrash(userID string, limit, offset int) ([]*domain.Project, int64, error) {
+	projects := r.filter(func(p *domain.Project) bool {
+		return p.IsTrashed() && (userID == "" || p.UserID == userID)
+	}, func(a, b *domain.Project) bool {
+		return a.DeletedAt.After(*b.DeletedAt)
+	})
+	return paginate(projects, limit, offset), int64(len(projects)), nil
+}
+
+// GetExpired busca projetos que estão na lixeira desde antes de before
+func (r *MemoryProjectRepository) GetExpired(before time.Time, limit int) ([]*domain.Project, error) {
+	projects := r.filter(func(p *domain.Project) bool {
+		return p.IsTrashed() && p.DeletedAt.Before(before)
+	}, func(a, b *domain.Project) bool {
+		return a.DeletedAt.Before(*b.DeletedAt)
+	})
+	return paginate(projects, limit, 0), nil
+}
+
+// SetManuscriptText guarda o texto extraído do manuscrito para a busca,
+// substituindo o anterior
+func (r *MemoryProjectRepository) SetManuscriptText(projectID string, revisionID, text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.lookup(projectID)
+	if !ok {
+		return fmt.Errorf("projeto não encontrado: %w", domain.ErrProjectNotFound)
+	}
+	r.texts[project.ID] = truncateText(text, MaxManuscriptText)
+	return nil
+}
+
+// Search busca projetos pelos filtros, como o ProjectRepository sem
+// full-text search: cada termo (ou "frase") precisa aparecer em algum campo
+func (r *MemoryProjectRepository) Search(filter domain.ProjectFilter) ([]*domain.Project, int64, error) {
+	terms := searchTerms(filter.Query)
+	scores := make(map[uint]int)
+
+	r.mu.RLock()
+	var projects []*domain.Project
+	for _, project := range r.projects {
+		if !filter.Matches(project) {
+			continue
+		}
+		if len(terms) > 0 {
+			score := searchScore(project, r.texts[project.ID], terms)
+			if score == 0 {
+				continue
+			}
+			scores[project.ID] = score
+		}
+		found := *project
+		projects = append(projects, &found)
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(projects, func(i, j int) bool {
+		a, b := projects[i], projects[j]
+		if filter.Ascending {
+			a, b = b, a
+		}
+		switch filter.Sort {
+		case domain.ProjectSortRelevance:
+			if scores[a.ID] != scores[b.ID] {
+				return scores[a.ID] > scores[b.ID]
+			}
+		case domain.ProjectSortTitle:
+			if a.Title != b.Title {
+				return a.Title > b.Title
+			}
+		case domain.ProjectSortAuthor:
+			if a.Author != b.Author {
+				return a.Author > b.Author
+			}
+		case domain.ProjectSortUpdated:
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.After(b.UpdatedAt)
+			}
+		case domain.ProjectSortCreated:
+			return newestFirst(a, b)
+		}
+		return newestFirst(projects[i], projects[j])
+	})
+	return paginate(projects, filter.Limit, filter.Offset), int64(len(projects)), nil
+}
+
+// lookup encontra um projeto pelo ID textual; o chamador detém o lock
+func (r *MemoryProjectRepository) lookup(id string) (*domain.Project, bool) {
+	n, err := strconv.ParseUint(id, 10, 0)
+	if err != nil {
+		return nil, false
+	}
+	project, ok := r.projects[uint(n)]
+	return project, ok
+}
+
+// filter copia os projetos que passam por keep, ordenados por less
+func (r *MemoryProjectRepository) filter(keep func(*domain.Project) bool, less func(a, b *domain.Project) bool) []*domain.Project {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var projects []*domain.Project
+	for _, project := range r.projects {
+		if keep(project) {
+			found := *project
+			projects = append(projects, &found)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return less(projects[i], projects[j])
+	})
+	return projects
+}
+
+// newestFirst ordena projetos como ORDER BY created_at DESC (e ID, no empate)
+func newestFirst(a, b *domain.Project) bool {
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return a.ID > b.ID
+	}
+	return a.CreatedAt.After(b.CreatedAt)
+}
+
+// paginate aplica LIMIT e OFFSET a uma lista (limit negativo: sem limite)
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return nil
+		}
+		items = items[offset:]
+	}
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
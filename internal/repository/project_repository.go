@@ -2,21 +2,26 @@ package repository
 
 import (
 	"fmt"
+	"time"
 
-	"github.com/JuanCS-Dev/typecraft/internal/database"
 	"github.com/JuanCS-Dev/typecraft/internal/domain"
 	"gorm.io/gorm"
 )
 
-// ProjectRepository lida com operações de banco de dados para Projects
+// notTrashed restringe as consultas aos projetos fora da lixeira
+const notTrashed = "deleted_at IS NULL"
+
+// ProjectRepository lida com operações de banco de dados para Projects.
+// Projetos na lixeira só são vistos pelos métodos de lixeira (Trash,
+// Restore, GetTrashed, ListTrash e GetExpired).
 type ProjectRepository struct {
 	db *gorm.DB
 }
 
 // NewProjectRepository cria uma nova instância do repositório
-func NewProjectRepository() *ProjectRepository {
+func NewProjectRepository(db *gorm.DB) *ProjectRepository {
 	return &ProjectRepository{
-		db: database.DB,
+		db: db,
 	}
 }
 
@@ -31,9 +36,9 @@ func (r *ProjectRepository) Create(project *domain.Project) error {
 // GetByID busca um projeto por ID
 func (r *ProjectRepository) GetByID(id string) (*domain.Project, error) {
 	var project domain.Project
-	if err := r.db.First(&project, "id = ?", id).Error; err != nil {
+	if err := r.db.Where(notTrashed).First(&project, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("projeto não encontrado")
+			return nil, fmt.Errorf("projeto não encontrado: %w", domain.ErrProjectNotFound)
 		}
 		return nil, fmt.Errorf("erro ao buscar projeto: %w", err)
 	}
@@ -45,7 +50,7 @@ func (r *ProjectRepository) GetAll(userID string, limit, offset int) ([]*domain.
 	var projects []*domain.Project
 	var total int64
 	
-	query := r.db.Model(&domain.Project{})
+	query := r.db.Model(&domain.Project{}).Where(notTrashed)
 	if userID != "" {
 		query = query.Where("user_id = ?", userID)
 	}
@@ -63,17 +68,35 @@ func (r *ProjectRepository) GetAll(userID string, limit, offset int) ([]*domain.
 	return projects, total, nil
 }
 
-// Update atualiza um projeto
+// Update atualiza um projeto fora da lixeira. Nunca o insere (ao contrário
+// do Save do GORM): um worker que salva o projeto depois da exclusão ou do
+// expurgo recebe domain.ErrProjectNotFound em vez de restaurá-lo.
 func (r *ProjectRepository) Update(project *domain.Project) error {
-	if err := r.db.Save(project).Error; err != nil {
-		return fmt.Errorf("erro ao atualizar projeto: %w", err)
+	result := r.db.Model(project).
+		Where("id = ?", project.ID).
+		Where(notTrashed).
+		Select("*").
+		Omit("deleted_at").
+		Updates(project)
+	if result.Error != nil {
+		return fmt.Errorf("erro ao atualizar projeto: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("projeto não encontrado ou na lixeira: %w", domain.ErrProjectNotFound)
 	}
 	return nil
 }
 
-// Delete deleta um projeto
+// Delete apaga um projeto definitivamente (esteja ou não na lixeira), com o
+// texto indexado do manuscrito
 func (r *ProjectRepository) Delete(id string) error {
-	if err := r.db.Delete(&domain.Project{}, "id = ?", id).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&domain.ManuscriptText{}, "project_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Project{}, "id = ?", id).Error
+	})
+	if err != nil {
 		return fmt.Errorf("erro ao deletar projeto: %w", err)
 	}
 	return nil
@@ -83,7 +106,7 @@ func (r *ProjectRepository) Delete(id string) error {
 func (r *ProjectRepository) GetByStatus(status domain.ProjectStatus, limit, offset int) ([]*domain.Project, error) {
 	var projects []*domain.Project
 	
-	if err := r.db.Where("status = ?", status).
+	if err := r.db.Where(notTrashed).Where("status = ?", status).
 		Limit(limit).
 		Offset(offset).
 		Order("created_at DESC").
@@ -98,7 +121,7 @@ func (r *ProjectRepository) GetByStatus(status domain.ProjectStatus, limit, offs
 func (r *ProjectRepository) GetProcessable(limit int) ([]*domain.Project, error) {
 	var projects []*domain.Project
 	
-	if err := r.db.Where("status = ? AND manuscript_url != ''", domain.StatusCreated).
+	if err := r.db.Where(notTrashed).Where("status = ? AND manuscript_url != ''", domain.StatusCreated).
 		Limit(limit).
 		Order("created_at ASC").
 		Find(&projects).Error; err != nil {
@@ -112,6 +135,7 @@ func (r *ProjectRepository) GetProcessable(limit int) ([]*domain.Project, error)
 func (r *ProjectRepository) UpdateStatus(id string, status domain.ProjectStatus, progress int) error {
 	if err := r.db.Model(&domain.Project{}).
 		Where("id = ?", id).
+		Where(notTrashed).
 		Updates(map[string]interface{}{
 			"status":   status,
 			"progress": progress,
@@ -120,3 +144,78 @@ func (r *ProjectRepository) UpdateStatus(id string, status domain.ProjectStatus,
 	}
 	return nil
 }
+
+// Trash move um projeto para a lixeira
+func (r *ProjectRepository) Trash(id string, at time.Time) error {
+	result := r.db.Model(&domain.Project{}).
+		Where("id = ?", id).
+		Where(notTrashed).
+		Update("deleted_at", at)
+	if result.Error != nil {
+		return fmt.Errorf("erro ao mover projeto para a lixeira: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("projeto não encontrado: %w", domain.ErrProjectNotFound)
+	}
+	return nil
+}
+
+// Restore tira um projeto da lixeira
+func (r *ProjectRepository) Restore(id string) error {
+	result := r.db.Model(&domain.Project{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return fmt.Errorf("erro ao restaurar projeto: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("projeto não está na lixeira: %w", domain.ErrProjectNotFound)
+	}
+	return nil
+}
+
+// GetTrashed busca um projeto na lixeira por ID
+func (r *ProjectRepository) GetTrashed(id string) (*domain.Project, error) {
+	var project domain.Project
+	if err := r.db.Where("deleted_at IS NOT NULL").First(&project, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("projeto não está na lixeira: %w", domain.ErrProjectNotFound)
+		}
+		return nil, fmt.Errorf("erro ao buscar projeto: %w", err)
+	}
+	return &project, nil
+}
+
+// ListTrash lista os projetos na lixeira, excluídos mais recentemente primeiro
+func (r *ProjectRepository) ListTrash(userID string, limit, offset int) ([]*domain.Project, int64, error) {
+	var projects []*domain.Project
+	var total int64
+
+	query := r.db.Model(&domain.Project{}).Where("deleted_at IS NOT NULL")
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao contar projetos na lixeira: %w", err)
+	}
+	if err := query.Limit(limit).Offset(offset).Order("deleted_at DESC").Find(&projects).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar a lixeira: %w", err)
+	}
+
+	return projects, total, nil
+}
+
+// GetExpired busca projetos que estão na lixeira desde antes de before
+func (r *ProjectRepository) GetExpired(before time.Time, limit int) ([]*domain.Project, error) {
+	var projects []*domain.Project
+
+	if err := r.db.Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Limit(limit).
+		Order("deleted_at ASC").
+		Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar projetos expirados na lixeira: %w", err)
+	}
+
+	return projects, nil
+}
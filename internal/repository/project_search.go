@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JuanCS-Dev/typecraft/internal/database"
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxManuscriptText limita o texto indexado por projeto: o tsvector do
+// Postgres não passa de 1 MB
+const MaxManuscriptText = 1 << 20
+
+// projectDocument é o documento de busca dos metadados, o mesmo do índice
+// idx_projects_search (migration 0005)
+const projectDocument = "to_tsvector('simple', coalesce(projects.title, '') || ' ' || coalesce(projects.author, '') || ' ' || coalesce(projects.description, ''))"
+
+// Pesos dos campos na relevância da busca sem full-text search
+var searchWeights = []struct {
+	column string
+	weight int
+	value  func(p *domain.Project, text string) string
+}{
+	{"projects.title", 3, func(p *domain.Project, _ string) string { return p.Title }},
+	{"projects.author", 3, func(p *domain.Project, _ string) string { return p.Author }},
+	{"projects.description", 2, func(p *domain.Project, _ string) string { return p.Description }},
+	{"manuscript_texts.content", 1, func(_ *domain.Project, text string) string { return text }},
+}
+
+// SetManuscriptText guarda o texto extraído do manuscrito para a busca,
+// substituindo o anterior
+func (r *ProjectRepository) SetManuscriptText(projectID string, revisionID, text string) error {
+	var project domain.Project
+	if err := r.db.Select("id").First(&project, "id = ?", projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("projeto não encontrado: %w", domain.ErrProjectNotFound)
+		}
+		return fmt.Errorf("erro ao buscar projeto: %w", err)
+	}
+
+	manuscript := &domain.ManuscriptText{
+		ProjectID:  project.ID,
+		RevisionID: revisionID,
+		Content:    truncateText(text, MaxManuscriptText),
+	}
+	if err := r.db.Save(manuscript).Error; err != nil {
+		return fmt.Errorf("erro ao indexar texto do manuscrito: %w", err)
+	}
+	return nil
+}
+
+// Search busca projetos pelos filtros. No Postgres o texto usa full-text
+// search (sintaxe de websearch_to_tsquery: "frase exata", -excluir, or);
+// nos demais bancos cada termo (ou "frase") precisa aparecer em algum campo,
+// sem diferenciar maiúsculas apenas em ASCII.
+func (r *ProjectRepository) Search(filter domain.ProjectFilter) ([]*domain.Project, int64, error) {
+	query := r.db.Model(&domain.Project{}).Where("projects.deleted_at IS NULL")
+	if filter.UserID != "" {
+		query = query.Where("projects.user_id = ?", filter.UserID)
+	}
+	if filter.Genre != "" {
+		query = query.Where("LOWER(projects.genre) = LOWER(?)", filter.Genre)
+	}
+	if filter.Status != "" {
+		query = query.Where("projects.status = ?", filter.Status)
+	}
+	if filter.Language != "" {
+		query = query.Where("projects.language = ?", filter.Language)
+	}
+	if filter.PageFormat != "" {
+		query = query.Where("projects.page_format = ?", filter.PageFormat)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("projects.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("projects.created_at < ?", *filter.CreatedTo)
+	}
+	if filter.UpdatedFrom != nil {
+		query = query.Where("projects.updated_at >= ?", *filter.UpdatedFrom)
+	}
+	if filter.UpdatedTo != nil {
+		query = query.Where("projects.updated_at < ?", *filter.UpdatedTo)
+	}
+
+	var relevance clause.Expr
+	if text := strings.TrimSpace(filter.Query); text != "" {
+		query = query.Joins("LEFT JOIN manuscript_texts ON manuscript_texts.project_id = projects.id")
+		if r.db.Dialector.Name() == database.DriverPostgres {
+			tsquery := "websearch_to_tsquery('simple', ?)"
+			query = query.Where("("+projectDocument+" @@ "+tsquery+" OR manuscript_texts.search_vector @@ "+tsquery+")", text, text)
+			relevance = clause.Expr{
+				SQL:  "2 * ts_rank(" + projectDocument + ", " + tsquery + ") + coalesce(ts_rank(manuscript_texts.search_vector, " + tsquery + "), 0)",
+				Vars: []interface{}{text, text},
+			}
+		} else {
+			terms := searchTerms(text)
+			var scores []string
+			for _, term := range terms {
+				pattern := likePattern(term)
+				var matches []string
+				var vars []interface{}
+				for _, field := range searchWeights {
+					matches = append(matches, "LOWER("+field.column+") LIKE ? ESCAPE '\\'")
+					vars = append(vars, pattern)
+					scores = append(scores, fmt.Sprintf("CASE WHEN LOWER(%s) LIKE ? ESCAPE '\\' THEN %d ELSE 0 END", field.column, field.weight))
+					relevance.Vars = append(relevance.Vars, pattern)
+				}
+				query = query.Where("("+strings.Join(matches, " OR ")+")", vars...)
+			}
+			relevance.SQL = strings.Join(scores, " + ")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao contar projetos: %w", err)
+	}
+
+	order := "DESC"
+	if filter.Ascending {
+		order = "ASC"
+	}
+	// Desempate (e ordenação padrão): mais recentes primeiro
+	const tieBreak = "projects.created_at DESC, projects.id DESC"
+	switch {
+	case filter.Sort == domain.ProjectSortRelevance && relevance.SQL != "":
+		// Uma expressão no ORDER BY substitui as colunas, então leva o desempate
+		relevance.SQL = "(" + relevance.SQL + ") " + order + ", " + tieBreak
+		query = query.Order(clause.OrderBy{Expression: relevance})
+	case filter.Sort == domain.ProjectSortTitle, filter.Sort == domain.ProjectSortAuthor, filter.Sort == domain.ProjectSortUpdated:
+		query = query.Order("projects." + string(filter.Sort) + " " + order + ", " + tieBreak)
+	case filter.Sort == domain.ProjectSortCreated:
+		query = query.Order("projects.created_at " + order + ", projects.id " + order)
+	default:
+		query = query.Order(tieBreak)
+	}
+
+	var projects []*domain.Project
+	if err := query.Select("projects.*").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&projects).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao buscar projetos: %w", err)
+	}
+
+	return projects, total, nil
+}
+
+// searchTerms separa a busca em termos minúsculos; trechos entre aspas
+// formam um só termo
+func searchTerms(text string) []string {
+	var terms []string
+	for i, part := range strings.Split(text, `"`) {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if i%2 == 1 {
+			terms = append(terms, part)
+			continue
+		}
+		terms = append(terms, strings.Fields(part)...)
+	}
+	return terms
+}
+
+// likePattern monta o padrão LIKE de um termo, escapando % e _
+func likePattern(term string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+	return "%" + escaped + "%"
+}
+
+// searchScore é a relevância da busca sem full-text search: para cada termo,
+// o peso dos campos em que aparece. Zero se algum termo não aparece.
+func searchScore(p *domain.Project, text string, terms []string) int {
+	score := 0
+	for _, term := range terms {
+		found := false
+		for _, field := range searchWeights {
+			if strings.Contains(strings.ToLower(field.value(p, text)), term) {
+				score += field.weight
+				found = true
+			}
+		}
+		if !found {
+			return 0
+		}
+	}
+	return score
+}
+
+// truncateText corta o texto em no máximo limit bytes, sem partir um
+// caractere UTF-8
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
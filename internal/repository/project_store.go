@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+// ProjectStore expõe um repositório de projetos pela interface
+// domain.ProjectRepository (IDs numéricos e context), usada pelo BookOrchestrator
+type ProjectStore struct {
+	repo Projects
+}
+
+// NewProjectStore cria o adaptador sobre um repositório de projetos
+func NewProjectStore(repo Projects) *ProjectStore {
+	return &ProjectStore{repo: repo}
+}
+
+// GetByID busca um projeto por ID, retornando domain.ErrProjectNotFound se não existir
+func (s *ProjectStore) GetByID(ctx context.Context, id uint) (*domain.Project, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(projectKey(id))
+}
+
+// Create cria um novo projeto
+func (s *ProjectStore) Create(ctx context.Context, project *domain.Project) error {
+	return s.repo.Create(project)
+}
+
+// Update atualiza um projeto
+func (s *ProjectStore) Update(ctx context.Context, project *domain.Project) error {
+	return s.repo.Update(project)
+}
+
+// Delete deleta um projeto
+func (s *ProjectStore) Delete(ctx context.Context, id uint) error {
+	return s.repo.Delete(projectKey(id))
+}
+
+// List lista todos os projetos, mais recentes primeiro
+func (s *ProjectStore) List(ctx context.Context) ([]*domain.Project, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	projects, _, err := s.repo.GetAll("", -1, 0)
+	return projects, err
+}
+
+// projectKey é o ID de um projeto como usado pelos repositórios
+func projectKey(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
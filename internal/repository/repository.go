@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/config"
+	"github.com/JuanCS-Dev/typecraft/internal/database"
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+)
+
+// DriverMemory guarda projetos, jobs e análises em memória, sem banco externo
+const DriverMemory = "memory"
+
+// Projects é o contrato dos repositórios de projetos (implementado por
+// ProjectRepository e MemoryProjectRepository)
+type Projects interface {
+	Create(project *domain.Project) error
+	GetByID(id string) (*domain.Project, error)
+	GetAll(userID string, limit, offset int) ([]*domain.Project, int64, error)
+	Update(project *domain.Project) error
+	Delete(id string) error
+	GetByStatus(status domain.ProjectStatus, limit, offset int) ([]*domain.Project, error)
+	GetProcessable(limit int) ([]*domain.Project, error)
+	UpdateStatus(id string, status domain.ProjectStatus, progress int) error
+	Trash(id string, at time.Time) error
+	Restore(id string) error
+	GetTrashed(id string) (*domain.Project, error)
+	ListTrash(userID string, limit, offset int) ([]*domain.Project, int64, error)
+	GetExpired(before time.Time, limit int) ([]*domain.Project, error)
+	Search(filter domain.ProjectFilter) ([]*domain.Project, int64, error)
+	SetManuscriptText(projectID string, revisionID, text string) error
+}
+
+// Jobs é o contrato dos repositórios de jobs (implementado por JobRepository
+// e MemoryJobRepository)
+type Jobs interface {
+	Create(job *domain.Job) error
+	GetByID(id string) (*domain.Job, error)
+	GetByProjectID(projectID string) ([]*domain.Job, error)
+	Update(job *domain.Job) error
+	UpdateFrom(job *domain.Job, from domain.JobStatus) error
+	List(filter domain.JobFilter) ([]*domain.Job, int64, error)
+	GetPending(limit int) ([]*domain.Job, error)
+	Claim(owner string, ttl time.Duration) (*domain.Job, error)
+	Heartbeat(id, owner string, ttl time.Duration) error
+	Release(job *domain.Job, owner string) error
+	ReapExpired(now time.Time) ([]*domain.Job, error)
+	GetByStatus(status domain.JobStatus, limit, offset int) ([]*domain.Job, error)
+	GetFailed(limit int) ([]*domain.Job, error)
+	Delete(id string) error
+	DeleteByProjectID(projectID string) error
+	CountByProject(projectID string) (int64, error)
+	CountByStatus(status domain.JobStatus) (int64, error)
+}
+
+// Analyses é o contrato dos repositórios de análises de IA (implementado por
+// AnalysisRepository e MemoryAnalysisRepository)
+type Analyses interface {
+	Save(analysis *domain.AIAnalysis) error
+	GetByProjectID(projectID string) (*domain.AIAnalysis, error)
+	GetByID(id string) (*domain.AIAnalysis, error)
+	GetCachedAnalysis(projectID string, maxAge time.Duration) (*domain.AIAnalysis, error)
+	Update(analysis *domain.AIAnalysis) error
+	Delete(id string) error
+	ListByProject(projectID string, limit int) ([]*domain.AIAnalysis, error)
+	CountByProject(projectID string) (int64, error)
+	GetTotalTokensUsed(projectID string) (int, error)
+	DeleteOldAnalyses(maxAge time.Duration) (int64, error)
+	DeleteByProjectID(projectID string) error
+}
+
+// Repositories reúne os repositórios usados pela API e pelo worker
+type Repositories struct {
+	Projects  Projects
+	Jobs      Jobs
+	Analyses  Analyses
+	Uploads   *UploadRepository
+	Artifacts *ArtifactRepository
+	Revisions *RevisionRepository
+	Webhooks  *WebhookRepository
+	JobLogs   *JobLogRepository
+	Users     *UserRepository
+
+	db *gorm.DB
+}
+
+// NewRepositories cria os repositórios GORM sobre db
+func NewRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Projects:  NewProjectRepository(db),
+		Jobs:      NewJobRepository(db),
+		Analyses:  NewAnalysisRepository(db),
+		Uploads:   NewUploadRepository(db),
+		Artifacts: NewArtifactRepository(db),
+		Revisions: NewRevisionRepository(db),
+		Webhooks:  NewWebhookRepository(db),
+		JobLogs:   NewJobLogRepository(db),
+		Users:     NewUserRepository(db),
+		db:        db,
+	}
+}
+
+// Open conecta os repositórios ao banco escolhido por DATABASE_DRIVER e
+// aplica as migrations pendentes (com AUTO_MIGRATE=false apenas exige que o
+// banco esteja na versão do binário). Drivers:
+//   - postgres: DATABASE_URL
+//   - sqlite: o arquivo SQLITE_PATH, que a API e o worker podem compartilhar
+//   - memory: projetos, jobs e análises em memória; uploads, revisões,
+//     artefatos, webhooks, logs e usuários num SQLite em memória. Nada sobrevive ao processo, nem
+//     é visto por outro: use apenas em desenvolvimento e testes.
+func Open(cfg *config.Config) (*Repositories, error) {
+	db, err := Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Um banco em memória nasce vazio: sempre é migrado
+	migrate := database.Migrate
+	if !cfg.AutoMigrate && cfg.DatabaseDriver != DriverMemory {
+		migrate = database.CheckSchema
+	}
+	if err := migrate(db); err != nil {
+		database.Close(db)
+		return nil, err
+	}
+
+	repos := NewRepositories(db)
+	if cfg.DatabaseDriver == DriverMemory {
+		repos.Projects = NewMemoryProjectRepository()
+		repos.Jobs = NewMemoryJobRepository()
+		repos.Analyses = NewMemoryAnalysisRepository()
+	}
+	return repos, nil
+}
+
+// Connect abre o banco escolhido por DATABASE_DRIVER, sem migrá-lo
+func Connect(cfg *config.Config) (*gorm.DB, error) {
+	switch cfg.DatabaseDriver {
+	case DriverMemory:
+		return database.Open(database.DriverSQLite, ":memory:")
+	case database.DriverSQLite:
+		return database.Open(database.DriverSQLite, cfg.SQLitePath)
+	default:
+		return database.Open(cfg.DatabaseDriver, cfg.DatabaseURL)
+	}
+}
+
+// ProjectStore expõe os projetos pela interface domain.ProjectRepository,
+// usada pelo BookOrchestrator
+func (r *Repositories) ProjectStore() domain.ProjectRepository {
+	return NewProjectStore(r.Projects)
+}
+
+// Health verifica se o banco está respondendo
+func (r *Repositories) Health() error {
+	if err := database.Health(r.db); err != nil {
+		return fmt.Errorf("banco indisponível: %w", err)
+	}
+	return nil
+}
+
+// Close fecha a conexão com o banco de dados
+func (r *Repositories) Close() error {
+	return database.Close(r.db)
+}
@@ -0,0 +1,522 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/config"
+	"github.com/JuanCS-Dev/typecraft/internal/database"
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+)
+
+// openTestDB abre um banco SQLite migrado num diretório temporário
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := database.Open(database.DriverSQLite, filepath.Join(t.TempDir(), "typecraft.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	return db
+}
+
+// projectRepositories e jobRepositories devolvem a implementação GORM (sobre
+// SQLite) e a em memória, que devem se comportar da mesma forma
+func projectRepositories(t *testing.T) map[string]Projects {
+	return map[string]Projects{
+		"sqlite": NewProjectRepository(openTestDB(t)),
+		"memory": NewMemoryProjectRepository(),
+	}
+}
+
+func jobRepositories(t *testing.T) map[string]Jobs {
+	return map[string]Jobs{
+		"sqlite": NewJobRepository(openTestDB(t)),
+		"memory": NewMemoryJobRepository(),
+	}
+}
+
+func TestProjects_CRUD(t *testing.T) {
+	for name, repo := range projectRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			channels := []string{"amazon_kdp", "ingram"}
+			analysis := map[string]interface{}{"genre": "fiction"}
+			project := &domain.Project{
+				UserID:               "user-1",
+				Title:                "Dom Casmurro",
+				Author:               "Machado de Assis",
+				DistributionChannels: &channels,
+				Analysis:             &analysis,
+			}
+			if err := repo.Create(project); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			if project.ID == 0 {
+				t.Fatal("expected ID to be assigned")
+			}
+
+			id := strconv.FormatUint(uint64(project.ID), 10)
+			found, err := repo.GetByID(id)
+			if err != nil {
+				t.Fatalf("GetByID failed: %v", err)
+			}
+			if found.Status != domain.StatusCreated || found.PageFormat != "6x9" || found.Language != "pt" {
+				t.Errorf("expected column defaults, got status=%s format=%s language=%s", found.Status, found.PageFormat, found.Language)
+			}
+			if found.DistributionChannels == nil || len(*found.DistributionChannels) != 2 {
+				t.Errorf("expected distribution channels to round-trip, got %v", found.DistributionChannels)
+			}
+			if found.Analysis == nil || (*found.Analysis)["genre"] != "fiction" {
+				t.Errorf("expected analysis to round-trip, got %v", found.Analysis)
+			}
+
+			found.Title = "Memórias Póstumas"
+			if err := repo.Update(found); err != nil {
+				t.Fatalf("Update failed: %v", err)
+			}
+			if err := repo.UpdateStatus(id, domain.StatusRendering, 40); err != nil {
+				t.Fatalf("UpdateStatus failed: %v", err)
+			}
+			found, _ = repo.GetByID(id)
+			if found.Title != "Memórias Póstumas" || found.Status != domain.StatusRendering || found.Progress != 40 {
+				t.Errorf("unexpected project after updates: %+v", found)
+			}
+
+			rendering, err := repo.GetByStatus(domain.StatusRendering, 10, 0)
+			if err != nil || len(rendering) != 1 {
+				t.Errorf("expected 1 rendering project, got %d (%v)", len(rendering), err)
+			}
+
+			if err := repo.Delete(id); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if _, err := repo.GetByID(id); !errors.Is(err, domain.ErrProjectNotFound) {
+				t.Errorf("expected ErrProjectNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProjects_GetAll(t *testing.T) {
+	for name, repo := range projectRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			base := time.Now().Add(-time.Hour)
+			for i, user := range []string{"user-1", "user-1", "user-1", "user-2"} {
+				project := &domain.Project{
+					UserID:    user,
+					Title:     "Livro " + strconv.Itoa(i),
+					Author:    "Autor",
+					CreatedAt: base.Add(time.Duration(i) * time.Minute),
+				}
+				if err := repo.Create(project); err != nil {
+					t.Fatalf("Create failed: %v", err)
+				}
+			}
+
+			projects, total, err := repo.GetAll("user-1", 2, 0)
+			if err != nil {
+				t.Fatalf("GetAll failed: %v", err)
+			}
+			if total != 3 || len(projects) != 2 {
+				t.Fatalf("expected 2 of 3 projects, got %d of %d", len(projects), total)
+			}
+			if projects[0].Title != "Livro 2" || projects[1].Title != "Livro 1" {
+				t.Errorf("expected newest first, got %s, %s", projects[0].Title, projects[1].Title)
+			}
+
+			projects, _, _ = repo.GetAll("user-1", 2, 2)
+			if len(projects) != 1 || projects[0].Title != "Livro 0" {
+				t.Errorf("expected last page with Livro 0, got %v", projects)
+			}
+
+			all, err := NewProjectStore(repo).List(context.Background())
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if len(all) != 4 {
+				t.Errorf("expected ProjectStore to list all 4 projects, got %d", len(all))
+			}
+		})
+	}
+}
+
+func TestProjects_Trash(t *testing.T) {
+	for name, repo := range projectRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			ids := make([]string, 3)
+			for i := range ids {
+				project := &domain.Project{UserID: "user-1", Title: "Livro " + strconv.Itoa(i), Author: "Autor", ManuscriptURL: "file:///m.md"}
+				if err := repo.Create(project); err != nil {
+					t.Fatalf("Create failed: %v", err)
+				}
+				ids[i] = strconv.FormatUint(uint64(project.ID), 10)
+			}
+
+			if err := repo.Trash(ids[0], now.Add(-40*24*time.Hour)); err != nil {
+				t.Fatalf("Trash failed: %v", err)
+			}
+			if err := repo.Trash(ids[1], now.Add(-time.Hour)); err != nil {
+				t.Fatalf("Trash failed: %v", err)
+			}
+			if err := repo.Trash(ids[1], now); !errors.Is(err, domain.ErrProjectNotFound) {
+				t.Errorf("expected trashing twice to fail with ErrProjectNotFound, got %v", err)
+			}
+
+			// Projetos na lixeira somem das consultas comuns
+			if _, err := repo.GetByID(ids[0]); !errors.Is(err, domain.ErrProjectNotFound) {
+				t.Errorf("expected a trashed project to be hidden, got %v", err)
+			}
+			if _, total, _ := repo.GetAll("user-1", 10, 0); total != 1 {
+				t.Errorf("expected only 1 project outside the trash, got %d", total)
+			}
+			if processable, _ := repo.GetProcessable(10); len(processable) != 1 {
+				t.Errorf("expected trashed projects not to be processable, got %d", len(processable))
+			}
+
+			trash, total, err := repo.ListTrash("user-1", 10, 0)
+			if err != nil {
+				t.Fatalf("ListTrash failed: %v", err)
+			}
+			if total != 2 || len(trash) != 2 || trash[0].Title != "Livro 1" || trash[0].DeletedAt == nil {
+				t.Fatalf("expected the 2 trashed projects, most recently deleted first, got %d: %+v", total, trash)
+			}
+
+			// Salvar um projeto na lixeira falha e não o restaura
+			trashed, err := repo.GetTrashed(ids[1])
+			if err != nil {
+				t.Fatalf("GetTrashed failed: %v", err)
+			}
+			trashed.DeletedAt = nil
+			trashed.Title = "Renomeado"
+			if err := repo.Update(trashed); !errors.Is(err, domain.ErrProjectNotFound) {
+				t.Errorf("expected updating a trashed project to fail with ErrProjectNotFound, got %v", err)
+			}
+			if trashed, err := repo.GetTrashed(ids[1]); err != nil || trashed.Title != "Livro 1" {
+				t.Errorf("expected the trashed project untouched, got %+v (%v)", trashed, err)
+			}
+
+			expired, err := repo.GetExpired(now.Add(-30*24*time.Hour), 10)
+			if err != nil {
+				t.Fatalf("GetExpired failed: %v", err)
+			}
+			if len(expired) != 1 || strconv.FormatUint(uint64(expired[0].ID), 10) != ids[0] {
+				t.Errorf("expected only the project trashed 40 days ago to expire, got %+v", expired)
+			}
+
+			if err := repo.Restore(ids[1]); err != nil {
+				t.Fatalf("Restore failed: %v", err)
+			}
+			if _, err := repo.GetByID(ids[1]); err != nil {
+				t.Errorf("expected the restored project to be visible, got %v", err)
+			}
+			if err := repo.Restore(ids[2]); !errors.Is(err, domain.ErrProjectNotFound) {
+				t.Errorf("expected restoring a project outside the trash to fail, got %v", err)
+			}
+			if _, err := repo.GetTrashed(ids[2]); !errors.Is(err, domain.ErrProjectNotFound) {
+				t.Errorf("expected GetTrashed to ignore projects outside the trash, got %v", err)
+			}
+		})
+	}
+}
+
+// Um worker que salva o projeto depois do expurgo não o recria
+func TestProjects_UpdateAfterPurge(t *testing.T) {
+	for name, repo := range projectRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			project := &domain.Project{UserID: "user-1", Title: "Livro", Author: "Autor"}
+			if err := repo.Create(project); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			id := strconv.FormatUint(uint64(project.ID), 10)
+
+			running, err := repo.GetByID(id)
+			if err != nil {
+				t.Fatalf("GetByID failed: %v", err)
+			}
+			if err := repo.Trash(id, time.Now()); err != nil {
+				t.Fatalf("Trash failed: %v", err)
+			}
+			if err := repo.Delete(id); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+
+			running.Status = domain.StatusRendering
+			if err := repo.Update(running); !errors.Is(err, domain.ErrProjectNotFound) {
+				t.Errorf("expected ErrProjectNotFound, got %v", err)
+			}
+			if _, err := repo.GetByID(id); !errors.Is(err, domain.ErrProjectNotFound) {
+				t.Errorf("expected the purged project to stay gone, got %v", err)
+			}
+			if _, err := repo.GetTrashed(id); !errors.Is(err, domain.ErrProjectNotFound) {
+				t.Errorf("expected the purged project to stay out of the trash, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProjects_Search(t *testing.T) {
+	for name, repo := range projectRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			projects := []*domain.Project{
+				{UserID: "user-1", Title: "The Lighthouse", Author: "Ana Costa", Genre: "Fiction", Language: "en", PageFormat: "6x9", Status: domain.StatusCreated},
+				{UserID: "user-1", Title: "Field Notes", Author: "Bruno Lima", Description: "Essays about the sea", Genre: "nonfiction", Language: "en", PageFormat: "5x8", Status: domain.StatusCompleted},
+				{UserID: "user-1", Title: "Receitas", Author: "Carla Dias", Genre: "fiction", Language: "pt", PageFormat: "6x9", Status: domain.StatusCreated},
+				{UserID: "user-2", Title: "The Lighthouse Keeper", Author: "Davi Rocha", Genre: "fiction", Language: "en", PageFormat: "6x9", Status: domain.StatusCreated},
+				{UserID: "user-1", Title: "Old Lighthouse", Author: "Eva Melo", Genre: "fiction", Language: "en", PageFormat: "6x9", Status: domain.StatusCreated},
+			}
+			ids := make([]string, len(projects))
+			for i, project := range projects {
+				if err := repo.Create(project); err != nil {
+					t.Fatalf("Create failed: %v", err)
+				}
+				ids[i] = strconv.FormatUint(uint64(project.ID), 10)
+			}
+			if err := repo.SetManuscriptText(ids[2], "rev-1", "Chapter 1\n\nA lighthouse on the coast, 100% worth the trip."); err != nil {
+				t.Fatalf("SetManuscriptText failed: %v", err)
+			}
+			if err := repo.SetManuscriptText("999", "", "text"); !errors.Is(err, domain.ErrProjectNotFound) {
+				t.Errorf("expected indexing an unknown project to fail with ErrProjectNotFound, got %v", err)
+			}
+			if err := repo.Trash(ids[4], time.Now()); err != nil {
+				t.Fatalf("Trash failed: %v", err)
+			}
+
+			titles := func(filter domain.ProjectFilter) []string {
+				t.Helper()
+				filter.UserID = "user-1"
+				if filter.Limit == 0 {
+					filter.Limit = 10
+				}
+				found, total, err := repo.Search(filter)
+				if err != nil {
+					t.Fatalf("Search failed: %v", err)
+				}
+				if int(total) < len(found) {
+					t.Errorf("expected total >= %d, got %d", len(found), total)
+				}
+				var titles []string
+				for _, project := range found {
+					titles = append(titles, project.Title)
+				}
+				return titles
+			}
+			expect := func(got []string, want ...string) {
+				t.Helper()
+				if strings.Join(got, "|") != strings.Join(want, "|") {
+					t.Errorf("expected %q, got %q", want, got)
+				}
+			}
+
+			// Título pesa mais que o texto do manuscrito; outro usuário e a
+			// lixeira ficam de fora
+			expect(titles(domain.ProjectFilter{Query: "LIGHTHOUSE", Sort: domain.ProjectSortRelevance}), "The Lighthouse", "Receitas")
+			expect(titles(domain.ProjectFilter{Query: "sea"}), "Field Notes")
+			expect(titles(domain.ProjectFilter{Query: "costa"}), "The Lighthouse")
+			expect(titles(domain.ProjectFilter{Query: "lighthouse coast"}), "Receitas")
+			expect(titles(domain.ProjectFilter{Query: "nothing-matches"}))
+
+			expect(titles(domain.ProjectFilter{Genre: "FICTION", Sort: domain.ProjectSortTitle, Ascending: true}), "Receitas", "The Lighthouse")
+			expect(titles(domain.ProjectFilter{Status: domain.StatusCompleted}), "Field Notes")
+			expect(titles(domain.ProjectFilter{Language: "en", PageFormat: "6x9"}), "The Lighthouse")
+			expect(titles(domain.ProjectFilter{Sort: domain.ProjectSortAuthor}), "Receitas", "Field Notes", "The Lighthouse")
+			expect(titles(domain.ProjectFilter{Sort: domain.ProjectSortCreated, Ascending: true}), "The Lighthouse", "Field Notes", "Receitas")
+			expect(titles(domain.ProjectFilter{Limit: 1, Offset: 1}), "Field Notes")
+
+			future := time.Now().Add(time.Hour)
+			expect(titles(domain.ProjectFilter{CreatedFrom: &future}))
+			expect(titles(domain.ProjectFilter{CreatedTo: &future, Sort: domain.ProjectSortTitle, Ascending: true}), "Field Notes", "Receitas", "The Lighthouse")
+
+			// Projetos excluídos levam o texto indexado junto
+			if err := repo.Delete(ids[2]); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			expect(titles(domain.ProjectFilter{Query: "coast"}))
+		})
+	}
+}
+
+func TestJobs_Claim(t *testing.T) {
+	for name, repo := range jobRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			base := time.Now().Add(-time.Minute)
+			later := time.Now().Add(time.Hour)
+			parents := []string{"analyze"}
+			jobs := []*domain.Job{
+				{ID: "analyze", ProjectID: "1", Type: domain.JobTypeAnalyze, CreatedAt: base},
+				{ID: "render", ProjectID: "1", Type: domain.JobTypeRender, Priority: 9, DependsOn: &parents, CreatedAt: base},
+				{ID: "backoff", ProjectID: "1", Type: domain.JobTypeExport, Priority: 10, RunAfter: &later, CreatedAt: base},
+			}
+			for _, job := range jobs {
+				if err := repo.Create(job); err != nil {
+					t.Fatalf("Create failed: %v", err)
+				}
+			}
+
+			// render tem prioridade maior, mas depende de analyze
+			claimed, err := repo.Claim("worker-1", time.Minute)
+			if err != nil {
+				t.Fatalf("Claim failed: %v", err)
+			}
+			if claimed == nil || claimed.ID != "analyze" {
+				t.Fatalf("expected analyze to be claimed first, got %+v", claimed)
+			}
+			if claimed.Status != domain.JobStatusRunning || claimed.LeaseOwner != "worker-1" {
+				t.Errorf("expected running job leased to worker-1, got %s/%s", claimed.Status, claimed.LeaseOwner)
+			}
+
+			if next, _ := repo.Claim("worker-2", time.Minute); next != nil {
+				t.Fatalf("expected no job while analyze runs, got %s", next.ID)
+			}
+
+			claimed.MarkCompleted(map[string]interface{}{"pages": 3})
+			if err := repo.Release(claimed, "worker-2"); !errors.Is(err, domain.ErrJobLeaseLost) {
+				t.Errorf("expected ErrJobLeaseLost for another owner, got %v", err)
+			}
+			if err := repo.Release(claimed, "worker-1"); err != nil {
+				t.Fatalf("Release failed: %v", err)
+			}
+
+			next, err := repo.Claim("worker-2", time.Minute)
+			if err != nil {
+				t.Fatalf("Claim failed: %v", err)
+			}
+			if next == nil || next.ID != "render" {
+				t.Fatalf("expected render once analyze completed, got %+v", next)
+			}
+
+			stored, _ := repo.GetByID("analyze")
+			if stored.Result == nil || (*stored.Result)["pages"] == nil {
+				t.Errorf("expected result to round-trip, got %v", stored.Result)
+			}
+		})
+	}
+}
+
+func TestJobs_ReapExpired(t *testing.T) {
+	for name, repo := range jobRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := repo.Create(&domain.Job{ID: "job-1", ProjectID: "1", Type: domain.JobTypeRender}); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			if _, err := repo.Claim("worker-1", time.Millisecond); err != nil {
+				t.Fatalf("Claim failed: %v", err)
+			}
+
+			time.Sleep(5 * time.Millisecond)
+			reaped, err := repo.ReapExpired(time.Now())
+			if err != nil {
+				t.Fatalf("ReapExpired failed: %v", err)
+			}
+			if len(reaped) != 1 || reaped[0].Status != domain.JobStatusPending {
+				t.Fatalf("expected 1 reaped job back in the queue, got %+v", reaped)
+			}
+			if err := repo.Heartbeat("job-1", "worker-1", time.Minute); !errors.Is(err, domain.ErrJobLeaseLost) {
+				t.Errorf("expected ErrJobLeaseLost after reaping, got %v", err)
+			}
+
+			claimed, err := repo.Claim("worker-2", time.Minute)
+			if err != nil || claimed == nil || claimed.ID != "job-1" {
+				t.Errorf("expected reaped job to be claimed again, got %+v (%v)", claimed, err)
+			}
+		})
+	}
+}
+
+func TestOpen_Memory(t *testing.T) {
+	repos, err := Open(&config.Config{DatabaseDriver: DriverMemory})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer repos.Close()
+
+	if _, ok := repos.Projects.(*MemoryProjectRepository); !ok {
+		t.Errorf("expected in-memory projects, got %T", repos.Projects)
+	}
+	if _, ok := repos.Jobs.(*MemoryJobRepository); !ok {
+		t.Errorf("expected in-memory jobs, got %T", repos.Jobs)
+	}
+	if err := repos.Health(); err != nil {
+		t.Errorf("Health failed: %v", err)
+	}
+
+	project := &domain.Project{UserID: "user-1", Title: "Livro", Author: "Autor"}
+	if err := repos.ProjectStore().Create(context.Background(), project); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := repos.ProjectStore().GetByID(context.Background(), project.ID); err != nil {
+		t.Errorf("GetByID failed: %v", err)
+	}
+}
+
+func TestRevisions_Numbering(t *testing.T) {
+	repo := NewRevisionRepository(openTestDB(t))
+
+	for i, projectID := range []uint{1, 1, 2, 1} {
+		revision := &domain.Revision{ID: "rev-" + strconv.Itoa(i), ProjectID: projectID, Filename: "livro.md"}
+		if err := repo.Create(revision); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	revisions, err := repo.ListByProject(1)
+	if err != nil {
+		t.Fatalf("ListByProject failed: %v", err)
+	}
+	if len(revisions) != 3 || revisions[0].Number != 3 || revisions[0].ID != "rev-3" || revisions[2].Number != 1 {
+		t.Fatalf("expected revisions 3, 2, 1 of project 1, got %+v", revisions)
+	}
+
+	second, err := repo.GetByNumber(2, 1)
+	if err != nil || second.ID != "rev-2" {
+		t.Errorf("expected the first revision of project 2 to be rev-2, got %+v (%v)", second, err)
+	}
+	if _, err := repo.GetByNumber(2, 2); !errors.Is(err, domain.ErrRevisionNotFound) {
+		t.Errorf("expected ErrRevisionNotFound, got %v", err)
+	}
+	if _, err := repo.GetByID("rev-1"); err != nil {
+		t.Errorf("GetByID failed: %v", err)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	repo := NewUserRepository(openTestDB(t))
+
+	user := &domain.User{ID: "user-1", Email: "ana@example.com", Name: "Ana", PasswordHash: "hash"}
+	if err := repo.Create(user); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Create(&domain.User{ID: "user-2", Email: "ana@example.com", PasswordHash: "hash"}); !errors.Is(err, domain.ErrEmailTaken) {
+		t.Errorf("expected ErrEmailTaken, got %v", err)
+	}
+
+	found, err := repo.GetByEmail("ana@example.com")
+	if err != nil || found.ID != "user-1" {
+		t.Fatalf("expected user-1 by email, got %+v (%v)", found, err)
+	}
+	if _, err := repo.GetByEmail("bia@example.com"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+
+	if err := repo.IncrementTokenVersion("user-1"); err != nil {
+		t.Fatalf("IncrementTokenVersion failed: %v", err)
+	}
+	found, err = repo.GetByID("user-1")
+	if err != nil || found.TokenVersion != 1 {
+		t.Errorf("expected token version 1, got %+v (%v)", found, err)
+	}
+	if err := repo.IncrementTokenVersion("user-2"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+)
+
+// revisionCreateAttempts limita as tentativas de numerar uma revisão quando
+// outro upload do mesmo projeto pega o mesmo número ao mesmo tempo
+const revisionCreateAttempts = 3
+
+// RevisionRepository lida com operações de banco de dados para as revisões
+// dos manuscritos
+type RevisionRepository struct {
+	db *gorm.DB
+}
+
+// NewRevisionRepository cria uma nova instância do repositório
+func NewRevisionRepository(db *gorm.DB) *RevisionRepository {
+	return &RevisionRepository{
+		db: db,
+	}
+}
+
+// Create registra uma revisão com o próximo número do projeto. O índice
+// único (project_id, number) impede números repetidos; se outro upload
+// levou o número, tenta de novo com o seguinte.
+func (r *RevisionRepository) Create(revision *domain.Revision) error {
+	var err error
+	for attempt := 0; attempt < revisionCreateAttempts; attempt++ {
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			var last int
+			if err := tx.Model(&domain.Revision{}).
+				Where("project_id = ?", revision.ProjectID).
+				Select("COALESCE(MAX(number), 0)").
+				Scan(&last).Error; err != nil {
+				return err
+			}
+			revision.Number = last + 1
+			return tx.Create(revision).Error
+		})
+		if err == nil {
+			return nil
+		}
+		if taken, _ := r.GetByNumber(revision.ProjectID, revision.Number); taken == nil || taken.ID == revision.ID {
+			break
+		}
+	}
+	return fmt.Errorf("erro ao registrar revisão: %w", err)
+}
+
+// GetByID busca uma revisão por ID
+func (r *RevisionRepository) GetByID(id string) (*domain.Revision, error) {
+	var revision domain.Revision
+	if err := r.db.First(&revision, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("erro ao buscar revisão: %w", err)
+	}
+	return &revision, nil
+}
+
+// GetByNumber busca a revisão de número number de um projeto
+func (r *RevisionRepository) GetByNumber(projectID uint, number int) (*domain.Revision, error) {
+	var revision domain.Revision
+	if err := r.db.First(&revision, "project_id = ? AND number = ?", projectID, number).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("erro ao buscar revisão: %w", err)
+	}
+	return &revision, nil
+}
+
+// ListByProject lista as revisões de um projeto, da mais recente para a
+// mais antiga
+func (r *RevisionRepository) ListByProject(projectID uint) ([]*domain.Revision, error) {
+	var revisions []*domain.Revision
+	if err := r.db.Where("project_id = ?", projectID).
+		Order("number DESC").
+		Find(&revisions).Error; err != nil {
+		return nil, fmt.Errorf("erro ao listar revisões: %w", err)
+	}
+	return revisions, nil
+}
+
+// DeleteByProjectID apaga as revisões de um projeto
+func (r *RevisionRepository) DeleteByProjectID(projectID uint) error {
+	if err := r.db.Where("project_id = ?", projectID).Delete(&domain.Revision{}).Error; err != nil {
+		return fmt.Errorf("erro ao deletar revisões do projeto: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+)
+
+// UploadRepository lida com operações de banco de dados para uploads de
+// manuscritos
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadRepository cria uma nova instância do repositório
+func NewUploadRepository(db *gorm.DB) *UploadRepository {
+	return &UploadRepository{
+		db: db,
+	}
+}
+
+// Create registra um upload
+func (r *UploadRepository) Create(upload *domain.Upload) error {
+	if err := r.db.Create(upload).Error; err != nil {
+		return fmt.Errorf("erro ao criar upload: %w", err)
+	}
+	return nil
+}
+
+// GetByID busca um upload por ID
+func (r *UploadRepository) GetByID(id string) (*domain.Upload, error) {
+	var upload domain.Upload
+	if err := r.db.First(&upload, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrUploadNotFound
+		}
+		return nil, fmt.Errorf("erro ao buscar upload: %w", err)
+	}
+	return &upload, nil
+}
+
+// Update salva o progresso de um upload
+func (r *UploadRepository) Update(upload *domain.Upload) error {
+	if err := r.db.Save(upload).Error; err != nil {
+		return fmt.Errorf("erro ao atualizar upload: %w", err)
+	}
+	return nil
+}
+
+// DeleteByProjectID apaga os uploads de um projeto
+func (r *UploadRepository) DeleteByProjectID(projectID uint) error {
+	if err := r.db.Where("project_id = ?", projectID).Delete(&domain.Upload{}).Error; err != nil {
+		return fmt.Errorf("erro ao deletar uploads do projeto: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+)
+
+// UserRepository lida com operações de banco de dados para usuários
+type UserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository cria uma nova instância do repositório
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+// Create cadastra um usuário; um e-mail já cadastrado retorna
+// domain.ErrEmailTaken
+func (r *UserRepository) Create(user *domain.User) error {
+	if _, err := r.GetByEmail(user.Email); err == nil {
+		return domain.ErrEmailTaken
+	} else if !errors.Is(err, domain.ErrUserNotFound) {
+		return err
+	}
+
+	if err := r.db.Create(user).Error; err != nil {
+		// Cadastro simultâneo barrado pelo índice único
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(strings.ToLower(err.Error()), "unique") {
+			return domain.ErrEmailTaken
+		}
+		return fmt.Errorf("erro ao criar usuário: %w", err)
+	}
+	return nil
+}
+
+// GetByID busca um usuário por ID
+func (r *UserRepository) GetByID(id string) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.First(&user, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	return &user, nil
+}
+
+// GetByEmail busca um usuário pelo e-mail (já normalizado)
+func (r *UserRepository) GetByEmail(email string) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.First(&user, "email = ?", email).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	return &user, nil
+}
+
+// IncrementTokenVersion invalida os refresh tokens já emitidos para o usuário
+func (r *UserRepository) IncrementTokenVersion(id string) error {
+	result := r.db.Model(&domain.User{}).
+		Where("id = ?", id).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1"))
+	if result.Error != nil {
+		return fmt.Errorf("erro ao atualizar usuário: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
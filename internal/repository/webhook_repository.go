@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository lida com operações de banco de dados para webhooks e
+// suas entregas
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository cria uma nova instância do repositório
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+// Create cria uma assinatura
+func (r *WebhookRepository) Create(hook *domain.Webhook) error {
+	if err := r.db.Create(hook).Error; err != nil {
+		return fmt.Errorf("erro ao criar webhook: %w", err)
+	}
+	return nil
+}
+
+// GetByID busca uma assinatura por ID
+func (r *WebhookRepository) GetByID(id string) (*domain.Webhook, error) {
+	var hook domain.Webhook
+	if err := r.db.First(&hook, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("erro ao buscar webhook: %w", err)
+	}
+	return &hook, nil
+}
+
+// List lista as assinaturas, mais recentes primeiro
+func (r *WebhookRepository) List(filter domain.WebhookFilter) ([]*domain.Webhook, error) {
+	query := r.db.Model(&domain.Webhook{})
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ProjectID != "" {
+		query = query.Where("project_id = ?", filter.ProjectID)
+	}
+
+	var hooks []*domain.Webhook
+	if err := query.Order("created_at DESC").Find(&hooks).Error; err != nil {
+		return nil, fmt.Errorf("erro ao listar webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// Subscribers busca as assinaturas ativas de um projeto e as do seu dono
+// que valem para todos os projetos
+func (r *WebhookRepository) Subscribers(userID, projectID string) ([]*domain.Webhook, error) {
+	var hooks []*domain.Webhook
+	if err := r.db.Where("active = ? AND (project_id = ? OR (project_id = '' AND user_id = ?))", true, projectID, userID).
+		Find(&hooks).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar webhooks do projeto: %w", err)
+	}
+	return hooks, nil
+}
+
+// Delete remove uma assinatura e suas entregas
+func (r *WebhookRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&domain.Webhook{}, "id = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("erro ao deletar webhook: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrWebhookNotFound
+		}
+		if err := tx.Delete(&domain.WebhookDelivery{}, "webhook_id = ?", id).Error; err != nil {
+			return fmt.Errorf("erro ao deletar entregas do webhook: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreateDelivery registra uma entrega a ser enviada
+func (r *WebhookRepository) CreateDelivery(delivery *domain.WebhookDelivery) error {
+	if err := r.db.Create(delivery).Error; err != nil {
+		return fmt.Errorf("erro ao criar entrega de webhook: %w", err)
+	}
+	return nil
+}
+
+// ClaimDeliveries reserva até limit entregas pendentes cuja tentativa venceu.
+// A próxima tentativa é adiada por lease, para que outro dispatcher não as
+// envie ao mesmo tempo; se este parar no meio, elas voltam sozinhas.
+func (r *WebhookRepository) ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	var deliveries []*domain.WebhookDelivery
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", domain.WebhookDeliveryPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil {
+			return err
+		}
+
+		until := now.Add(lease)
+		for _, delivery := range deliveries {
+			delivery.NextAttemptAt = &until
+			if err := tx.Model(delivery).Update("next_attempt_at", until).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao reservar entregas de webhook: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery salva o resultado de uma tentativa
+func (r *WebhookRepository) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	if err := r.db.Save(delivery).Error; err != nil {
+		return fmt.Errorf("erro ao atualizar entrega de webhook: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries lista as entregas de um webhook, mais recentes primeiro
+func (r *WebhookRepository) ListDeliveries(webhookID string, limit, offset int) ([]*domain.WebhookDelivery, int64, error) {
+	query := r.db.Model(&domain.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao contar entregas de webhook: %w", err)
+	}
+
+	var deliveries []*domain.WebhookDelivery
+	if err := query.Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar entregas de webhook: %w", err)
+	}
+
+	return deliveries, total, nil
+}
@@ -16,16 +16,16 @@ import (
 // AnalysisService handles manuscript content analysis
 type AnalysisService struct {
 	analyzer     *ai.Analyzer
-	projectRepo  *repository.ProjectRepository
-	analysisRepo *repository.AnalysisRepository
+	projectRepo  repository.Projects
+	analysisRepo repository.Analyses
 }
 
 // NewAnalysisService creates a new analysis service
-func NewAnalysisService(analyzer *ai.Analyzer, projectRepo *repository.ProjectRepository) *AnalysisService {
+func NewAnalysisService(analyzer *ai.Analyzer, projectRepo repository.Projects, analysisRepo repository.Analyses) *AnalysisService {
 	return &AnalysisService{
 		analyzer:     analyzer,
 		projectRepo:  projectRepo,
-		analysisRepo: repository.NewAnalysisRepository(),
+		analysisRepo: analysisRepo,
 	}
 }
 
@@ -46,7 +46,8 @@ func (s *AnalysisService) AnalyzeProject(ctx context.Context, projectID string,
 		fmt.Printf("Warning: cache check failed: %v\n", err)
 	}
 	
-	if cachedAnalysis != nil {
+	// A cached analysis of an earlier revision does not describe this text
+	if cachedAnalysis != nil && cachedAnalysis.RevisionID == project.RevisionID {
 		// Return cached analysis
 		return cachedAnalysis, nil
 	}
@@ -69,6 +70,7 @@ func (s *AnalysisService) AnalyzeProject(ctx context.Context, projectID string,
 	// Convert to domain model
 	domainAnalysis := s.convertToDomainAnalysis(analysis)
 	domainAnalysis.ProjectID = projectID
+	domainAnalysis.RevisionID = project.RevisionID
 	
 	// Save analysis to database (cache for future)
 	if err := s.analysisRepo.Save(domainAnalysis); err != nil {
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+)
+
+// defaultDownloadTTL is how long a signed download link stays valid
+const defaultDownloadTTL = 15 * time.Minute
+
+// ErrInvalidDownloadLink means a download link is malformed, tampered with or
+// expired
+var ErrInvalidDownloadLink = errors.New("invalid or expired download link")
+
+// ArtifactRecorder records stored outputs (implemented by
+// repository.ArtifactRepository)
+type ArtifactRecorder interface {
+	Create(artifact *domain.Artifact) error
+}
+
+// ArtifactStore persists generated artifacts (implemented by
+// repository.ArtifactRepository)
+type ArtifactStore interface {
+	ArtifactRecorder
+	GetByID(id string) (*domain.Artifact, error)
+	ListByProject(projectID uint) ([]*domain.Artifact, error)
+}
+
+// ArtifactFile is an artifact with a signed, expiring download link
+type ArtifactFile struct {
+	*domain.Artifact
+	DownloadURL string    `json:"download_url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// ArtifactService lists the generated files of a project and hands out
+// signed download links. Links are checked against the project owner when
+// issued; the download itself only needs a valid, unexpired signature, so
+// links work from a browser or a download manager.
+type ArtifactService struct {
+	artifacts ArtifactStore
+	projects  ProjectLookup
+	storage   storage.Storage
+	secret    []byte
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+// ArtifactServiceOption configures optional ArtifactService settings
+type ArtifactServiceOption func(*ArtifactService)
+
+// WithDownloadTTL sets how long download links stay valid (default 15 minutes)
+func WithDownloadTTL(ttl time.Duration) ArtifactServiceOption {
+	return func(s *ArtifactService) {
+		if ttl > 0 {
+			s.ttl = ttl
+		}
+	}
+}
+
+// NewArtifactService creates the service. secret signs the download links.
+func NewArtifactService(artifacts ArtifactStore, projects ProjectLookup, store storage.Storage, secret string, opts ...ArtifactServiceOption) *ArtifactService {
+	s := &ArtifactService{
+		artifacts: artifacts,
+		projects:  projects,
+		storage:   store,
+		secret:    []byte(secret),
+		ttl:       defaultDownloadTTL,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// List returns every artifact of a project owned by userID, newest first,
+// each with a fresh download link
+func (s *ArtifactService) List(userID, projectID string) ([]*ArtifactFile, error) {
+	project, err := ownedProject(s.projects, Caller{UserID: userID}, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts, err := s.artifacts.ListByProject(project.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*ArtifactFile, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		files = append(files, s.link(artifact))
+	}
+	return files, nil
+}
+
+// Get returns one artifact of a project owned by userID with a fresh
+// download link
+func (s *ArtifactService) Get(userID, projectID, artifactID string) (*ArtifactFile, error) {
+	project, err := ownedProject(s.projects, Caller{UserID: userID}, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact, err := s.artifacts.GetByID(artifactID)
+	if err != nil {
+		return nil, err
+	}
+	if artifact.ProjectID != project.ID {
+		return nil, domain.ErrArtifactNotFound
+	}
+	return s.link(artifact), nil
+}
+
+// Open checks a download link and opens the artifact it points to. The
+// reader supports seeking, for Range requests; the caller closes it.
+func (s *ArtifactService) Open(ctx context.Context, artifactID, expires, signature string) (*domain.Artifact, *storage.ObjectReader, error) {
+	if err := s.verify(artifactID, expires, signature); err != nil {
+		return nil, nil, err
+	}
+
+	artifact, err := s.artifacts.GetByID(artifactID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	obj, err := s.storage.Stat(ctx, artifact.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, fmt.Errorf("%w: file %s is gone", domain.ErrArtifactNotFound, artifact.Key)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return artifact, storage.NewObjectReader(ctx, s.storage, obj), nil
+}
+
+// link signs a download link valid for the configured TTL
+func (s *ArtifactService) link(artifact *domain.Artifact) *ArtifactFile {
+	expiresAt := s.now().Add(s.ttl).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.signature(artifact.ID, expires))
+
+	return &ArtifactFile{
+		Artifact:    artifact,
+		DownloadURL: "/api/v1/artifacts/" + url.PathEscape(artifact.ID) + "/download?" + query.Encode(),
+		ExpiresAt:   expiresAt,
+	}
+}
+
+// verify checks the signature and expiry of a download link
+func (s *ArtifactService) verify(artifactID, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidDownloadLink
+	}
+	given, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidDownloadLink
+	}
+	expected, _ := hex.DecodeString(s.signature(artifactID, expires))
+	if !hmac.Equal(given, expected) {
+		return ErrInvalidDownloadLink
+	}
+	if s.now().Unix() > expiresAt {
+		return ErrInvalidDownloadLink
+	}
+	return nil
+}
+
+// signature is the HMAC-SHA256 of the artifact ID and expiry
+func (s *ArtifactService) signature(artifactID, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("artifact:" + artifactID + ":" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+)
+
+// mockArtifactStore keeps artifacts in memory
+type mockArtifactStore struct {
+	artifacts []*domain.Artifact
+}
+
+func (m *mockArtifactStore) Create(artifact *domain.Artifact) error {
+	m.artifacts = append(m.artifacts, artifact)
+	return nil
+}
+
+func (m *mockArtifactStore) GetByID(id string) (*domain.Artifact, error) {
+	for _, artifact := range m.artifacts {
+		if artifact.ID == id {
+			return artifact, nil
+		}
+	}
+	return nil, domain.ErrArtifactNotFound
+}
+
+func (m *mockArtifactStore) ListByProject(projectID uint) ([]*domain.Artifact, error) {
+	var artifacts []*domain.Artifact
+	for i := len(m.artifacts) - 1; i >= 0; i-- {
+		if m.artifacts[i].ProjectID == projectID {
+			artifacts = append(artifacts, m.artifacts[i])
+		}
+	}
+	return artifacts, nil
+}
+
+func (m *mockArtifactStore) DeleteByProjectID(projectID uint) error {
+	kept := m.artifacts[:0]
+	for _, artifact := range m.artifacts {
+		if artifact.ProjectID != projectID {
+			kept = append(kept, artifact)
+		}
+	}
+	m.artifacts = kept
+	return nil
+}
+
+// newTestArtifacts stores a PDF generated by job "render-1" of project 1
+// (owned by alice) and returns the service over it
+func newTestArtifacts(t *testing.T) (*ArtifactService, *mockArtifactStore, storage.Storage) {
+	tmpDir := t.TempDir()
+	store, err := storage.NewLocalStorage(filepath.Join(tmpDir, "storage"))
+	if err != nil {
+		t.Fatalf("NewLocalStorage failed: %v", err)
+	}
+	artifacts := &mockArtifactStore{}
+
+	pdf := filepath.Join(tmpDir, "project_1.pdf")
+	os.WriteFile(pdf, []byte("%PDF-1.7 test"), 0644)
+	orchestrator := NewBookOrchestrator(newMockProjectRepository(), nil, tmpDir, WithStorage(store), WithArtifactStore(artifacts))
+	result := &GenerationResult{ProjectID: 1, RevisionID: "rev-1", OutputFiles: map[string]string{"pdf": pdf}}
+	if err := orchestrator.storeOutputs(context.Background(), result, "render-1"); err != nil {
+		t.Fatalf("storeOutputs failed: %v", err)
+	}
+
+	projects := webhookFixtures{projects: map[string]*domain.Project{
+		"1": {ID: 1, UserID: "alice"},
+		"2": {ID: 2, UserID: "bob"},
+	}}
+	return NewArtifactService(artifacts, projects, store, "secret"), artifacts, store
+}
+
+// linkParams extracts the artifact ID, expiry and signature of a download URL
+func linkParams(t *testing.T, downloadURL string) (string, string, string) {
+	u, err := url.Parse(downloadURL)
+	if err != nil {
+		t.Fatalf("Invalid download URL %q: %v", downloadURL, err)
+	}
+	id := filepath.Base(filepath.Dir(u.Path))
+	return id, u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestBookOrchestrator_StoreOutputsRecordsArtifacts(t *testing.T) {
+	_, artifacts, store := newTestArtifacts(t)
+
+	if len(artifacts.artifacts) != 1 {
+		t.Fatalf("Expected one artifact, got %d", len(artifacts.artifacts))
+	}
+	artifact := artifacts.artifacts[0]
+	if artifact.ProjectID != 1 || artifact.JobID != "render-1" || artifact.RevisionID != "rev-1" || artifact.Format != "pdf" ||
+		artifact.Size != 13 || artifact.ContentType != "application/pdf" {
+		t.Errorf("Unexpected artifact: %+v", artifact)
+	}
+	if len(artifact.Checksum) != 64 {
+		t.Errorf("Expected a SHA-256 checksum, got %q", artifact.Checksum)
+	}
+	if artifact.Key != "projects/1/output/render-1/book.pdf" {
+		t.Errorf("Expected the output kept per generation, got %s", artifact.Key)
+	}
+	if _, err := store.Stat(context.Background(), artifact.Key); err != nil {
+		t.Errorf("Expected the stored object: %v", err)
+	}
+}
+
+func TestArtifactService_ListChecksOwner(t *testing.T) {
+	service, _, _ := newTestArtifacts(t)
+
+	files, err := service.List("alice", "1")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(files) != 1 || files[0].DownloadURL == "" || !files[0].ExpiresAt.After(time.Now()) {
+		t.Fatalf("Expected one file with a download link, got %+v", files)
+	}
+
+	if _, err := service.List("bob", "1"); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected ErrProjectAccessDenied for another user, got %v", err)
+	}
+	if _, err := service.List("alice", "9"); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Errorf("Expected ErrProjectNotFound, got %v", err)
+	}
+
+	// An artifact is only reachable through its own project
+	if _, err := service.Get("bob", "2", files[0].ID); !errors.Is(err, domain.ErrArtifactNotFound) {
+		t.Errorf("Expected ErrArtifactNotFound through another project, got %v", err)
+	}
+	if file, err := service.Get("alice", "1", files[0].ID); err != nil || file.ID != files[0].ID {
+		t.Errorf("Expected the artifact, got %+v, %v", file, err)
+	}
+}
+
+func TestArtifactService_OpenVerifiesLink(t *testing.T) {
+	service, _, _ := newTestArtifacts(t)
+	ctx := context.Background()
+
+	files, _ := service.List("alice", "1")
+	id, expires, signature := linkParams(t, files[0].DownloadURL)
+
+	artifact, reader, err := service.Open(ctx, id, expires, signature)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "%PDF-1.7 test" || artifact.Format != "pdf" {
+		t.Errorf("Unexpected download %q of %+v", data, artifact)
+	}
+
+	forged := []byte(signature)
+	if forged[0] == '0' {
+		forged[0] = '1'
+	} else {
+		forged[0] = '0'
+	}
+	tampered := []struct{ name, id, expires, signature string }{
+		{"other expiry", id, "9999999999", signature},
+		{"bad signature", id, expires, string(forged)},
+		{"not hex", id, expires, "zz"},
+		{"missing expiry", id, "", signature},
+	}
+	for _, tt := range tampered {
+		if _, _, err := service.Open(ctx, tt.id, tt.expires, tt.signature); !errors.Is(err, ErrInvalidDownloadLink) {
+			t.Errorf("%s: expected ErrInvalidDownloadLink, got %v", tt.name, err)
+		}
+	}
+
+	// Links stop working once expired
+	service.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, _, err := service.Open(ctx, id, expires, signature); !errors.Is(err, ErrInvalidDownloadLink) {
+		t.Errorf("Expected an expired link rejected, got %v", err)
+	}
+}
+
+func TestArtifactService_OpenMissingFile(t *testing.T) {
+	service, artifacts, store := newTestArtifacts(t)
+	ctx := context.Background()
+
+	files, _ := service.List("alice", "1")
+	store.Delete(ctx, artifacts.artifacts[0].Key)
+
+	id, expires, signature := linkParams(t, files[0].DownloadURL)
+	if _, _, err := service.Open(ctx, id, expires, signature); !errors.Is(err, domain.ErrArtifactNotFound) {
+		t.Errorf("Expected ErrArtifactNotFound for a deleted file, got %v", err)
+	}
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+	// minPasswordLength is the shortest password accepted on registration
+	minPasswordLength = 8
+
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+var (
+	// ErrInvalidCredentials means the email is unknown or the password wrong
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidToken means a token is malformed, tampered with, expired,
+	// of the wrong type or revoked
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrInvalidRegistration means a registration request failed validation
+	ErrInvalidRegistration = errors.New("invalid registration")
+)
+
+// jwtHeader is the header of every token issued: HMAC-SHA256 signed JWTs
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// UserStore persists accounts (implemented by repository.UserRepository)
+type UserStore interface {
+	Create(user *domain.User) error
+	GetByID(id string) (*domain.User, error)
+	GetByEmail(email string) (*domain.User, error)
+	IncrementTokenVersion(id string) error
+}
+
+// RegisterRequest creates an account
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Name     string `json:"name"`
+}
+
+// TokenPair is what a login, registration or refresh returns: a short-lived
+// access token for the Authorization header and a long-lived refresh token
+// to get the next pair
+type TokenPair struct {
+	AccessToken      string       `json:"access_token"`
+	RefreshToken     string       `json:"refresh_token"`
+	TokenType        string       `json:"token_type"`
+	ExpiresIn        int64        `json:"expires_in"` // seconds until the access token expires
+	RefreshExpiresAt time.Time    `json:"refresh_expires_at"`
+	User             *domain.User `json:"user"`
+}
+
+// TokenClaims are the JWT claims of the tokens issued by AuthService
+type TokenClaims struct {
+	Subject   string `json:"sub"`            // user ID
+	Type      string `json:"typ"`            // access or refresh
+	Role      string `json:"role,omitempty"` // access tokens only: domain.RoleUser or domain.RoleOperator
+	Version   int    `json:"ver,omitempty"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Caller is the authenticated user a request acts for
+type Caller struct {
+	UserID string
+	Role   string
+}
+
+// IsOperator reports whether the caller administers the jobs of every user
+func (c Caller) IsOperator() bool {
+	return c.Role == domain.RoleOperator
+}
+
+// AuthService registers users, checks their passwords and issues and verifies
+// the JWTs the API is called with. Access tokens are verified by signature
+// alone; refresh tokens also carry the user's token version, so a logout
+// revokes every refresh token issued before it.
+type AuthService struct {
+	users      UserStore
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+
+	// dummyHash is compared against on unknown emails, so a login takes
+	// the same time whether the account exists or not
+	dummyHash []byte
+}
+
+// AuthServiceOption configures optional AuthService settings
+type AuthServiceOption func(*AuthService)
+
+// WithAccessTokenTTL sets how long access tokens stay valid (default 15 minutes)
+func WithAccessTokenTTL(ttl time.Duration) AuthServiceOption {
+	return func(s *AuthService) {
+		if ttl > 0 {
+			s.accessTTL = ttl
+		}
+	}
+}
+
+// WithRefreshTokenTTL sets how long refresh tokens stay valid (default 30 days)
+func WithRefreshTokenTTL(ttl time.Duration) AuthServiceOption {
+	return func(s *AuthService) {
+		if ttl > 0 {
+			s.refreshTTL = ttl
+		}
+	}
+}
+
+// NewAuthService creates the service. secret signs the tokens.
+func NewAuthService(users UserStore, secret string, opts ...AuthServiceOption) *AuthService {
+	s := &AuthService{
+		users:      users,
+		secret:     []byte(secret),
+		accessTTL:  defaultAccessTokenTTL,
+		refreshTTL: defaultRefreshTokenTTL,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("typecraft"), bcrypt.DefaultCost)
+	return s
+}
+
+// Register creates an account and signs it in
+func (s *AuthService) Register(req RegisterRequest) (*TokenPair, error) {
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Password) < minPasswordLength {
+		return nil, fmt.Errorf("%w: password must have at least %d characters", ErrInvalidRegistration, minPasswordLength)
+	}
+	// bcrypt ignores everything after 72 bytes
+	if len(req.Password) > 72 {
+		return nil, fmt.Errorf("%w: password must have at most 72 bytes", ErrInvalidRegistration)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &domain.User{
+		ID:           uuid.New().String(),
+		Email:        email,
+		Name:         strings.TrimSpace(req.Name),
+		PasswordHash: string(hash),
+		Role:         domain.RoleUser,
+	}
+	if err := s.users.Create(user); err != nil {
+		return nil, err
+	}
+	return s.issue(user)
+}
+
+// Login checks an email and password and signs the user in
+func (s *AuthService) Login(email, password string) (*TokenPair, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.users.GetByEmail(email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return s.issue(user)
+}
+
+// Refresh exchanges a refresh token for a new token pair
+func (s *AuthService) Refresh(refreshToken string) (*TokenPair, error) {
+	claims, err := s.verify(refreshToken, tokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(claims.Subject)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if claims.Version != user.TokenVersion {
+		return nil, ErrInvalidToken
+	}
+	return s.issue(user)
+}
+
+// Logout revokes every refresh token of the user. Access tokens already
+// issued stay valid until they expire.
+func (s *AuthService) Logout(userID string) error {
+	return s.users.IncrementTokenVersion(userID)
+}
+
+// Authenticate verifies an access token and returns the caller it was
+// issued to. The role is the one the user had when the token was issued.
+func (s *AuthService) Authenticate(accessToken string) (Caller, error) {
+	claims, err := s.verify(accessToken, tokenTypeAccess)
+	if err != nil {
+		return Caller{}, err
+	}
+	role := claims.Role
+	if role == "" {
+		role = domain.RoleUser
+	}
+	return Caller{UserID: claims.Subject, Role: role}, nil
+}
+
+// User returns the account of userID
+func (s *AuthService) User(userID string) (*domain.User, error) {
+	return s.users.GetByID(userID)
+}
+
+// issue signs a new access and refresh token for user
+func (s *AuthService) issue(user *domain.User) (*TokenPair, error) {
+	now := s.now()
+
+	access, err := s.sign(TokenClaims{
+		Subject:   user.ID,
+		Type:      tokenTypeAccess,
+		Role:      user.Role,
+		ID:        uuid.New().String(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.accessTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshExpiresAt := now.Add(s.refreshTTL).Truncate(time.Second)
+	refresh, err := s.sign(TokenClaims{
+		Subject:   user.ID,
+		Type:      tokenTypeRefresh,
+		Version:   user.TokenVersion,
+		ID:        uuid.New().String(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: refreshExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(s.accessTTL / time.Second),
+		RefreshExpiresAt: refreshExpiresAt,
+		User:             user,
+	}, nil
+}
+
+// sign encodes claims as a compact HS256 JWT
+func (s *AuthService) sign(claims TokenClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token: %w", err)
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(s.mac(unsigned)), nil
+}
+
+// verify checks the signature, expiry and type of a token and returns its
+// claims. Only the header this service issues is accepted, so a token
+// cannot pick its own algorithm.
+func (s *AuthService) verify(token, tokenType string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, s.mac(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Type != tokenType || claims.Subject == "" || s.now().Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+// mac is the HMAC-SHA256 of the signed part of a token
+func (s *AuthService) mac(unsigned string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+// normalizeEmail validates an email address and lower-cases it
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", fmt.Errorf("%w: invalid email %q", ErrInvalidRegistration, email)
+	}
+	return email, nil
+}
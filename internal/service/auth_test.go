@@ -0,0 +1,217 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// mockUserStore implements UserStore in memory
+type mockUserStore struct {
+	mu    sync.Mutex
+	users map[string]*domain.User
+}
+
+func newMockUserStore() *mockUserStore {
+	return &mockUserStore{users: make(map[string]*domain.User)}
+}
+
+func (m *mockUserStore) Create(user *domain.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.users {
+		if existing.Email == user.Email {
+			return domain.ErrEmailTaken
+		}
+	}
+	copied := *user
+	m.users[user.ID] = &copied
+	return nil
+}
+
+func (m *mockUserStore) GetByID(id string) (*domain.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, ok := m.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (m *mockUserStore) GetByEmail(email string) (*domain.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, user := range m.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (m *mockUserStore) IncrementTokenVersion(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, ok := m.users[id]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	user.TokenVersion++
+	return nil
+}
+
+func newTestAuthService(opts ...AuthServiceOption) *AuthService {
+	s := NewAuthService(newMockUserStore(), "test-secret", opts...)
+	// The minimum cost keeps the tests fast
+	s.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("typecraft"), bcrypt.MinCost)
+	return s
+}
+
+func TestAuthService_RegisterAndLogin(t *testing.T) {
+	auth := newTestAuthService()
+
+	registered, err := auth.Register(RegisterRequest{Email: " Ana@Example.com ", Password: "correct horse", Name: "Ana"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if registered.User.Email != "ana@example.com" || registered.TokenType != "Bearer" || registered.ExpiresIn != 900 {
+		t.Errorf("Unexpected registration: %+v", registered)
+	}
+	if strings.Contains(registered.User.PasswordHash, "correct horse") {
+		t.Errorf("Expected the password to be hashed")
+	}
+
+	caller, err := auth.Authenticate(registered.AccessToken)
+	if err != nil || caller.UserID != registered.User.ID || caller.IsOperator() {
+		t.Errorf("Expected the access token to authenticate user %s, got %+v (%v)", registered.User.ID, caller, err)
+	}
+
+	if _, err := auth.Register(RegisterRequest{Email: "ana@example.com", Password: "another one"}); !errors.Is(err, domain.ErrEmailTaken) {
+		t.Errorf("Expected ErrEmailTaken, got %v", err)
+	}
+	if _, err := auth.Register(RegisterRequest{Email: "bia@example.com", Password: "short"}); !errors.Is(err, ErrInvalidRegistration) {
+		t.Errorf("Expected a short password to be rejected, got %v", err)
+	}
+	if _, err := auth.Register(RegisterRequest{Email: "not an email", Password: "long enough"}); !errors.Is(err, ErrInvalidRegistration) {
+		t.Errorf("Expected an invalid email to be rejected, got %v", err)
+	}
+
+	if _, err := auth.Login("ANA@example.com", "correct horse"); err != nil {
+		t.Errorf("Login failed: %v", err)
+	}
+	if _, err := auth.Login("ana@example.com", "wrong horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected a wrong password to fail with ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := auth.Login("bia@example.com", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected an unknown email to fail with ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestAuthService_RefreshAndLogout(t *testing.T) {
+	auth := newTestAuthService()
+	tokens, err := auth.Register(RegisterRequest{Email: "ana@example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	// Each token is only accepted for its own purpose
+	if _, err := auth.Refresh(tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected an access token to be rejected as refresh token, got %v", err)
+	}
+	if _, err := auth.Authenticate(tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a refresh token to be rejected as access token, got %v", err)
+	}
+
+	refreshed, err := auth.Refresh(tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if refreshed.AccessToken == tokens.AccessToken || refreshed.User.ID != tokens.User.ID {
+		t.Errorf("Expected a new token pair for the same user, got %+v", refreshed)
+	}
+
+	// Logging out revokes every refresh token issued before
+	if err := auth.Logout(tokens.User.ID); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	for _, token := range []string{tokens.RefreshToken, refreshed.RefreshToken} {
+		if _, err := auth.Refresh(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Expected a refresh token issued before logout to be revoked, got %v", err)
+		}
+	}
+	relogged, err := auth.Login("ana@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if _, err := auth.Refresh(relogged.RefreshToken); err != nil {
+		t.Errorf("Expected a refresh token issued after logout to work, got %v", err)
+	}
+}
+
+func TestAuthService_RejectsInvalidTokens(t *testing.T) {
+	now := time.Now()
+	auth := newTestAuthService(WithAccessTokenTTL(time.Minute))
+	auth.now = func() time.Time { return now }
+
+	tokens, err := auth.Register(RegisterRequest{Email: "ana@example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	parts := strings.Split(tokens.AccessToken, ".")
+	other := NewAuthService(newMockUserStore(), "other-secret")
+	forged, _ := other.sign(TokenClaims{Subject: "someone-else", Type: tokenTypeAccess, ExpiresAt: now.Add(time.Hour).Unix()})
+	unsigned := strings.Replace(tokens.AccessToken, parts[0], "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0", 1)
+
+	for name, token := range map[string]string{
+		"empty":        "",
+		"malformed":    "not.a.token",
+		"tampered":     parts[0] + "." + parts[1] + "x." + parts[2],
+		"other secret": forged,
+		"alg none":     unsigned,
+	} {
+		if _, err := auth.Authenticate(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+
+	if _, err := auth.Authenticate(tokens.AccessToken); err != nil {
+		t.Errorf("Expected the access token to be valid, got %v", err)
+	}
+	auth.now = func() time.Time { return now.Add(time.Minute) }
+	if _, err := auth.Authenticate(tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected an expired access token to be rejected, got %v", err)
+	}
+}
+
+func TestAuthService_OperatorRole(t *testing.T) {
+	users := newMockUserStore()
+	auth := NewAuthService(users, "test-secret")
+
+	registered, err := auth.Register(RegisterRequest{Email: "ops@example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if registered.User.Role != domain.RoleUser {
+		t.Errorf("Expected new accounts to be users, got %q", registered.User.Role)
+	}
+
+	// Promoted in the database: the role is in the next tokens issued
+	users.users[registered.User.ID].Role = domain.RoleOperator
+	tokens, err := auth.Refresh(registered.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	caller, err := auth.Authenticate(tokens.AccessToken)
+	if err != nil || !caller.IsOperator() {
+		t.Errorf("Expected an operator caller, got %+v (%v)", caller, err)
+	}
+}
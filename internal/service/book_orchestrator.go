@@ -4,13 +4,33 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"html/template"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	htmlpipeline "github.com/JuanCS-Dev/typecraft/internal/pipeline/html"
+	"github.com/JuanCS-Dev/typecraft/internal/pipeline/html/paged"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+	"github.com/JuanCS-Dev/typecraft/pkg/converter"
 	"github.com/JuanCS-Dev/typecraft/pkg/design"
+	"github.com/JuanCS-Dev/typecraft/pkg/epub"
+	"github.com/JuanCS-Dev/typecraft/pkg/latex"
+	"github.com/JuanCS-Dev/typecraft/pkg/pipeline"
+	"github.com/google/uuid"
+)
+
+const (
+	// latexCompileTimeout bounds a single lualatex pass; full books take minutes
+	latexCompileTimeout = 5 * time.Minute
+	// pagedRenderTimeout bounds pagination of the whole book by pagedjs-cli
+	pagedRenderTimeout = 5 * time.Minute
 )
 
 // BookOrchestrator coordinates the complete book generation workflow.
@@ -19,11 +39,51 @@ type BookOrchestrator struct {
 	projectRepo    domain.ProjectRepository
 	analysisClient AnalysisClient
 	designService  *design.Service
+	progress       ProgressStore
+	events         EventPublisher
+	cancellations  *cancellationRegistry
+	storage        storage.Storage
+	artifacts      ArtifactRecorder
 	
 	// Output configuration
 	outputDir string
 }
 
+// OrchestratorOption configures optional orchestrator dependencies
+type OrchestratorOption func(*BookOrchestrator)
+
+// WithProgressStore sets where generation progress is published
+// (defaults to an in-memory store)
+func WithProgressStore(store ProgressStore) OrchestratorOption {
+	return func(o *BookOrchestrator) {
+		o.progress = store
+	}
+}
+
+// WithEventPublisher publishes stage progress and validation issues as
+// project events
+func WithEventPublisher(events EventPublisher) OrchestratorOption {
+	return func(o *BookOrchestrator) {
+		o.events = events
+	}
+}
+
+// WithStorage stores validated outputs under projects/<id>/output and lets
+// generations read content from storage URLs
+func WithStorage(store storage.Storage) OrchestratorOption {
+	return func(o *BookOrchestrator) {
+		o.storage = store
+	}
+}
+
+// WithArtifactStore records every stored output as a downloadable artifact
+// (requires WithStorage)
+func WithArtifactStore(artifacts ArtifactRecorder) OrchestratorOption {
+	return func(o *BookOrchestrator) {
+		o.artifacts = artifacts
+	}
+}
+
 // AnalysisClient interface for AI content analysis
 type AnalysisClient interface {
 	AnalyzeContent(ctx context.Context, content string) (*domain.Analysis, error)
@@ -34,41 +94,59 @@ func NewBookOrchestrator(
 	projectRepo domain.ProjectRepository,
 	analysisClient AnalysisClient,
 	outputDir string,
+	opts ...OrchestratorOption,
 ) *BookOrchestrator {
-	return &BookOrchestrator{
+	o := &BookOrchestrator{
 		projectRepo:    projectRepo,
 		analysisClient: analysisClient,
 		designService:  design.NewService(),
+		progress:       NewMemoryProgressStore(),
+		cancellations:  newCancellationRegistry(),
 		outputDir:      outputDir,
 	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
 }
 
 // GenerationRequest encapsulates all parameters for book generation
 type GenerationRequest struct {
 	ProjectID       uint
-	ContentPath     string
+	ContentPath     string // Manuscript to read: a storage URL or local path, read as is (never from API input)
 	OutputFormats   []string // ["pdf", "epub"]
 	OverridePipeline string   // "latex" or "html" (optional)
 	CustomDesign    *DesignOptions
+	JobID           string      // Job tracking this generation (optional)
+	Revision        int         // Manuscript revision to generate from instead of ContentPath (optional, resolved by GenerationJobs.Enqueue)
+	RevisionID      string      // Manuscript revision ContentPath holds (optional)
+	Assets          *BookAssets // Images of a bundle manuscript (set by Generate for bundles)
 }
 
 // DesignOptions allows custom design parameters
 type DesignOptions struct {
-	BodyFont        string
-	HeadingFont     string
-	ColorScheme     []string
-	MarginPreset    string
-	CustomMargins   *design.Margins
+	BodyFont        string          `json:"body_font,omitempty"`
+	HeadingFont     string          `json:"heading_font,omitempty"`
+	ColorScheme     []string        `json:"color_scheme,omitempty"`
+	MarginPreset    string          `json:"margin_preset,omitempty"`
+	CustomMargins   *design.Margins `json:"custom_margins,omitempty"`
 }
 
 // GenerationResult contains all outputs and metadata
 type GenerationResult struct {
 	ProjectID      uint
+	RevisionID     string // Manuscript revision the outputs were built from, if known
 	Pipeline       string
 	OutputFiles    map[string]string // format -> filepath
+	OutputURLs     map[string]string // format -> storage URL (the filepath without storage)
 	DesignMetadata *design.DesignResult
 	Analysis       *domain.Analysis
 	Metrics        *GenerationMetrics
+	CompileErrors  []latex.CompileError // LaTeX errors from the last compilation
+	Warnings       []string             // Non-fatal warnings reported by the renderers
+	Images         []ImageReport        // Print and ePub readiness of bundle images
 	Success        bool
 	Error          error
 }
@@ -90,50 +168,104 @@ type GenerationMetrics struct {
 
 // Generate orchestrates the complete book generation pipeline.
 // This is the MAIN INTEGRATION POINT following VÉRTICE architecture.
+// Every step publishes its stage to the progress store (see GetProgress).
+// A generation whose context is cancelled (e.g. its job was cancelled and the
+// worker lost the lease) returns ErrGenerationCancelled.
 func (o *BookOrchestrator) Generate(ctx context.Context, req *GenerationRequest) (*GenerationResult, error) {
 	metrics := &GenerationMetrics{StartTime: time.Now()}
 	result := &GenerationResult{
 		ProjectID:   req.ProjectID,
+		RevisionID:  req.RevisionID,
 		OutputFiles: make(map[string]string),
 		Metrics:     metrics,
 	}
 
+	ctx, release, err := o.cancellations.register(ctx, req.ProjectID)
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
+	defer release()
+
+	tracker := o.newTracker(req.ProjectID)
+	fail := func(err error) (*GenerationResult, error) {
+		if cause := context.Cause(ctx); cause != nil {
+			// Cancelled mid-stage: drop half-written outputs. The job status
+			// belongs to whoever cancelled it (see JobService.CancelGeneration)
+			err = fmt.Errorf("%w: %v", ErrGenerationCancelled, err)
+			o.removeOutputs(req.ProjectID)
+			result.OutputFiles = make(map[string]string)
+			tracker.cancel(ctx)
+		} else {
+			tracker.fail(ctx, err)
+		}
+		result.Error = err
+		return result, err
+	}
+
 	// STEP 1: Load project
+	tracker.stage(ctx, StageLoad, "Loading project")
 	project, err := o.projectRepo.GetByID(ctx, req.ProjectID)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to load project: %w", err)
-		return result, result.Error
+		return fail(fmt.Errorf("failed to load project: %w", err))
 	}
 
-	// STEP 2: Read and validate content
-	content, err := o.readContent(req.ContentPath)
-	if err != nil {
-		result.Error = fmt.Errorf("failed to read content: %w", err)
-		return result, result.Error
+	// STEP 2: Read and validate content (a single file, or a bundle of
+	// chapters and images as a ZIP or folder)
+	var content string
+	if o.isBundleLocation(req.ContentPath) {
+		workDir, err := os.MkdirTemp("", "typecraft-bundle-*")
+		if err != nil {
+			return fail(fmt.Errorf("failed to create bundle dir: %w", err))
+		}
+		defer os.RemoveAll(workDir)
+
+		var warnings []string
+		bundleReq := *req
+		content, bundleReq.Assets, warnings, err = o.readBundle(ctx, req.ContentPath, workDir)
+		if err != nil {
+			return fail(fmt.Errorf("failed to read bundle: %w", err))
+		}
+		req = &bundleReq
+		result.Warnings = append(result.Warnings, warnings...)
+	} else {
+		content, err = o.readStoredContent(ctx, req.ContentPath)
+		if err != nil {
+			return fail(fmt.Errorf("failed to read content: %w", err))
+		}
 	}
 
 	// STEP 3: AI Content Analysis
+	tracker.stage(ctx, StageAnalyze, "Analyzing content")
 	analysisStart := time.Now()
 	analysis, err := o.analysisClient.AnalyzeContent(ctx, content)
 	if err != nil {
-		result.Error = fmt.Errorf("content analysis failed: %w", err)
-		return result, result.Error
+		return fail(fmt.Errorf("content analysis failed: %w", err))
 	}
 	result.Analysis = analysis
 	metrics.ContentAnalysisMs = time.Since(analysisStart).Milliseconds()
 
 	// STEP 4: Design Generation
+	tracker.stage(ctx, StageDesign, "Generating design")
 	designStart := time.Now()
 	designReq := o.buildDesignRequest(project, analysis, req.CustomDesign)
 	designResult, err := o.designService.GenerateDesign(ctx, designReq)
 	if err != nil {
-		result.Error = fmt.Errorf("design generation failed: %w", err)
-		return result, result.Error
+		return fail(fmt.Errorf("design generation failed: %w", err))
 	}
 	result.DesignMetadata = designResult
 	metrics.DesignGenerationMs = time.Since(designStart).Milliseconds()
 
+	// STEP 4b: Image analysis (bundles only)
+	if req.Assets != nil {
+		tracker.stage(ctx, StageImages, "Checking images")
+		if err := o.checkImages(ctx, req, project, designResult, result, tracker); err != nil {
+			return fail(fmt.Errorf("image check failed: %w", err))
+		}
+	}
+
 	// STEP 5: Pipeline Selection
+	tracker.stage(ctx, StageSelect, "Selecting pipeline")
 	selectionStart := time.Now()
 	selectedPipeline := o.selectPipeline(analysis, req.OverridePipeline)
 	result.Pipeline = selectedPipeline
@@ -141,24 +273,29 @@ func (o *BookOrchestrator) Generate(ctx context.Context, req *GenerationRequest)
 
 	// STEP 6: Rendering
 	renderStart := time.Now()
-	if err := o.renderOutputs(ctx, req, content, designResult, selectedPipeline, result); err != nil {
-		result.Error = fmt.Errorf("rendering failed: %w", err)
-		return result, result.Error
+	if err := o.renderOutputs(ctx, req, project, content, designResult, selectedPipeline, result, tracker); err != nil {
+		return fail(fmt.Errorf("rendering failed: %w", err))
 	}
 	metrics.RenderingMs = time.Since(renderStart).Milliseconds()
 
 	// STEP 7: Validation
+	tracker.stage(ctx, StageValidate, "Validating outputs")
 	validationStart := time.Now()
-	if err := o.validateOutputs(result); err != nil {
-		result.Error = fmt.Errorf("validation failed: %w", err)
-		return result, result.Error
+	if err := o.validateOutputs(ctx, result, tracker); err != nil {
+		return fail(fmt.Errorf("validation failed: %w", err))
 	}
 	metrics.ValidationMs = time.Since(validationStart).Milliseconds()
 
+	// STEP 8: Storage
+	if err := o.storeOutputs(ctx, result, req.JobID); err != nil {
+		return fail(err)
+	}
+
 	// Finalize metrics
 	metrics.EndTime = time.Now()
 	metrics.Duration = metrics.EndTime.Sub(metrics.StartTime)
 	result.Success = true
+	tracker.complete(ctx, result)
 
 	return result, nil
 }
@@ -178,6 +315,158 @@ func (o *BookOrchestrator) readContent(path string) (string, error) {
 	return content, nil
 }
 
+// readStoredContent reads content from a storage URL, or from a local path
+// when the location is outside the storage
+func (o *BookOrchestrator) readStoredContent(ctx context.Context, location string) (string, error) {
+	if o.storage == nil {
+		return o.readContent(location)
+	}
+	key, ok := o.storage.Key(location)
+	if !ok {
+		return o.readContent(location)
+	}
+
+	r, _, err := o.storage.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("content file is empty")
+	}
+	return string(data), nil
+}
+
+// isBundleLocation reports whether content is a bundle: a ZIP, in storage or
+// on disk, or a local folder
+func (o *BookOrchestrator) isBundleLocation(location string) bool {
+	if isBundle(location) {
+		return true
+	}
+	info, err := os.Stat(location)
+	return err == nil && info.IsDir()
+}
+
+// readBundle joins the chapters of a bundle into one manuscript and returns
+// its images. ZIPs are unpacked (after downloading from storage) into workDir.
+func (o *BookOrchestrator) readBundle(ctx context.Context, location, workDir string) (string, *BookAssets, []string, error) {
+	var bundle *manuscriptBundle
+	var err error
+
+	if info, statErr := os.Stat(location); statErr == nil && info.IsDir() {
+		bundle, err = loadBundleDir(location)
+	} else {
+		archive := location
+		if o.storage != nil {
+			if key, ok := o.storage.Key(location); ok {
+				archive = filepath.Join(workDir, "bundle.zip")
+				if err := storage.Download(ctx, o.storage, key, archive); err != nil {
+					return "", nil, nil, err
+				}
+			}
+		}
+		bundle, err = extractBundle(archive, filepath.Join(workDir, "bundle"))
+	}
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	content, assets, warnings, err := bundle.assemble(ctx)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", nil, nil, fmt.Errorf("content file is empty")
+	}
+	return content, assets, warnings, nil
+}
+
+// storeOutputs uploads the validated outputs of a generation to
+// projects/<id>/output/<generation>/book.<format>, records them as artifacts
+// and sets their URLs. Web variants of the images go to web/<image> next to
+// them. The generation is the job that ran it, if any.
+// Without a storage the local paths are the URLs.
+func (o *BookOrchestrator) storeOutputs(ctx context.Context, result *GenerationResult, generationID string) error {
+	result.OutputURLs = make(map[string]string, len(result.OutputFiles))
+	if o.storage == nil {
+		for format, path := range result.OutputFiles {
+			result.OutputURLs[format] = path
+		}
+		for i := range result.Images {
+			result.Images[i].WebURL = result.Images[i].webVariant
+		}
+		return nil
+	}
+
+	if generationID == "" {
+		generationID = uuid.New().String()
+	}
+	for i := range result.Images {
+		image := &result.Images[i]
+		if image.webVariant == "" {
+			continue
+		}
+		key := storage.ProjectKey(result.ProjectID, "output", generationID, "web", image.Name)
+		if _, err := storage.PutFile(ctx, o.storage, key, image.webVariant); err != nil {
+			return fmt.Errorf("failed to store web variant of %s: %w", image.Name, err)
+		}
+		image.WebURL = o.storage.URL(key)
+	}
+	for format, path := range result.OutputFiles {
+		key := storage.ProjectKey(result.ProjectID, "output", generationID, "book."+format)
+		artifact, err := o.storeOutput(ctx, key, path)
+		if err != nil {
+			return fmt.Errorf("failed to store %s output: %w", format, err)
+		}
+		result.OutputURLs[format] = o.storage.URL(key)
+
+		if o.artifacts == nil {
+			continue
+		}
+		artifact.ProjectID = result.ProjectID
+		artifact.JobID = generationID
+		artifact.RevisionID = result.RevisionID
+		artifact.Format = format
+		if err := o.artifacts.Create(artifact); err != nil {
+			return fmt.Errorf("failed to record %s artifact: %w", format, err)
+		}
+	}
+	return nil
+}
+
+// storeOutput uploads one output, computing its checksum on the way
+func (o *BookOrchestrator) storeOutput(ctx context.Context, key, path string) (*domain.Artifact, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	obj, err := o.storage.Put(ctx, key, io.TeeReader(f, hash), info.Size(), storage.ContentType(path))
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Artifact{
+		ID:          uuid.New().String(),
+		Key:         obj.Key,
+		Size:        obj.Size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		ContentType: obj.ContentType,
+	}, nil
+}
+
 // buildDesignRequest creates design generation request from project data
 func (o *BookOrchestrator) buildDesignRequest(
 	project *domain.Project,
@@ -233,22 +522,32 @@ func (o *BookOrchestrator) selectPipeline(analysis *domain.Analysis, override st
 func (o *BookOrchestrator) renderOutputs(
 	ctx context.Context,
 	req *GenerationRequest,
+	project *domain.Project,
 	content string,
 	design *design.DesignResult,
 	selectedPipeline string,
 	result *GenerationResult,
+	tracker *progressTracker,
 ) error {
-	for _, format := range req.OutputFormats {
+	// Every renderer (PDF, ePub and their tables of contents) shares one structure
+	sections := pipeline.SplitSections(content)
+
+	for i, format := range req.OutputFormats {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		tracker.render(ctx, i, len(req.OutputFormats), format)
+
 		switch format {
 		case "pdf":
-			pdfPath, err := o.renderPDF(ctx, content, design, selectedPipeline, req.ProjectID)
+			pdfPath, err := o.renderPDF(ctx, project, sections, design, req.Assets, selectedPipeline, result)
 			if err != nil {
 				return fmt.Errorf("PDF rendering failed: %w", err)
 			}
 			result.OutputFiles["pdf"] = pdfPath
 
 		case "epub":
-			epubPath, err := o.renderEPUB(ctx, content, design, req.ProjectID)
+			epubPath, err := o.renderEPUB(ctx, project, sections, design, req.Assets)
 			if err != nil {
 				return fmt.Errorf("ePub rendering failed: %w", err)
 			}
@@ -265,90 +564,292 @@ func (o *BookOrchestrator) renderOutputs(
 // renderPDF generates PDF using the selected pipeline
 func (o *BookOrchestrator) renderPDF(
 	ctx context.Context,
-	content string,
+	project *domain.Project,
+	sections []pipeline.BookSection,
 	design *design.DesignResult,
+	assets *BookAssets,
 	pipelineType string,
-	projectID uint,
+	result *GenerationResult,
 ) (string, error) {
-	outputPath := filepath.Join(o.outputDir, fmt.Sprintf("project_%d.pdf", projectID))
+	outputPath := filepath.Join(o.outputDir, fmt.Sprintf("project_%d.pdf", project.ID))
 
 	switch pipelineType {
 	case "latex":
-		return o.renderPDFLaTeX(content, design, outputPath)
+		return o.renderPDFLaTeX(ctx, project, sections, design, assets, outputPath, result)
 	case "html":
-		return o.renderPDFHTML(content, design, outputPath)
+		return o.renderPDFHTML(ctx, project, sections, design, assets, outputPath, result)
 	default:
 		return "", fmt.Errorf("unknown pipeline type: %s", pipelineType)
 	}
 }
 
-// renderPDFLaTeX generates PDF via LaTeX pipeline
-func (o *BookOrchestrator) renderPDFLaTeX(content string, design *design.DesignResult, outputPath string) (string, error) {
-	// Simplified stub - demonstrates integration point
-	// In real implementation, would call latex.Compiler
-	
-	// Create a placeholder PDF file
-	placeholder := []byte("%PDF-1.4\n%Placeholder PDF generated by orchestrator\n%%EOF")
-	if err := os.WriteFile(outputPath, placeholder, 0644); err != nil {
-		return "", fmt.Errorf("failed to write PDF: %w", err)
+// renderPDFLaTeX generates PDF via LaTeX pipeline.
+// The manuscript is converted to LaTeX by Pandoc, wrapped in a book document built
+// from the design (fontspec, geometry, xcolor) and compiled with lualatex.
+// Bundle images are copied next to the document so \includegraphics finds them.
+func (o *BookOrchestrator) renderPDFLaTeX(
+	ctx context.Context,
+	project *domain.Project,
+	sections []pipeline.BookSection,
+	design *design.DesignResult,
+	assets *BookAssets,
+	outputPath string,
+	result *GenerationResult,
+) (string, error) {
+	compiler, err := latex.NewCompiler(
+		latex.WithEngine("lualatex"),
+		latex.WithTimeout(latexCompileTimeout),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to create LaTeX compiler: %w", err)
+	}
+	defer compiler.Cleanup()
+
+	if err := copyAssets(assets, compiler.GetWorkDir()); err != nil {
+		return "", err
+	}
+	result.Warnings = append(result.Warnings, latexImageWarnings(assets)...)
+
+	body, err := o.markdownToLaTeX(ctx, latexStructuredMarkdown(sections), compiler.GetWorkDir())
+	if err != nil {
+		return "", err
+	}
+
+	document := buildLaTeXDocument(project, body, design)
+
+	compileResult, err := compiler.CompileContext(ctx, document.Generate())
+	if compileResult != nil {
+		result.CompileErrors = append(result.CompileErrors, compileResult.Errors...)
+		result.Warnings = append(result.Warnings, compileResult.Warnings...)
+	}
+	if err != nil {
+		if compileResult != nil && len(compileResult.Errors) > 0 {
+			first := compileResult.Errors[0]
+			return "", fmt.Errorf("%w (line %d: %s)", err, first.Line, first.Message)
+		}
+		return "", err
+	}
+
+	if err := compiler.CopyPDF(compileResult, outputPath); err != nil {
+		return "", err
+	}
+
+	if result.Metrics != nil {
+		result.Metrics.TotalPages = compileResult.Pages
 	}
 
 	return outputPath, nil
 }
 
-// renderPDFHTML generates PDF via HTML/CSS + Paged.js pipeline
-func (o *BookOrchestrator) renderPDFHTML(content string, design *design.DesignResult, outputPath string) (string, error) {
-	// Simplified stub - demonstrates integration point
-	// In real implementation, would call pipeline.HTMLGenerator and PDFGenerator
-	
-	// Create a placeholder PDF file
-	placeholder := []byte("%PDF-1.4\n%Placeholder PDF generated via HTML pipeline\n%%EOF")
-	if err := os.WriteFile(outputPath, placeholder, 0644); err != nil {
-		return "", fmt.Errorf("failed to write PDF: %w", err)
+// markdownToLaTeX converts the structured manuscript to a LaTeX fragment with
+// Pandoc. Sectioning comes from latexStructuredMarkdown; the preamble from
+// buildLaTeXDocument.
+func (o *BookOrchestrator) markdownToLaTeX(ctx context.Context, content string, workDir string) (string, error) {
+	pandoc, err := converter.NewPandocConverter()
+	if err != nil {
+		return "", err
+	}
+
+	inputPath := filepath.Join(workDir, "manuscript.md")
+	if err := os.WriteFile(inputPath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write manuscript: %w", err)
+	}
+
+	outputPath := filepath.Join(workDir, "body.tex")
+	err = pandoc.ConvertContext(ctx, converter.ConvertRequest{
+		InputFile:  inputPath,
+		OutputFile: outputPath,
+		FromFormat: "markdown",
+		ToFormat:   "latex",
+		Options: []string{
+			"--top-level-division=chapter",
+			"--no-highlight",
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to convert manuscript to LaTeX: %w", err)
+	}
+
+	body, err := os.ReadFile(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read converted LaTeX: %w", err)
+	}
+
+	return string(body), nil
+}
+
+// renderPDFHTML generates PDF via HTML/CSS + Paged.js pipeline.
+// The manuscript is converted to HTML by Pandoc, styled with CSS generated from
+// the design (Van de Graaf canon, fonts, colors) and paginated by Paged.js.
+// Bundle images are copied next to the page, where its relative references point.
+func (o *BookOrchestrator) renderPDFHTML(
+	ctx context.Context,
+	project *domain.Project,
+	sections []pipeline.BookSection,
+	design *design.DesignResult,
+	assets *BookAssets,
+	outputPath string,
+	result *GenerationResult,
+) (string, error) {
+	pandoc, err := htmlpipeline.NewPandocConverter()
+	if err != nil {
+		return "", err
+	}
+
+	body, err := pandoc.ConvertContext(ctx, htmlStructuredMarkdown(project, sections), htmlpipeline.ConvertOptions{
+		InputFormat:  "markdown+smart",
+		OutputFormat: "html5",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to convert manuscript to HTML: %w", err)
+	}
+
+	css, err := buildBookCSS(project, design)
+	if err != nil {
+		return "", err
+	}
+
+	book := paged.DefaultBookTemplate()
+	book.Title = project.Title
+	book.Author = project.Author
+	book.ISBN = project.ISBN
+	book.Content = template.HTML(body)
+
+	document, err := book.Render()
+	if err != nil {
+		return "", err
+	}
+
+	workDir, err := os.MkdirTemp("", "typecraft-paged-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create work dir: %w", err)
+	}
+	if err := copyAssets(assets, workDir); err != nil {
+		os.RemoveAll(workDir)
+		return "", err
+	}
+
+	engine, err := paged.NewEngine(paged.Config{TempDir: workDir})
+	if err != nil {
+		os.RemoveAll(workDir)
+		return "", fmt.Errorf("failed to create Paged.js engine: %w", err)
+	}
+	defer engine.Cleanup()
+
+	output, err := engine.ConvertToPDF(ctx, document, paged.PageOptions{
+		Timeout:   int(pagedRenderTimeout.Milliseconds()),
+		CustomCSS: css,
+	})
+	if err != nil {
+		return "", err
+	}
+	result.Warnings = append(result.Warnings, output.Warnings...)
+
+	if err := copyFile(output.PDFPath, outputPath); err != nil {
+		return "", fmt.Errorf("failed to copy PDF: %w", err)
+	}
+
+	if result.Metrics != nil {
+		result.Metrics.TotalPages = output.PageCount
 	}
 
 	return outputPath, nil
 }
 
-// renderEPUB generates ePub output
+// copyFile copies a rendered artifact out of a renderer's temp directory
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// renderEPUB generates an EPUB 3 book.
+// Each chapter is converted to XHTML by Pandoc; metadata comes from the project
+// and the stylesheet from the design. Bundle images are packaged, the cover
+// as the book's cover image.
 func (o *BookOrchestrator) renderEPUB(
 	ctx context.Context,
-	content string,
+	project *domain.Project,
+	sections []pipeline.BookSection,
 	design *design.DesignResult,
-	projectID uint,
+	assets *BookAssets,
 ) (string, error) {
-	outputPath := filepath.Join(o.outputDir, fmt.Sprintf("project_%d.epub", projectID))
+	outputPath := filepath.Join(o.outputDir, fmt.Sprintf("project_%d.epub", project.ID))
 
-	// Simplified stub - demonstrates integration point
-	// In real implementation, would call epub.Generator
-	
-	// Create a placeholder ZIP file (ePub is a ZIP)
-	placeholder := []byte("PK\x03\x04") // ZIP magic bytes
-	if err := os.WriteFile(outputPath, placeholder, 0644); err != nil {
+	pandoc, err := htmlpipeline.NewPandocConverter()
+	if err != nil {
+		return "", err
+	}
+
+	book := epub.NewEPub(epub.EPub3)
+	book.Metadata = epubMetadata(project)
+	book.CSS = buildEPUBCSS(design)
+
+	images := epubImages(assets)
+	for _, image := range images.files {
+		book.AddImage(image)
+	}
+	if images.cover != "" {
+		book.Metadata.CoverImage = images.cover
+	}
+
+	for i, section := range sections {
+		var html string
+		if strings.TrimSpace(section.Content) != "" {
+			html, err = pandoc.ConvertContext(ctx, section.Content, htmlpipeline.ConvertOptions{
+				InputFormat:  "markdown+smart",
+				OutputFormat: "html5",
+			})
+			if err != nil {
+				return "", fmt.Errorf("failed to convert chapter %d to HTML: %w", i+1, err)
+			}
+		}
+
+		book.AddChapter(epubChapter(project, section, images.rewrite(html)))
+	}
+
+	if err := book.Write(outputPath); err != nil {
 		return "", fmt.Errorf("failed to write ePub: %w", err)
 	}
 
 	return outputPath, nil
 }
 
-// splitIntoChapters splits content into chapters (simplified version)
-func (o *BookOrchestrator) splitIntoChapters(content string) []string {
-	// For now, return as single chapter
-	// TODO: Implement smart chapter detection
-	return []string{content}
+// newTracker starts tracking a generation, publishing to the progress store
+// and, when configured, as project events
+func (o *BookOrchestrator) newTracker(projectID uint) *progressTracker {
+	tracker := newProgressTracker(o.progress, projectID)
+	tracker.events = o.events
+	return tracker
 }
 
-// validateOutputs performs final validation on all generated files
-func (o *BookOrchestrator) validateOutputs(result *GenerationResult) error {
+// validateOutputs performs final validation on all generated files.
+// Every issue found is reported through the tracker as it is found.
+func (o *BookOrchestrator) validateOutputs(ctx context.Context, result *GenerationResult, tracker *progressTracker) error {
 	for format, path := range result.OutputFiles {
 		// Check file exists
 		info, err := os.Stat(path)
 		if err != nil {
+			tracker.issue(ctx, format, "error", "missing", err.Error())
 			return fmt.Errorf("%s file not found: %w", format, err)
 		}
 
 		// Check file size
 		if info.Size() == 0 {
+			tracker.issue(ctx, format, "error", "empty", "file is empty")
 			return fmt.Errorf("%s file is empty", format)
 		}
 
@@ -361,10 +862,11 @@ func (o *BookOrchestrator) validateOutputs(result *GenerationResult) error {
 		switch format {
 		case "pdf":
 			if err := o.validatePDF(path); err != nil {
+				tracker.issue(ctx, format, "error", "invalid-pdf", err.Error())
 				return fmt.Errorf("PDF validation failed: %w", err)
 			}
 		case "epub":
-			if err := o.validateEPUBFile(path); err != nil {
+			if err := o.validateEPUBFile(ctx, path, result, tracker); err != nil {
 				return fmt.Errorf("ePub validation failed: %w", err)
 			}
 		}
@@ -394,50 +896,44 @@ func (o *BookOrchestrator) validatePDF(path string) error {
 	return nil
 }
 
-// validateEPUBFile validates ePub file integrity
-func (o *BookOrchestrator) validateEPUBFile(path string) error {
-	// Basic validation: check ZIP magic bytes
-	data := make([]byte, 4)
-	f, err := os.Open(path)
+// validateEPUBFile validates the ePub container, package document and
+// navigation; any error-level issue fails the generation
+func (o *BookOrchestrator) validateEPUBFile(ctx context.Context, path string, result *GenerationResult, tracker *progressTracker) error {
+	validation, err := epub.NewValidator().ValidateFile(path)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	if _, err := f.Read(data); err != nil {
-		return err
+	for _, issue := range validation.Issues {
+		tracker.issue(ctx, "epub", strings.ToLower(string(issue.Level)), issue.Code, issue.Message)
 	}
 
-	// Check for ZIP signature (PK)
-	if string(data[0:2]) != "PK" {
-		return fmt.Errorf("invalid ePub/ZIP header")
+	for _, issue := range validation.GetIssuesByLevel(epub.LevelWarning) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("epub %s: %s", issue.Code, issue.Message))
+	}
+
+	if !validation.Valid {
+		errs := validation.GetIssuesByLevel(epub.LevelError)
+		messages := make([]string, 0, len(errs))
+		for _, issue := range errs {
+			messages = append(messages, fmt.Sprintf("%s: %s", issue.Code, issue.Message))
+		}
+		return fmt.Errorf("invalid ePub: %s", strings.Join(messages, "; "))
 	}
 
 	return nil
 }
 
-// GetProgress returns the current progress of a generation job
+// GetProgress returns the latest progress published for a project's generation.
+// ErrProgressNotFound means no generation was tracked (or it expired).
 func (o *BookOrchestrator) GetProgress(ctx context.Context, projectID uint) (*GenerationProgress, error) {
-	// TODO: Implement progress tracking with Redis
-	return &GenerationProgress{
-		ProjectID:    projectID,
-		Status:       "processing",
-		CurrentStage: "rendering",
-		Progress:     75,
-	}, nil
-}
-
-// GenerationProgress tracks the current state of generation
-type GenerationProgress struct {
-	ProjectID    uint
-	Status       string
-	CurrentStage string
-	Progress     int
-	Message      string
+	return o.progress.Get(ctx, projectID)
 }
 
-// CancelGeneration cancels an in-progress generation
-func (o *BookOrchestrator) CancelGeneration(ctx context.Context, projectID uint) error {
-	// TODO: Implement cancellation logic
-	return nil
+// removeOutputs deletes the (possibly partial) output files of a project
+func (o *BookOrchestrator) removeOutputs(projectID uint) {
+	for _, ext := range []string{"pdf", "epub"} {
+		os.Remove(filepath.Join(o.outputDir, fmt.Sprintf("project_%d.%s", projectID, ext)))
+	}
+	os.RemoveAll(o.imagesDir(projectID))
 }
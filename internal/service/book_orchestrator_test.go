@@ -3,10 +3,12 @@ package service
 import (
 	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
 	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/pipeline/html/paged"
 )
 
 // mockAnalysisClient implements AnalysisClient for testing
@@ -65,6 +67,27 @@ func (m *mockProjectRepository) List(ctx context.Context) ([]*domain.Project, er
 
 // Helper functions for tests
 
+// requireTools skips the test when an external renderer is not installed
+func requireTools(t *testing.T, tools ...string) {
+	t.Helper()
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found in PATH", tool)
+		}
+	}
+}
+
+// requirePagedJS skips tests that need pagedjs installed in node_modules
+func requirePagedJS(t *testing.T) {
+	t.Helper()
+	requireTools(t, "pandoc", "npx")
+	engine, err := paged.NewEngine(paged.Config{TempDir: t.TempDir()})
+	if err != nil {
+		t.Skipf("Paged.js not available: %v", err)
+	}
+	engine.Cleanup()
+}
+
 func setupTestEnvironment(t *testing.T) (string, func()) {
 	tmpDir, err := os.MkdirTemp("", "typecraft-test-*")
 	if err != nil {
@@ -122,6 +145,8 @@ A table with data.
 // Test Cases
 
 func TestBookOrchestrator_Generate_BasicFlow(t *testing.T) {
+	requireTools(t, "pandoc", "lualatex")
+
 	tmpDir, cleanup := setupTestEnvironment(t)
 	defer cleanup()
 
@@ -182,6 +207,8 @@ func TestBookOrchestrator_Generate_BasicFlow(t *testing.T) {
 }
 
 func TestBookOrchestrator_PipelineSelection_LaTeX(t *testing.T) {
+	requireTools(t, "pandoc", "lualatex")
+
 	tmpDir, cleanup := setupTestEnvironment(t)
 	defer cleanup()
 
@@ -228,6 +255,8 @@ func TestBookOrchestrator_PipelineSelection_LaTeX(t *testing.T) {
 }
 
 func TestBookOrchestrator_PipelineSelection_HTML(t *testing.T) {
+	requirePagedJS(t)
+
 	tmpDir, cleanup := setupTestEnvironment(t)
 	defer cleanup()
 
@@ -273,6 +302,8 @@ func TestBookOrchestrator_PipelineSelection_HTML(t *testing.T) {
 }
 
 func TestBookOrchestrator_MultiFormat(t *testing.T) {
+	requireTools(t, "pandoc", "lualatex")
+
 	tmpDir, cleanup := setupTestEnvironment(t)
 	defer cleanup()
 
@@ -322,6 +353,8 @@ func TestBookOrchestrator_MultiFormat(t *testing.T) {
 }
 
 func TestBookOrchestrator_CustomDesign(t *testing.T) {
+	requireTools(t, "pandoc", "lualatex")
+
 	tmpDir, cleanup := setupTestEnvironment(t)
 	defer cleanup()
 
@@ -436,6 +469,12 @@ func TestBookOrchestrator_EmptyContent(t *testing.T) {
 // Benchmark tests
 
 func BenchmarkOrchestrator_Generate(b *testing.B) {
+	for _, tool := range []string{"pandoc", "lualatex"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			b.Skipf("%s not found in PATH", tool)
+		}
+	}
+
 	tmpDir, cleanup := setupTestEnvironment(&testing.T{})
 	defer cleanup()
 
@@ -0,0 +1,605 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// bundleManifestFile is the optional manifest at the root of a bundle
+const bundleManifestFile = "manifest.json"
+
+// Limits on what a bundle may unpack to, so a small ZIP cannot fill the disk
+const (
+	maxBundleFiles = 5000
+	maxBundleSize  = 1 << 30
+)
+
+// ErrInvalidBundle means a manuscript bundle is malformed or its manifest
+// points at files the bundle does not hold
+var ErrInvalidBundle = errors.New("invalid manuscript bundle")
+
+// imageTypes are the images a bundle may carry as assets
+var imageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+// BundleManifest sets the chapter order, the image assets and the cover of a
+// bundle, with paths relative to the bundle root. Without a manifest the
+// chapters are the manuscript files in natural name order (README files
+// aside), the assets every image and the cover an image named "cover".
+type BundleManifest struct {
+	Chapters []string `json:"chapters"`
+	Assets   []string `json:"assets,omitempty"` // image files or directories
+	Cover    string   `json:"cover,omitempty"`
+}
+
+// BookAssets are the images of a bundle handed to the renderers. Manuscript
+// image references point at Files, relative to the bundle root.
+type BookAssets struct {
+	Dir   string   `json:"-"`     // local directory holding the files
+	Files []string `json:"files"` // slash-separated, relative to Dir
+	Cover string   `json:"cover,omitempty"`
+
+	// EPUB maps an asset to its downscaled ePub variant, for images too large
+	// for e-readers (set by the image check)
+	EPUB map[string]string `json:"-"`
+}
+
+// Path returns the local path of an asset
+func (a *BookAssets) Path(name string) string {
+	return filepath.Join(a.Dir, filepath.FromSlash(name))
+}
+
+// manuscriptBundle is a bundle unpacked on local disk
+type manuscriptBundle struct {
+	dir      string // bundle root
+	manifest *BundleManifest
+}
+
+// isBundle reports whether a manuscript file name is a bundle archive
+func isBundle(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".zip")
+}
+
+// isImage reports whether a file name is an image asset
+func isImage(name string) bool {
+	_, ok := imageTypes[strings.ToLower(path.Ext(name))]
+	return ok
+}
+
+// verifyBundle checks a bundle archive and its manifest without unpacking it
+func verifyBundle(archive *zip.Reader) error {
+	_, _, err := zipBundle(archive)
+	return err
+}
+
+// extractBundle unpacks a bundle archive into dir
+func extractBundle(zipPath, dir string) (*manuscriptBundle, error) {
+	rc, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	defer rc.Close()
+
+	entries, manifest, err := zipBundle(&rc.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, f := range entries {
+		if err := extractBundleFile(f, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return nil, err
+		}
+	}
+
+	return &manuscriptBundle{dir: dir, manifest: manifest}, nil
+}
+
+// extractBundleFile writes one archive entry, holding it to its declared size
+func extractBundleFile(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	r, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidBundle, f.Name, err)
+	}
+	defer r.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, io.LimitReader(r, int64(f.UncompressedSize64)+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+	}
+	if n > int64(f.UncompressedSize64) {
+		return fmt.Errorf("%w: %s is larger than declared", ErrInvalidBundle, f.Name)
+	}
+	return nil
+}
+
+// zipBundle indexes the files of a bundle archive by bundle path and
+// resolves its manifest
+func zipBundle(archive *zip.Reader) (map[string]*zip.File, *BundleManifest, error) {
+	byName := make(map[string]*zip.File)
+	var names []string
+	var total uint64
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if f.Mode()&fs.ModeSymlink != 0 {
+			return nil, nil, fmt.Errorf("%w: %s is a symbolic link", ErrInvalidBundle, f.Name)
+		}
+		name, err := cleanBundlePath(f.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		total += f.UncompressedSize64
+		if len(names) >= maxBundleFiles || total > maxBundleSize {
+			return nil, nil, fmt.Errorf("%w: more than %d files or %d MB", ErrInvalidBundle, maxBundleFiles, maxBundleSize>>20)
+		}
+		byName[name] = f
+		names = append(names, name)
+	}
+
+	root, files := bundleIndex(names)
+	entries := make(map[string]*zip.File, len(files))
+	for _, name := range files {
+		entries[name] = byName[root+name]
+	}
+
+	var manifestData []byte
+	if f, ok := entries[bundleManifestFile]; ok {
+		r, err := f.Open()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: %v", ErrInvalidBundle, bundleManifestFile, err)
+		}
+		manifestData, err = io.ReadAll(io.LimitReader(r, 1<<20))
+		r.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: %v", ErrInvalidBundle, bundleManifestFile, err)
+		}
+	}
+
+	manifest, err := resolveBundle(files, manifestData)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, manifest, nil
+}
+
+// loadBundleDir reads a bundle from a local folder
+func loadBundleDir(dir string) (*manuscriptBundle, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	root, files := bundleIndex(names)
+	bundleDir := filepath.Join(dir, filepath.FromSlash(root))
+
+	manifestData, err := os.ReadFile(filepath.Join(bundleDir, bundleManifestFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	manifest, err := resolveBundle(files, manifestData)
+	if err != nil {
+		return nil, err
+	}
+	return &manuscriptBundle{dir: bundleDir, manifest: manifest}, nil
+}
+
+// bundleIndex drops system files (__MACOSX, dotfiles) and finds the bundle
+// root: a ZIP of a folder holds everything under that folder's name
+func bundleIndex(names []string) (string, []string) {
+	var files []string
+	for _, name := range names {
+		hidden := false
+		for _, elem := range strings.Split(name, "/") {
+			if strings.HasPrefix(elem, ".") || elem == "__MACOSX" {
+				hidden = true
+				break
+			}
+		}
+		if !hidden {
+			files = append(files, name)
+		}
+	}
+
+	root := ""
+	for i, name := range files {
+		dir, _, nested := strings.Cut(name, "/")
+		if !nested || (i > 0 && dir+"/" != root) {
+			root = ""
+			break
+		}
+		root = dir + "/"
+	}
+	for i := range files {
+		files[i] = strings.TrimPrefix(files[i], root)
+	}
+	return root, files
+}
+
+// resolveBundle reads the manifest of a bundle holding files, or infers one
+// when manifestData is nil, and checks that it points at files of the bundle
+func resolveBundle(files []string, manifestData []byte) (*BundleManifest, error) {
+	exists := make(map[string]bool, len(files))
+	for _, name := range files {
+		exists[name] = true
+	}
+
+	manifest := &BundleManifest{}
+	if manifestData != nil {
+		if err := json.Unmarshal(manifestData, manifest); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidBundle, bundleManifestFile, err)
+		}
+	} else {
+		for _, name := range files {
+			base := strings.ToLower(path.Base(name))
+			if _, ok := manuscriptReader(name); ok && !strings.HasPrefix(base, "readme.") {
+				manifest.Chapters = append(manifest.Chapters, name)
+			}
+			if isImage(name) && strings.TrimSuffix(base, path.Ext(base)) == "cover" && manifest.Cover == "" {
+				manifest.Cover = name
+			}
+		}
+		sort.Slice(manifest.Chapters, func(i, j int) bool {
+			return naturalLess(manifest.Chapters[i], manifest.Chapters[j])
+		})
+	}
+
+	if len(manifest.Chapters) == 0 {
+		return nil, fmt.Errorf("%w: no chapters", ErrInvalidBundle)
+	}
+	for i, chapter := range manifest.Chapters {
+		name, err := cleanBundlePath(chapter)
+		if err != nil {
+			return nil, err
+		}
+		if !exists[name] {
+			return nil, fmt.Errorf("%w: chapter %s not found", ErrInvalidBundle, chapter)
+		}
+		if _, ok := manuscriptReader(name); !ok {
+			return nil, fmt.Errorf("%w: chapter %s: %w", ErrInvalidBundle, chapter, ErrUnsupportedFormat)
+		}
+		manifest.Chapters[i] = name
+	}
+
+	if manifest.Cover != "" {
+		name, err := cleanBundlePath(manifest.Cover)
+		if err != nil {
+			return nil, err
+		}
+		if !exists[name] || !isImage(name) {
+			return nil, fmt.Errorf("%w: cover %s is not an image of the bundle", ErrInvalidBundle, manifest.Cover)
+		}
+		manifest.Cover = name
+	}
+
+	assets, err := expandAssets(manifest.Assets, files, exists)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Cover != "" && !containsString(assets, manifest.Cover) {
+		assets = append(assets, manifest.Cover)
+	}
+	manifest.Assets = assets
+
+	return manifest, nil
+}
+
+// expandAssets resolves asset entries (files or directories) to image files;
+// no entries means every image of the bundle
+func expandAssets(entries, files []string, exists map[string]bool) ([]string, error) {
+	var assets []string
+	if len(entries) == 0 {
+		for _, name := range files {
+			if isImage(name) {
+				assets = append(assets, name)
+			}
+		}
+		return assets, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		name, err := cleanBundlePath(entry)
+		if err != nil {
+			return nil, err
+		}
+		if exists[name] {
+			if !isImage(name) {
+				return nil, fmt.Errorf("%w: asset %s is not an image", ErrInvalidBundle, entry)
+			}
+			if !seen[name] {
+				seen[name] = true
+				assets = append(assets, name)
+			}
+			continue
+		}
+
+		found := false
+		for _, file := range files {
+			if strings.HasPrefix(file, name+"/") && isImage(file) {
+				found = true
+				if !seen[file] {
+					seen[file] = true
+					assets = append(assets, file)
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: asset %s not found", ErrInvalidBundle, entry)
+		}
+	}
+	return assets, nil
+}
+
+// cleanBundlePath normalizes a path inside a bundle, refusing paths that
+// would escape it
+func cleanBundlePath(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, "\\", "/")
+	cleaned := path.Clean(strings.TrimPrefix(slashed, "./"))
+	if slashed == "" || path.IsAbs(slashed) || filepath.VolumeName(name) != "" ||
+		cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: unsafe path %q", ErrInvalidBundle, name)
+	}
+	return cleaned, nil
+}
+
+// naturalLess orders names with numbers by value: "chapter2" < "chapter10"
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// markdownImageRef matches ![alt](target "title")
+	markdownImageRef = regexp.MustCompile(`(!\[[^\]]*\]\(\s*)(<[^>]*>|[^)\s]+)`)
+	// markdownImageDefinition matches reference definitions: [id]: target
+	markdownImageDefinition = regexp.MustCompile(`(?m)^( {0,3}\[[^\]]+\]:[ \t]*)(<[^>]*>|\S+)`)
+	// htmlImageRef matches <img src="target">
+	htmlImageRef = regexp.MustCompile(`(<img\b[^>]*?\bsrc\s*=\s*)("[^"]*"|'[^']*')`)
+	// markdownTopHeading matches a level 1 heading, ATX or setext
+	markdownTopHeading = regexp.MustCompile(`(?m)^(#[ \t]+\S|\S.*\n=+[ \t]*$)`)
+)
+
+// assemble converts the chapters into one Markdown manuscript, in manifest
+// order. Image references are rewritten relative to the bundle root; images
+// referenced but not listed become assets too, and missing ones are
+// returned as warnings. A chapter without a title is headed by its file name.
+func (b *manuscriptBundle) assemble(ctx context.Context) (string, *BookAssets, []string, error) {
+	assets := &BookAssets{
+		Dir:   b.dir,
+		Files: append([]string(nil), b.manifest.Assets...),
+		Cover: b.manifest.Cover,
+	}
+	listed := make(map[string]bool)
+	for _, name := range assets.Files {
+		listed[name] = true
+	}
+
+	var book strings.Builder
+	var warnings []string
+	for _, chapter := range b.manifest.Chapters {
+		markdown, err := b.chapterMarkdown(ctx, chapter)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		markdown = rewriteImageRefs(markdown, func(ref string) string {
+			name, local, found := b.resolve(path.Dir(chapter), ref)
+			switch {
+			case !local:
+				return ref
+			case !found:
+				warnings = append(warnings, fmt.Sprintf("%s: image %s not found in the bundle", chapter, ref))
+				return ref
+			}
+			if !listed[name] {
+				listed[name] = true
+				assets.Files = append(assets.Files, name)
+			}
+			return name
+		})
+
+		if !markdownTopHeading.MatchString(markdown) {
+			fmt.Fprintf(&book, "# %s\n\n", chapterTitle(chapter))
+		}
+		book.WriteString(strings.TrimSpace(markdown))
+		book.WriteString("\n\n")
+	}
+
+	return book.String(), assets, warnings, nil
+}
+
+// chapterMarkdown converts a chapter to Markdown. Images embedded in Word,
+// ODT or ePub chapters are extracted under media/ in the bundle.
+func (b *manuscriptBundle) chapterMarkdown(ctx context.Context, chapter string) (string, error) {
+	source := filepath.Join(b.dir, filepath.FromSlash(chapter))
+	if reader, _ := manuscriptReader(chapter); reader == "" {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return "", fmt.Errorf("failed to read chapter %s: %w", chapter, err)
+		}
+		return string(data), nil
+	}
+
+	out, err := os.CreateTemp("", "typecraft-chapter-*.md")
+	if err != nil {
+		return "", err
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	media := filepath.Join(b.dir, "media", filepath.FromSlash(strings.TrimSuffix(chapter, path.Ext(chapter))))
+	if err := convertManuscript(ctx, source, out.Name(), "--extract-media="+media); err != nil {
+		return "", fmt.Errorf("chapter %s: %w", chapter, err)
+	}
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// resolve finds the bundle image an image reference of a chapter in dir
+// points at. local is false for URLs, which are left alone.
+func (b *manuscriptBundle) resolve(dir, ref string) (name string, local, found bool) {
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "data:") || strings.Contains(ref, "://") {
+		return "", false, false
+	}
+	if unescaped, err := url.PathUnescape(ref); err == nil {
+		ref = unescaped
+	}
+
+	if filepath.IsAbs(ref) {
+		// Extracted media are referenced by absolute path
+		rel, err := filepath.Rel(b.dir, ref)
+		if err != nil {
+			return "", true, false
+		}
+		name = path.Clean(filepath.ToSlash(rel))
+	} else {
+		name = path.Join(dir, ref)
+	}
+	if name == ".." || strings.HasPrefix(name, "../") || !isImage(name) {
+		return "", true, false
+	}
+
+	info, err := os.Stat(filepath.Join(b.dir, filepath.FromSlash(name)))
+	return name, true, err == nil && info.Mode().IsRegular()
+}
+
+// rewriteImageRefs replaces the image targets of a Markdown document (inline
+// images, image reference definitions and HTML <img> tags)
+func rewriteImageRefs(markdown string, rewrite func(ref string) string) string {
+	markdownTarget := func(target string) string {
+		ref := strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+		ref = rewrite(ref)
+		if strings.ContainsAny(ref, " \t") {
+			return "<" + ref + ">"
+		}
+		return ref
+	}
+
+	markdown = markdownImageRef.ReplaceAllStringFunc(markdown, func(match string) string {
+		m := markdownImageRef.FindStringSubmatch(match)
+		return m[1] + markdownTarget(m[2])
+	})
+	markdown = markdownImageDefinition.ReplaceAllStringFunc(markdown, func(match string) string {
+		m := markdownImageDefinition.FindStringSubmatch(match)
+		if !isImage(strings.Trim(m[2], "<>")) {
+			return match
+		}
+		return m[1] + markdownTarget(m[2])
+	})
+	return htmlImageRef.ReplaceAllStringFunc(markdown, func(match string) string {
+		m := htmlImageRef.FindStringSubmatch(match)
+		quote := m[2][:1]
+		return m[1] + quote + rewrite(m[2][1:len(m[2])-1]) + quote
+	})
+}
+
+// chapterTitle derives a title from a chapter file name:
+// "chapters/01-the-beginning.md" is "the beginning"
+func chapterTitle(chapter string) string {
+	base := path.Base(chapter)
+	base = strings.TrimSuffix(base, path.Ext(base))
+	title := strings.TrimLeft(base, "0123456789")
+	title = strings.TrimLeft(title, " -_.")
+	if title == "" {
+		title = base
+	}
+	return strings.Join(strings.Fields(strings.NewReplacer("-", " ", "_", " ").Replace(title)), " ")
+}
+
+// copyAssets copies the images of a bundle into dir, keeping their paths, so
+// renderers working there resolve the manuscript's references
+func copyAssets(assets *BookAssets, dir string) error {
+	if assets == nil {
+		return nil
+	}
+	for _, name := range assets.Files {
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := copyFile(assets.Path(name), dest); err != nil {
+			return fmt.Errorf("failed to copy image %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+)
+
+// bundleEntry is a file of a test bundle
+type bundleEntry struct {
+	name, content string
+}
+
+// zipBundleData builds a bundle archive
+func zipBundleData(t *testing.T, entries ...bundleEntry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := zw.Create(entry.name)
+		if err != nil {
+			t.Fatalf("zip Create failed: %v", err)
+		}
+		w.Write([]byte(entry.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// writeBundle writes a bundle archive to dir/book.zip
+func writeBundle(t *testing.T, dir string, entries ...bundleEntry) string {
+	path := filepath.Join(dir, "book.zip")
+	if err := os.WriteFile(path, zipBundleData(t, entries...), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return path
+}
+
+func TestResolveBundle_Inferred(t *testing.T) {
+	root, files := bundleIndex([]string{
+		"book/chapter10.md",
+		"book/chapter2.md",
+		"book/chapter1.docx",
+		"book/README.md",
+		"book/images/Cover.JPG",
+		"book/images/fig.png",
+		"book/.DS_Store",
+		"__MACOSX/book/._chapter2.md",
+	})
+	if root != "book/" {
+		t.Errorf("Expected the folder as root, got %q", root)
+	}
+
+	manifest, err := resolveBundle(files, nil)
+	if err != nil {
+		t.Fatalf("resolveBundle failed: %v", err)
+	}
+	if want := []string{"chapter1.docx", "chapter2.md", "chapter10.md"}; !reflect.DeepEqual(manifest.Chapters, want) {
+		t.Errorf("Expected chapters in natural order %v, got %v", want, manifest.Chapters)
+	}
+	if manifest.Cover != "images/Cover.JPG" {
+		t.Errorf("Expected the cover found by name, got %q", manifest.Cover)
+	}
+	if want := []string{"images/Cover.JPG", "images/fig.png"}; !reflect.DeepEqual(manifest.Assets, want) {
+		t.Errorf("Expected every image as asset %v, got %v", want, manifest.Assets)
+	}
+}
+
+func TestResolveBundle_Manifest(t *testing.T) {
+	files := []string{"manifest.json", "text/intro.md", "text/one.md", "art/a.png", "art/deep/b.svg", "notes.pdf"}
+
+	manifest, err := resolveBundle(files, []byte(`{
+		"chapters": ["./text/one.md", "text/intro.md"],
+		"assets": ["art"],
+		"cover": "art/a.png"
+	}`))
+	if err != nil {
+		t.Fatalf("resolveBundle failed: %v", err)
+	}
+	if want := []string{"text/one.md", "text/intro.md"}; !reflect.DeepEqual(manifest.Chapters, want) {
+		t.Errorf("Expected the manifest order %v, got %v", want, manifest.Chapters)
+	}
+	if want := []string{"art/a.png", "art/deep/b.svg"}; !reflect.DeepEqual(manifest.Assets, want) {
+		t.Errorf("Expected the folder expanded %v, got %v", want, manifest.Assets)
+	}
+
+	invalid := map[string]string{
+		"bad json":         `{"chapters": [`,
+		"no chapters":      `{"chapters": []}`,
+		"missing chapter":  `{"chapters": ["text/two.md"]}`,
+		"escaping chapter": `{"chapters": ["../text/one.md"]}`,
+		"absolute chapter": `{"chapters": ["/text/one.md"]}`,
+		"unsupported":      `{"chapters": ["notes.pdf"]}`,
+		"cover not image":  `{"chapters": ["text/one.md"], "cover": "text/intro.md"}`,
+		"missing asset":    `{"chapters": ["text/one.md"], "assets": ["photos"]}`,
+		"asset not image":  `{"chapters": ["text/one.md"], "assets": ["notes.pdf"]}`,
+		"missing cover":    `{"chapters": ["text/one.md"], "cover": "art/c.png"}`,
+	}
+	for name, data := range invalid {
+		if _, err := resolveBundle(files, []byte(data)); !errors.Is(err, ErrInvalidBundle) {
+			t.Errorf("%s: expected ErrInvalidBundle, got %v", name, err)
+		}
+	}
+}
+
+func TestExtractBundle_RejectsUnsafePaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	archive := writeBundle(t, tmpDir,
+		bundleEntry{"one.md", "# One"},
+		bundleEntry{"../../escaped.md", "# Escaped"},
+	)
+
+	if _, err := extractBundle(archive, filepath.Join(tmpDir, "out")); !errors.Is(err, ErrInvalidBundle) {
+		t.Fatalf("Expected ErrInvalidBundle, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "escaped.md")); err == nil {
+		t.Error("Expected nothing written outside the bundle")
+	}
+}
+
+func TestBundle_Assemble(t *testing.T) {
+	tmpDir := t.TempDir()
+	archive := writeBundle(t, tmpDir,
+		bundleEntry{"manifest.json", `{"chapters": ["chapters/02-the-end.md", "chapters/01.md"], "assets": ["images/listed.png"]}`},
+		bundleEntry{"chapters/01.md", "# Início\n\n![Figura](../images/fig%201.png \"Um título\")\n\n![Logo](https://example.com/logo.png)\n\n![Sumiu](../images/missing.png)\n"},
+		bundleEntry{"chapters/02-the-end.md", "Sem título.\n\n<img src='../images/b.jpg' alt=\"b\">\n\n[diagram]: ../images/b.jpg\n"},
+		bundleEntry{"images/fig 1.png", "png"},
+		bundleEntry{"images/b.jpg", "jpg"},
+		bundleEntry{"images/listed.png", "png"},
+	)
+
+	bundle, err := extractBundle(archive, filepath.Join(tmpDir, "bundle"))
+	if err != nil {
+		t.Fatalf("extractBundle failed: %v", err)
+	}
+	markdown, assets, warnings, err := bundle.assemble(context.Background())
+	if err != nil {
+		t.Fatalf("assemble failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"# the end\n\nSem título.",
+		"<img src='images/b.jpg'",
+		"[diagram]: images/b.jpg",
+		`![Figura](<images/fig 1.png> "Um título")`,
+		"![Logo](https://example.com/logo.png)",
+		"![Sumiu](../images/missing.png)",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Expected %q in:\n%s", want, markdown)
+		}
+	}
+	if strings.Index(markdown, "Sem título") > strings.Index(markdown, "# Início") {
+		t.Error("Expected the manifest chapter order")
+	}
+
+	if want := []string{"images/listed.png", "images/b.jpg", "images/fig 1.png"}; !reflect.DeepEqual(assets.Files, want) {
+		t.Errorf("Expected referenced images added to the assets %v, got %v", want, assets.Files)
+	}
+	if _, err := os.Stat(assets.Path("images/fig 1.png")); err != nil {
+		t.Errorf("Expected the image on disk: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "missing.png") {
+		t.Errorf("Expected a warning for the missing image, got %v", warnings)
+	}
+}
+
+func TestProjectPipeline_BundleHandoff(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := storage.NewLocalStorage(filepath.Join(tmpDir, "storage"))
+	if err != nil {
+		t.Fatalf("NewLocalStorage failed: %v", err)
+	}
+	ctx := context.Background()
+
+	data := zipBundleData(t,
+		bundleEntry{"book/01-start.md", "# Start\n\n![Map](img/map.png)\n"},
+		bundleEntry{"book/02-finish.md", "# Finish\n\nThe end."},
+		bundleEntry{"book/img/map.png", "png"},
+		bundleEntry{"book/cover.jpg", "jpg"},
+	)
+	key := storage.ProjectKey(7, "manuscript", "book.zip")
+	if _, err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), ""); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	project := &domain.Project{ID: 7, ManuscriptURL: store.URL(key)}
+
+	orchestrator := NewBookOrchestrator(newMockProjectRepository(), NewLocalAnalysisClient(), tmpDir, WithStorage(store))
+	result, err := NewProjectPipeline(orchestrator, filepath.Join(tmpDir, "converter")).Convert(ctx, &domain.Job{}, project)
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if result["cover"] != "cover.jpg" || !reflect.DeepEqual(result["chapters"], []string{"01-start.md", "02-finish.md"}) {
+		t.Errorf("Unexpected convert result: %v", result)
+	}
+
+	// The render stage on another machine fetches the images from storage
+	renderer := NewProjectPipeline(orchestrator, filepath.Join(tmpDir, "renderer"))
+	content, err := renderer.readManuscript(ctx, project)
+	if err != nil || !strings.Contains(content, "![Map](img/map.png)") || !strings.Contains(content, "# Finish") {
+		t.Fatalf("Unexpected manuscript %q, %v", content, err)
+	}
+	assets, err := renderer.loadAssets(ctx, project)
+	if err != nil {
+		t.Fatalf("loadAssets failed: %v", err)
+	}
+	if assets == nil || assets.Cover != "cover.jpg" || len(assets.Files) != 2 {
+		t.Fatalf("Unexpected assets: %+v", assets)
+	}
+	for _, name := range assets.Files {
+		if _, err := os.Stat(assets.Path(name)); err != nil {
+			t.Errorf("Expected %s fetched: %v", name, err)
+		}
+	}
+
+	// A single-file manuscript drops the bundle's images
+	single := storage.ProjectKey(7, "manuscript", "book.md")
+	store.Put(ctx, single, strings.NewReader("# Only\n\nText."), -1, "")
+	project.ManuscriptURL = store.URL(single)
+	if _, err := NewProjectPipeline(orchestrator, filepath.Join(tmpDir, "converter")).Convert(ctx, &domain.Job{}, project); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if assets, err := NewProjectPipeline(orchestrator, filepath.Join(tmpDir, "other")).loadAssets(ctx, project); err != nil || assets != nil {
+		t.Errorf("Expected no assets after a single-file manuscript, got %+v, %v", assets, err)
+	}
+}
+
+func TestBookOrchestrator_ReadBundleFolder(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "figures"), 0755)
+	os.WriteFile(filepath.Join(dir, "b.md"), []byte("# B\n\n![x](figures/x.png)"), 0644)
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("# A"), 0644)
+	os.WriteFile(filepath.Join(dir, "figures", "x.png"), []byte("png"), 0644)
+
+	orchestrator := NewBookOrchestrator(newMockProjectRepository(), nil, t.TempDir())
+	if !orchestrator.isBundleLocation(dir) || orchestrator.isBundleLocation(filepath.Join(dir, "a.md")) {
+		t.Error("Expected folders and only folders (or ZIPs) treated as bundles")
+	}
+
+	content, assets, _, err := orchestrator.readBundle(context.Background(), dir, t.TempDir())
+	if err != nil {
+		t.Fatalf("readBundle failed: %v", err)
+	}
+	if !strings.HasPrefix(content, "# A") || !strings.Contains(content, "![x](figures/x.png)") {
+		t.Errorf("Unexpected content %q", content)
+	}
+	if len(assets.Files) != 1 || assets.Path("figures/x.png") != filepath.Join(dir, "figures", "x.png") {
+		t.Errorf("Unexpected assets %+v", assets)
+	}
+}
+
+func TestUploadService_VerifiesBundle(t *testing.T) {
+	uploads, _, saver := newTestUploads(t)
+	ctx := context.Background()
+
+	broken := zipBundleData(t,
+		bundleEntry{"manifest.json", `{"chapters": ["one.md", "two.md"]}`},
+		bundleEntry{"one.md", "# One"},
+	)
+	if _, err := uploads.Upload(ctx, "alice", "1", CreateUploadRequest{Filename: "book.zip", Size: int64(len(broken))}, bytes.NewReader(broken)); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("Expected ErrInvalidBundle for a manifest naming a missing chapter, got %v", err)
+	}
+
+	valid := zipBundleData(t, bundleEntry{"one.md", "# One"}, bundleEntry{"images/a.png", "png"})
+	upload, err := uploads.Upload(ctx, "alice", "1", CreateUploadRequest{Filename: "book.zip", Size: int64(len(valid))}, bytes.NewReader(valid))
+	if err != nil || upload.ContentType != "application/zip" {
+		t.Fatalf("Expected the bundle accepted, got %+v, %v", upload, err)
+	}
+	if !bytes.Equal(saver.files["book.zip"], valid) {
+		t.Error("Expected the bundle stored as the manuscript")
+	}
+}
+
+func TestEpubImages(t *testing.T) {
+	assets := &BookAssets{Dir: "/bundle", Files: []string{"a/fig.png", "b/fig.png", "cover.jpg"}, Cover: "cover.jpg"}
+	images := epubImages(assets)
+
+	if len(images.files) != 3 || images.files[0].FileName == images.files[1].FileName {
+		t.Fatalf("Expected distinct names for same-named images, got %+v", images.files)
+	}
+	if images.files[2].ID != "cover-image" || images.cover != "/bundle/cover.jpg" {
+		t.Errorf("Expected the cover marked, got %+v", images.files[2])
+	}
+	if images.files[1].MimeType != "image/png" {
+		t.Errorf("Expected the image type, got %q", images.files[1].MimeType)
+	}
+
+	html := `<figure><img src="b/fig.png" alt="B" /></figure><img src="https://x/y.png">`
+	got := images.rewrite(html)
+	if !strings.Contains(got, `src="../Images/002-fig.png"`) || !strings.Contains(got, `src="https://x/y.png"`) {
+		t.Errorf("Unexpected rewrite: %s", got)
+	}
+}
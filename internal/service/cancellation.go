@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+var (
+	// ErrGenerationCancelled is returned by generations stopped mid-run
+	ErrGenerationCancelled = errors.New("generation cancelled")
+	// ErrGenerationInProgress is returned when a project is already being generated
+	ErrGenerationInProgress = errors.New("generation already in progress")
+	// ErrGenerationNotRunning is returned when cancelling a project with no pending or running generation
+	ErrGenerationNotRunning = errors.New("no generation in progress")
+)
+
+// JobStore loads and saves the job tracking a generation
+type JobStore interface {
+	GetByID(id string) (*domain.Job, error)
+	Update(job *domain.Job) error
+}
+
+// cancellationRegistry tracks the generations running in this process, keyed
+// by project. Only one generation per project runs at a time; cancelling one
+// goes through its job (see JobService.CancelGeneration).
+type cancellationRegistry struct {
+	mu      sync.Mutex
+	running map[uint]context.CancelCauseFunc
+}
+
+func newCancellationRegistry() *cancellationRegistry {
+	return &cancellationRegistry{
+		running: make(map[uint]context.CancelCauseFunc),
+	}
+}
+
+// register derives a cancellable context for a project's generation.
+// The returned release func must be called when the generation ends.
+func (r *cancellationRegistry) register(ctx context.Context, projectID uint) (context.Context, func(), error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.running[projectID]; ok {
+		return nil, nil, ErrGenerationInProgress
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	r.running[projectID] = cancel
+
+	release := func() {
+		r.mu.Lock()
+		delete(r.running, projectID)
+		r.mu.Unlock()
+		cancel(nil)
+	}
+	return ctx, release, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+// blockingAnalysisClient blocks until the generation context is cancelled
+type blockingAnalysisClient struct {
+	started chan struct{}
+}
+
+func (m *blockingAnalysisClient) AnalyzeContent(ctx context.Context, content string) (*domain.Analysis, error) {
+	close(m.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// mockJobStore implements JobQueue for testing, storing copies of the jobs
+type mockJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*domain.Job
+}
+
+func newMockJobStore(jobs ...*domain.Job) *mockJobStore {
+	m := &mockJobStore{jobs: make(map[string]*domain.Job)}
+	for _, job := range jobs {
+		m.jobs[job.ID] = job
+	}
+	return m
+}
+
+func (m *mockJobStore) Create(job *domain.Job) error {
+	return m.Update(job)
+}
+
+func (m *mockJobStore) GetByID(id string) (*domain.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, domain.ErrJobNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (m *mockJobStore) Update(job *domain.Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+func TestCancellationRegistry(t *testing.T) {
+	registry := newCancellationRegistry()
+
+	ctx, release, err := registry.register(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	if _, _, err := registry.register(context.Background(), 1); !errors.Is(err, ErrGenerationInProgress) {
+		t.Errorf("Expected ErrGenerationInProgress, got %v", err)
+	}
+
+	release()
+	if ctx.Err() == nil {
+		t.Error("Expected release to cancel the generation context")
+	}
+	if _, release, err := registry.register(context.Background(), 1); err != nil {
+		t.Errorf("Expected project to be registrable again, got %v", err)
+	} else {
+		release()
+	}
+}
+
+func TestBookOrchestrator_GenerateCancelled(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	projectRepo := newMockProjectRepository()
+	projectRepo.Create(context.Background(), &domain.Project{ID: 1, Title: "Cancelled Book"})
+
+	// A stale output from a previous run must not survive the cancellation
+	stale := filepath.Join(tmpDir, "project_1.pdf")
+	if err := os.WriteFile(stale, []byte("%PDF-partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis := &blockingAnalysisClient{started: make(chan struct{})}
+	orchestrator := NewBookOrchestrator(projectRepo, analysis, tmpDir)
+
+	// The worker cancels the run when the job is cancelled and its lease lost
+	ctx, cancel := context.WithCancelCause(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := orchestrator.Generate(ctx, &GenerationRequest{
+			ProjectID:     1,
+			ContentPath:   createTestContent(t, tmpDir),
+			OutputFormats: []string{"pdf"},
+			JobID:         "job-1",
+		})
+		done <- err
+	}()
+
+	<-analysis.started
+	cancel(domain.ErrJobLeaseLost)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrGenerationCancelled) {
+			t.Errorf("Expected ErrGenerationCancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Generation did not stop after cancellation")
+	}
+
+	progress, err := orchestrator.GetProgress(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetProgress failed: %v", err)
+	}
+	if progress.Status != ProgressStatusCancelled {
+		t.Errorf("Expected cancelled progress, got %s", progress.Status)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("Expected partial output to be removed")
+	}
+}
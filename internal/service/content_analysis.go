@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/JuanCS-Dev/typecraft/internal/analyzer"
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+// LocalAnalysisClient implements AnalysisClient with the heuristic content
+// analyzer, so generation works without an AI provider
+type LocalAnalysisClient struct {
+	analyzer *analyzer.ContentAnalyzer
+}
+
+// NewLocalAnalysisClient creates an analysis client backed by internal/analyzer
+func NewLocalAnalysisClient() *LocalAnalysisClient {
+	return &LocalAnalysisClient{analyzer: analyzer.NewContentAnalyzer()}
+}
+
+// AnalyzeContent analyzes the manuscript and reduces it to the fields the
+// orchestrator uses for design and pipeline selection
+func (c *LocalAnalysisClient) AnalyzeContent(ctx context.Context, content string) (*domain.Analysis, error) {
+	analysis, err := c.analyzer.Analyze(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Analysis{
+		Genre:      analysis.PrimaryGenre,
+		Tone:       dominantTone(analysis.Tone),
+		Complexity: analysis.Complexity,
+		HasMath:    analysis.EquationCount > 0,
+		ImageCount: analysis.ImageCount,
+		TableCount: analysis.TableCount,
+		CodeBlocks: strings.Count(content, "```") / 2,
+	}, nil
+}
+
+// dominantTone names the strongest dimension of the tone profile
+func dominantTone(tone analyzer.ToneProfile) string {
+	dominant, score := "formal", tone.Formal
+	for _, candidate := range []struct {
+		name  string
+		score float64
+	}{
+		{"casual", tone.Casual},
+		{"technical", tone.Technical},
+		{"creative", tone.Creative},
+		{"academic", tone.Academic},
+	} {
+		if candidate.score > score {
+			dominant, score = candidate.name, candidate.score
+		}
+	}
+	return dominant
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/pkg/design"
+	"github.com/JuanCS-Dev/typecraft/pkg/epub"
+	"github.com/JuanCS-Dev/typecraft/pkg/pipeline"
+	"github.com/google/uuid"
+)
+
+// epubMetadata fills the EPUB package metadata from the project
+func epubMetadata(project *domain.Project) epub.Metadata {
+	language := strings.TrimSpace(project.Language)
+	if language == "" {
+		language = "pt"
+	}
+
+	metadata := epub.Metadata{
+		Title:       project.Title,
+		Author:      project.Author,
+		Language:    language,
+		Identifier:  epubIdentifier(project),
+		Description: project.Description,
+		Date:        time.Now(),
+	}
+	if project.Genre != "" {
+		metadata.Subject = []string{project.Genre}
+	}
+
+	return metadata
+}
+
+// epubIdentifier returns the ISBN URN when available, otherwise a UUID
+// derived from the project ID so regenerations keep the same identifier
+func epubIdentifier(project *domain.Project) string {
+	isbn := strings.NewReplacer("-", "", " ", "").Replace(project.ISBN)
+	if isbn != "" {
+		return "urn:isbn:" + isbn
+	}
+
+	id := uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("typecraft:project:%d", project.ID)))
+	return "urn:uuid:" + id.String()
+}
+
+// buildEPUBCSS generates the reflowable stylesheet from the design.
+// Unlike print, page size and margins are left to the reading system; only
+// fonts and colors are carried over.
+func buildEPUBCSS(designResult *design.DesignResult) string {
+	primary := "#000000"
+	if len(designResult.Colors) > 0 {
+		if hex, ok := parseHexColor(designResult.Colors[0]); ok {
+			primary = "#" + hex
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("@charset \"UTF-8\";\n\n")
+	fmt.Fprintf(&sb, "body {\n  font-family: %s;\n  font-size: 1em;\n  line-height: %.1f;\n  margin: 1em;\n  text-align: justify;\n}\n\n",
+		cssFontStack(designResult.Fonts.Body, "serif"), htmlLineHeight)
+	fmt.Fprintf(&sb, "h1, h2, h3, h4, h5, h6 {\n  font-family: %s;\n  color: %s;\n  line-height: 1.2;\n  margin-top: 1em;\n  margin-bottom: 0.5em;\n  text-align: left;\n  page-break-after: avoid;\n}\n\n",
+		cssFontStack(designResult.Fonts.Heading, "sans-serif"), primary)
+	sb.WriteString(epubBaseCSS)
+
+	return sb.String()
+}
+
+// epubBaseCSS holds the design-independent rules for EPUB content
+const epubBaseCSS = `h1 {
+  font-size: 2em;
+  page-break-before: always;
+}
+
+h2 {
+  font-size: 1.5em;
+}
+
+h3 {
+  font-size: 1.17em;
+}
+
+p {
+  margin: 0;
+  text-indent: 1em;
+  orphans: 2;
+  widows: 2;
+}
+
+p:first-child,
+h1 + p,
+h2 + p,
+h3 + p {
+  text-indent: 0;
+}
+
+blockquote {
+  margin: 1em 2em;
+  font-style: italic;
+}
+
+img {
+  max-width: 100%;
+  height: auto;
+}
+
+table {
+  border-collapse: collapse;
+  margin: 1em 0;
+}
+
+th, td {
+  border: 1px solid #ccc;
+  padding: 0.3em;
+}
+`
+
+// epubSectionTypes maps book section types to EPUB structural semantics
+var epubSectionTypes = map[string]string{
+	pipeline.SectionPart:             "part",
+	pipeline.SectionChapter:          "chapter",
+	pipeline.SectionPreface:          "preface",
+	pipeline.SectionForeword:         "foreword",
+	pipeline.SectionAcknowledgements: "acknowledgments",
+	pipeline.SectionAppendix:         "appendix",
+	pipeline.SectionGlossary:         "glossary",
+}
+
+// epubChapter builds the EPUB chapter for a converted book section.
+// Untitled sections (text before the first heading) take the book title so the
+// navigation document never has empty entries.
+func epubChapter(project *domain.Project, section pipeline.BookSection, content string) epub.Chapter {
+	title := section.Title
+	if title == "" {
+		title = project.Title
+	}
+
+	sectionType, ok := epubSectionTypes[section.Type]
+	if !ok {
+		sectionType = "chapter"
+	}
+
+	return epub.Chapter{
+		Title:   title,
+		Content: content,
+		Type:    sectionType,
+	}
+}
+
+// epubBookImages are the bundle images packaged in an ePub. They are stored
+// flat in OEBPS/Images under numbered names, so images with the same name in
+// different folders do not collide.
+type epubBookImages struct {
+	files []epub.ImageFile
+	cover string            // local path of the cover, "" without one
+	hrefs map[string]string // bundle path -> href from a chapter
+}
+
+// epubImages lays out the images of a bundle in the ePub
+func epubImages(assets *BookAssets) *epubBookImages {
+	images := &epubBookImages{hrefs: make(map[string]string)}
+	if assets == nil {
+		return images
+	}
+
+	for i, name := range assets.Files {
+		local := assets.Path(name)
+		if variant, ok := assets.EPUB[name]; ok {
+			local = variant
+		}
+		image := epub.ImageFile{
+			ID:       fmt.Sprintf("image%d", i+1),
+			Path:     local,
+			MimeType: imageTypes[strings.ToLower(path.Ext(name))],
+			FileName: fmt.Sprintf("%03d-%s", i+1, path.Base(name)),
+		}
+		if name == assets.Cover {
+			image.ID = "cover-image"
+			images.cover = image.Path
+		}
+		images.files = append(images.files, image)
+		images.hrefs[name] = "../Images/" + image.FileName
+	}
+	return images
+}
+
+// rewrite points the image references of a chapter at the packaged images
+func (images *epubBookImages) rewrite(html string) string {
+	if len(images.hrefs) == 0 {
+		return html
+	}
+	return htmlImageRef.ReplaceAllStringFunc(html, func(match string) string {
+		m := htmlImageRef.FindStringSubmatch(match)
+		src := m[2][1 : len(m[2])-1]
+		if unescaped, err := url.PathUnescape(src); err == nil {
+			src = unescaped
+		}
+		href, ok := images.hrefs[src]
+		if !ok {
+			return match
+		}
+		return m[1] + `"` + href + `"`
+	})
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/pkg/design"
+	"github.com/JuanCS-Dev/typecraft/pkg/pipeline"
+)
+
+func TestEPUBMetadata(t *testing.T) {
+	project := &domain.Project{
+		ID:          7,
+		Title:       "Test Book",
+		Author:      "Test Author",
+		ISBN:        "978-3-16-148410-0",
+		Language:    "en",
+		Description: "A test book",
+		Genre:       "Fiction",
+	}
+
+	metadata := epubMetadata(project)
+
+	if metadata.Title != "Test Book" || metadata.Author != "Test Author" {
+		t.Errorf("Unexpected title/author: %q / %q", metadata.Title, metadata.Author)
+	}
+	if metadata.Identifier != "urn:isbn:9783161484100" {
+		t.Errorf("Expected ISBN identifier, got %s", metadata.Identifier)
+	}
+	if metadata.Language != "en" {
+		t.Errorf("Expected language en, got %s", metadata.Language)
+	}
+	if metadata.Description != "A test book" {
+		t.Errorf("Expected description, got %q", metadata.Description)
+	}
+}
+
+func TestEPUBIdentifier_WithoutISBN(t *testing.T) {
+	first := epubIdentifier(&domain.Project{ID: 1})
+	second := epubIdentifier(&domain.Project{ID: 1})
+	other := epubIdentifier(&domain.Project{ID: 2})
+
+	if !strings.HasPrefix(first, "urn:uuid:") {
+		t.Errorf("Expected UUID identifier, got %s", first)
+	}
+	if first != second {
+		t.Error("Expected stable identifier for the same project")
+	}
+	if first == other {
+		t.Error("Expected different identifiers for different projects")
+	}
+}
+
+func TestBuildEPUBCSS(t *testing.T) {
+	css := buildEPUBCSS(&design.DesignResult{
+		Fonts:  design.Fonts{Body: "Garamond", Heading: "Futura"},
+		Colors: []string{"#2c3e50"},
+	})
+
+	for _, want := range []string{"'Garamond', serif", "'Futura', sans-serif", "color: #2C3E50;"} {
+		if !strings.Contains(css, want) {
+			t.Errorf("CSS missing %q", want)
+		}
+	}
+	if strings.Contains(css, "@page") {
+		t.Error("EPUB CSS should not fix page geometry")
+	}
+}
+
+func TestEPUBChapter(t *testing.T) {
+	project := &domain.Project{Title: "Test Book"}
+
+	preface := epubChapter(project, pipeline.BookSection{Title: "Prefácio", Type: pipeline.SectionPreface}, "<p>P</p>")
+	if preface.Type != "preface" || preface.Title != "Prefácio" {
+		t.Errorf("Unexpected preface chapter: %+v", preface)
+	}
+
+	thanks := epubChapter(project, pipeline.BookSection{Title: "Thanks", Type: pipeline.SectionAcknowledgements}, "")
+	if thanks.Type != "acknowledgments" {
+		t.Errorf("Expected EPUB acknowledgments semantics, got %s", thanks.Type)
+	}
+
+	untitled := epubChapter(project, pipeline.BookSection{Type: pipeline.SectionChapter}, "<p>Intro</p>")
+	if untitled.Title != "Test Book" {
+		t.Errorf("Expected book title for untitled section, got %q", untitled.Title)
+	}
+}
+
+func TestEPUBChapters_FromSections(t *testing.T) {
+	project := &domain.Project{ID: 1, Title: "Test Book"}
+	content := "# Preface\n\nWhy.\n\n# One\n\nFirst.\n\n# Appendix A\n\nData.\n"
+
+	sections := pipeline.SplitSections(content)
+
+	if len(sections) != 3 {
+		t.Fatalf("Expected 3 sections, got %d", len(sections))
+	}
+	types := []string{"preface", "chapter", "appendix"}
+	for i, want := range types {
+		if chapter := epubChapter(project, sections[i], ""); chapter.Type != want {
+			t.Errorf("Section %d: expected EPUB type %s, got %s", i, want, chapter.Type)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/config"
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// Project event types, used as the SSE event name.
+// generation.completed carries a GenerationJobResult.
+const (
+	EventProjectStatus       = "project.status"
+	EventJobStatus           = "job.status"
+	EventGenerationProgress  = "generation.progress"
+	EventGenerationCompleted = "generation.completed"
+	EventValidationIssue     = "validation.issue"
+)
+
+// eventRetention is how many events are kept per project for reconnects
+const eventRetention = 1000
+
+// ErrInvalidEventID is returned when resuming from an ID the store never issued
+var ErrInvalidEventID = errors.New("invalid event ID")
+
+// ProjectEvent is something that happened to a project, in publication order.
+// IDs are opaque, increasing per project and accepted back by Read.
+type ProjectEvent struct {
+	ID        string          `json:"id"`
+	ProjectID string          `json:"project_id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	Time      time.Time       `json:"time"`
+}
+
+// ProjectStatusEvent is the data of a project.status event
+type ProjectStatusEvent struct {
+	Status   domain.ProjectStatus `json:"status"`
+	Previous domain.ProjectStatus `json:"previous_status,omitempty"` // differs from Status on transitions
+	Progress int                  `json:"progress"`
+	Errors   []string             `json:"errors,omitempty"`
+}
+
+// JobStatusEvent is the data of a job.status event
+type JobStatusEvent struct {
+	JobID       string           `json:"job_id"`
+	Type        domain.JobType   `json:"type"`
+	Status      domain.JobStatus `json:"status"`
+	Attempts    int              `json:"attempts"`
+	MaxAttempts int              `json:"max_attempts"`
+	Error       string           `json:"error,omitempty"`
+	RunAfter    *time.Time       `json:"run_after,omitempty"`
+}
+
+// ValidationIssueEvent is the data of a validation.issue event
+type ValidationIssueEvent struct {
+	Format  string `json:"format"`
+	Level   string `json:"level"` // error, warning or info
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// EventPublisher receives project events as they happen
+type EventPublisher interface {
+	Publish(ctx context.Context, projectID, eventType string, data interface{}) error
+}
+
+// EventPublishers publishes every event to each of its publishers
+type EventPublishers []EventPublisher
+
+// Publish hands the event to every publisher, even when one of them fails
+func (p EventPublishers) Publish(ctx context.Context, projectID, eventType string, data interface{}) error {
+	var errs []error
+	for _, publisher := range p {
+		if publisher == nil {
+			continue
+		}
+		if err := publisher.Publish(ctx, projectID, eventType, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EventStore keeps the recent events of each project so subscribers can
+// follow them and resume after a disconnect
+type EventStore interface {
+	EventPublisher
+
+	// Read returns the events published after afterID ("0" for every retained
+	// event), waiting up to wait for one when there is none yet
+	Read(ctx context.Context, projectID, afterID string, wait time.Duration) ([]ProjectEvent, error)
+
+	// LastID returns the ID of the latest event of a project ("0" when none)
+	LastID(ctx context.Context, projectID string) (string, error)
+}
+
+// NewEventStore selects the backend from the configuration: Redis when
+// cfg.UseRedis, in-memory otherwise
+func NewEventStore(cfg *config.Config) (EventStore, error) {
+	if !cfg.UseRedis() {
+		return NewMemoryEventStore(), nil
+	}
+	return NewRedisEventStore(cfg.RedisURL)
+}
+
+// PublishProjectStatus publishes the current status of a project, saved over
+// previous. Events are best effort: a nil publisher or a failing store is ignored.
+func PublishProjectStatus(ctx context.Context, events EventPublisher, project *domain.Project, previous domain.ProjectStatus) {
+	if events == nil || project == nil {
+		return
+	}
+	data := ProjectStatusEvent{Status: project.Status, Previous: previous, Progress: project.Progress}
+	if project.ErrorLog != nil {
+		data.Errors = *project.ErrorLog
+	}
+	_ = events.Publish(context.WithoutCancel(ctx), strconv.FormatUint(uint64(project.ID), 10), EventProjectStatus, data)
+}
+
+// PublishJobStatus publishes the current state of a job. Like
+// PublishProjectStatus, it never fails the caller.
+func PublishJobStatus(ctx context.Context, events EventPublisher, job *domain.Job) {
+	if events == nil || job == nil {
+		return
+	}
+	data := JobStatusEvent{
+		JobID:       job.ID,
+		Type:        job.Type,
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		Error:       job.ErrorMsg,
+	}
+	if job.Status == domain.JobStatusPending && job.RunAfter != nil && job.RunAfter.After(time.Now()) {
+		data.RunAfter = job.RunAfter
+	}
+	_ = events.Publish(context.WithoutCancel(ctx), job.ProjectID, EventJobStatus, data)
+}
+
+// MemoryEventStore keeps events in process memory (single instance only)
+type MemoryEventStore struct {
+	mu      sync.Mutex
+	streams map[string]*memoryEventStream
+}
+
+type memoryEventStream struct {
+	seq    uint64
+	events []ProjectEvent
+	notify chan struct{} // closed and replaced on every publish
+}
+
+// NewMemoryEventStore creates an empty in-memory store
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{streams: make(map[string]*memoryEventStream)}
+}
+
+// Publish appends an event, dropping the oldest beyond eventRetention
+func (s *MemoryEventStore) Publish(ctx context.Context, projectID, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream := s.stream(projectID)
+	stream.seq++
+	stream.events = append(stream.events, ProjectEvent{
+		ID:        strconv.FormatUint(stream.seq, 10),
+		ProjectID: projectID,
+		Type:      eventType,
+		Data:      payload,
+		Time:      time.Now(),
+	})
+	if len(stream.events) > eventRetention {
+		stream.events = append([]ProjectEvent(nil), stream.events[len(stream.events)-eventRetention:]...)
+	}
+
+	close(stream.notify)
+	stream.notify = make(chan struct{})
+	return nil
+}
+
+// Read returns the events after afterID, waiting for the next publish when
+// there is none
+func (s *MemoryEventStore) Read(ctx context.Context, projectID, afterID string, wait time.Duration) ([]ProjectEvent, error) {
+	after, err := strconv.ParseUint(afterID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEventID, afterID)
+	}
+
+	events, notify := s.since(projectID, after)
+	if len(events) > 0 || wait <= 0 {
+		return events, nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-notify:
+	case <-timer.C:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	events, _ = s.since(projectID, after)
+	return events, nil
+}
+
+// LastID returns the ID of the latest event of a project
+func (s *MemoryEventStore) LastID(ctx context.Context, projectID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strconv.FormatUint(s.stream(projectID).seq, 10), nil
+}
+
+// since copies the retained events after seq, along with the channel closed
+// by the next publish
+func (s *MemoryEventStore) since(projectID string, seq uint64) ([]ProjectEvent, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream := s.stream(projectID)
+	var events []ProjectEvent
+	for _, event := range stream.events {
+		if id, _ := strconv.ParseUint(event.ID, 10, 64); id > seq {
+			events = append(events, event)
+		}
+	}
+	return events, stream.notify
+}
+
+// stream returns the stream of a project, creating it; callers hold s.mu
+func (s *MemoryEventStore) stream(projectID string) *memoryEventStream {
+	stream, ok := s.streams[projectID]
+	if !ok {
+		stream = &memoryEventStream{notify: make(chan struct{})}
+		s.streams[projectID] = stream
+	}
+	return stream
+}
+
+// RedisEventStore shares events between API and worker instances through
+// Redis Streams, whose entry IDs double as SSE event IDs
+type RedisEventStore struct {
+	client *redis.Client
+}
+
+// redisStreamID matches the IDs Redis assigns to stream entries
+var redisStreamID = regexp.MustCompile(`^\d+(-\d+)?$`)
+
+// NewRedisEventStore connects to the Redis instance at redisURL
+func NewRedisEventStore(redisURL string) (*RedisEventStore, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	return &RedisEventStore{client: redis.NewClient(opts)}, nil
+}
+
+// Close releases the Redis connection pool
+func (s *RedisEventStore) Close() error {
+	return s.client.Close()
+}
+
+// Publish appends an event to the project stream, trimmed to about
+// eventRetention entries and expiring after progressTTL without activity
+func (s *RedisEventStore) Publish(ctx context.Context, projectID, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	key := eventsKey(projectID)
+	pipe := s.client.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: eventRetention,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type": eventType,
+			"data": string(payload),
+			"time": time.Now().UTC().Format(time.RFC3339Nano),
+		},
+	})
+	pipe.Expire(ctx, key, progressTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries after afterID, blocking up to wait for new ones
+func (s *RedisEventStore) Read(ctx context.Context, projectID, afterID string, wait time.Duration) ([]ProjectEvent, error) {
+	if !redisStreamID.MatchString(afterID) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEventID, afterID)
+	}
+
+	args := &redis.XReadArgs{
+		Streams: []string{eventsKey(projectID), afterID},
+		Count:   100,
+		Block:   -1, // do not block
+	}
+	if wait > 0 {
+		args.Block = wait
+	}
+
+	streams, err := s.client.XRead(ctx, args).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	var events []ProjectEvent
+	for _, stream := range streams {
+		for _, message := range stream.Messages {
+			events = append(events, decodeRedisEvent(projectID, message))
+		}
+	}
+	return events, nil
+}
+
+// LastID returns the ID of the latest entry of the project stream
+func (s *RedisEventStore) LastID(ctx context.Context, projectID string) (string, error) {
+	messages, err := s.client.XRevRangeN(ctx, eventsKey(projectID), "+", "-", 1).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to read events: %w", err)
+	}
+	if len(messages) == 0 {
+		return "0", nil
+	}
+	return messages[0].ID, nil
+}
+
+// decodeRedisEvent converts a stream entry written by Publish
+func decodeRedisEvent(projectID string, message redis.XMessage) ProjectEvent {
+	event := ProjectEvent{ID: message.ID, ProjectID: projectID}
+	if value, ok := message.Values["type"].(string); ok {
+		event.Type = value
+	}
+	if value, ok := message.Values["data"].(string); ok {
+		event.Data = json.RawMessage(value)
+	}
+	if value, ok := message.Values["time"].(string); ok {
+		event.Time, _ = time.Parse(time.RFC3339Nano, value)
+	}
+	return event
+}
+
+// eventsKey is the Redis stream holding a project's events
+func eventsKey(projectID string) string {
+	return "typecraft:project:events:" + projectID
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/config"
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+func TestMemoryEventStore_ReadAfter(t *testing.T) {
+	store := NewMemoryEventStore()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := store.Publish(ctx, "1", EventJobStatus, map[string]int{"n": i}); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+	store.Publish(ctx, "2", EventJobStatus, nil)
+
+	all, err := store.Read(ctx, "1", "0", 0)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(all) != 3 || all[0].ID != "1" || all[2].ID != "3" {
+		t.Fatalf("Expected events 1..3 of project 1, got %+v", all)
+	}
+
+	// Resuming replays only what came after the last seen event
+	missed, _ := store.Read(ctx, "1", all[0].ID, 0)
+	if len(missed) != 2 || missed[0].ID != "2" {
+		t.Errorf("Expected events 2 and 3 after resuming, got %+v", missed)
+	}
+	var data map[string]int
+	if err := json.Unmarshal(missed[0].Data, &data); err != nil || data["n"] != 1 {
+		t.Errorf("Expected event data preserved, got %s", missed[0].Data)
+	}
+
+	last, _ := store.LastID(ctx, "1")
+	if last != "3" {
+		t.Errorf("Expected last ID 3, got %s", last)
+	}
+	if none, _ := store.Read(ctx, "1", last, 0); len(none) != 0 {
+		t.Errorf("Expected nothing after the last event, got %+v", none)
+	}
+}
+
+func TestMemoryEventStore_ReadWaitsForPublish(t *testing.T) {
+	store := NewMemoryEventStore()
+	ctx := context.Background()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		store.Publish(ctx, "1", EventProjectStatus, ProjectStatusEvent{Status: domain.StatusRendering})
+	}()
+
+	events, err := store.Read(ctx, "1", "0", time.Second)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != EventProjectStatus {
+		t.Errorf("Expected the published event, got %+v", events)
+	}
+
+	// Nothing new: the wait times out empty
+	events, err = store.Read(ctx, "1", events[0].ID, 10*time.Millisecond)
+	if err != nil || len(events) != 0 {
+		t.Errorf("Expected an empty read after the timeout, got %+v, %v", events, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := store.Read(cancelled, "1", "1", time.Second); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestMemoryEventStore_Retention(t *testing.T) {
+	store := NewMemoryEventStore()
+	ctx := context.Background()
+
+	for i := 0; i < eventRetention+10; i++ {
+		store.Publish(ctx, "1", EventGenerationProgress, i)
+	}
+
+	events, _ := store.Read(ctx, "1", "0", 0)
+	if len(events) != eventRetention {
+		t.Fatalf("Expected %d retained events, got %d", eventRetention, len(events))
+	}
+	if events[0].ID != strconv.Itoa(11) {
+		t.Errorf("Expected the oldest events dropped, first is %s", events[0].ID)
+	}
+}
+
+func TestMemoryEventStore_InvalidID(t *testing.T) {
+	store := NewMemoryEventStore()
+
+	if _, err := store.Read(context.Background(), "1", "1700000000000-0", 0); !errors.Is(err, ErrInvalidEventID) {
+		t.Errorf("Expected ErrInvalidEventID, got %v", err)
+	}
+}
+
+func TestNewEventStore(t *testing.T) {
+	store, err := NewEventStore(&config.Config{})
+	if err != nil {
+		t.Fatalf("NewEventStore failed: %v", err)
+	}
+	if _, ok := store.(*MemoryEventStore); !ok {
+		t.Errorf("Expected in-memory store without Redis URL, got %T", store)
+	}
+
+	store, err = NewEventStore(&config.Config{RedisURL: "redis://localhost:6379/0"})
+	if err != nil {
+		t.Fatalf("NewEventStore failed: %v", err)
+	}
+	if _, ok := store.(*RedisEventStore); !ok {
+		t.Errorf("Expected Redis store with Redis URL, got %T", store)
+	}
+}
+
+// The memory driver runs without external services, whatever REDIS_URL says
+func TestNewStores_MemoryDriver(t *testing.T) {
+	cfg := &config.Config{DatabaseDriver: "memory", RedisURL: "redis://localhost:6379/0"}
+
+	events, err := NewEventStore(cfg)
+	if err != nil {
+		t.Fatalf("NewEventStore failed: %v", err)
+	}
+	if _, ok := events.(*MemoryEventStore); !ok {
+		t.Errorf("Expected in-memory event store, got %T", events)
+	}
+
+	progress, err := NewProgressStore(cfg)
+	if err != nil {
+		t.Fatalf("NewProgressStore failed: %v", err)
+	}
+	if _, ok := progress.(*MemoryProgressStore); !ok {
+		t.Errorf("Expected in-memory progress store, got %T", progress)
+	}
+}
+
+func TestPublishStatusEvents(t *testing.T) {
+	store := NewMemoryEventStore()
+	ctx := context.Background()
+
+	errorLog := []string{"render: lualatex failed"}
+	PublishProjectStatus(ctx, store, &domain.Project{ID: 7, Status: domain.StatusFailed, Progress: 80, ErrorLog: &errorLog}, domain.StatusRendering)
+	PublishJobStatus(ctx, store, &domain.Job{ID: "j", ProjectID: "7", Type: domain.JobTypeRender, Status: domain.JobStatusFailed, Attempts: 3, MaxAttempts: 3, ErrorMsg: "lualatex failed"})
+
+	// A missing publisher is a no-op
+	PublishJobStatus(ctx, nil, &domain.Job{ID: "j", ProjectID: "7"})
+
+	events, _ := store.Read(ctx, "7", "0", 0)
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	var project ProjectStatusEvent
+	json.Unmarshal(events[0].Data, &project)
+	if events[0].Type != EventProjectStatus || project.Status != domain.StatusFailed ||
+		project.Previous != domain.StatusRendering || len(project.Errors) != 1 {
+		t.Errorf("Unexpected project event: %s %s", events[0].Type, events[0].Data)
+	}
+
+	var job JobStatusEvent
+	json.Unmarshal(events[1].Data, &job)
+	if events[1].Type != EventJobStatus || job.JobID != "j" || job.Status != domain.JobStatusFailed || job.Error != "lualatex failed" {
+		t.Errorf("Unexpected job event: %s %s", events[1].Type, events[1].Data)
+	}
+}
+
+func TestProgressTracker_PublishesEvents(t *testing.T) {
+	store := NewMemoryEventStore()
+	tracker := newProgressTracker(NewMemoryProgressStore(), 3)
+	tracker.events = store
+	ctx := context.Background()
+
+	tracker.stage(ctx, StageAnalyze, "Analyzing content")
+	tracker.issue(ctx, "epub", "warning", "OPF-001", "missing cover")
+
+	events, _ := store.Read(ctx, "3", "0", 0)
+	if len(events) != 2 {
+		t.Fatalf("Expected progress and issue events, got %d", len(events))
+	}
+
+	var progress GenerationProgress
+	json.Unmarshal(events[0].Data, &progress)
+	if events[0].Type != EventGenerationProgress || progress.CurrentStage != StageAnalyze || progress.Progress != 5 {
+		t.Errorf("Unexpected progress event: %s %s", events[0].Type, events[0].Data)
+	}
+
+	var issue ValidationIssueEvent
+	json.Unmarshal(events[1].Data, &issue)
+	if events[1].Type != EventValidationIssue || issue.Format != "epub" || issue.Code != "OPF-001" {
+		t.Errorf("Unexpected issue event: %s %s", events[1].Type, events[1].Data)
+	}
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/pkg/design"
+	"github.com/JuanCS-Dev/typecraft/pkg/latex"
+	"github.com/google/uuid"
+)
+
+// generationJobPriority is the priority of export jobs queued by the API
+const generationJobPriority = 5
+
+// generationJobMaxAttempts bounds how often a failed export is retried
+const generationJobMaxAttempts = 3
+
+// JobQueue persists the jobs created for asynchronous generations
+type JobQueue interface {
+	JobStore
+	Create(job *domain.Job) error
+}
+
+// GenerationPayload is the generation request stored in Job.Payload
+type GenerationPayload struct {
+	ProjectID        uint           `json:"project_id"`
+	ContentPath      string         `json:"content_path"`
+	OutputFormats    []string       `json:"output_formats"`
+	OverridePipeline string         `json:"override_pipeline,omitempty"`
+	CustomDesign     *DesignOptions `json:"custom_design,omitempty"`
+}
+
+// GenerationJobResult is the generation outcome stored in Job.Result
+type GenerationJobResult struct {
+	Pipeline       string               `json:"pipeline"`
+	OutputFiles    map[string]string    `json:"output_files"`
+	OutputURLs     map[string]string    `json:"output_urls,omitempty"`
+	DesignMetadata *design.DesignResult `json:"design_metadata,omitempty"`
+	Metrics        *JobMetrics          `json:"metrics,omitempty"`
+	CompileErrors  []latex.CompileError `json:"compile_errors,omitempty"`
+	Warnings       []string             `json:"warnings,omitempty"`
+	Images         []ImageReport        `json:"images,omitempty"`
+}
+
+// JobMetrics is the serializable subset of GenerationMetrics
+type JobMetrics struct {
+	DurationMs         int64   `json:"duration_ms"`
+	ContentAnalysisMs  int64   `json:"content_analysis_ms"`
+	DesignGenerationMs int64   `json:"design_generation_ms"`
+	RenderingMs        int64   `json:"rendering_ms"`
+	ValidationMs       int64   `json:"validation_ms"`
+	TotalPages         int     `json:"total_pages"`
+	FileSize           int64   `json:"file_size"`
+	QualityScore       float64 `json:"quality_score"`
+}
+
+// GenerationJobs queues book generations as export jobs and runs them for
+// the worker. Callers get the job back immediately; a worker (cmd/worker, or
+// the one embedded in the API without an external database) claims it under a
+// lease and stores the outcome in Job.Result.
+type GenerationJobs struct {
+	orchestrator *BookOrchestrator
+	jobs         JobQueue
+
+	// revisions resolves GenerationRequest.Revision
+	revisions RevisionStore
+}
+
+// GenerationJobsOption configures GenerationJobs
+type GenerationJobsOption func(*GenerationJobs)
+
+// WithGenerationRevisions lets requests name a manuscript revision to
+// generate from (GenerationRequest.Revision)
+func WithGenerationRevisions(revisions RevisionStore) GenerationJobsOption {
+	return func(g *GenerationJobs) {
+		g.revisions = revisions
+	}
+}
+
+// NewGenerationJobs creates the export job queue for the orchestrator
+func NewGenerationJobs(orchestrator *BookOrchestrator, jobs JobQueue, opts ...GenerationJobsOption) *GenerationJobs {
+	g := &GenerationJobs{
+		orchestrator: orchestrator,
+		jobs:         jobs,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// Enqueue stores a pending export job for a project of userID, for a worker
+// to claim. Job state changes are published through the orchestrator's event
+// publisher. The job records the manuscript revision it builds (see
+// resolveRevision).
+func (g *GenerationJobs) Enqueue(ctx context.Context, userID string, req *GenerationRequest) (*domain.Job, error) {
+	if err := g.checkOwner(ctx, userID, req.ProjectID); err != nil {
+		return nil, err
+	}
+
+	req, err := g.resolveRevision(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := toJobMap(GenerationPayload{
+		ProjectID:        req.ProjectID,
+		ContentPath:      req.ContentPath,
+		OutputFormats:    req.OutputFormats,
+		OverridePipeline: req.OverridePipeline,
+		CustomDesign:     req.CustomDesign,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	job := &domain.Job{
+		ID:          uuid.New().String(),
+		ProjectID:   strconv.FormatUint(uint64(req.ProjectID), 10),
+		RevisionID:  req.RevisionID,
+		Type:        domain.JobTypeExport,
+		Status:      domain.JobStatusPending,
+		Priority:    generationJobPriority,
+		Payload:     &payload,
+		MaxAttempts: generationJobMaxAttempts,
+		CreatedAt:   time.Now(),
+	}
+	if err := g.jobs.Create(job); err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+	PublishJobStatus(ctx, g.orchestrator.events, job)
+
+	return job, nil
+}
+
+// resolveRevision returns the request pointed at the manuscript revision it
+// builds: the numbered revision it asks for, or the project's current
+// revision when the content is the current manuscript (the default when no
+// content is given). Other content is not a revision.
+func (g *GenerationJobs) resolveRevision(ctx context.Context, req *GenerationRequest) (*GenerationRequest, error) {
+	resolved := *req
+	if req.Revision > 0 {
+		if g.revisions == nil {
+			return nil, fmt.Errorf("manuscript revisions are not available")
+		}
+		revision, err := g.revisions.GetByNumber(req.ProjectID, req.Revision)
+		if err != nil {
+			return nil, fmt.Errorf("revision %d: %w", req.Revision, err)
+		}
+		resolved.ContentPath = revision.ManuscriptURL
+		resolved.RevisionID = revision.ID
+		return &resolved, nil
+	}
+	if req.RevisionID != "" {
+		return &resolved, nil
+	}
+
+	project, err := g.orchestrator.projectRepo.GetByID(ctx, req.ProjectID)
+	if err != nil {
+		// Generate reports the missing project on the job
+		return &resolved, nil
+	}
+	if resolved.ContentPath == "" {
+		resolved.ContentPath = project.ManuscriptURL
+	}
+	if resolved.ContentPath == project.ManuscriptURL {
+		resolved.RevisionID = project.RevisionID
+	}
+	return &resolved, nil
+}
+
+// Execute runs the generation described by an export job and returns what to
+// store in Job.Result. The worker owns the job status and its lease.
+func (g *GenerationJobs) Execute(ctx context.Context, job *domain.Job) (map[string]interface{}, error) {
+	req, err := generationRequestFromJob(job)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := g.orchestrator.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := toJobMap(newGenerationJobResult(result))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job result: %w", err)
+	}
+	return output, nil
+}
+
+// Get returns a job of a project of userID, including its result once
+// finished
+func (g *GenerationJobs) Get(ctx context.Context, userID, id string) (*domain.Job, error) {
+	job, err := g.jobs.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ownedProject(g.projects(ctx), Caller{UserID: userID}, job.ProjectID); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Progress returns the latest progress of the generation of a project of
+// userID (see BookOrchestrator.GetProgress)
+func (g *GenerationJobs) Progress(ctx context.Context, userID string, projectID uint) (*GenerationProgress, error) {
+	if err := g.checkOwner(ctx, userID, projectID); err != nil {
+		return nil, err
+	}
+	return g.orchestrator.GetProgress(ctx, projectID)
+}
+
+// checkOwner returns ErrProjectAccessDenied unless userID owns the project
+func (g *GenerationJobs) checkOwner(ctx context.Context, userID string, projectID uint) error {
+	_, err := ownedProject(g.projects(ctx), Caller{UserID: userID}, strconv.FormatUint(uint64(projectID), 10))
+	return err
+}
+
+// projects looks projects up by string ID in the orchestrator's repository
+func (g *GenerationJobs) projects(ctx context.Context) ProjectLookup {
+	return projectLookupFunc(func(id string) (*domain.Project, error) {
+		projectID, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid project ID %q: %w", id, domain.ErrProjectNotFound)
+		}
+		return g.orchestrator.projectRepo.GetByID(ctx, uint(projectID))
+	})
+}
+
+// generationRequestFromJob rebuilds the generation request from the job payload
+func generationRequestFromJob(job *domain.Job) (*GenerationRequest, error) {
+	if job.Payload == nil {
+		return nil, fmt.Errorf("job %s has no payload", job.ID)
+	}
+
+	var payload GenerationPayload
+	if err := decodeJobMap(*job.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+
+	return &GenerationRequest{
+		ProjectID:        payload.ProjectID,
+		ContentPath:      payload.ContentPath,
+		OutputFormats:    payload.OutputFormats,
+		OverridePipeline: payload.OverridePipeline,
+		CustomDesign:     payload.CustomDesign,
+		JobID:            job.ID,
+		RevisionID:       job.RevisionID,
+	}, nil
+}
+
+// newGenerationJobResult keeps the parts of a generation worth storing
+func newGenerationJobResult(result *GenerationResult) GenerationJobResult {
+	output := GenerationJobResult{
+		Pipeline:       result.Pipeline,
+		OutputFiles:    result.OutputFiles,
+		OutputURLs:     result.OutputURLs,
+		DesignMetadata: result.DesignMetadata,
+		CompileErrors:  result.CompileErrors,
+		Warnings:       result.Warnings,
+		Images:         result.Images,
+	}
+
+	if m := result.Metrics; m != nil {
+		output.Metrics = &JobMetrics{
+			DurationMs:         m.Duration.Milliseconds(),
+			ContentAnalysisMs:  m.ContentAnalysisMs,
+			DesignGenerationMs: m.DesignGenerationMs,
+			RenderingMs:        m.RenderingMs,
+			ValidationMs:       m.ValidationMs,
+			TotalPages:         m.TotalPages,
+			FileSize:           m.FileSize,
+			QualityScore:       m.QualityScore,
+		}
+	}
+
+	return output
+}
+
+// toJobMap converts a value to the generic map stored in jsonb job columns
+func toJobMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
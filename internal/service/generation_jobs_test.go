@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+func TestGenerationJobs_EnqueueQueuesJob(t *testing.T) {
+	projectRepo := newMockProjectRepository()
+	projectRepo.Create(context.Background(), &domain.Project{ID: 7, UserID: "alice", Title: "Queued Book"})
+
+	jobs := newMockJobStore()
+	runner := NewGenerationJobs(NewBookOrchestrator(projectRepo, &mockAnalysisClient{}, t.TempDir()), jobs)
+
+	job, err := runner.Enqueue(context.Background(), "alice", &GenerationRequest{
+		ProjectID:        7,
+		ContentPath:      "projects/7/manuscript.md",
+		OutputFormats:    []string{"pdf", "epub"},
+		OverridePipeline: "html",
+		CustomDesign:     &DesignOptions{BodyFont: "Garamond"},
+	})
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	// The job waits untouched for a worker to claim it
+	stored, err := runner.Get(context.Background(), "alice", job.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if stored.ProjectID != "7" || stored.Type != domain.JobTypeExport ||
+		stored.Status != domain.JobStatusPending || stored.Attempts != 0 || stored.LeaseOwner != "" {
+		t.Errorf("Expected untouched pending export job, got %+v", stored)
+	}
+
+	// The payload must round-trip into the same request
+	req, err := generationRequestFromJob(stored)
+	if err != nil {
+		t.Fatalf("generationRequestFromJob failed: %v", err)
+	}
+	if req.ProjectID != 7 || req.JobID != job.ID || req.OverridePipeline != "html" ||
+		strings.Join(req.OutputFormats, ",") != "pdf,epub" ||
+		req.CustomDesign == nil || req.CustomDesign.BodyFont != "Garamond" {
+		t.Errorf("Unexpected request from payload: %+v", req)
+	}
+}
+
+func TestGenerationJobs_ScopedToOwner(t *testing.T) {
+	projectRepo := newMockProjectRepository()
+	projectRepo.Create(context.Background(), &domain.Project{ID: 1, UserID: "alice", Title: "Alice's Book"})
+
+	jobs := newMockJobStore()
+	orchestrator := NewBookOrchestrator(projectRepo, &mockAnalysisClient{}, t.TempDir())
+	runner := NewGenerationJobs(orchestrator, jobs)
+
+	if _, err := runner.Enqueue(context.Background(), "bob", &GenerationRequest{ProjectID: 1, OutputFormats: []string{"pdf"}}); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected ErrProjectAccessDenied queueing alice's project, got %v", err)
+	}
+	if _, err := runner.Enqueue(context.Background(), "alice", &GenerationRequest{ProjectID: 2, OutputFormats: []string{"pdf"}}); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Errorf("Expected ErrProjectNotFound for a missing project, got %v", err)
+	}
+
+	job, err := runner.Enqueue(context.Background(), "alice", &GenerationRequest{ProjectID: 1, OutputFormats: []string{"pdf"}})
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if _, err := runner.Get(context.Background(), "bob", job.ID); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected ErrProjectAccessDenied reading alice's job, got %v", err)
+	}
+
+	orchestrator.progress.Save(context.Background(), &GenerationProgress{ProjectID: 1, Status: ProgressStatusProcessing})
+	if _, err := runner.Progress(context.Background(), "bob", 1); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected ErrProjectAccessDenied reading alice's progress, got %v", err)
+	}
+	if progress, err := runner.Progress(context.Background(), "alice", 1); err != nil || progress.Status != ProgressStatusProcessing {
+		t.Errorf("Expected alice to read her progress, got %+v (%v)", progress, err)
+	}
+}
+
+func TestGenerationJobs_ExecuteFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	projectRepo := newMockProjectRepository()
+	projectRepo.Create(context.Background(), &domain.Project{ID: 1, UserID: "alice", Title: "Broken Book"})
+
+	jobs := newMockJobStore()
+	runner := NewGenerationJobs(NewBookOrchestrator(projectRepo, &mockAnalysisClient{
+		analysis: &domain.Analysis{Genre: "Fiction"},
+	}, tmpDir), jobs)
+
+	job, err := runner.Enqueue(context.Background(), "alice", &GenerationRequest{
+		ProjectID:     1,
+		ContentPath:   createTestContent(t, tmpDir),
+		OutputFormats: []string{"docx"},
+	})
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	if _, err := runner.Execute(context.Background(), job); err == nil || !strings.Contains(err.Error(), "unsupported output format") {
+		t.Errorf("Expected failure reason, got %v", err)
+	}
+}
+
+func TestGenerationJobs_ExecuteResult(t *testing.T) {
+	requireTools(t, "pandoc")
+
+	tmpDir := t.TempDir()
+
+	projectRepo := newMockProjectRepository()
+	projectRepo.Create(context.Background(), &domain.Project{ID: 1, UserID: "alice", Title: "Finished Book", Author: "Author"})
+
+	jobs := newMockJobStore()
+	runner := NewGenerationJobs(NewBookOrchestrator(projectRepo, &mockAnalysisClient{
+		analysis: &domain.Analysis{Genre: "Fiction"},
+	}, tmpDir), jobs)
+
+	job, err := runner.Enqueue(context.Background(), "alice", &GenerationRequest{
+		ProjectID:     1,
+		ContentPath:   createTestContent(t, tmpDir),
+		OutputFormats: []string{"epub"},
+	})
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	result, err := runner.Execute(context.Background(), job)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	outputs, ok := result["output_files"].(map[string]interface{})
+	if !ok || outputs["epub"] == "" {
+		t.Errorf("Expected epub output in result, got %v", result["output_files"])
+	}
+	if _, ok := result["metrics"].(map[string]interface{}); !ok {
+		t.Errorf("Expected metrics in result, got %v", result["metrics"])
+	}
+	if _, ok := result["design_metadata"].(map[string]interface{}); !ok {
+		t.Errorf("Expected design metadata in result, got %v", result["design_metadata"])
+	}
+}
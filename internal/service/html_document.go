@@ -0,0 +1,202 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	htmlpipeline "github.com/JuanCS-Dev/typecraft/internal/pipeline/html"
+	"github.com/JuanCS-Dev/typecraft/internal/pipeline/html/paged"
+	"github.com/JuanCS-Dev/typecraft/pkg/design"
+	"github.com/JuanCS-Dev/typecraft/pkg/pipeline"
+)
+
+const (
+	// htmlBaseFontSize is the body size (pt) the typographic scale starts from
+	htmlBaseFontSize = 11.0
+	// htmlScaleRatio is the minor third, a calm scale for book headings
+	htmlScaleRatio = 1.2
+	// htmlLineHeight is the default leading for body text
+	htmlLineHeight = 1.5
+)
+
+// buildBookCSS generates the print stylesheet for the HTML pipeline.
+// Page size and margins follow the Van de Graaf canon for the project's trim
+// size; fonts and colors come from the design. Page numbers and running headers
+// are appended as CSS Paged Media rules for Paged.js.
+func buildBookCSS(project *domain.Project, designResult *design.DesignResult) (string, error) {
+	width, height, ok := htmlpipeline.GetPageSize(project.PageFormat)
+	if !ok {
+		width, height, _ = htmlpipeline.GetPageSize(defaultPageFormat)
+	}
+
+	generator := htmlpipeline.NewCSSGenerator(htmlpipeline.CSSConfig{
+		Canon:      htmlpipeline.CalculateVanDeGraaf(width, height),
+		Grid:       htmlpipeline.NewGrid(htmlpipeline.GridSingleColumn),
+		FontFamily: cssFontStack(designResult.Fonts.Body, "serif"),
+		FontSize:   htmlBaseFontSize,
+		LineHeight: htmlLineHeight,
+		Colors:     cssColorPalette(designResult.Colors),
+		Typography: htmlpipeline.NewTypographyScale(htmlBaseFontSize, htmlScaleRatio),
+	})
+
+	css, err := generator.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	// Size and margins already come from the canon, only numbering and headers are added
+	pageOptions := paged.PageOptions{
+		RunningHeaders: true,
+		PageNumbers: paged.PageNumberOptions{
+			Enabled:  true,
+			Format:   "decimal",
+			Position: "bottom-center",
+		},
+	}
+
+	var sb strings.Builder
+	sb.WriteString(css)
+	sb.WriteString("\n/* === HEADINGS === */\n")
+	fmt.Fprintf(&sb, "h1, h2, h3, h4, h5, h6 {\n  font-family: %s;\n}\n\n",
+		cssFontStack(designResult.Fonts.Heading, "sans-serif"))
+	sb.WriteString("h1 {\n  break-before: right;\n}\n\n")
+	sb.WriteString(htmlStructureCSS)
+	sb.WriteString(pageOptions.GeneratePagedCSS())
+
+	return sb.String(), nil
+}
+
+// cssFontStack quotes a font name and appends a generic fallback family
+func cssFontStack(font, fallback string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`"'\;{}<>`, r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(font))
+
+	if name == "" {
+		return fallback
+	}
+	return fmt.Sprintf("'%s', %s", name, fallback)
+}
+
+// cssColorPalette maps the design colors onto the CSS palette.
+// The first color is the primary (headings), the second the secondary and the
+// optional third the accent; text and background keep the neutral defaults.
+func cssColorPalette(colors []string) htmlpipeline.ColorPalette {
+	palette := htmlpipeline.DefaultColorPalette()
+
+	targets := []*string{&palette.Primary, &palette.Secondary, &palette.Accent}
+	for i, target := range targets {
+		if i >= len(colors) {
+			break
+		}
+		if hex, ok := parseHexColor(colors[i]); ok {
+			*target = "#" + hex
+		}
+	}
+
+	return palette
+}
+
+// htmlStructureCSS styles the table of contents and part dividers
+const htmlStructureCSS = `/* === STRUCTURE === */
+.toc {
+  break-after: right;
+}
+
+.toc ul {
+  list-style: none;
+  padding-left: 0;
+}
+
+.toc .toc-part {
+  font-weight: bold;
+  margin-top: 1em;
+}
+
+.toc a {
+  color: var(--color-text);
+}
+
+.toc a::after {
+  content: leader('.') target-counter(attr(href), page);
+}
+
+.section-part {
+  break-before: right;
+  break-after: page;
+  text-align: center;
+}
+
+.section-part h1 {
+  margin-top: 30%;
+}
+
+`
+
+// tocTitles holds the table of contents heading per project language
+var tocTitles = map[string]string{
+	"pt": "Sumário",
+	"es": "Índice",
+	"fr": "Table des matières",
+	"de": "Inhaltsverzeichnis",
+	"it": "Indice",
+}
+
+// htmlStructuredMarkdown wraps each section body in a <section> element typed
+// after pipeline.BookSection.Type (section-chapter, section-preface, ...) and
+// prepends a table of contents whose page numbers Paged.js resolves.
+// The structure is emitted as raw HTML blocks so Pandoc converts the whole book
+// in a single pass.
+func htmlStructuredMarkdown(project *domain.Project, sections []pipeline.BookSection) string {
+	var sb strings.Builder
+	writeRaw := func(markup string) {
+		sb.WriteString("```{=html}\n")
+		sb.WriteString(markup)
+		sb.WriteString("\n```\n\n")
+	}
+
+	writeRaw(htmlTableOfContents(project, sections))
+
+	for i, section := range sections {
+		markup := fmt.Sprintf(`<section class="section-%s" id="section-%d">`, section.Type, i+1)
+		if section.Title != "" {
+			markup += fmt.Sprintf("\n<h1>%s</h1>", html.EscapeString(section.Title))
+		}
+		writeRaw(markup)
+
+		if strings.TrimSpace(section.Content) != "" {
+			sb.WriteString(section.Content)
+			sb.WriteString("\n\n")
+		}
+
+		writeRaw("</section>")
+	}
+
+	return sb.String()
+}
+
+// htmlTableOfContents lists the titled sections, linking to their anchors
+func htmlTableOfContents(project *domain.Project, sections []pipeline.BookSection) string {
+	title, ok := tocTitles[strings.ToLower(project.Language)]
+	if !ok {
+		title = "Contents"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<nav class=\"toc\">\n<h2>%s</h2>\n<ul>\n", title)
+	for i, section := range sections {
+		if section.Title == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "<li class=\"toc-%s\"><a href=\"#section-%d\">%s</a></li>\n",
+			section.Type, i+1, html.EscapeString(section.Title))
+	}
+	sb.WriteString("</ul>\n</nav>")
+
+	return sb.String()
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/pkg/design"
+	"github.com/JuanCS-Dev/typecraft/pkg/pipeline"
+)
+
+func TestBuildBookCSS(t *testing.T) {
+	project := &domain.Project{
+		Title:      "Visual Book",
+		PageFormat: "8.5x11",
+	}
+	designResult := &design.DesignResult{
+		Fonts:  design.Fonts{Body: "Garamond", Heading: "Futura"},
+		Colors: []string{"#2C3E50", "#ecf0f1"},
+	}
+
+	css, err := buildBookCSS(project, designResult)
+	if err != nil {
+		t.Fatalf("buildBookCSS failed: %v", err)
+	}
+
+	expected := []string{
+		"size: 8.50in 11.00in;",
+		"--font-family: 'Garamond', serif;",
+		"font-family: 'Futura', sans-serif;",
+		"--color-primary: #2C3E50;",
+		"--color-secondary: #ECF0F1;",
+		"content: counter(page, decimal);",
+		"string-set: chapter content();",
+	}
+	for _, want := range expected {
+		if !strings.Contains(css, want) {
+			t.Errorf("CSS missing %q", want)
+		}
+	}
+}
+
+func TestBuildBookCSS_UnknownFormat(t *testing.T) {
+	css, err := buildBookCSS(&domain.Project{PageFormat: "unknown"}, &design.DesignResult{})
+	if err != nil {
+		t.Fatalf("buildBookCSS failed: %v", err)
+	}
+
+	if !strings.Contains(css, "size: 6.00in 9.00in;") {
+		t.Error("Expected 6x9 fallback page size")
+	}
+}
+
+func TestCSSFontStack(t *testing.T) {
+	tests := []struct {
+		font     string
+		expected string
+	}{
+		{"EB Garamond", "'EB Garamond', serif"},
+		{"Evil'; } body {", "'Evil  body ', serif"},
+		{"", "serif"},
+	}
+
+	for _, tt := range tests {
+		if got := cssFontStack(tt.font, "serif"); got != tt.expected {
+			t.Errorf("cssFontStack(%q) = %q, want %q", tt.font, got, tt.expected)
+		}
+	}
+}
+
+func TestCSSColorPalette_InvalidColors(t *testing.T) {
+	palette := cssColorPalette([]string{"red", "#00ff00"})
+
+	if palette.Primary != "#2c3e50" {
+		t.Errorf("Expected default primary for invalid color, got %s", palette.Primary)
+	}
+	if palette.Secondary != "#00FF00" {
+		t.Errorf("Expected secondary #00FF00, got %s", palette.Secondary)
+	}
+}
+
+func TestHTMLStructuredMarkdown(t *testing.T) {
+	project := &domain.Project{Language: "pt"}
+	sections := []pipeline.BookSection{
+		{Content: "Epígrafe."},
+		{Title: "Prefácio", Type: pipeline.SectionPreface, Content: "Texto."},
+		{Title: "Rock & Roll", Type: pipeline.SectionChapter, Content: "## Seção\n\nCorpo."},
+	}
+
+	markdown := htmlStructuredMarkdown(project, sections)
+
+	expected := []string{
+		"<h2>Sumário</h2>",
+		"<li class=\"toc-preface\"><a href=\"#section-2\">Prefácio</a></li>",
+		"<section class=\"section-chapter\" id=\"section-3\">",
+		"<h1>Rock &amp; Roll</h1>",
+		"## Seção",
+	}
+	for _, want := range expected {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Structured markdown missing %q", want)
+		}
+	}
+
+	if strings.Contains(markdown, "href=\"#section-1\"") {
+		t.Error("Untitled sections should not appear in the table of contents")
+	}
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // decoded for analysis
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	htmlpipeline "github.com/JuanCS-Dev/typecraft/internal/pipeline/html"
+	"github.com/JuanCS-Dev/typecraft/pkg/design"
+)
+
+const (
+	// printMinDPI is the lowest effective resolution that prints sharp
+	printMinDPI = 300
+	// epubMaxPixels and epubMaxImageBytes bound ePub images: Apple Books
+	// rejects images over 4 megapixels, and large files slow down e-readers
+	epubMaxPixels     = 4_000_000
+	epubMaxImageBytes = 5 << 20
+	// webMaxSide is the longest side of web variants, in pixels
+	webMaxSide = 1600
+	// variantQuality is the JPEG quality of downscaled variants
+	variantQuality = 85
+	// grayTolerance is how far apart (16-bit) the channels of a pixel may be
+	// for it to still count as gray, absorbing compression noise
+	grayTolerance = 2 * 0x101
+)
+
+// Image color modes
+const (
+	ColorModeGrayscale = "grayscale"
+	ColorModeRGB       = "rgb"
+	ColorModeCMYK      = "cmyk"
+)
+
+// ImageReport is the print and ePub readiness of one bundle image.
+// Images are assumed to be fitted to the text block of the trim size (the
+// cover to the whole page), the largest size the renderers place them at.
+type ImageReport struct {
+	Name          string  `json:"name"`
+	Format        string  `json:"format"`                     // png, jpeg, gif, svg or webp
+	Size          int64   `json:"size"`                       // bytes
+	Width         int     `json:"width,omitempty"`            // pixels
+	Height        int     `json:"height,omitempty"`           // pixels
+	ColorMode     string  `json:"color_mode,omitempty"`       // grayscale, rgb or cmyk
+	PlacedWidth   float64 `json:"placed_width_in,omitempty"`  // inches
+	PlacedHeight  float64 `json:"placed_height_in,omitempty"` // inches
+	EffectiveDPI  int     `json:"effective_dpi,omitempty"`
+	LowResolution bool    `json:"low_resolution,omitempty"` // under 300 dpi in print
+	Oversized     bool    `json:"epub_oversized,omitempty"` // over the ePub pixel or byte limit
+	WebURL        string  `json:"web_url,omitempty"`        // downscaled web variant
+	Error         string  `json:"error,omitempty"`          // why the image could not be analyzed
+
+	// Local paths of the generated variants, "" when none was needed
+	webVariant  string
+	epubVariant string
+}
+
+// imageChecker analyzes bundle images for a trim size and writes their
+// downscaled variants under dir/web and dir/epub
+type imageChecker struct {
+	page [2]float64 // trim size, inches
+	text [2]float64 // text block, inches
+	dir  string
+}
+
+// newImageChecker fits images to the text block left by the design margins
+// (mm) on the trim size, falling back to the Van de Graaf canon
+func newImageChecker(pageFormat string, margins design.Margins, dir string) *imageChecker {
+	width, height, ok := htmlpipeline.GetPageSize(pageFormat)
+	if !ok {
+		width, height, _ = htmlpipeline.GetPageSize(defaultPageFormat)
+	}
+
+	c := &imageChecker{page: [2]float64{width, height}, dir: dir}
+	c.text = [2]float64{
+		width - (margins.Left+margins.Right)/25.4,
+		height - (margins.Top+margins.Bottom)/25.4,
+	}
+	if c.text[0] <= 0 || c.text[1] <= 0 || margins == (design.Margins{}) {
+		canon := htmlpipeline.CalculateVanDeGraaf(width, height)
+		c.text = [2]float64{canon.TextBlockWidth, canon.TextBlockHeight}
+	}
+	return c
+}
+
+// check analyzes every image of the bundle. Unreadable images are reported,
+// not returned; errors are failures to write the variants.
+func (c *imageChecker) check(ctx context.Context, assets *BookAssets) ([]ImageReport, error) {
+	reports := make([]ImageReport, 0, len(assets.Files))
+	for _, name := range assets.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report, err := c.checkImage(assets, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write variants of %s: %w", name, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (c *imageChecker) checkImage(assets *BookAssets, name string) (ImageReport, error) {
+	report := ImageReport{Name: name, Format: strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")}
+	if report.Format == "jpg" {
+		report.Format = "jpeg"
+	}
+
+	f, err := os.Open(assets.Path(name))
+	if err != nil {
+		report.Error = err.Error()
+		return report, nil
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil {
+		report.Size = info.Size()
+	}
+
+	switch report.Format {
+	case "svg":
+		// Vector images print sharp at any size
+		return report, nil
+	case "webp":
+		report.Error = "webp images cannot be analyzed"
+		return report, nil
+	}
+
+	img, format, err := image.Decode(f)
+	if err != nil {
+		report.Error = err.Error()
+		return report, nil
+	}
+	report.Format = format
+
+	bounds := img.Bounds()
+	report.Width, report.Height = bounds.Dx(), bounds.Dy()
+	if report.Width == 0 || report.Height == 0 {
+		report.Error = "image is empty"
+		return report, nil
+	}
+	report.ColorMode = colorMode(img)
+
+	area := c.text
+	if name == assets.Cover {
+		area = c.page
+	}
+	inchesPerPixel := math.Min(area[0]/float64(report.Width), area[1]/float64(report.Height))
+	report.PlacedWidth = roundInches(float64(report.Width) * inchesPerPixel)
+	report.PlacedHeight = roundInches(float64(report.Height) * inchesPerPixel)
+	report.EffectiveDPI = int(math.Round(1 / inchesPerPixel))
+	report.LowResolution = report.EffectiveDPI < printMinDPI
+
+	pixels := report.Width * report.Height
+	report.Oversized = pixels > epubMaxPixels || report.Size > epubMaxImageBytes
+
+	// GIFs may be animated: re-encoding would keep only the first frame
+	if format == "gif" {
+		return report, nil
+	}
+
+	if longest := max(report.Width, report.Height); longest > webMaxSide {
+		report.webVariant = filepath.Join(c.dir, "web", filepath.FromSlash(name))
+		scale := float64(webMaxSide) / float64(longest)
+		if err := writeVariant(img, report, scale, report.webVariant); err != nil {
+			return report, err
+		}
+	}
+
+	if report.Oversized {
+		report.epubVariant = filepath.Join(c.dir, "epub", filepath.FromSlash(name))
+		ratio := float64(epubMaxPixels) / float64(pixels)
+		if report.Size > epubMaxImageBytes {
+			ratio = math.Min(ratio, float64(epubMaxImageBytes)/float64(report.Size))
+		}
+		if err := writeVariant(img, report, math.Sqrt(ratio), report.epubVariant); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// checkImages analyzes the images of a bundle manuscript for the project's
+// trim size. Downscaled web and ePub variants are written next to the outputs
+// (the ePub packages its variants), and every finding joins the validation
+// report: issues through the tracker, warnings in the result.
+func (o *BookOrchestrator) checkImages(
+	ctx context.Context,
+	req *GenerationRequest,
+	project *domain.Project,
+	designResult *design.DesignResult,
+	result *GenerationResult,
+	tracker *progressTracker,
+) error {
+	if req.Assets == nil || len(req.Assets.Files) == 0 {
+		return nil
+	}
+
+	dir := o.imagesDir(project.ID)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear image variants: %w", err)
+	}
+	var margins design.Margins
+	if designResult != nil {
+		margins = designResult.Margins
+	}
+
+	reports, err := newImageChecker(project.PageFormat, margins, dir).check(ctx, req.Assets)
+	if err != nil {
+		return err
+	}
+	result.Images = reports
+
+	req.Assets.EPUB = make(map[string]string)
+	for _, report := range reports {
+		if report.epubVariant != "" {
+			req.Assets.EPUB[report.Name] = report.epubVariant
+		}
+		for _, finding := range imageFindings(report, req.OutputFormats) {
+			tracker.issue(ctx, finding.Format, finding.Level, finding.Code, finding.Message)
+			if finding.Level == "warning" {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s %s: %s", finding.Format, finding.Code, finding.Message))
+			}
+		}
+	}
+	return nil
+}
+
+// imagesDir holds the image variants of a project's latest generation
+func (o *BookOrchestrator) imagesDir(projectID uint) string {
+	return filepath.Join(o.outputDir, fmt.Sprintf("project_%d_images", projectID))
+}
+
+// imageFindings lists the issues of an image for the requested formats
+func imageFindings(report ImageReport, formats []string) []ValidationIssueEvent {
+	var findings []ValidationIssueEvent
+	add := func(format, level, code, message string) {
+		findings = append(findings, ValidationIssueEvent{Format: format, Level: level, Code: code, Message: message})
+	}
+
+	if report.Error != "" {
+		add("images", "warning", "image-unreadable", fmt.Sprintf("%s: %s", report.Name, report.Error))
+		return findings
+	}
+	if report.Width == 0 {
+		return findings
+	}
+
+	if containsString(formats, "pdf") {
+		if report.LowResolution {
+			add("pdf", "warning", "image-low-resolution", fmt.Sprintf("%s is %d dpi at %.2fx%.2fin (%dx%d px); print needs %d dpi",
+				report.Name, report.EffectiveDPI, report.PlacedWidth, report.PlacedHeight, report.Width, report.Height, printMinDPI))
+		}
+		if report.ColorMode == ColorModeRGB {
+			add("pdf", "info", "image-rgb", fmt.Sprintf("%s is RGB; it prints in grayscale on a black-and-white interior", report.Name))
+		}
+	}
+
+	if containsString(formats, "epub") {
+		if report.Oversized {
+			message := fmt.Sprintf("%s is %dx%d px (%d KB), over the ePub limit", report.Name, report.Width, report.Height, report.Size>>10)
+			if report.epubVariant != "" {
+				message += "; a downscaled copy is packaged"
+			}
+			add("epub", "warning", "image-oversized", message)
+		}
+		if report.ColorMode == ColorModeCMYK {
+			add("epub", "warning", "image-cmyk", fmt.Sprintf("%s is CMYK; e-readers expect RGB", report.Name))
+		}
+	}
+
+	return findings
+}
+
+// colorMode tells grayscale images, including color-encoded ones whose
+// pixels are all gray, from RGB and CMYK ones
+func colorMode(img image.Image) string {
+	switch m := img.(type) {
+	case *image.Gray, *image.Gray16:
+		return ColorModeGrayscale
+	case *image.CMYK:
+		return ColorModeCMYK
+	case *image.Paletted:
+		for _, c := range m.Palette {
+			if !isGray(c) {
+				return ColorModeRGB
+			}
+		}
+		return ColorModeGrayscale
+	case *image.YCbCr:
+		// Gray pixels carry no chroma
+		for _, planes := range [][]uint8{m.Cb, m.Cr} {
+			for _, v := range planes {
+				if v < 126 || v > 130 {
+					return ColorModeRGB
+				}
+			}
+		}
+		return ColorModeGrayscale
+	}
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !isGray(img.At(x, y)) {
+				return ColorModeRGB
+			}
+		}
+	}
+	return ColorModeGrayscale
+}
+
+func isGray(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return absDiff(r, g) <= grayTolerance && absDiff(g, b) <= grayTolerance
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// writeVariant scales an image down and encodes it in its own format (JPEG
+// stays JPEG, PNG keeps its transparency); grayscale images stay grayscale
+func writeVariant(img image.Image, report ImageReport, scale float64, dest string) error {
+	width := max(1, int(float64(report.Width)*scale))
+	height := max(1, int(float64(report.Height)*scale))
+	variant := downscale(img, width, height)
+	if report.ColorMode == ColorModeGrayscale {
+		gray := image.NewGray(variant.Bounds())
+		draw.Draw(gray, gray.Bounds(), variant, image.Point{}, draw.Src)
+		variant = gray
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	if report.Format == "jpeg" {
+		err = jpeg.Encode(out, variant, &jpeg.Options{Quality: variantQuality})
+	} else {
+		err = png.Encode(out, variant)
+	}
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// downscale resizes an image by averaging the source pixels each target
+// pixel covers (a box filter), which avoids the aliasing of plain sampling
+func downscale(src image.Image, width, height int) image.Image {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA64(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*sh/height
+		y1 := max(bounds.Min.Y+(y+1)*sh/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*sw/width
+			x1 := max(bounds.Min.X+(x+1)*sw/width, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+// roundInches rounds a placed size to hundredths of an inch
+func roundInches(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JuanCS-Dev/typecraft/pkg/design"
+)
+
+// writeTestImage encodes an image into dir, as JPEG or PNG by extension
+func writeTestImage(t *testing.T, dir, name string, img image.Image) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(name, ".jpg") {
+		err = jpeg.Encode(f, img, nil)
+	} else {
+		err = png.Encode(f, img)
+	}
+	if err != nil {
+		t.Fatalf("Encode %s failed: %v", name, err)
+	}
+}
+
+// filledImage is an RGBA image of one color
+func filledImage(width, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func decodeTestImage(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open variant failed: %v", err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatalf("Decode variant failed: %v", err)
+	}
+	return img
+}
+
+func TestImageChecker_Check(t *testing.T) {
+	dir := t.TempDir()
+	writeTestImage(t, dir, "low.png", filledImage(600, 400, color.RGBA{200, 30, 30, 255}))
+	writeTestImage(t, dir, "big.jpg", image.NewGray(image.Rect(0, 0, 3000, 2000)))
+	writeTestImage(t, dir, "cover.png", filledImage(1800, 2700, color.RGBA{90, 90, 90, 255}))
+	os.WriteFile(filepath.Join(dir, "diagram.svg"), []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.png"), []byte("not a png"), 0644)
+
+	assets := &BookAssets{
+		Dir:   dir,
+		Files: []string{"low.png", "big.jpg", "cover.png", "diagram.svg", "broken.png"},
+		Cover: "cover.png",
+	}
+	margins := design.Margins{Top: 20, Bottom: 30, Left: 20, Right: 20}
+	variants := filepath.Join(t.TempDir(), "variants")
+
+	reports, err := newImageChecker("6x9", margins, variants).check(context.Background(), assets)
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	if len(reports) != 5 {
+		t.Fatalf("Expected 5 reports, got %d", len(reports))
+	}
+
+	// 600 px across a 6in page less 40mm of margins: 136 dpi
+	low := reports[0]
+	if low.EffectiveDPI != 136 || !low.LowResolution || low.ColorMode != ColorModeRGB {
+		t.Errorf("Unexpected low.png report: %+v", low)
+	}
+	if low.PlacedWidth != 4.43 || low.webVariant != "" || low.epubVariant != "" {
+		t.Errorf("Expected no variants and a 4.43in width, got %+v", low)
+	}
+
+	big := reports[1]
+	if big.Format != "jpeg" || big.ColorMode != ColorModeGrayscale || big.LowResolution || !big.Oversized {
+		t.Errorf("Unexpected big.jpg report: %+v", big)
+	}
+	web := decodeTestImage(t, big.webVariant)
+	if web.Bounds().Dx() != webMaxSide || web.Bounds().Dy() != 1066 {
+		t.Errorf("Expected a 1600x1066 web variant, got %v", web.Bounds())
+	}
+	epub := decodeTestImage(t, big.epubVariant)
+	if pixels := epub.Bounds().Dx() * epub.Bounds().Dy(); pixels > epubMaxPixels {
+		t.Errorf("ePub variant has %d pixels", pixels)
+	}
+	if _, ok := epub.(*image.Gray); !ok {
+		t.Errorf("Expected a grayscale ePub variant, got %T", epub)
+	}
+
+	// The cover fills the page: 1800 px over 6in
+	cover := reports[2]
+	if cover.EffectiveDPI != 300 || cover.LowResolution || cover.ColorMode != ColorModeGrayscale {
+		t.Errorf("Unexpected cover report: %+v", cover)
+	}
+
+	if svg := reports[3]; svg.Error != "" || svg.Width != 0 {
+		t.Errorf("Expected SVG to be skipped, got %+v", svg)
+	}
+	if broken := reports[4]; broken.Error == "" {
+		t.Error("Expected broken.png to be reported")
+	}
+}
+
+func TestImageFindings(t *testing.T) {
+	report := ImageReport{
+		Name: "low.png", Width: 600, Height: 400, EffectiveDPI: 136, LowResolution: true,
+		ColorMode: ColorModeRGB, PlacedWidth: 4.43, PlacedHeight: 2.95,
+	}
+
+	codes := func(formats ...string) []string {
+		var got []string
+		for _, finding := range imageFindings(report, formats) {
+			got = append(got, finding.Format+":"+finding.Code)
+		}
+		return got
+	}
+
+	if got := strings.Join(codes("pdf"), ","); got != "pdf:image-low-resolution,pdf:image-rgb" {
+		t.Errorf("Unexpected print findings: %s", got)
+	}
+	if got := codes("epub"); len(got) != 0 {
+		t.Errorf("Expected no ePub findings, got %v", got)
+	}
+
+	report.Oversized = true
+	report.ColorMode = ColorModeCMYK
+	if got := strings.Join(codes("epub"), ","); got != "epub:image-oversized,epub:image-cmyk" {
+		t.Errorf("Unexpected ePub findings: %s", got)
+	}
+}
+
+func TestColorMode(t *testing.T) {
+	palette := image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.Black, color.White})
+	tinted := filledImage(4, 4, color.RGBA{128, 128, 128, 255})
+	tinted.Set(3, 3, color.RGBA{128, 60, 128, 255})
+
+	tests := []struct {
+		name string
+		img  image.Image
+		want string
+	}{
+		{"gray", image.NewGray(image.Rect(0, 0, 2, 2)), ColorModeGrayscale},
+		{"cmyk", image.NewCMYK(image.Rect(0, 0, 2, 2)), ColorModeCMYK},
+		{"gray palette", palette, ColorModeGrayscale},
+		{"gray pixels", filledImage(4, 4, color.RGBA{50, 50, 50, 255}), ColorModeGrayscale},
+		{"one color pixel", tinted, ColorModeRGB},
+		{"ycbcr", image.NewYCbCr(image.Rect(0, 0, 2, 2), image.YCbCrSubsampleRatio444), ColorModeRGB},
+	}
+	for _, tt := range tests {
+		if got := colorMode(tt.img); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestEpubImages_UsesVariants(t *testing.T) {
+	assets := &BookAssets{
+		Dir:   "/bundle",
+		Files: []string{"images/big.jpg"},
+		EPUB:  map[string]string{"images/big.jpg": "/out/epub/images/big.jpg"},
+	}
+
+	images := epubImages(assets)
+	if images.files[0].Path != "/out/epub/images/big.jpg" {
+		t.Errorf("Expected the ePub variant, got %s", images.files[0].Path)
+	}
+	if images.hrefs["images/big.jpg"] != "../Images/001-big.jpg" {
+		t.Errorf("Unexpected href: %v", images.hrefs)
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"path/filepath"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// JobLogStore persists the external tool runs of jobs
+// (implemented by repository.JobLogRepository)
+type JobLogStore interface {
+	Create(log *domain.JobLog) error
+	GetByJobID(jobID string) ([]*domain.JobLog, error)
+	GetByID(jobID, id string) (*domain.JobLog, error)
+}
+
+// JobLogView is a tool run without its outputs, listing the artifacts that
+// can be fetched. Failed runs carry the end of stderr for a quick look.
+type JobLogView struct {
+	*domain.JobLog
+	Artifacts  []string `json:"artifacts"`
+	StderrTail string   `json:"stderr_tail,omitempty"`
+}
+
+// stderrTailSize is how much of a failed run's stderr is inlined in JobLogView
+const stderrTailSize = 4096
+
+// NewJobLogView summarizes a tool run
+func NewJobLogView(entry *domain.JobLog) JobLogView {
+	view := JobLogView{JobLog: entry, Artifacts: entry.Artifacts()}
+	if entry.Failed() {
+		view.StderrTail = entry.Stderr
+		if len(view.StderrTail) > stderrTailSize {
+			view.StderrTail = view.StderrTail[len(view.StderrTail)-stderrTailSize:]
+		}
+	}
+	return view
+}
+
+// NewJobLog records a tool run made by the current attempt of job
+func NewJobLog(job *domain.Job, run *process.Run) *domain.JobLog {
+	entry := &domain.JobLog{
+		ID:         uuid.New().String(),
+		JobID:      job.ID,
+		Attempt:    job.Attempts,
+		Command:    run.Command,
+		Dir:        run.Dir,
+		ExitCode:   run.ExitCode,
+		DurationMs: run.Duration.Milliseconds(),
+		Error:      run.Error,
+		Stdout:     run.Stdout,
+		Stderr:     run.Stderr,
+		Truncated:  run.Truncated,
+		StartedAt:  run.StartedAt,
+	}
+	if len(run.Command) > 0 {
+		entry.Tool = filepath.Base(run.Command[0])
+	}
+	if len(run.Files) > 0 {
+		files := run.Files
+		entry.Files = &files
+	}
+	return entry
+}
+
+// JobLogRecorder saves every tool run made under the returned recorder as a
+// log of job. A failing store is logged and never fails the job itself.
+func JobLogRecorder(store JobLogStore, job *domain.Job) process.Recorder {
+	return process.RecorderFunc(func(run *process.Run) {
+		entry := NewJobLog(job, run)
+		if err := store.Create(entry); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID).Str("tool", entry.Tool).Msg("failed to save tool run")
+		}
+	})
+}
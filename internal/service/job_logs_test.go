@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
+)
+
+// mockJobLogStore implements JobLogStore in memory
+type mockJobLogStore struct {
+	mu   sync.Mutex
+	logs []*domain.JobLog
+}
+
+func newMockJobLogStore(logs ...*domain.JobLog) *mockJobLogStore {
+	return &mockJobLogStore{logs: logs}
+}
+
+func (m *mockJobLogStore) Create(log *domain.JobLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.logs = append(m.logs, log)
+	return nil
+}
+
+func (m *mockJobLogStore) GetByJobID(jobID string) ([]*domain.JobLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var logs []*domain.JobLog
+	for _, log := range m.logs {
+		if log.JobID == jobID {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (m *mockJobLogStore) GetByID(jobID, id string) (*domain.JobLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, log := range m.logs {
+		if log.JobID == jobID && log.ID == id {
+			return log, nil
+		}
+	}
+	return nil, domain.ErrJobLogNotFound
+}
+
+func (m *mockJobLogStore) DeleteByJobIDs(jobIDs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := make(map[string]bool, len(jobIDs))
+	for _, id := range jobIDs {
+		deleted[id] = true
+	}
+	kept := m.logs[:0]
+	for _, log := range m.logs {
+		if !deleted[log.JobID] {
+			kept = append(kept, log)
+		}
+	}
+	m.logs = kept
+	return nil
+}
+
+func TestNewJobLog(t *testing.T) {
+	job := &domain.Job{ID: "job-1", Attempts: 2}
+	started := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	log := NewJobLog(job, &process.Run{
+		Command:   []string{"/usr/bin/lualatex", "-interaction=nonstopmode", "document.tex"},
+		StartedAt: started,
+		Duration:  1500 * time.Millisecond,
+		ExitCode:  1,
+		Error:     "exit status 1",
+		Stderr:    "! Undefined control sequence.",
+		Files:     map[string]string{"document.log": "l.42 \\foo"},
+	})
+
+	if log.ID == "" || log.JobID != "job-1" || log.Attempt != 2 || log.Tool != "lualatex" {
+		t.Errorf("Unexpected log identity: %+v", log)
+	}
+	if log.DurationMs != 1500 || log.ExitCode != 1 || !log.StartedAt.Equal(started) {
+		t.Errorf("Unexpected run details: %+v", log)
+	}
+	if content, ok := log.Artifact("document.log"); !ok || content != "l.42 \\foo" {
+		t.Errorf("Expected document.log artifact, got %q", content)
+	}
+	if got := strings.Join(log.Artifacts(), ","); got != "stdout,stderr,document.log" {
+		t.Errorf("Unexpected artifacts: %s", got)
+	}
+}
+
+func TestJobLogRecorder_SavesRuns(t *testing.T) {
+	store := newMockJobLogStore()
+	job := &domain.Job{ID: "job-1", Attempts: 1}
+	ctx := process.WithRecorder(context.Background(), JobLogRecorder(store, job))
+
+	if err := process.Exec(ctx, process.CommandContext(ctx, "sh", "-c", "echo converted")); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	logs, _ := store.GetByJobID("job-1")
+	if len(logs) != 1 || logs[0].Tool != "sh" || logs[0].Stdout != "converted\n" || logs[0].ExitCode != 0 {
+		t.Errorf("Expected the run saved for the job, got %+v", logs)
+	}
+}
+
+func TestJobService_Logs(t *testing.T) {
+	jobs := newMockJobStore(&domain.Job{ID: "job-1", ProjectID: "1", Status: domain.JobStatusFailed})
+	logs := newMockJobLogStore(
+		&domain.JobLog{ID: "ok", JobID: "job-1", Tool: "pandoc", Stdout: "converted", Stderr: "warning"},
+		&domain.JobLog{ID: "failed", JobID: "job-1", Tool: "pagedjs-cli", ExitCode: 1, Stderr: "TimeoutError: Navigation timeout"},
+	)
+	svc := NewJobService(jobs, logs, newJobProjects(t))
+
+	views, err := svc.Logs(Caller{UserID: "alice"}, "job-1")
+	if err != nil {
+		t.Fatalf("Logs failed: %v", err)
+	}
+	if len(views) != 2 || views[0].StderrTail != "" || views[1].StderrTail != "TimeoutError: Navigation timeout" {
+		t.Errorf("Expected stderr inlined only for the failed run, got %+v", views)
+	}
+	if _, err := svc.Logs(Caller{UserID: "alice"}, "missing"); !errors.Is(err, domain.ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+
+	content, err := svc.LogArtifact(Caller{UserID: "alice"}, "job-1", "ok", "stdout")
+	if err != nil || content != "converted" {
+		t.Errorf("Expected stdout artifact, got %q, %v", content, err)
+	}
+	if _, err := svc.LogArtifact(Caller{UserID: "alice"}, "job-1", "ok", "document.log"); !errors.Is(err, ErrJobLogArtifactNotFound) {
+		t.Errorf("Expected ErrJobLogArtifactNotFound, got %v", err)
+	}
+	if _, err := svc.LogArtifact(Caller{UserID: "alice"}, "job-1", "missing", "stdout"); !errors.Is(err, domain.ErrJobLogNotFound) {
+		t.Errorf("Expected ErrJobLogNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+const (
+	defaultJobListLimit = 50
+	maxJobListLimit     = 200
+)
+
+var (
+	// ErrJobNotRetryable is returned when retrying a job that did not fail or get cancelled
+	ErrJobNotRetryable = errors.New("only failed or cancelled jobs can be retried")
+
+	// ErrJobNotCancellable is returned when cancelling a job that already finished
+	ErrJobNotCancellable = errors.New("only pending or running jobs can be cancelled")
+
+	// ErrJobLogArtifactNotFound is returned for an artifact a tool run did not produce
+	ErrJobLogArtifactNotFound = errors.New("job log artifact not found")
+)
+
+// JobAdminStore is the job persistence used by JobService
+// (implemented by repository.JobRepository)
+type JobAdminStore interface {
+	GetByID(id string) (*domain.Job, error)
+	GetByProjectID(projectID string) ([]*domain.Job, error)
+	List(filter domain.JobFilter) ([]*domain.Job, int64, error)
+	UpdateFrom(job *domain.Job, from domain.JobStatus) error
+}
+
+// JobProjects finds the projects of a user, trashed ones included, to scope
+// job administration to them (implemented by repository.ProjectRepository)
+type JobProjects interface {
+	GetByID(id string) (*domain.Project, error)
+	GetTrashed(id string) (*domain.Project, error)
+	GetAll(userID string, limit, offset int) ([]*domain.Project, int64, error)
+	ListTrash(userID string, limit, offset int) ([]*domain.Project, int64, error)
+}
+
+// JobList is a page of jobs
+type JobList struct {
+	Jobs   []*domain.Job `json:"jobs"`
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// DeadLetter is a job that exhausted its attempts, with what it left behind
+type DeadLetter struct {
+	Job    *domain.Job            `json:"job"`
+	Error  string                 `json:"error"`
+	Output map[string]interface{} `json:"output,omitempty"` // partial result returned by the handler
+	Logs   []JobLogView           `json:"logs"`             // tool runs of the last attempt
+}
+
+// DeadLetterList is a page of dead-letter jobs
+type DeadLetterList struct {
+	Jobs   []DeadLetter `json:"jobs"`
+	Total  int64        `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+// JobService administers the jobs of a user's projects: listing, retrying,
+// cancelling and inspecting the tool runs they recorded. Operators
+// (Caller.IsOperator) administer the jobs of every project.
+// Status changes are compare-and-swap, so a worker finishing the same job wins
+// cleanly instead of being overwritten.
+type JobService struct {
+	jobs     JobAdminStore
+	logs     JobLogStore
+	projects JobProjects
+	events   EventPublisher
+}
+
+// JobServiceOption configures optional JobService dependencies
+type JobServiceOption func(*JobService)
+
+// WithJobEvents publishes retries and cancellations as project events
+func WithJobEvents(events EventPublisher) JobServiceOption {
+	return func(s *JobService) {
+		s.events = events
+	}
+}
+
+// NewJobService creates the job administration service. projects decides
+// who owns a job: the owner of its project.
+func NewJobService(jobs JobAdminStore, logs JobLogStore, projects JobProjects, opts ...JobServiceOption) *JobService {
+	s := &JobService{jobs: jobs, logs: logs, projects: projects}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// List returns the jobs of the caller's projects matching filter, newest first
+func (s *JobService) List(caller Caller, filter domain.JobFilter) (*JobList, error) {
+	filter, err := s.ownedJobFilter(caller, filter)
+	if err != nil {
+		return nil, err
+	}
+	filter = normalizeJobFilter(filter)
+
+	jobs, total, err := s.jobs.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JobList{Jobs: jobs, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// DeadLetters returns the failed jobs of the caller's projects that exhausted
+// MaxAttempts
+func (s *JobService) DeadLetters(caller Caller, filter domain.JobFilter) (*DeadLetterList, error) {
+	filter, err := s.ownedJobFilter(caller, filter)
+	if err != nil {
+		return nil, err
+	}
+	filter.DeadLetter = true
+	filter = normalizeJobFilter(filter)
+
+	jobs, total, err := s.jobs.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &DeadLetterList{Jobs: []DeadLetter{}, Total: total, Limit: filter.Limit, Offset: filter.Offset}
+	for _, job := range jobs {
+		entry := DeadLetter{Job: job, Error: job.ErrorMsg, Logs: []JobLogView{}}
+		if job.Result != nil {
+			entry.Output = *job.Result
+		}
+
+		logs, err := s.logs.GetByJobID(job.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, run := range logs {
+			if run.Attempt == job.Attempts {
+				entry.Logs = append(entry.Logs, NewJobLogView(run))
+			}
+		}
+
+		list.Jobs = append(list.Jobs, entry)
+	}
+	return list, nil
+}
+
+// Retry puts a failed or cancelled job back in the queue with its attempts and
+// error reset. Descendants cancelled because of it are requeued as well.
+func (s *JobService) Retry(caller Caller, id string) (*domain.Job, error) {
+	job, err := s.ownedJob(caller, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != domain.JobStatusFailed && job.Status != domain.JobStatusCancelled {
+		return nil, fmt.Errorf("%w (job %s is %s)", ErrJobNotRetryable, job.ID, job.Status)
+	}
+
+	if err := s.requeue(job); err != nil {
+		return nil, err
+	}
+
+	siblings, err := s.jobs.GetByProjectID(job.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range domain.Descendants(siblings, job.ID) {
+		if child.Status != domain.JobStatusCancelled {
+			continue
+		}
+		if err := s.requeue(child); err != nil && !errors.Is(err, domain.ErrJobConflict) {
+			return nil, err
+		}
+	}
+
+	return job, nil
+}
+
+// Cancel stops a pending or running job and cancels the jobs depending on it.
+// A running job loses its lease; its worker notices on the next heartbeat,
+// kills the job and drops its outcome.
+func (s *JobService) Cancel(caller Caller, id, reason string) (*domain.Job, error) {
+	job, err := s.ownedJob(caller, id)
+	if err != nil {
+		return nil, err
+	}
+	if !job.CanCancel() {
+		return nil, fmt.Errorf("%w (job %s is %s)", ErrJobNotCancellable, job.ID, job.Status)
+	}
+
+	if reason == "" {
+		reason = "cancelled by operator"
+	}
+	if err := s.cancel(job, reason); err != nil {
+		return nil, err
+	}
+
+	siblings, err := s.jobs.GetByProjectID(job.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	childReason := fmt.Sprintf("dependency %s job %s cancelled: %s", job.Type, job.ID, reason)
+	for _, child := range domain.Descendants(siblings, job.ID) {
+		if !child.CanCancel() {
+			continue
+		}
+		if err := s.cancel(child, childReason); err != nil && !errors.Is(err, domain.ErrJobConflict) {
+			return nil, err
+		}
+	}
+
+	return job, nil
+}
+
+// CancelGeneration cancels the pending and running export jobs of a project,
+// whichever process runs them: the worker stops the generation when the
+// job.status event reaches it, or at the latest on its next heartbeat, and
+// restores the project status (see Cancel).
+// ErrGenerationNotRunning means the project has no export job to cancel.
+func (s *JobService) CancelGeneration(caller Caller, projectID string) ([]*domain.Job, error) {
+	if err := s.checkProjectOwner(caller, projectID); err != nil {
+		return nil, err
+	}
+
+	jobs, err := s.jobs.GetByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var cancelled []*domain.Job
+	for _, job := range jobs {
+		if job.Type != domain.JobTypeExport || !job.CanCancel() {
+			continue
+		}
+		job, err := s.Cancel(caller, job.ID, "generation cancelled by user")
+		if errors.Is(err, domain.ErrJobConflict) || errors.Is(err, ErrJobNotCancellable) {
+			// Finished (or cancelled) meanwhile
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cancelled = append(cancelled, job)
+	}
+
+	if len(cancelled) == 0 {
+		return nil, ErrGenerationNotRunning
+	}
+	return cancelled, nil
+}
+
+// Logs returns the tool runs of a job, oldest first
+func (s *JobService) Logs(caller Caller, jobID string) ([]JobLogView, error) {
+	if _, err := s.ownedJob(caller, jobID); err != nil {
+		return nil, err
+	}
+
+	logs, err := s.logs.GetByJobID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]JobLogView, 0, len(logs))
+	for _, run := range logs {
+		views = append(views, NewJobLogView(run))
+	}
+	return views, nil
+}
+
+// LogArtifact returns one output of a tool run: stdout, stderr or a log file
+// the tool wrote (e.g. the LaTeX document.log)
+func (s *JobService) LogArtifact(caller Caller, jobID, logID, name string) (string, error) {
+	if _, err := s.ownedJob(caller, jobID); err != nil {
+		return "", err
+	}
+
+	run, err := s.logs.GetByID(jobID, logID)
+	if err != nil {
+		return "", err
+	}
+
+	content, ok := run.Artifact(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s (available: %v)", ErrJobLogArtifactNotFound, name, run.Artifacts())
+	}
+	return content, nil
+}
+
+// ownedJob loads a job, checking that the caller owns its project
+func (s *JobService) ownedJob(caller Caller, id string) (*domain.Job, error) {
+	job, err := s.jobs.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkProjectOwner(caller, job.ProjectID); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// checkProjectOwner returns ErrProjectAccessDenied unless the caller owns the
+// project, in the trash or not, or is an operator
+func (s *JobService) checkProjectOwner(caller Caller, projectID string) error {
+	_, err := ownedProject(projectLookupFunc(s.anyProject), caller, projectID)
+	return err
+}
+
+// anyProject loads a project, in the trash or not
+func (s *JobService) anyProject(projectID string) (*domain.Project, error) {
+	project, err := s.projects.GetByID(projectID)
+	if errors.Is(err, domain.ErrProjectNotFound) {
+		return s.projects.GetTrashed(projectID)
+	}
+	return project, err
+}
+
+// ownedJobFilter restricts filter to the projects of the caller; operators
+// see every project
+func (s *JobService) ownedJobFilter(caller Caller, filter domain.JobFilter) (domain.JobFilter, error) {
+	if filter.ProjectID != "" {
+		return filter, s.checkProjectOwner(caller, filter.ProjectID)
+	}
+	if caller.IsOperator() {
+		return filter, nil
+	}
+
+	active, _, err := s.projects.GetAll(caller.UserID, -1, 0)
+	if err != nil {
+		return filter, err
+	}
+	trashed, _, err := s.projects.ListTrash(caller.UserID, -1, 0)
+	if err != nil {
+		return filter, err
+	}
+	filter.ProjectIDs = make([]string, 0, len(active)+len(trashed))
+	for _, project := range append(active, trashed...) {
+		filter.ProjectIDs = append(filter.ProjectIDs, strconv.FormatUint(uint64(project.ID), 10))
+	}
+	return filter, nil
+}
+
+func (s *JobService) requeue(job *domain.Job) error {
+	from := job.Status
+	job.Requeue()
+	if err := s.jobs.UpdateFrom(job, from); err != nil {
+		return err
+	}
+	PublishJobStatus(context.Background(), s.events, job)
+	return nil
+}
+
+func (s *JobService) cancel(job *domain.Job, reason string) error {
+	from := job.Status
+	job.MarkCancelled(reason)
+	job.ReleaseLease()
+	if err := s.jobs.UpdateFrom(job, from); err != nil {
+		return err
+	}
+	PublishJobStatus(context.Background(), s.events, job)
+	return nil
+}
+
+// normalizeJobFilter applies the default and maximum page size
+func normalizeJobFilter(filter domain.JobFilter) domain.JobFilter {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultJobListLimit
+	}
+	if filter.Limit > maxJobListLimit {
+		filter.Limit = maxJobListLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return filter
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/repository"
+)
+
+// The methods below make mockJobStore a JobAdminStore
+
+func (m *mockJobStore) GetByProjectID(projectID string) ([]*domain.Job, error) {
+	jobs, _, err := m.List(domain.JobFilter{ProjectID: projectID})
+	return jobs, err
+}
+
+func (m *mockJobStore) List(filter domain.JobFilter) ([]*domain.Job, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var jobs []*domain.Job
+	for _, job := range m.jobs {
+		if filter.Matches(job) {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+
+	total := int64(len(jobs))
+	if filter.Offset > len(jobs) {
+		filter.Offset = len(jobs)
+	}
+	jobs = jobs[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(jobs) {
+		jobs = jobs[:filter.Limit]
+	}
+	return jobs, total, nil
+}
+
+func (m *mockJobStore) UpdateFrom(job *domain.Job, from domain.JobStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.jobs[job.ID]
+	if !ok {
+		return domain.ErrJobNotFound
+	}
+	if stored.Status != from {
+		return domain.ErrJobConflict
+	}
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+// newPipelineJobs returns convert → analyze → design jobs of project 1
+func newPipelineJobs() []*domain.Job {
+	convert := &domain.Job{ID: "a-convert", ProjectID: "1", Type: domain.JobTypeConvert, Status: domain.JobStatusPending, MaxAttempts: 3}
+	analyze := &domain.Job{ID: "b-analyze", ProjectID: "1", Type: domain.JobTypeAnalyze, Status: domain.JobStatusPending, MaxAttempts: 3}
+	design := &domain.Job{ID: "c-design", ProjectID: "1", Type: domain.JobTypeDesign, Status: domain.JobStatusPending, MaxAttempts: 3}
+	analyze.AddDependency(convert)
+	design.AddDependency(analyze)
+	return []*domain.Job{convert, analyze, design}
+}
+
+// newJobProjects returns projects 1 and 2 of alice and project 3 of bob
+func newJobProjects(t *testing.T) *repository.MemoryProjectRepository {
+	t.Helper()
+	projects := repository.NewMemoryProjectRepository()
+	for _, user := range []string{"alice", "alice", "bob"} {
+		if err := projects.Create(&domain.Project{UserID: user, Title: "Book", Author: user}); err != nil {
+			t.Fatalf("Create project failed: %v", err)
+		}
+	}
+	return projects
+}
+
+func TestJobService_ListFilters(t *testing.T) {
+	jobs := newPipelineJobs()
+	jobs[0].Status = domain.JobStatusCompleted
+	other := &domain.Job{ID: "d-export", ProjectID: "2", Type: domain.JobTypeExport, Status: domain.JobStatusPending}
+	svc := NewJobService(newMockJobStore(append(jobs, other)...), newMockJobLogStore(), newJobProjects(t))
+
+	list, err := svc.List(Caller{UserID: "alice"}, domain.JobFilter{Status: domain.JobStatusPending})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if list.Total != 3 || list.Limit != defaultJobListLimit {
+		t.Errorf("Expected 3 pending jobs with default limit, got %d (limit %d)", list.Total, list.Limit)
+	}
+
+	list, _ = svc.List(Caller{UserID: "alice"}, domain.JobFilter{Type: domain.JobTypeExport, Limit: 1000})
+	if list.Total != 1 || list.Jobs[0].ID != "d-export" || list.Limit != maxJobListLimit {
+		t.Errorf("Expected only the export job with capped limit, got %+v", list)
+	}
+
+	list, _ = svc.List(Caller{UserID: "alice"}, domain.JobFilter{ProjectID: "1", Limit: 1, Offset: 1})
+	if list.Total != 3 || len(list.Jobs) != 1 || list.Jobs[0].ID != "b-analyze" {
+		t.Errorf("Expected second page of project 1, got %+v", list)
+	}
+}
+
+func TestJobService_ScopedToOwner(t *testing.T) {
+	mine := &domain.Job{ID: "mine", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusFailed, MaxAttempts: 3}
+	theirs := &domain.Job{ID: "theirs", ProjectID: "3", Type: domain.JobTypeRender, Status: domain.JobStatusFailed, MaxAttempts: 3}
+	projects := newJobProjects(t)
+	svc := NewJobService(newMockJobStore(mine, theirs), newMockJobLogStore(), projects)
+
+	list, err := svc.List(Caller{UserID: "alice"}, domain.JobFilter{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if list.Total != 1 || list.Jobs[0].ID != "mine" {
+		t.Errorf("Expected only alice's job, got %+v", list.Jobs)
+	}
+	if list, _ := svc.List(Caller{UserID: "carol"}, domain.JobFilter{}); list.Total != 0 {
+		t.Errorf("Expected no jobs for a user without projects, got %d", list.Total)
+	}
+	if _, err := svc.List(Caller{UserID: "alice"}, domain.JobFilter{ProjectID: "3"}); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected ErrProjectAccessDenied listing bob's project, got %v", err)
+	}
+
+	if _, err := svc.Retry(Caller{UserID: "alice"}, "theirs"); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected ErrProjectAccessDenied retrying bob's job, got %v", err)
+	}
+	if _, err := svc.Cancel(Caller{UserID: "alice"}, "theirs", ""); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected ErrProjectAccessDenied cancelling bob's job, got %v", err)
+	}
+	if _, err := svc.Logs(Caller{UserID: "alice"}, "theirs"); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected ErrProjectAccessDenied reading bob's job logs, got %v", err)
+	}
+
+	// Jobs of a project in the trash still belong to its owner
+	if err := projects.Trash("1", time.Now()); err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+	if list, _ := svc.List(Caller{UserID: "alice"}, domain.JobFilter{}); list.Total != 1 {
+		t.Errorf("Expected the trashed project's job to stay listed, got %d", list.Total)
+	}
+	if _, err := svc.Retry(Caller{UserID: "alice"}, "mine"); err != nil {
+		t.Errorf("Expected alice to retry her trashed project's job, got %v", err)
+	}
+}
+
+func TestJobService_OperatorSeesEveryProject(t *testing.T) {
+	mine := &domain.Job{ID: "mine", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusFailed, MaxAttempts: 3}
+	theirs := &domain.Job{ID: "theirs", ProjectID: "3", Type: domain.JobTypeRender, Status: domain.JobStatusFailed, MaxAttempts: 3}
+	svc := NewJobService(newMockJobStore(mine, theirs), newMockJobLogStore(), newJobProjects(t))
+	operator := Caller{UserID: "carol", Role: domain.RoleOperator}
+
+	list, err := svc.List(operator, domain.JobFilter{})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if list.Total != 2 {
+		t.Errorf("Expected the operator to see every job, got %d", list.Total)
+	}
+	if list, err := svc.List(operator, domain.JobFilter{ProjectID: "3"}); err != nil || list.Total != 1 {
+		t.Errorf("Expected the operator to list bob's project, got %v", err)
+	}
+
+	if _, err := svc.Retry(operator, "theirs"); err != nil {
+		t.Errorf("Expected the operator to retry bob's job, got %v", err)
+	}
+	if _, err := svc.Cancel(operator, "theirs", ""); err != nil {
+		t.Errorf("Expected the operator to cancel bob's job, got %v", err)
+	}
+}
+
+func TestJobService_DeadLetters(t *testing.T) {
+	exhausted := &domain.Job{ID: "dead", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusFailed,
+		Attempts: 3, MaxAttempts: 3, ErrorMsg: "lualatex exited with status 1",
+		Result: &map[string]interface{}{"compile_errors": []interface{}{"Undefined control sequence"}}}
+	retrying := &domain.Job{ID: "retrying", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusFailed, Attempts: 1, MaxAttempts: 3}
+	logs := newMockJobLogStore(
+		&domain.JobLog{ID: "first", JobID: "dead", Attempt: 2, Tool: "lualatex", ExitCode: 1},
+		&domain.JobLog{ID: "last", JobID: "dead", Attempt: 3, Tool: "lualatex", ExitCode: 1, Stderr: "! Undefined control sequence."},
+	)
+	svc := NewJobService(newMockJobStore(exhausted, retrying), logs, newJobProjects(t))
+
+	list, err := svc.DeadLetters(Caller{UserID: "alice"}, domain.JobFilter{})
+	if err != nil {
+		t.Fatalf("DeadLetters failed: %v", err)
+	}
+	if list.Total != 1 || list.Jobs[0].Job.ID != "dead" {
+		t.Fatalf("Expected only the exhausted job, got %+v", list)
+	}
+	if list.Jobs[0].Error != "lualatex exited with status 1" || list.Jobs[0].Output["compile_errors"] == nil {
+		t.Errorf("Expected error and captured output, got %+v", list.Jobs[0])
+	}
+	if runs := list.Jobs[0].Logs; len(runs) != 1 || runs[0].ID != "last" || runs[0].StderrTail != "! Undefined control sequence." {
+		t.Errorf("Expected the last attempt's tool run with its stderr, got %+v", runs)
+	}
+}
+
+func TestJobService_RetryRequeuesJobAndCancelledDescendants(t *testing.T) {
+	jobs := newPipelineJobs()
+	jobs[0].Status, jobs[0].Attempts, jobs[0].ErrorMsg = domain.JobStatusFailed, 3, "pandoc crashed"
+	jobs[1].MarkCancelled("dependency convert job a-convert failed: pandoc crashed")
+	jobs[2].MarkCancelled("dependency convert job a-convert failed: pandoc crashed")
+	store := newMockJobStore(jobs...)
+	svc := NewJobService(store, newMockJobLogStore(), newJobProjects(t))
+
+	job, err := svc.Retry(Caller{UserID: "alice"}, "a-convert")
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	if job.Status != domain.JobStatusPending || job.Attempts != 0 || job.ErrorMsg != "" || job.CompletedAt != nil {
+		t.Errorf("Expected reset pending job, got %+v", job)
+	}
+
+	for _, id := range []string{"b-analyze", "c-design"} {
+		child, _ := store.GetByID(id)
+		if child.Status != domain.JobStatusPending || child.ErrorMsg != "" {
+			t.Errorf("Expected %s requeued with its parent, got %s %q", id, child.Status, child.ErrorMsg)
+		}
+	}
+
+	if _, err := svc.Retry(Caller{UserID: "alice"}, "a-convert"); !errors.Is(err, ErrJobNotRetryable) {
+		t.Errorf("Expected ErrJobNotRetryable for a pending job, got %v", err)
+	}
+	if _, err := svc.Retry(Caller{UserID: "alice"}, "missing"); !errors.Is(err, domain.ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+}
+
+func TestJobService_CancelCascades(t *testing.T) {
+	jobs := newPipelineJobs()
+	jobs[0].MarkStarted()
+	jobs[0].LeaseOwner = "worker-1"
+	store := newMockJobStore(jobs...)
+	svc := NewJobService(store, newMockJobLogStore(), newJobProjects(t))
+
+	job, err := svc.Cancel(Caller{UserID: "alice"}, "a-convert", "")
+	if err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if job.Status != domain.JobStatusCancelled || job.ErrorMsg != "cancelled by operator" || job.LeaseOwner != "" {
+		t.Errorf("Expected cancelled job without lease, got %s %q owned by %q", job.Status, job.ErrorMsg, job.LeaseOwner)
+	}
+
+	child, _ := store.GetByID("c-design")
+	if child.Status != domain.JobStatusCancelled || !strings.Contains(child.ErrorMsg, "dependency convert job a-convert cancelled") {
+		t.Errorf("Expected descendant cancelled with reason, got %s %q", child.Status, child.ErrorMsg)
+	}
+
+	if _, err := svc.Cancel(Caller{UserID: "alice"}, "a-convert", ""); !errors.Is(err, ErrJobNotCancellable) {
+		t.Errorf("Expected ErrJobNotCancellable for a cancelled job, got %v", err)
+	}
+}
+
+func TestJobService_CancelGeneration(t *testing.T) {
+	running := &domain.Job{ID: "a-export", ProjectID: "1", Type: domain.JobTypeExport, MaxAttempts: 3}
+	running.MarkStarted()
+	running.LeaseOwner = "worker-1"
+	queued := &domain.Job{ID: "b-export", ProjectID: "1", Type: domain.JobTypeExport, Status: domain.JobStatusPending, MaxAttempts: 3}
+	render := &domain.Job{ID: "c-render", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusPending, MaxAttempts: 3}
+	theirs := &domain.Job{ID: "d-export", ProjectID: "3", Type: domain.JobTypeExport, Status: domain.JobStatusPending, MaxAttempts: 3}
+	store := newMockJobStore(running, queued, render, theirs)
+	svc := NewJobService(store, newMockJobLogStore(), newJobProjects(t))
+
+	if _, err := svc.CancelGeneration(Caller{UserID: "alice"}, "3"); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected ErrProjectAccessDenied cancelling bob's generation, got %v", err)
+	}
+
+	cancelled, err := svc.CancelGeneration(Caller{UserID: "alice"}, "1")
+	if err != nil {
+		t.Fatalf("CancelGeneration failed: %v", err)
+	}
+	if len(cancelled) != 2 {
+		t.Fatalf("Expected both export jobs cancelled, got %d", len(cancelled))
+	}
+
+	// The worker running the job loses its lease on the next heartbeat
+	if job, _ := store.GetByID("a-export"); job.Status != domain.JobStatusCancelled || job.LeaseOwner != "" {
+		t.Errorf("Expected running export cancelled without lease, got %s owned by %q", job.Status, job.LeaseOwner)
+	}
+	if job, _ := store.GetByID("c-render"); job.Status != domain.JobStatusPending {
+		t.Errorf("Expected other job types untouched, got %s", job.Status)
+	}
+
+	if _, err := svc.CancelGeneration(Caller{UserID: "alice"}, "1"); !errors.Is(err, ErrGenerationNotRunning) {
+		t.Errorf("Expected ErrGenerationNotRunning, got %v", err)
+	}
+}
+
+func TestJobService_CancelLosesToFinishedWorker(t *testing.T) {
+	jobs := newPipelineJobs()
+	store := newMockJobStore(jobs...)
+	svc := NewJobService(&racingJobStore{mockJobStore: store}, newMockJobLogStore(), newJobProjects(t))
+
+	if _, err := svc.Cancel(Caller{UserID: "alice"}, "a-convert", ""); !errors.Is(err, domain.ErrJobConflict) {
+		t.Errorf("Expected ErrJobConflict, got %v", err)
+	}
+	if job, _ := store.GetByID("a-convert"); job.Status != domain.JobStatusCompleted {
+		t.Errorf("Expected the worker's outcome kept, got %s", job.Status)
+	}
+}
+
+// racingJobStore completes a job right after it is read, as a worker would
+type racingJobStore struct {
+	*mockJobStore
+}
+
+func (r *racingJobStore) GetByID(id string) (*domain.Job, error) {
+	job, err := r.mockJobStore.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	finished := *job
+	finished.MarkCompleted(nil)
+	r.mockJobStore.Update(&finished)
+	return job, nil
+}
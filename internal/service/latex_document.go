@@ -0,0 +1,237 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	htmlpipeline "github.com/JuanCS-Dev/typecraft/internal/pipeline/html"
+	"github.com/JuanCS-Dev/typecraft/pkg/design"
+	"github.com/JuanCS-Dev/typecraft/pkg/latex"
+	"github.com/JuanCS-Dev/typecraft/pkg/pipeline"
+)
+
+// defaultPageFormat is used when a project has no (or an unknown) trim size
+const defaultPageFormat = "6x9"
+
+// pandocCompatPreamble defines the macros pandoc's LaTeX writer emits outside
+// of its own standalone template.
+var pandocCompatPreamble = []string{
+	`\providecommand{\tightlist}{\setlength{\itemsep}{0pt}\setlength{\parskip}{0pt}}`,
+	`\providecommand{\pandocbounded}[1]{#1}`,
+}
+
+// babelLanguages maps project language codes to babel language names
+var babelLanguages = map[string]string{
+	"pt": "brazilian",
+	"en": "english",
+	"es": "spanish",
+	"fr": "french",
+	"de": "ngerman",
+	"it": "italian",
+}
+
+// buildLaTeXDocument assembles the complete LaTeX book from the converted
+// manuscript body and the generated design.
+// Fonts go through fontspec, margins through geometry and colors through xcolor,
+// so the document must be compiled with lualatex (or xelatex).
+func buildLaTeXDocument(project *domain.Project, body string, designResult *design.DesignResult) *latex.Document {
+	builder := latex.NewDocumentBuilder(latex.ClassBook).
+		WithOptions("11pt", "twoside", "openright").
+		WithPackage("fontspec").
+		WithPackage("babel", babelLanguage(project.Language)).
+		WithPackage("geometry", latexGeometryOptions(project.PageFormat, designResult.Margins)...).
+		WithPackage("xcolor").
+		WithPackage("sectsty").
+		WithPackage("graphicx").
+		WithPackage("amsmath").
+		WithPackage("amssymb").
+		WithPackage("longtable").
+		WithPackage("booktabs").
+		WithPackage("array").
+		WithPackage("calc").
+		WithPackage("hyperref", "hidelinks").
+		WithPreamble(latexFontCommands(designResult.Fonts)...).
+		WithPreamble(latexColorCommands(designResult.Colors)...).
+		WithPreamble(`\allsectionsfont{\headingfont\color{typecraftprimary}}`).
+		WithPreamble(pandocCompatPreamble...).
+		WithPreamble(`\date{}`).
+		WithMetadata(latex.DocumentMetadata{
+			Title:  latex.Escape(project.Title),
+			Author: latex.Escape(project.Author),
+		})
+
+	return builder.WithContent(body).Build()
+}
+
+// latexStructuredMarkdown interleaves the book structure, as raw LaTeX blocks,
+// with the section bodies so Pandoc converts the whole book in a single pass.
+// Front matter goes to \frontmatter (unnumbered), chapters and parts to
+// \mainmatter and appendices/glossaries to \backmatter.
+func latexStructuredMarkdown(sections []pipeline.BookSection) string {
+	var sb strings.Builder
+	writeRaw := func(commands ...string) {
+		sb.WriteString("```{=latex}\n")
+		sb.WriteString(strings.Join(commands, "\n"))
+		sb.WriteString("\n```\n\n")
+	}
+
+	writeRaw(`\frontmatter`, `\tableofcontents`)
+
+	inMain, inBack := false, false
+	for _, section := range sections {
+		if !inMain && !section.IsFrontMatter() {
+			writeRaw(`\mainmatter`)
+			inMain = true
+		}
+		if !inBack && section.IsBackMatter() {
+			writeRaw(`\backmatter`)
+			inBack = true
+		}
+
+		if heading := latexSectionHeading(section); len(heading) > 0 {
+			writeRaw(heading...)
+		}
+
+		if strings.TrimSpace(section.Content) != "" {
+			sb.WriteString(section.Content)
+			sb.WriteString("\n\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// latexSectionHeading returns the sectioning commands for a book section.
+// Titles that already carry their label ("Capítulo 1", "Parte II") are set
+// unnumbered so LaTeX does not print the number twice.
+func latexSectionHeading(section pipeline.BookSection) []string {
+	if section.Title == "" {
+		return nil
+	}
+	title := latex.Escape(section.Title)
+
+	switch {
+	case section.Type == pipeline.SectionPart:
+		return []string{
+			fmt.Sprintf(`\part*{%s}`, title),
+			fmt.Sprintf(`\addcontentsline{toc}{part}{%s}`, title),
+		}
+	case section.Type == pipeline.SectionChapter && pipeline.IsChapterHeading(section.Title):
+		return []string{
+			fmt.Sprintf(`\chapter*{%s}`, title),
+			fmt.Sprintf(`\addcontentsline{toc}{chapter}{%s}`, title),
+			fmt.Sprintf(`\markboth{%s}{%s}`, title, title),
+		}
+	default:
+		return []string{fmt.Sprintf(`\chapter{%s}`, title)}
+	}
+}
+
+// latexGeometryOptions converts the trim size and design margins (mm) into
+// geometry package options
+func latexGeometryOptions(pageFormat string, margins design.Margins) []string {
+	width, height, ok := htmlpipeline.GetPageSize(pageFormat)
+	if !ok {
+		width, height, _ = htmlpipeline.GetPageSize(defaultPageFormat)
+	}
+
+	return []string{
+		fmt.Sprintf("paperwidth=%.2fin", width),
+		fmt.Sprintf("paperheight=%.2fin", height),
+		fmt.Sprintf("top=%.1fmm", margins.Top),
+		fmt.Sprintf("bottom=%.1fmm", margins.Bottom),
+		fmt.Sprintf("inner=%.1fmm", margins.Left),
+		fmt.Sprintf("outer=%.1fmm", margins.Right),
+	}
+}
+
+// latexFontCommands selects body and heading fonts through fontspec.
+// Fonts missing on the host fall back to Latin Modern instead of aborting the build.
+func latexFontCommands(fonts design.Fonts) []string {
+	commands := make([]string, 0, 2)
+
+	if body := sanitizeFontName(fonts.Body); body != "" {
+		commands = append(commands,
+			fmt.Sprintf(`\IfFontExistsTF{%s}{\setmainfont{%s}}{}`, body, body))
+	}
+
+	if heading := sanitizeFontName(fonts.Heading); heading != "" {
+		commands = append(commands,
+			fmt.Sprintf(`\IfFontExistsTF{%s}{\newfontfamily\headingfont{%s}}{\let\headingfont\sffamily}`, heading, heading))
+	} else {
+		commands = append(commands, `\let\headingfont\sffamily`)
+	}
+
+	return commands
+}
+
+// latexColorCommands defines the design palette as xcolor colors.
+// The first color is the primary (headings), the second the secondary.
+func latexColorCommands(colors []string) []string {
+	names := []string{"typecraftprimary", "typecraftsecondary"}
+	commands := make([]string, 0, len(names))
+
+	for i, name := range names {
+		hex := "000000"
+		if i < len(colors) {
+			if parsed, ok := parseHexColor(colors[i]); ok {
+				hex = parsed
+			}
+		}
+		commands = append(commands, fmt.Sprintf(`\definecolor{%s}{HTML}{%s}`, name, hex))
+	}
+
+	return commands
+}
+
+// babelLanguage returns the babel option for a project language code
+func babelLanguage(language string) string {
+	if name, ok := babelLanguages[strings.ToLower(language)]; ok {
+		return name
+	}
+	return "english"
+}
+
+// parseHexColor normalizes "#RRGGBB" / "RRGGBB" into upper-case "RRGGBB"
+func parseHexColor(color string) (string, bool) {
+	hex := strings.TrimPrefix(strings.TrimSpace(color), "#")
+	if len(hex) != 6 {
+		return "", false
+	}
+	for _, r := range hex {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return "", false
+		}
+	}
+	return strings.ToUpper(hex), true
+}
+
+// sanitizeFontName strips characters that would break a LaTeX argument
+func sanitizeFontName(name string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`{}\%#$&^_~`, r) {
+			return -1
+		}
+		return r
+	}, name))
+}
+
+// latexImageTypes are the image formats lualatex can include
+var latexImageTypes = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".pdf": true}
+
+// latexImageWarnings reports bundle images the LaTeX pipeline cannot include
+func latexImageWarnings(assets *BookAssets) []string {
+	if assets == nil {
+		return nil
+	}
+	var warnings []string
+	for _, name := range assets.Files {
+		if name == assets.Cover || latexImageTypes[strings.ToLower(path.Ext(name))] {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("image %s: LaTeX cannot include %s images; use PNG, JPEG or PDF", name, path.Ext(name)))
+	}
+	return warnings
+}
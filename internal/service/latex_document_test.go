@@ -0,0 +1,120 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/pkg/design"
+	"github.com/JuanCS-Dev/typecraft/pkg/pipeline"
+)
+
+func TestBuildLaTeXDocument(t *testing.T) {
+	project := &domain.Project{
+		Title:      "Memórias & Sonhos",
+		Author:     "Test Author",
+		Language:   "pt",
+		PageFormat: "6x9",
+	}
+	designResult := &design.DesignResult{
+		Fonts:   design.Fonts{Body: "Garamond", Heading: "Futura"},
+		Colors:  []string{"#2C3E50", "#ecf0f1"},
+		Margins: design.Margins{Top: 30, Bottom: 60, Left: 20, Right: 40},
+	}
+
+	source := buildLaTeXDocument(project, "\\chapter{Um}\nTexto.", designResult).Generate()
+
+	expected := []string{
+		"\\documentclass[11pt,twoside,openright]{book}",
+		"\\usepackage{fontspec}",
+		"\\usepackage[brazilian]{babel}",
+		"\\usepackage[paperwidth=6.00in,paperheight=9.00in,top=30.0mm,bottom=60.0mm,inner=20.0mm,outer=40.0mm]{geometry}",
+		"\\IfFontExistsTF{Garamond}{\\setmainfont{Garamond}}{}",
+		"\\newfontfamily\\headingfont{Futura}",
+		"\\definecolor{typecraftprimary}{HTML}{2C3E50}",
+		"\\definecolor{typecraftsecondary}{HTML}{ECF0F1}",
+		"\\title{Memórias \\& Sonhos}",
+		"\\chapter{Um}",
+	}
+	for _, want := range expected {
+		if !strings.Contains(source, want) {
+			t.Errorf("LaTeX source missing %q", want)
+		}
+	}
+
+	if strings.Index(source, "\\setmainfont") > strings.Index(source, "\\begin{document}") {
+		t.Error("Expected font setup in the preamble")
+	}
+}
+
+func TestLaTeXGeometryOptions_UnknownFormat(t *testing.T) {
+	options := latexGeometryOptions("unknown", design.Margins{Top: 10, Bottom: 10, Left: 10, Right: 10})
+
+	if options[0] != "paperwidth=6.00in" || options[1] != "paperheight=9.00in" {
+		t.Errorf("Expected 6x9 fallback, got %v", options[:2])
+	}
+}
+
+func TestLaTeXColorCommands_InvalidColors(t *testing.T) {
+	commands := latexColorCommands([]string{"red"})
+
+	if len(commands) != 2 {
+		t.Fatalf("Expected 2 color definitions, got %d", len(commands))
+	}
+	for _, command := range commands {
+		if !strings.HasSuffix(command, "{HTML}{000000}") {
+			t.Errorf("Expected black fallback, got %s", command)
+		}
+	}
+}
+
+func TestLaTeXFontCommands_Sanitized(t *testing.T) {
+	commands := latexFontCommands(design.Fonts{Body: "Evil}\\font", Heading: ""})
+
+	if strings.Contains(commands[0], "Evil}") {
+		t.Errorf("Expected braces stripped from font name, got %s", commands[0])
+	}
+	if commands[1] != "\\let\\headingfont\\sffamily" {
+		t.Errorf("Expected sans fallback for empty heading font, got %s", commands[1])
+	}
+}
+
+func TestLaTeXStructuredMarkdown(t *testing.T) {
+	sections := []pipeline.BookSection{
+		{Title: "Prefácio", Type: pipeline.SectionPreface, Content: "Texto."},
+		{Title: "Parte I", Type: pipeline.SectionPart},
+		{Title: "Capítulo 1", Type: pipeline.SectionChapter, Content: "Era uma vez."},
+		{Title: "A Tempestade", Type: pipeline.SectionChapter, Content: "Chuva."},
+		{Title: "Apêndice A", Type: pipeline.SectionAppendix, Content: "Dados."},
+	}
+
+	markdown := latexStructuredMarkdown(sections)
+
+	ordered := []string{
+		"\\frontmatter",
+		"\\tableofcontents",
+		"\\chapter{Prefácio}",
+		"\\mainmatter",
+		"\\part*{Parte I}",
+		"\\chapter*{Capítulo 1}",
+		"Era uma vez.",
+		"\\chapter{A Tempestade}",
+		"\\backmatter",
+		"\\chapter{Apêndice A}",
+	}
+	last := -1
+	for _, want := range ordered {
+		index := strings.Index(markdown, want)
+		if index == -1 {
+			t.Fatalf("Structured markdown missing %q", want)
+		}
+		if index < last {
+			t.Errorf("%q is out of order", want)
+		}
+		last = index
+	}
+
+	if !strings.Contains(markdown, "```{=latex}") {
+		t.Error("Expected structure as raw LaTeX blocks")
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+)
+
+// ErrUnsupportedFormat means a manuscript is not in a format the pipeline can
+// convert, or its content does not match its extension
+var ErrUnsupportedFormat = errors.New("unsupported manuscript format")
+
+// manuscriptFormats maps manuscript extensions to the pandoc reader that
+// converts them to Markdown; "" means the file is used as is
+var manuscriptFormats = map[string]string{
+	".md":       "",
+	".markdown": "",
+	".txt":      "",
+	".docx":     "docx",
+	".odt":      "odt",
+	".rtf":      "rtf",
+	".html":     "html",
+	".htm":      "html",
+	".epub":     "epub",
+}
+
+// sniffLen is how much of a manuscript is inspected before accepting it
+const sniffLen = 512
+
+// manuscriptReader returns the pandoc reader for a manuscript file name;
+// ok is false for unknown extensions
+func manuscriptReader(filename string) (reader string, ok bool) {
+	reader, ok = manuscriptFormats[strings.ToLower(filepath.Ext(filename))]
+	return reader, ok
+}
+
+// supportedManuscript checks that a file name is a bundle or has a known
+// extension whose reader is among the available pandoc input formats
+func supportedManuscript(filename string, inputFormats map[string]bool) error {
+	if isBundle(filename) {
+		return nil
+	}
+	reader, ok := manuscriptReader(filename)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, filepath.Ext(filename))
+	}
+	if reader != "" && !inputFormats[reader] {
+		return fmt.Errorf("%w: %s is not supported by the converter", ErrUnsupportedFormat, reader)
+	}
+	return nil
+}
+
+// sniffManuscript checks that the first bytes of a manuscript look like its
+// extension says, and returns the content type detected
+func sniffManuscript(filename string, head []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	detected := http.DetectContentType(head)
+
+	mismatch := func() (string, error) {
+		return "", fmt.Errorf("%w: content of %q looks like %s", ErrUnsupportedFormat, filename, detected)
+	}
+
+	switch ext {
+	case ".docx", ".odt", ".epub", ".zip":
+		if !bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+			return mismatch()
+		}
+	case ".rtf":
+		if !bytes.HasPrefix(head, []byte(`{\rtf`)) {
+			return mismatch()
+		}
+	case ".html", ".htm":
+		if !strings.HasPrefix(detected, "text/") {
+			return mismatch()
+		}
+	default:
+		// Plain text: valid UTF-8 (the sample may end mid-rune)
+		if !strings.HasPrefix(detected, "text/plain") || !validUTF8Prefix(head) {
+			return mismatch()
+		}
+	}
+
+	return storage.ContentType(filename), nil
+}
+
+// verifyManuscript inspects a complete manuscript: packaged formats must
+// hold the document their extension promises, and bundles chapters matching
+// their manifest
+func verifyManuscript(filename string, r io.ReaderAt, size int64) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext != ".docx" && ext != ".odt" && ext != ".epub" && ext != ".zip" {
+		return nil
+	}
+
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%w: %q is not a valid %s package: %v", ErrUnsupportedFormat, filename, ext, err)
+	}
+
+	switch ext {
+	case ".zip":
+		return verifyBundle(archive)
+	case ".docx":
+		for _, f := range archive.File {
+			if f.Name == "word/document.xml" {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %q has no Word document", ErrUnsupportedFormat, filename)
+	case ".odt":
+		return checkZipMimetype(archive, filename, "application/vnd.oasis.opendocument.text")
+	default:
+		return checkZipMimetype(archive, filename, "application/epub+zip")
+	}
+}
+
+// checkZipMimetype checks the "mimetype" entry of ODF and ePub packages
+func checkZipMimetype(archive *zip.Reader, filename, expected string) error {
+	for _, f := range archive.File {
+		if f.Name != "mimetype" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%w: %q: %v", ErrUnsupportedFormat, filename, err)
+		}
+		data, _ := io.ReadAll(io.LimitReader(rc, 128))
+		rc.Close()
+		if strings.TrimSpace(string(data)) == expected {
+			return nil
+		}
+		return fmt.Errorf("%w: %q is a %s package", ErrUnsupportedFormat, filename, strings.TrimSpace(string(data)))
+	}
+	return fmt.Errorf("%w: %q has no mimetype entry", ErrUnsupportedFormat, filename)
+}
+
+// validUTF8Prefix is utf8.Valid allowing a truncated last rune
+func validUTF8Prefix(data []byte) bool {
+	for i := 0; i < utf8.UTFMax && len(data) > 0; i++ {
+		if utf8.Valid(data) {
+			return true
+		}
+		data = data[:len(data)-1]
+	}
+	return utf8.Valid(data)
+}
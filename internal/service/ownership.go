@@ -0,0 +1,34 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+// ErrProjectAccessDenied means the project, or the resource asked for through
+// it (job, upload, revision, artifact, ...), belongs to another user
+var ErrProjectAccessDenied = errors.New("acesso ao projeto negado")
+
+// projectLookupFunc adapts a function to ProjectLookup, for lookups other than
+// a plain GetByID (trashed projects, context-aware repositories)
+type projectLookupFunc func(id string) (*domain.Project, error)
+
+// GetByID calls f(id)
+func (f projectLookupFunc) GetByID(id string) (*domain.Project, error) {
+	return f(id)
+}
+
+// ownedProject loads a project, checking that the caller owns it. Every
+// service scopes projects through it. Operators own every project; a Caller
+// built from a bare user ID is never one.
+func ownedProject(projects ProjectLookup, caller Caller, projectID string) (*domain.Project, error) {
+	project, err := projects.GetByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project.UserID != caller.UserID && !caller.IsOperator() {
+		return nil, ErrProjectAccessDenied
+	}
+	return project, nil
+}
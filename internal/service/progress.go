@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Generation stages, in the order Generate runs them
+const (
+	StageLoad     = "load"
+	StageAnalyze  = "analyze"
+	StageDesign   = "design"
+	StageImages   = "images" // bundles only
+	StageSelect   = "select"
+	StageRender   = "render"
+	StageValidate = "validate"
+	StageDone     = "done"
+)
+
+// Generation statuses
+const (
+	ProgressStatusProcessing = "processing"
+	ProgressStatusCompleted  = "completed"
+	ProgressStatusFailed     = "failed"
+	ProgressStatusCancelled  = "cancelled"
+)
+
+// stageStart is the overall percentage at which each stage begins.
+// Rendering dominates the runtime, so it owns the 35-90% band, split evenly
+// between the requested formats.
+var stageStart = map[string]int{
+	StageLoad:     0,
+	StageAnalyze:  5,
+	StageDesign:   20,
+	StageImages:   25,
+	StageSelect:   30,
+	StageRender:   35,
+	StageValidate: 90,
+	StageDone:     100,
+}
+
+// progressTTL bounds how long finished progress records are kept
+const progressTTL = 24 * time.Hour
+
+// ErrProgressNotFound is returned when no generation was tracked for a project
+var ErrProgressNotFound = errors.New("generation progress not found")
+
+// GenerationProgress tracks the current state of generation
+type GenerationProgress struct {
+	ProjectID    uint          `json:"project_id"`
+	Status       string        `json:"status"`
+	CurrentStage string        `json:"current_stage"`
+	Progress     int           `json:"progress"`
+	Message      string        `json:"message"`
+	StartedAt    time.Time     `json:"started_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	ETA          time.Duration `json:"eta"`
+}
+
+// estimateETA extrapolates the remaining time from the elapsed time and progress
+func (p *GenerationProgress) estimateETA() time.Duration {
+	if p.Progress <= 0 || p.Progress >= 100 {
+		return 0
+	}
+	elapsed := p.UpdatedAt.Sub(p.StartedAt)
+	return elapsed * time.Duration(100-p.Progress) / time.Duration(p.Progress)
+}
+
+// ProgressStore persists generation progress so any API instance can report it
+type ProgressStore interface {
+	Save(ctx context.Context, progress *GenerationProgress) error
+	Get(ctx context.Context, projectID uint) (*GenerationProgress, error)
+}
+
+// NewProgressStore selects the backend from the configuration: Redis when
+// cfg.UseRedis, in-memory otherwise
+func NewProgressStore(cfg *config.Config) (ProgressStore, error) {
+	if !cfg.UseRedis() {
+		return NewMemoryProgressStore(), nil
+	}
+	return NewRedisProgressStore(cfg.RedisURL)
+}
+
+// MemoryProgressStore keeps progress in process memory (single instance only)
+type MemoryProgressStore struct {
+	mu       sync.RWMutex
+	progress map[uint]GenerationProgress
+}
+
+// NewMemoryProgressStore creates an empty in-memory store
+func NewMemoryProgressStore() *MemoryProgressStore {
+	return &MemoryProgressStore{
+		progress: make(map[uint]GenerationProgress),
+	}
+}
+
+// Save stores a copy of the progress
+func (s *MemoryProgressStore) Save(ctx context.Context, progress *GenerationProgress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress[progress.ProjectID] = *progress
+	return nil
+}
+
+// Get returns a copy of the latest progress for a project
+func (s *MemoryProgressStore) Get(ctx context.Context, projectID uint) (*GenerationProgress, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	progress, ok := s.progress[projectID]
+	if !ok {
+		return nil, ErrProgressNotFound
+	}
+	return &progress, nil
+}
+
+// RedisProgressStore shares progress between API instances through Redis
+type RedisProgressStore struct {
+	client *redis.Client
+}
+
+// NewRedisProgressStore connects to the Redis instance at redisURL
+func NewRedisProgressStore(redisURL string) (*RedisProgressStore, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	return &RedisProgressStore{client: redis.NewClient(opts)}, nil
+}
+
+// Ping checks the Redis connection
+func (s *RedisProgressStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Close releases the Redis connection pool
+func (s *RedisProgressStore) Close() error {
+	return s.client.Close()
+}
+
+// Save stores the progress as JSON, expiring after progressTTL
+func (s *RedisProgressStore) Save(ctx context.Context, progress *GenerationProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to encode progress: %w", err)
+	}
+	if err := s.client.Set(ctx, progressKey(progress.ProjectID), data, progressTTL).Err(); err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	return nil
+}
+
+// Get loads the latest progress for a project
+func (s *RedisProgressStore) Get(ctx context.Context, projectID uint) (*GenerationProgress, error) {
+	data, err := s.client.Get(ctx, progressKey(projectID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrProgressNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load progress: %w", err)
+	}
+
+	var progress GenerationProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to decode progress: %w", err)
+	}
+	return &progress, nil
+}
+
+// progressKey is the Redis key holding a project's progress
+func progressKey(projectID uint) string {
+	return fmt.Sprintf("typecraft:generation:progress:%d", projectID)
+}
+
+// progressTracker publishes the progress of a single Generate call, to the
+// progress store and as project events.
+// Store failures never abort a generation: progress is best effort.
+type progressTracker struct {
+	store    ProgressStore
+	events   EventPublisher
+	progress GenerationProgress
+}
+
+// newProgressTracker starts tracking a generation for a project
+func newProgressTracker(store ProgressStore, projectID uint) *progressTracker {
+	now := time.Now()
+	return &progressTracker{
+		store: store,
+		progress: GenerationProgress{
+			ProjectID: projectID,
+			Status:    ProgressStatusProcessing,
+			StartedAt: now,
+			UpdatedAt: now,
+		},
+	}
+}
+
+// stage reports the start of a generation stage
+func (t *progressTracker) stage(ctx context.Context, stage, message string) {
+	t.update(ctx, stage, stageStart[stage], message)
+}
+
+// render reports the start of rendering the i-th of n output formats
+func (t *progressTracker) render(ctx context.Context, i, n int, format string) {
+	band := stageStart[StageValidate] - stageStart[StageRender]
+	percent := stageStart[StageRender]
+	if n > 0 {
+		percent += band * i / n
+	}
+	t.update(ctx, StageRender, percent, fmt.Sprintf("Rendering %s", format))
+}
+
+// complete marks the generation as finished and publishes its outcome
+func (t *progressTracker) complete(ctx context.Context, result *GenerationResult) {
+	t.progress.Status = ProgressStatusCompleted
+	t.update(ctx, StageDone, 100, "Generation completed")
+
+	if t.events != nil && result != nil {
+		_ = t.events.Publish(context.WithoutCancel(ctx), t.projectKey(), EventGenerationCompleted, newGenerationJobResult(result))
+	}
+}
+
+// fail marks the generation as failed, keeping the stage where it stopped
+func (t *progressTracker) fail(ctx context.Context, err error) {
+	t.progress.Status = ProgressStatusFailed
+	t.update(ctx, t.progress.CurrentStage, t.progress.Progress, err.Error())
+}
+
+// cancel marks the generation as cancelled, keeping the stage where it stopped
+func (t *progressTracker) cancel(ctx context.Context) {
+	t.progress.Status = ProgressStatusCancelled
+	t.update(ctx, t.progress.CurrentStage, t.progress.Progress, "Generation cancelled")
+}
+
+// issue reports a problem found while validating an output format
+func (t *progressTracker) issue(ctx context.Context, format, level, code, message string) {
+	if t == nil || t.events == nil {
+		return
+	}
+	issue := ValidationIssueEvent{Format: format, Level: level, Code: code, Message: message}
+	_ = t.events.Publish(context.WithoutCancel(ctx), t.projectKey(), EventValidationIssue, issue)
+}
+
+func (t *progressTracker) update(ctx context.Context, stage string, percent int, message string) {
+	if t == nil {
+		return
+	}
+
+	t.progress.CurrentStage = stage
+	t.progress.Progress = percent
+	t.progress.Message = message
+	t.progress.UpdatedAt = time.Now()
+	t.progress.ETA = t.progress.estimateETA()
+
+	// Publish even when the generation context was cancelled
+	ctx = context.WithoutCancel(ctx)
+	if t.store != nil {
+		_ = t.store.Save(ctx, &t.progress)
+	}
+	if t.events != nil {
+		_ = t.events.Publish(ctx, t.projectKey(), EventGenerationProgress, t.progress)
+	}
+}
+
+// projectKey is the project ID as used by project events
+func (t *progressTracker) projectKey() string {
+	return strconv.FormatUint(uint64(t.progress.ProjectID), 10)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/config"
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+// recordingProgressStore keeps every published update
+type recordingProgressStore struct {
+	*MemoryProgressStore
+	updates []GenerationProgress
+}
+
+func (s *recordingProgressStore) Save(ctx context.Context, progress *GenerationProgress) error {
+	s.updates = append(s.updates, *progress)
+	return s.MemoryProgressStore.Save(ctx, progress)
+}
+
+func TestMemoryProgressStore(t *testing.T) {
+	store := NewMemoryProgressStore()
+	ctx := context.Background()
+
+	if _, err := store.Get(ctx, 1); !errors.Is(err, ErrProgressNotFound) {
+		t.Fatalf("Expected ErrProgressNotFound, got %v", err)
+	}
+
+	progress := &GenerationProgress{ProjectID: 1, CurrentStage: StageDesign, Progress: 20}
+	if err := store.Save(ctx, progress); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	progress.Progress = 99 // stored copy must not change
+
+	got, err := store.Get(ctx, 1)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.CurrentStage != StageDesign || got.Progress != 20 {
+		t.Errorf("Unexpected progress: %+v", got)
+	}
+}
+
+func TestNewProgressStore(t *testing.T) {
+	store, err := NewProgressStore(&config.Config{})
+	if err != nil {
+		t.Fatalf("NewProgressStore failed: %v", err)
+	}
+	if _, ok := store.(*MemoryProgressStore); !ok {
+		t.Errorf("Expected in-memory store without Redis URL, got %T", store)
+	}
+
+	store, err = NewProgressStore(&config.Config{RedisURL: "redis://localhost:6379/0"})
+	if err != nil {
+		t.Fatalf("NewProgressStore failed: %v", err)
+	}
+	if _, ok := store.(*RedisProgressStore); !ok {
+		t.Errorf("Expected Redis store with Redis URL, got %T", store)
+	}
+
+	if _, err := NewProgressStore(&config.Config{RedisURL: "not-a-url"}); err == nil {
+		t.Error("Expected error for invalid Redis URL")
+	}
+}
+
+func TestRedisProgressStore(t *testing.T) {
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		t.Skip("REDIS_URL not set")
+	}
+
+	store, err := NewRedisProgressStore(redisURL)
+	if err != nil {
+		t.Fatalf("NewRedisProgressStore failed: %v", err)
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.Ping(ctx); err != nil {
+		t.Skipf("Redis not reachable: %v", err)
+	}
+
+	progress := &GenerationProgress{ProjectID: 987654, Status: ProgressStatusProcessing, Progress: 35, ETA: time.Minute}
+	if err := store.Save(ctx, progress); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	got, err := store.Get(ctx, 987654)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Progress != 35 || got.ETA != time.Minute {
+		t.Errorf("Unexpected progress: %+v", got)
+	}
+}
+
+func TestGenerationProgress_EstimateETA(t *testing.T) {
+	start := time.Now()
+	progress := &GenerationProgress{StartedAt: start, UpdatedAt: start.Add(30 * time.Second), Progress: 25}
+
+	if eta := progress.estimateETA(); eta != 90*time.Second {
+		t.Errorf("Expected 90s ETA, got %v", eta)
+	}
+
+	progress.Progress = 100
+	if eta := progress.estimateETA(); eta != 0 {
+		t.Errorf("Expected no ETA when finished, got %v", eta)
+	}
+}
+
+func TestProgressTracker_RenderBand(t *testing.T) {
+	store := NewMemoryProgressStore()
+	tracker := newProgressTracker(store, 1)
+	ctx := context.Background()
+
+	tracker.render(ctx, 1, 2, "epub")
+
+	got, _ := store.Get(ctx, 1)
+	if got.CurrentStage != StageRender || got.Progress != 62 {
+		t.Errorf("Expected render at 62%%, got %s at %d%%", got.CurrentStage, got.Progress)
+	}
+	if got.Message != "Rendering epub" {
+		t.Errorf("Unexpected message: %q", got.Message)
+	}
+}
+
+func TestBookOrchestrator_ProgressStages(t *testing.T) {
+	tmpDir, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	projectRepo := newMockProjectRepository()
+	projectRepo.Create(context.Background(), &domain.Project{ID: 1, Title: "Progress Book", Genre: "Fiction"})
+
+	store := &recordingProgressStore{MemoryProgressStore: NewMemoryProgressStore()}
+	orchestrator := NewBookOrchestrator(projectRepo, &mockAnalysisClient{
+		analysis: &domain.Analysis{Genre: "Fiction", Complexity: 0.5},
+	}, tmpDir, WithProgressStore(store))
+
+	// An unsupported format fails right at the render stage, without external tools
+	_, err := orchestrator.Generate(context.Background(), &GenerationRequest{
+		ProjectID:     1,
+		ContentPath:   createTestContent(t, tmpDir),
+		OutputFormats: []string{"docx"},
+	})
+	if err == nil {
+		t.Fatal("Expected generation to fail")
+	}
+
+	var stages []string
+	for _, update := range store.updates {
+		if len(stages) == 0 || stages[len(stages)-1] != update.CurrentStage {
+			stages = append(stages, update.CurrentStage)
+		}
+	}
+	expected := []string{StageLoad, StageAnalyze, StageDesign, StageSelect, StageRender}
+	if strings.Join(stages, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected stages %v, got %v", expected, stages)
+	}
+
+	progress, err := orchestrator.GetProgress(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetProgress failed: %v", err)
+	}
+	if progress.Status != ProgressStatusFailed || progress.CurrentStage != StageRender {
+		t.Errorf("Expected failed at render, got %s at %s", progress.Status, progress.CurrentStage)
+	}
+	if !strings.Contains(progress.Message, "unsupported output format") {
+		t.Errorf("Expected failure message, got %q", progress.Message)
+	}
+}
+
+func TestBookOrchestrator_GetProgress_NotFound(t *testing.T) {
+	orchestrator := NewBookOrchestrator(newMockProjectRepository(), &mockAnalysisClient{}, t.TempDir())
+
+	if _, err := orchestrator.GetProgress(context.Background(), 42); !errors.Is(err, ErrProgressNotFound) {
+		t.Errorf("Expected ErrProgressNotFound, got %v", err)
+	}
+}
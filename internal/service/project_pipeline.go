@@ -0,0 +1,470 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+	"github.com/JuanCS-Dev/typecraft/pkg/converter"
+	"github.com/JuanCS-Dev/typecraft/pkg/design"
+	"github.com/rs/zerolog/log"
+)
+
+// manuscriptFile is the converted Markdown kept in each project's work dir
+const manuscriptFile = "manuscript.md"
+
+// Bundles are unpacked into bundleDir of the work dir; assetsFile lists the
+// images the renderers need (a BookAssets)
+const (
+	bundleDir  = "bundle"
+	assetsFile = "assets.json"
+)
+
+// ProjectPipeline runs the staged jobs created by ProjectService.StartProcessing
+// (convert → analyze → design → render). Stages hand data to each other through
+// the project (Analysis, DesignConfig, output URLs) and a per-project work dir.
+// With a storage configured on the orchestrator the manuscript is read from it
+// and the converted Markdown and bundle images are kept there too, so stages
+// may run on different machines.
+type ProjectPipeline struct {
+	orchestrator *BookOrchestrator
+	workDir      string
+	searchIndex  ManuscriptIndex
+}
+
+// ManuscriptIndex stores the text of converted manuscripts for project search
+type ManuscriptIndex interface {
+	SetManuscriptText(projectID string, revisionID, text string) error
+}
+
+// ProjectPipelineOption configures a ProjectPipeline
+type ProjectPipelineOption func(*ProjectPipeline)
+
+// WithSearchIndex indexes the text of each converted manuscript, so project
+// search also matches the manuscript contents
+func WithSearchIndex(index ManuscriptIndex) ProjectPipelineOption {
+	return func(p *ProjectPipeline) {
+		p.searchIndex = index
+	}
+}
+
+// NewProjectPipeline creates the stage runner. Analysis, design and rendering
+// reuse the orchestrator; workDir holds the converted manuscripts.
+func NewProjectPipeline(orchestrator *BookOrchestrator, workDir string, opts ...ProjectPipelineOption) *ProjectPipeline {
+	p := &ProjectPipeline{
+		orchestrator: orchestrator,
+		workDir:      workDir,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Convert turns the uploaded manuscript into Markdown. A bundle (ZIP) is
+// unpacked and its chapters joined in manifest order; its images are kept for
+// the render stage. The manuscript is the one recorded in the job payload
+// when processing started (the project's current one for older jobs), so a
+// revision uploaded meanwhile does not change what the job works on.
+func (p *ProjectPipeline) Convert(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+	dir := p.projectDir(project)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create work dir: %w", err)
+	}
+	output := filepath.Join(dir, manuscriptFile)
+
+	source, err := p.fetchManuscript(ctx, project, jobManuscriptURL(job, project))
+	if err != nil {
+		return nil, err
+	}
+	if err := p.clearAssets(ctx, project); err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{"markdown_path": output}
+	if job.RevisionID != "" {
+		result["revision_id"] = job.RevisionID
+	}
+	if isBundle(source) {
+		bundle, err := p.convertBundle(ctx, project, source, output)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range bundle {
+			result[key] = value
+		}
+	} else if err := convertManuscript(ctx, source, output); err != nil {
+		return nil, err
+	}
+
+	if store := p.orchestrator.storage; store != nil {
+		key := storage.ProjectKey(project.ID, "intermediate", manuscriptFile)
+		if _, err := storage.PutFile(ctx, store, key, output); err != nil {
+			return nil, fmt.Errorf("failed to store converted manuscript: %w", err)
+		}
+		result["markdown_url"] = store.URL(key)
+	}
+
+	p.indexManuscript(job, project, output)
+
+	return result, nil
+}
+
+// indexManuscript hands the converted text to the search index. Search is a
+// convenience, so failures are logged and the conversion still succeeds.
+func (p *ProjectPipeline) indexManuscript(job *domain.Job, project *domain.Project, markdownPath string) {
+	if p.searchIndex == nil {
+		return
+	}
+
+	content, err := os.ReadFile(markdownPath)
+	if err == nil {
+		err = p.searchIndex.SetManuscriptText(strconv.FormatUint(uint64(project.ID), 10), job.RevisionID, string(content))
+	}
+	if err != nil {
+		log.Warn().Err(err).Uint("project_id", project.ID).Msg("failed to index manuscript for search")
+	}
+}
+
+// Analyze analyzes the converted manuscript and stores the result on the project
+func (p *ProjectPipeline) Analyze(ctx context.Context, project *domain.Project) (map[string]interface{}, error) {
+	content, err := p.readManuscript(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis, err := p.orchestrator.analysisClient.AnalyzeContent(ctx, content)
+	if err != nil {
+		return nil, fmt.Errorf("content analysis failed: %w", err)
+	}
+
+	stored, err := toJobMap(analysis)
+	if err != nil {
+		return nil, err
+	}
+	project.Analysis = &stored
+
+	return stored, nil
+}
+
+// Design generates the book design from the stored analysis
+func (p *ProjectPipeline) Design(ctx context.Context, project *domain.Project) (map[string]interface{}, error) {
+	analysis, err := projectAnalysis(project)
+	if err != nil {
+		return nil, err
+	}
+
+	designResult, err := p.orchestrator.designService.GenerateDesign(ctx,
+		p.orchestrator.buildDesignRequest(project, analysis, nil))
+	if err != nil {
+		return nil, fmt.Errorf("design generation failed: %w", err)
+	}
+
+	stored, err := toJobMap(designResult)
+	if err != nil {
+		return nil, err
+	}
+	project.DesignConfig = &stored
+
+	return stored, nil
+}
+
+// Render produces the PDF and ePub with the stored design and records their
+// locations on the project. The outputs are stored as artifacts of the
+// render job.
+func (p *ProjectPipeline) Render(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+	analysis, err := projectAnalysis(project)
+	if err != nil {
+		return nil, err
+	}
+	if project.DesignConfig == nil {
+		return nil, fmt.Errorf("project %d has no design yet", project.ID)
+	}
+	var designResult design.DesignResult
+	if err := decodeJobMap(*project.DesignConfig, &designResult); err != nil {
+		return nil, fmt.Errorf("invalid design config: %w", err)
+	}
+
+	content, err := p.readManuscript(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := p.loadAssets(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &GenerationRequest{ProjectID: project.ID, OutputFormats: []string{"pdf", "epub"}, Assets: assets}
+	result := &GenerationResult{
+		ProjectID:      project.ID,
+		Pipeline:       p.orchestrator.selectPipeline(analysis, ""),
+		OutputFiles:    make(map[string]string),
+		DesignMetadata: &designResult,
+		Analysis:       analysis,
+		Metrics:        &GenerationMetrics{},
+	}
+
+	tracker := p.orchestrator.newTracker(project.ID)
+	if err := p.orchestrator.checkImages(ctx, req, project, &designResult, result, tracker); err != nil {
+		tracker.fail(ctx, err)
+		return nil, fmt.Errorf("image check failed: %w", err)
+	}
+	if err := p.orchestrator.renderOutputs(ctx, req, project, content, &designResult, result.Pipeline, result, tracker); err != nil {
+		tracker.fail(ctx, err)
+		return nil, fmt.Errorf("rendering failed: %w", err)
+	}
+	if err := p.orchestrator.validateOutputs(ctx, result, tracker); err != nil {
+		tracker.fail(ctx, err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	result.RevisionID = job.RevisionID
+	if err := p.orchestrator.storeOutputs(ctx, result, job.ID); err != nil {
+		tracker.fail(ctx, err)
+		return nil, err
+	}
+	tracker.complete(ctx, result)
+
+	project.PDFKdpURL = result.OutputURLs["pdf"]
+	project.EpubURL = result.OutputURLs["epub"]
+
+	return toJobMap(newGenerationJobResult(result))
+}
+
+// projectDir is the work dir holding a project's intermediate files
+func (p *ProjectPipeline) projectDir(project *domain.Project) string {
+	return filepath.Join(p.workDir, "projects", fmt.Sprintf("%d", project.ID))
+}
+
+// jobManuscriptURL is the manuscript a job works on
+func jobManuscriptURL(job *domain.Job, project *domain.Project) string {
+	if job.Payload != nil {
+		if manuscriptURL, ok := (*job.Payload)["manuscript_url"].(string); ok && manuscriptURL != "" {
+			return manuscriptURL
+		}
+	}
+	return project.ManuscriptURL
+}
+
+// fetchManuscript returns a local copy of an uploaded manuscript of the
+// project, downloading it into the work dir when it lives in storage
+func (p *ProjectPipeline) fetchManuscript(ctx context.Context, project *domain.Project, manuscriptURL string) (string, error) {
+	if store := p.orchestrator.storage; store != nil {
+		if key, ok := store.Key(manuscriptURL); ok {
+			local := filepath.Join(p.projectDir(project), "source", path.Base(key))
+			if err := storage.Download(ctx, store, key, local); err != nil {
+				return "", fmt.Errorf("failed to fetch manuscript: %w", err)
+			}
+			return local, nil
+		}
+	}
+	return localManuscriptPath(manuscriptURL)
+}
+
+// readManuscript reads the converted Markdown, fetching it from storage when
+// the convert stage ran elsewhere
+func (p *ProjectPipeline) readManuscript(ctx context.Context, project *domain.Project) (string, error) {
+	local := filepath.Join(p.projectDir(project), manuscriptFile)
+	if _, err := os.Stat(local); err != nil && p.orchestrator.storage != nil {
+		key := storage.ProjectKey(project.ID, "intermediate", manuscriptFile)
+		if err := storage.Download(ctx, p.orchestrator.storage, key, local); err != nil {
+			return "", fmt.Errorf("failed to fetch converted manuscript: %w", err)
+		}
+	}
+	return p.orchestrator.readContent(local)
+}
+
+// convertBundle unpacks a bundle, writes its chapters as one Markdown
+// manuscript and keeps its images, locally and in storage
+func (p *ProjectPipeline) convertBundle(ctx context.Context, project *domain.Project, source, output string) (map[string]interface{}, error) {
+	dir := filepath.Join(p.projectDir(project), bundleDir)
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+
+	bundle, err := extractBundle(source, dir)
+	if err != nil {
+		return nil, err
+	}
+	markdown, assets, warnings, err := bundle.assemble(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(output, []byte(markdown), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manuscript: %w", err)
+	}
+	if err := p.saveAssets(ctx, project, assets); err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"chapters": bundle.manifest.Chapters,
+		"assets":   assets.Files,
+	}
+	if assets.Cover != "" {
+		result["cover"] = assets.Cover
+	}
+	if len(warnings) > 0 {
+		result["warnings"] = warnings
+	}
+	return result, nil
+}
+
+// saveAssets records the images of a bundle in the work dir and, with a
+// storage, uploads them under projects/<id>/intermediate/assets/
+func (p *ProjectPipeline) saveAssets(ctx context.Context, project *domain.Project, assets *BookAssets) error {
+	data, err := json.Marshal(assets)
+	if err != nil {
+		return err
+	}
+	index := filepath.Join(p.projectDir(project), assetsFile)
+	if err := os.WriteFile(index, data, 0644); err != nil {
+		return fmt.Errorf("failed to write assets: %w", err)
+	}
+
+	store := p.orchestrator.storage
+	if store == nil {
+		return nil
+	}
+	for _, name := range assets.Files {
+		key := storage.ProjectKey(project.ID, "intermediate", "assets", name)
+		if _, err := storage.PutFile(ctx, store, key, assets.Path(name)); err != nil {
+			return fmt.Errorf("failed to store image %s: %w", name, err)
+		}
+	}
+	if _, err := storage.PutFile(ctx, store, storage.ProjectKey(project.ID, "intermediate", assetsFile), index); err != nil {
+		return fmt.Errorf("failed to store assets: %w", err)
+	}
+	return nil
+}
+
+// clearAssets forgets the images of a previous bundle, so a manuscript
+// replaced by a single file renders without them
+func (p *ProjectPipeline) clearAssets(ctx context.Context, project *domain.Project) error {
+	if err := os.Remove(filepath.Join(p.projectDir(project), assetsFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if store := p.orchestrator.storage; store != nil {
+		err := store.Delete(ctx, storage.ProjectKey(project.ID, "intermediate", assetsFile))
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("failed to clear assets: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadAssets returns the images kept by the convert stage, fetching them from
+// storage when it ran elsewhere; nil when the manuscript was a single file
+func (p *ProjectPipeline) loadAssets(ctx context.Context, project *domain.Project) (*BookAssets, error) {
+	store := p.orchestrator.storage
+	index := filepath.Join(p.projectDir(project), assetsFile)
+	if _, err := os.Stat(index); err != nil {
+		if store == nil {
+			return nil, nil
+		}
+		err := storage.Download(ctx, store, storage.ProjectKey(project.ID, "intermediate", assetsFile), index)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch assets: %w", err)
+		}
+	}
+
+	data, err := os.ReadFile(index)
+	if err != nil {
+		return nil, err
+	}
+	assets := &BookAssets{Dir: filepath.Join(p.projectDir(project), bundleDir)}
+	if err := json.Unmarshal(data, assets); err != nil {
+		return nil, fmt.Errorf("invalid assets: %w", err)
+	}
+
+	for _, name := range assets.Files {
+		local := assets.Path(name)
+		if _, err := os.Stat(local); err == nil || store == nil {
+			continue
+		}
+		if err := storage.Download(ctx, store, storage.ProjectKey(project.ID, "intermediate", "assets", name), local); err != nil {
+			return nil, fmt.Errorf("failed to fetch image %s: %w", name, err)
+		}
+	}
+	return assets, nil
+}
+
+// convertManuscript converts a manuscript file to Markdown with pandoc, or
+// copies it when it already is Markdown or plain text
+func convertManuscript(ctx context.Context, source, output string, options ...string) error {
+	reader, ok := manuscriptReader(source)
+	switch {
+	case !ok:
+		return fmt.Errorf("unsupported manuscript format: %s", filepath.Ext(source))
+	case reader == "":
+		if err := copyFile(source, output); err != nil {
+			return fmt.Errorf("failed to copy manuscript: %w", err)
+		}
+		return nil
+	}
+
+	pandoc, err := converter.NewPandocConverter()
+	if err != nil {
+		return err
+	}
+	err = pandoc.ConvertContext(ctx, converter.ConvertRequest{
+		InputFile:  source,
+		OutputFile: output,
+		FromFormat: reader,
+		ToFormat:   "markdown",
+		Options:    options,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to convert manuscript: %w", err)
+	}
+	return nil
+}
+
+// projectAnalysis decodes the analysis stored by the analyze stage
+func projectAnalysis(project *domain.Project) (*domain.Analysis, error) {
+	if project.Analysis == nil {
+		return nil, fmt.Errorf("project %d has not been analyzed yet", project.ID)
+	}
+	var analysis domain.Analysis
+	if err := decodeJobMap(*project.Analysis, &analysis); err != nil {
+		return nil, fmt.Errorf("invalid project analysis: %w", err)
+	}
+	return &analysis, nil
+}
+
+// localManuscriptPath resolves a manuscript URL to a file on this machine
+func localManuscriptPath(manuscriptURL string) (string, error) {
+	if manuscriptURL == "" {
+		return "", fmt.Errorf("project has no manuscript")
+	}
+
+	u, err := url.Parse(manuscriptURL)
+	if err == nil && u.Scheme != "" && u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported manuscript location: %s", manuscriptURL)
+	}
+	if err == nil && u.Scheme == "file" {
+		return u.Path, nil
+	}
+	return manuscriptURL, nil
+}
+
+// decodeJobMap converts a jsonb map back into a typed value
+func decodeJobMap(m map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+)
+
+func TestProjectPipeline_ConvertAnalyzeDesign(t *testing.T) {
+	tmpDir := t.TempDir()
+	manuscript := createTestContent(t, tmpDir)
+
+	orchestrator := NewBookOrchestrator(newMockProjectRepository(), NewLocalAnalysisClient(), tmpDir)
+	pipeline := NewProjectPipeline(orchestrator, filepath.Join(tmpDir, "work"))
+	project := &domain.Project{ID: 3, Title: "Staged Book", Genre: "Fiction", ManuscriptURL: "file://" + manuscript}
+
+	result, err := pipeline.Convert(context.Background(), &domain.Job{}, project)
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	converted, _ := result["markdown_path"].(string)
+	if data, err := os.ReadFile(converted); err != nil || !strings.Contains(string(data), "Chapter 1") {
+		t.Fatalf("Expected converted manuscript at %q: %v", converted, err)
+	}
+
+	if _, err := pipeline.Analyze(context.Background(), project); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	analysis, err := projectAnalysis(project)
+	if err != nil {
+		t.Fatalf("Expected stored analysis: %v", err)
+	}
+	if !analysis.HasMath {
+		t.Error("Expected the equation to be detected")
+	}
+
+	if _, err := pipeline.Design(context.Background(), project); err != nil {
+		t.Fatalf("Design failed: %v", err)
+	}
+	if project.DesignConfig == nil {
+		t.Fatal("Expected design config on the project")
+	}
+	fonts, _ := (*project.DesignConfig)["fonts"].(map[string]interface{})
+	if fonts["body"] != "Garamond" {
+		t.Errorf("Expected Fiction body font, got %v", fonts["body"])
+	}
+}
+
+func TestProjectPipeline_IndexesManuscript(t *testing.T) {
+	tmpDir := t.TempDir()
+	manuscript := createTestContent(t, tmpDir)
+
+	index := fakeManuscriptIndex{}
+	orchestrator := NewBookOrchestrator(newMockProjectRepository(), NewLocalAnalysisClient(), tmpDir)
+	pipeline := NewProjectPipeline(orchestrator, filepath.Join(tmpDir, "work"), WithSearchIndex(index))
+	project := &domain.Project{ID: 7, ManuscriptURL: "file://" + manuscript}
+
+	if _, err := pipeline.Convert(context.Background(), &domain.Job{RevisionID: "rev-2"}, project); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if text := index["7@rev-2"]; !strings.Contains(text, "Chapter 1") {
+		t.Errorf("Expected the converted text to be indexed, got %q", text)
+	}
+}
+
+// fakeManuscriptIndex records indexed text by "projectID@revisionID"
+type fakeManuscriptIndex map[string]string
+
+func (f fakeManuscriptIndex) SetManuscriptText(projectID string, revisionID, text string) error {
+	f[projectID+"@"+revisionID] = text
+	return nil
+}
+
+func TestProjectPipeline_StorageHandoff(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := storage.NewLocalStorage(filepath.Join(tmpDir, "storage"))
+	if err != nil {
+		t.Fatalf("NewLocalStorage failed: %v", err)
+	}
+	ctx := context.Background()
+
+	manuscript, _ := os.ReadFile(createTestContent(t, tmpDir))
+	key := storage.ProjectKey(5, "manuscript", "book.md")
+	if _, err := store.Put(ctx, key, strings.NewReader(string(manuscript)), -1, ""); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	project := &domain.Project{ID: 5, Genre: "Fiction", ManuscriptURL: store.URL(key)}
+
+	orchestrator := NewBookOrchestrator(newMockProjectRepository(), NewLocalAnalysisClient(), tmpDir, WithStorage(store))
+	converter := NewProjectPipeline(orchestrator, filepath.Join(tmpDir, "converter"))
+	result, err := converter.Convert(ctx, &domain.Job{}, project)
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	intermediate := storage.ProjectKey(5, "intermediate", manuscriptFile)
+	if result["markdown_url"] != store.URL(intermediate) {
+		t.Errorf("Expected the converted manuscript URL, got %v", result["markdown_url"])
+	}
+
+	// A worker with an empty work dir picks the Markdown up from storage
+	analyzer := NewProjectPipeline(orchestrator, filepath.Join(tmpDir, "analyzer"))
+	if _, err := analyzer.Analyze(ctx, project); err != nil {
+		t.Fatalf("Analyze on another work dir failed: %v", err)
+	}
+	if project.Analysis == nil {
+		t.Error("Expected the analysis stored on the project")
+	}
+}
+
+func TestBookOrchestrator_StoreOutputs(t *testing.T) {
+	tmpDir := t.TempDir()
+	pdf := filepath.Join(tmpDir, "project_6.pdf")
+	os.WriteFile(pdf, []byte("%PDF-1.7"), 0644)
+	ctx := context.Background()
+
+	// Without storage the local files are the URLs
+	result := &GenerationResult{ProjectID: 6, OutputFiles: map[string]string{"pdf": pdf}}
+	if err := NewBookOrchestrator(newMockProjectRepository(), nil, tmpDir).storeOutputs(ctx, result, ""); err != nil {
+		t.Fatalf("storeOutputs failed: %v", err)
+	}
+	if result.OutputURLs["pdf"] != pdf {
+		t.Errorf("Expected the local path, got %v", result.OutputURLs)
+	}
+
+	store, _ := storage.NewLocalStorage(filepath.Join(tmpDir, "storage"))
+	orchestrator := NewBookOrchestrator(newMockProjectRepository(), nil, tmpDir, WithStorage(store))
+	if err := orchestrator.storeOutputs(ctx, result, "job-1"); err != nil {
+		t.Fatalf("storeOutputs failed: %v", err)
+	}
+	key := storage.ProjectKey(6, "output", "job-1", "book.pdf")
+	if result.OutputURLs["pdf"] != store.URL(key) {
+		t.Errorf("Expected the stored URL, got %v", result.OutputURLs)
+	}
+	if obj, err := store.Stat(ctx, key); err != nil || obj.Size != 8 {
+		t.Errorf("Expected the stored PDF, got %+v, %v", obj, err)
+	}
+}
+
+func TestProjectPipeline_StageOrder(t *testing.T) {
+	pipeline := NewProjectPipeline(NewBookOrchestrator(newMockProjectRepository(), NewLocalAnalysisClient(), t.TempDir()), t.TempDir())
+	project := &domain.Project{ID: 4}
+
+	if _, err := pipeline.Design(context.Background(), project); err == nil || !strings.Contains(err.Error(), "not been analyzed") {
+		t.Errorf("Expected design to require analysis, got %v", err)
+	}
+	if _, err := pipeline.Analyze(context.Background(), project); err == nil {
+		t.Error("Expected analyze to require a converted manuscript")
+	}
+}
+
+func TestLocalManuscriptPath(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+		wantErr  bool
+	}{
+		{"/data/book.docx", "/data/book.docx", false},
+		{"file:///data/book.md", "/data/book.md", false},
+		{"s3://typecraft-files/manuscripts/1/book.docx", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := localManuscriptPath(tt.url)
+		if (err != nil) != tt.wantErr || got != tt.expected {
+			t.Errorf("localManuscriptPath(%q) = %q, %v", tt.url, got, err)
+		}
+	}
+}
@@ -1,26 +1,78 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/JuanCS-Dev/typecraft/internal/domain"
 	"github.com/JuanCS-Dev/typecraft/internal/repository"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
 	"github.com/google/uuid"
 )
 
 // ProjectService contém a lógica de negócio para projetos
 type ProjectService struct {
-	projectRepo *repository.ProjectRepository
-	jobRepo     *repository.JobRepository
+	projectRepo repository.Projects
+	jobRepo     repository.Jobs
+	events      EventPublisher
+	storage     storage.Storage
+	revisions   RevisionStore
+	retention   time.Duration
 }
 
-// NewProjectService cria uma nova instância do serviço
-func NewProjectService() *ProjectService {
-	return &ProjectService{
-		projectRepo: repository.NewProjectRepository(),
-		jobRepo:     repository.NewJobRepository(),
+// ProjectServiceOption configura dependências opcionais do serviço
+type ProjectServiceOption func(*ProjectService)
+
+// WithProjectEvents publica mudanças de status do projeto e os jobs criados
+// como eventos do projeto
+func WithProjectEvents(events EventPublisher) ProjectServiceOption {
+	return func(s *ProjectService) {
+		s.events = events
+	}
+}
+
+// WithProjectStorage define onde os manuscritos enviados são armazenados
+func WithProjectStorage(store storage.Storage) ProjectServiceOption {
+	return func(s *ProjectService) {
+		s.storage = store
+	}
+}
+
+// WithProjectRevisions guarda cada manuscrito enviado como uma nova revisão
+// numerada, mantendo os arquivos das anteriores
+func WithProjectRevisions(revisions RevisionStore) ProjectServiceOption {
+	return func(s *ProjectService) {
+		s.revisions = revisions
+	}
+}
+
+// WithTrashRetention informa por quanto tempo um projeto excluído fica na
+// lixeira antes de ser expurgado (ver TrashPurger), para exibir a data do
+// expurgo na listagem da lixeira
+func WithTrashRetention(retention time.Duration) ProjectServiceOption {
+	return func(s *ProjectService) {
+		s.retention = retention
+	}
+}
+
+// NewProjectService cria uma nova instância do serviço sobre os repositórios
+// de projetos e jobs
+func NewProjectService(projects repository.Projects, jobs repository.Jobs, opts ...ProjectServiceOption) *ProjectService {
+	s := &ProjectService{
+		projectRepo: projects,
+		jobRepo:     jobs,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // CreateProjectRequest representa os dados para criar um projeto
@@ -75,9 +127,9 @@ func (s *ProjectService) CreateProject(userID string, req CreateProjectRequest)
 	return project, nil
 }
 
-// GetProject busca um projeto por ID
-func (s *ProjectService) GetProject(id string) (*domain.Project, error) {
-	return s.projectRepo.GetByID(id)
+// GetProject busca um projeto de userID por ID
+func (s *ProjectService) GetProject(userID, id string) (*domain.Project, error) {
+	return ownedProject(s.projectRepo, Caller{UserID: userID}, id)
 }
 
 // ListProjects lista projetos com paginação
@@ -93,9 +145,9 @@ func (s *ProjectService) ListProjects(userID string, page, pageSize int) ([]*dom
 	return s.projectRepo.GetAll(userID, pageSize, offset)
 }
 
-// UpdateProject atualiza metadados de um projeto
-func (s *ProjectService) UpdateProject(id string, updates map[string]interface{}) (*domain.Project, error) {
-	project, err := s.projectRepo.GetByID(id)
+// UpdateProject atualiza metadados de um projeto de userID
+func (s *ProjectService) UpdateProject(userID, id string, updates map[string]interface{}) (*domain.Project, error) {
+	project, err := ownedProject(s.projectRepo, Caller{UserID: userID}, id)
 	if err != nil {
 		return nil, err
 	}
@@ -126,43 +178,240 @@ func (s *ProjectService) UpdateProject(id string, updates map[string]interface{}
 	return project, nil
 }
 
-// DeleteProject deleta um projeto e seus jobs
-func (s *ProjectService) DeleteProject(id string) error {
-	// Verificar se existe
-	if _, err := s.projectRepo.GetByID(id); err != nil {
+// DeleteProject move um projeto de userID para a lixeira. Os jobs pendentes
+// ou em andamento são cancelados; jobs, análises, revisões e arquivos só são
+// apagados quando o projeto é expurgado (TrashPurger).
+func (s *ProjectService) DeleteProject(userID, id string) error {
+	if _, err := ownedProject(s.projectRepo, Caller{UserID: userID}, id); err != nil {
 		return err
 	}
-	
-	// Deletar jobs associados
-	if err := s.jobRepo.DeleteByProjectID(id); err != nil {
-		return fmt.Errorf("erro ao deletar jobs do projeto: %w", err)
+	if err := s.projectRepo.Trash(id, time.Now()); err != nil {
+		return err
 	}
 	
-	// Deletar projeto
-	if err := s.projectRepo.Delete(id); err != nil {
-		return fmt.Errorf("erro ao deletar projeto: %w", err)
+	jobs, err := s.jobRepo.GetByProjectID(id)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar jobs do projeto: %w", err)
+	}
+	for _, job := range jobs {
+		if !job.CanCancel() {
+			continue
+		}
+		from := job.Status
+		job.MarkCancelled("project moved to trash")
+		job.ReleaseLease()
+		// Um job que terminou nesse meio tempo fica como está
+		if err := s.jobRepo.UpdateFrom(job, from); err != nil && !errors.Is(err, domain.ErrJobConflict) {
+			return fmt.Errorf("erro ao cancelar job do projeto: %w", err)
+		}
+		PublishJobStatus(context.Background(), s.events, job)
 	}
 	
 	return nil
 }
 
-// SetManuscriptURL atualiza a URL do manuscrito
+// TrashedProject é um projeto na lixeira e quando ele será expurgado
+type TrashedProject struct {
+	*domain.Project
+	PurgeAt *time.Time `json:"purge_at,omitempty"`
+}
+
+// ListTrash lista os projetos do usuário na lixeira, com paginação
+func (s *ProjectService) ListTrash(userID string, page, pageSize int) ([]TrashedProject, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	
+	projects, total, err := s.projectRepo.ListTrash(userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	
+	trashed := make([]TrashedProject, 0, len(projects))
+	for _, project := range projects {
+		item := TrashedProject{Project: project}
+		if s.retention > 0 {
+			item.PurgeAt = project.PurgeAt(s.retention)
+		}
+		trashed = append(trashed, item)
+	}
+	return trashed, total, nil
+}
+
+// RestoreProject tira um projeto de userID da lixeira. Jobs cancelados pela
+// exclusão continuam cancelados e podem ser reenviados pela administração de
+// jobs.
+func (s *ProjectService) RestoreProject(userID, id string) (*domain.Project, error) {
+	if _, err := ownedProject(projectLookupFunc(s.projectRepo.GetTrashed), Caller{UserID: userID}, id); err != nil {
+		return nil, err
+	}
+	if err := s.projectRepo.Restore(id); err != nil {
+		return nil, err
+	}
+	return s.projectRepo.GetByID(id)
+}
+
+// ErrInvalidProjectSort indica uma ordenação desconhecida na busca de projetos
+var ErrInvalidProjectSort = errors.New("ordenação de projetos inválida")
+
+// ProjectSearchResult é uma página da busca de projetos
+type ProjectSearchResult struct {
+	Projects []*domain.Project `json:"projects"`
+	Total    int64             `json:"total"`
+	Limit    int               `json:"limit"`
+	Offset   int               `json:"offset"`
+}
+
+// SearchProjects busca projetos por texto (título, autor, descrição e texto
+// do manuscrito) e filtros. Sem ordenação informada, com texto os mais
+// relevantes vêm primeiro e sem texto os mais recentes; título e autor são
+// ordenados em ordem alfabética.
+func (s *ProjectService) SearchProjects(filter domain.ProjectFilter, ascending *bool) (*ProjectSearchResult, error) {
+	if filter.Sort != "" && !filter.Sort.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidProjectSort, filter.Sort)
+	}
+
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Sort == "" {
+		filter.Sort = domain.ProjectSortCreated
+		if filter.Query != "" {
+			filter.Sort = domain.ProjectSortRelevance
+		}
+	}
+	// Sem texto não há relevância
+	if filter.Sort == domain.ProjectSortRelevance && filter.Query == "" {
+		filter.Sort = domain.ProjectSortCreated
+	}
+
+	filter.Ascending = filter.Sort == domain.ProjectSortTitle || filter.Sort == domain.ProjectSortAuthor
+	if ascending != nil {
+		filter.Ascending = *ascending
+	}
+
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	projects, total, err := s.projectRepo.Search(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &ProjectSearchResult{
+		Projects: projects,
+		Total:    total,
+		Limit:    filter.Limit,
+		Offset:   filter.Offset,
+	}, nil
+}
+
+// SetManuscriptURL atualiza a URL do manuscrito. Um manuscrito apontado
+// diretamente não é uma revisão: o projeto fica sem revisão atual.
 func (s *ProjectService) SetManuscriptURL(projectID, url string) error {
+	return s.setManuscript(projectID, url, "")
+}
+
+// setManuscript aponta o projeto para um manuscrito e a revisão dele
+func (s *ProjectService) setManuscript(projectID, url, revisionID string) error {
 	project, err := s.projectRepo.GetByID(projectID)
 	if err != nil {
 		return err
 	}
 	
+	previous := project.Status
 	project.ManuscriptURL = url
+	project.RevisionID = revisionID
 	project.Status = domain.StatusUploading
 	project.UpdatedAt = time.Now()
 	
-	return s.projectRepo.Update(project)
+	if err := s.projectRepo.Update(project); err != nil {
+		return err
+	}
+	PublishProjectStatus(context.Background(), s.events, project, previous)
+	return nil
+}
+
+// ManuscriptFile é um manuscrito enviado para um projeto
+type ManuscriptFile struct {
+	Filename   string
+	Content    io.Reader
+	Size       int64
+	UploadedBy string // usuário que enviou
+	Note       string // comentário opcional sobre a versão
 }
 
-// StartProcessing inicia o processamento de um projeto
-func (s *ProjectService) StartProcessing(projectID string) error {
+// UploadManuscript armazena o manuscrito enviado e aponta ManuscriptURL para
+// ele. Com revisões (WithProjectRevisions) cada envio vira uma nova revisão,
+// guardada em projects/<id>/revisions/<revisão>/<arquivo> sem apagar as
+// anteriores. Sem elas o arquivo vai para projects/<id>/manuscript/<arquivo>
+// e um manuscrito anterior com outro nome é removido.
+func (s *ProjectService) UploadManuscript(ctx context.Context, projectID string, file ManuscriptFile) (*domain.Project, error) {
+	if s.storage == nil {
+		return nil, fmt.Errorf("armazenamento de arquivos não configurado")
+	}
+
 	project, err := s.projectRepo.GetByID(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := path.Base(strings.ReplaceAll(file.Filename, "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		return nil, fmt.Errorf("nome de arquivo inválido: %q", file.Filename)
+	}
+
+	if s.revisions == nil {
+		key := storage.ProjectKey(project.ID, "manuscript", name)
+		if _, err := s.storage.Put(ctx, key, file.Content, file.Size, storage.ContentType(name)); err != nil {
+			return nil, fmt.Errorf("erro ao armazenar manuscrito: %w", err)
+		}
+		if oldKey, ok := s.storage.Key(project.ManuscriptURL); ok && oldKey != key {
+			s.storage.Delete(ctx, oldKey)
+		}
+		if err := s.SetManuscriptURL(projectID, s.storage.URL(key)); err != nil {
+			return nil, err
+		}
+		return s.projectRepo.GetByID(projectID)
+	}
+
+	revision := &domain.Revision{
+		ID:         uuid.New().String(),
+		ProjectID:  project.ID,
+		Filename:   name,
+		UploadedBy: file.UploadedBy,
+		Note:       file.Note,
+	}
+	key := storage.ProjectKey(project.ID, "revisions", revision.ID, name)
+	hash := sha256.New()
+	object, err := s.storage.Put(ctx, key, io.TeeReader(file.Content, hash), file.Size, storage.ContentType(name))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao armazenar manuscrito: %w", err)
+	}
+	revision.Size = object.Size
+	revision.Checksum = hex.EncodeToString(hash.Sum(nil))
+	revision.ManuscriptURL = s.storage.URL(key)
+
+	if err := s.revisions.Create(revision); err != nil {
+		s.storage.Delete(ctx, key)
+		return nil, err
+	}
+	if err := s.setManuscript(projectID, revision.ManuscriptURL, revision.ID); err != nil {
+		return nil, err
+	}
+	return s.projectRepo.GetByID(projectID)
+}
+
+// StartProcessing inicia o processamento de um projeto de userID
+func (s *ProjectService) StartProcessing(userID, projectID string) error {
+	project, err := ownedProject(s.projectRepo, Caller{UserID: userID}, projectID)
 	if err != nil {
 		return err
 	}
@@ -173,12 +422,15 @@ func (s *ProjectService) StartProcessing(projectID string) error {
 			project.Status, project.ManuscriptURL)
 	}
 	
-	// Criar jobs de processamento
+	// Criar jobs de processamento. O convert guarda qual manuscrito ler, para
+	// que um upload durante o processamento não troque o rascunho no meio.
+	manuscript := map[string]interface{}{"manuscript_url": project.ManuscriptURL}
 	jobs := []domain.Job{
 		{
 			ID:          uuid.New().String(),
 			ProjectID:   projectID,
 			Type:        domain.JobTypeConvert,
+			Payload:     &manuscript,
 			Status:      domain.JobStatusPending,
 			Priority:    10,
 			MaxAttempts: 3,
@@ -213,22 +465,50 @@ func (s *ProjectService) StartProcessing(projectID string) error {
 		},
 	}
 	
+	// Cada estágio só roda após a conclusão do anterior, todos sobre a
+	// revisão atual
+	graph := make([]*domain.Job, len(jobs))
+	for i := range jobs {
+		jobs[i].RevisionID = project.RevisionID
+		if i > 0 {
+			jobs[i].AddDependency(&jobs[i-1])
+		}
+		graph[i] = &jobs[i]
+	}
+	if err := domain.ValidateJobGraph(graph); err != nil {
+		return err
+	}
+	
 	// Criar jobs no banco
 	for i := range jobs {
 		if err := s.jobRepo.Create(&jobs[i]); err != nil {
 			return fmt.Errorf("erro ao criar job: %w", err)
 		}
+		PublishJobStatus(context.Background(), s.events, &jobs[i])
 	}
 	
 	// Atualizar status do projeto
+	previous := project.Status
 	project.Status = domain.StatusAnalyzing
 	project.Progress = 10
 	project.UpdatedAt = time.Now()
 	
-	return s.projectRepo.Update(project)
+	if err := s.projectRepo.Update(project); err != nil {
+		return err
+	}
+	PublishProjectStatus(context.Background(), s.events, project, previous)
+	return nil
 }
 
-// GetProjectJobs retorna todos os jobs de um projeto
-func (s *ProjectService) GetProjectJobs(projectID string) ([]*domain.Job, error) {
-	return s.jobRepo.GetByProjectID(projectID)
+// GetProjectJobs retorna os jobs de um projeto de userID com suas
+// dependências
+func (s *ProjectService) GetProjectJobs(userID, projectID string) (*domain.JobGraph, error) {
+	if _, err := ownedProject(s.projectRepo, Caller{UserID: userID}, projectID); err != nil {
+		return nil, err
+	}
+	jobs, err := s.jobRepo.GetByProjectID(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewJobGraph(jobs), nil
 }
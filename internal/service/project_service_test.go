@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/repository"
+)
+
+func TestProjectService_SearchProjects(t *testing.T) {
+	projects := repository.NewMemoryProjectRepository()
+	svc := NewProjectService(projects, repository.NewMemoryJobRepository())
+
+	for _, req := range []CreateProjectRequest{
+		{Title: "Zebra Tales", Author: "Ana", Description: "Stories about the sea"},
+		{Title: "Atlas", Author: "Bruno", Description: "Maps of the sea"},
+		{Title: "Sea", Author: "Carla"},
+	} {
+		if _, err := svc.CreateProject("alice", req); err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+	}
+
+	titles := func(result *ProjectSearchResult) []string {
+		var titles []string
+		for _, project := range result.Projects {
+			titles = append(titles, project.Title)
+		}
+		return titles
+	}
+
+	// With text, the most relevant come first
+	result, err := svc.SearchProjects(domain.ProjectFilter{UserID: "alice", Query: "  sea "}, nil)
+	if err != nil {
+		t.Fatalf("SearchProjects failed: %v", err)
+	}
+	if got := titles(result); len(got) != 3 || got[0] != "Sea" {
+		t.Errorf("Expected the title match first, got %q", got)
+	}
+	if result.Limit != 20 || result.Total != 3 {
+		t.Errorf("Expected the default page of 20 with 3 results, got limit %d, total %d", result.Limit, result.Total)
+	}
+
+	// Titles sort alphabetically unless an order is given
+	result, _ = svc.SearchProjects(domain.ProjectFilter{UserID: "alice", Sort: domain.ProjectSortTitle, Limit: 500}, nil)
+	if got := titles(result); got[0] != "Atlas" || result.Limit != 100 {
+		t.Errorf("Expected titles A-Z with the limit capped at 100, got %q (limit %d)", got, result.Limit)
+	}
+	descending := false
+	result, _ = svc.SearchProjects(domain.ProjectFilter{UserID: "alice", Sort: domain.ProjectSortTitle}, &descending)
+	if got := titles(result); got[0] != "Zebra Tales" {
+		t.Errorf("Expected titles Z-A, got %q", got)
+	}
+
+	if _, err := svc.SearchProjects(domain.ProjectFilter{Sort: "pages"}, nil); !errors.Is(err, ErrInvalidProjectSort) {
+		t.Errorf("Expected ErrInvalidProjectSort, got %v", err)
+	}
+}
+
+func TestProjectService_ScopedToOwner(t *testing.T) {
+	svc := NewProjectService(repository.NewMemoryProjectRepository(), repository.NewMemoryJobRepository())
+
+	project, err := svc.CreateProject("alice", CreateProjectRequest{Title: "Book", Author: "Alice"})
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	id := strconv.FormatUint(uint64(project.ID), 10)
+
+	if _, err := svc.GetProject("bob", id); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected GetProject to be denied, got %v", err)
+	}
+	if _, err := svc.UpdateProject("bob", id, map[string]interface{}{"title": "Mine"}); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected UpdateProject to be denied, got %v", err)
+	}
+	if err := svc.StartProcessing("bob", id); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected StartProcessing to be denied, got %v", err)
+	}
+	if _, err := svc.GetProjectJobs("bob", id); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected GetProjectJobs to be denied, got %v", err)
+	}
+	if err := svc.DeleteProject("bob", id); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected DeleteProject to be denied, got %v", err)
+	}
+	if _, total, _ := svc.ListProjects("bob", 1, 20); total != 0 {
+		t.Errorf("Expected bob to see no projects, got %d", total)
+	}
+
+	if err := svc.DeleteProject("alice", id); err != nil {
+		t.Fatalf("DeleteProject failed: %v", err)
+	}
+	if _, err := svc.RestoreProject("bob", id); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected RestoreProject to be denied, got %v", err)
+	}
+	if _, err := svc.RestoreProject("alice", id); err != nil {
+		t.Errorf("RestoreProject failed: %v", err)
+	}
+}
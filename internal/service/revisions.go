@@ -0,0 +1,394 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+	"github.com/JuanCS-Dev/typecraft/pkg/pipeline"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	// diffContextLines is how many unchanged lines surround each hunk
+	diffContextLines = 3
+	// renamedChapterSimilarity is how alike the text of two chapters with
+	// different titles must be (0-1) to count as one renamed chapter
+	renamedChapterSimilarity = 0.5
+)
+
+// RevisionStore persists manuscript revisions (implemented by
+// repository.RevisionRepository)
+type RevisionStore interface {
+	Create(revision *domain.Revision) error
+	GetByID(id string) (*domain.Revision, error)
+	GetByNumber(projectID uint, number int) (*domain.Revision, error)
+	ListByProject(projectID uint) ([]*domain.Revision, error)
+}
+
+// ChapterStatus says how a chapter changed between two revisions
+type ChapterStatus string
+
+const (
+	ChapterAdded     ChapterStatus = "added"
+	ChapterRemoved   ChapterStatus = "removed"
+	ChapterModified  ChapterStatus = "modified"
+	ChapterUnchanged ChapterStatus = "unchanged"
+)
+
+// RevisionDiff is the text difference between two revisions of a
+// manuscript, chapter by chapter
+type RevisionDiff struct {
+	ProjectID uint             `json:"project_id"`
+	From      *domain.Revision `json:"from"`
+	To        *domain.Revision `json:"to"`
+	Chapters  []ChapterDiff    `json:"chapters"`
+	Stats     DiffStats        `json:"stats"`
+}
+
+// DiffStats summarizes a RevisionDiff
+type DiffStats struct {
+	ChaptersAdded     int `json:"chapters_added"`
+	ChaptersRemoved   int `json:"chapters_removed"`
+	ChaptersModified  int `json:"chapters_modified"`
+	ChaptersUnchanged int `json:"chapters_unchanged"`
+	LinesAdded        int `json:"lines_added"`
+	LinesRemoved      int `json:"lines_removed"`
+}
+
+// ChapterDiff is one chapter of a RevisionDiff. Positions are 1-based and
+// zero when the chapter is missing from that revision; FromTitle is set when
+// the chapter was renamed.
+type ChapterDiff struct {
+	Status       ChapterStatus `json:"status"`
+	Title        string        `json:"title"`
+	FromTitle    string        `json:"from_title,omitempty"`
+	FromIndex    int           `json:"from_index,omitempty"`
+	ToIndex      int           `json:"to_index,omitempty"`
+	LinesAdded   int           `json:"lines_added"`
+	LinesRemoved int           `json:"lines_removed"`
+	Hunks        []DiffHunk    `json:"hunks,omitempty"`
+}
+
+// DiffHunk is a run of changed lines with some context, like a hunk of a
+// unified diff. Line numbers are 1-based within the chapter.
+type DiffHunk struct {
+	FromLine  int        `json:"from_line"`
+	FromLines int        `json:"from_lines"`
+	ToLine    int        `json:"to_line"`
+	ToLines   int        `json:"to_lines"`
+	Lines     []DiffLine `json:"lines"`
+}
+
+// DiffLine is a line of a hunk; Op is " " (context), "-" (removed) or
+// "+" (added)
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// RevisionService lists the manuscript revisions of a project and compares
+// them. Revisions never change, so the Markdown extracted from each one is
+// kept in workDir/revisions/<id> and reused by later diffs.
+type RevisionService struct {
+	revisions RevisionStore
+	projects  ProjectLookup
+	storage   storage.Storage
+	workDir   string
+}
+
+// NewRevisionService creates the service. store may be nil when manuscripts
+// are local files.
+func NewRevisionService(revisions RevisionStore, projects ProjectLookup, store storage.Storage, workDir string) *RevisionService {
+	return &RevisionService{
+		revisions: revisions,
+		projects:  projects,
+		storage:   store,
+		workDir:   workDir,
+	}
+}
+
+// List returns the revisions of a project owned by userID, newest first
+func (s *RevisionService) List(userID, projectID string) ([]*domain.Revision, error) {
+	project, err := ownedProject(s.projects, Caller{UserID: userID}, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return s.revisions.ListByProject(project.ID)
+}
+
+// Get returns revision number of a project owned by userID
+func (s *RevisionService) Get(userID, projectID string, number int) (*domain.Revision, error) {
+	project, err := ownedProject(s.projects, Caller{UserID: userID}, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return s.revisions.GetByNumber(project.ID, number)
+}
+
+// Diff compares revisions from and to of a project owned by userID. Both are
+// converted to Markdown and split into chapters; chapters are matched by
+// title (or, when renamed, by content) and each changed one carries its line
+// hunks.
+func (s *RevisionService) Diff(ctx context.Context, userID, projectID string, from, to int) (*RevisionDiff, error) {
+	project, err := ownedProject(s.projects, Caller{UserID: userID}, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	older, err := s.revisions.GetByNumber(project.ID, from)
+	if err != nil {
+		return nil, fmt.Errorf("revision %d: %w", from, err)
+	}
+	newer, err := s.revisions.GetByNumber(project.ID, to)
+	if err != nil {
+		return nil, fmt.Errorf("revision %d: %w", to, err)
+	}
+
+	olderText, err := s.markdown(ctx, older)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision %d: %w", from, err)
+	}
+	newerText, err := s.markdown(ctx, newer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision %d: %w", to, err)
+	}
+
+	diff := &RevisionDiff{
+		ProjectID: project.ID,
+		From:      older,
+		To:        newer,
+		Chapters:  diffChapters(pipeline.SplitSections(olderText), pipeline.SplitSections(newerText)),
+	}
+	for _, chapter := range diff.Chapters {
+		switch chapter.Status {
+		case ChapterAdded:
+			diff.Stats.ChaptersAdded++
+		case ChapterRemoved:
+			diff.Stats.ChaptersRemoved++
+		case ChapterModified:
+			diff.Stats.ChaptersModified++
+		default:
+			diff.Stats.ChaptersUnchanged++
+		}
+		diff.Stats.LinesAdded += chapter.LinesAdded
+		diff.Stats.LinesRemoved += chapter.LinesRemoved
+	}
+	return diff, nil
+}
+
+// markdown returns the text of a revision as Markdown, converting the
+// manuscript (or assembling the bundle) the first time
+func (s *RevisionService) markdown(ctx context.Context, revision *domain.Revision) (string, error) {
+	dir := filepath.Join(s.workDir, "revisions", revision.ID)
+	output := filepath.Join(dir, manuscriptFile)
+	if data, err := os.ReadFile(output); err == nil {
+		return string(data), nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create work dir: %w", err)
+	}
+
+	source, err := s.fetch(ctx, revision, dir)
+	if err != nil {
+		return "", err
+	}
+
+	// Written aside and renamed, so an interrupted conversion is not cached
+	partial := output + ".partial"
+	if isBundle(source) {
+		bundle, err := extractBundle(source, filepath.Join(dir, bundleDir))
+		if err != nil {
+			return "", err
+		}
+		markdown, _, _, err := bundle.assemble(ctx)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(partial, []byte(markdown), 0644); err != nil {
+			return "", err
+		}
+	} else if err := convertManuscript(ctx, source, partial); err != nil {
+		return "", err
+	}
+	if err := os.Rename(partial, output); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// fetch returns a local copy of a revision's manuscript
+func (s *RevisionService) fetch(ctx context.Context, revision *domain.Revision, dir string) (string, error) {
+	if s.storage != nil {
+		if key, ok := s.storage.Key(revision.ManuscriptURL); ok {
+			local := filepath.Join(dir, "source", path.Base(key))
+			if err := storage.Download(ctx, s.storage, key, local); err != nil {
+				return "", fmt.Errorf("failed to fetch manuscript: %w", err)
+			}
+			return local, nil
+		}
+	}
+	return localManuscriptPath(revision.ManuscriptURL)
+}
+
+// diffChapters aligns the chapters of two revisions by title, keeping their
+// order. Chapters whose titles differ are paired when their text is still
+// mostly the same (a renamed chapter); otherwise they count as removed and
+// added.
+func diffChapters(from, to []pipeline.BookSection) []ChapterDiff {
+	fromKeys := make([]string, len(from))
+	for i, section := range from {
+		fromKeys[i] = chapterKey(section.Title)
+	}
+	toKeys := make([]string, len(to))
+	for i, section := range to {
+		toKeys[i] = chapterKey(section.Title)
+	}
+
+	var chapters []ChapterDiff
+	removed := func(i int) {
+		lines := splitLines(from[i].Content)
+		chapters = append(chapters, ChapterDiff{
+			Status:       ChapterRemoved,
+			Title:        from[i].Title,
+			FromIndex:    i + 1,
+			LinesRemoved: len(lines),
+			Hunks:        diffLines(lines, nil),
+		})
+	}
+	added := func(j int) {
+		lines := splitLines(to[j].Content)
+		chapters = append(chapters, ChapterDiff{
+			Status:     ChapterAdded,
+			Title:      to[j].Title,
+			ToIndex:    j + 1,
+			LinesAdded: len(lines),
+			Hunks:      diffLines(nil, lines),
+		})
+	}
+
+	matcher := difflib.NewMatcherWithJunk(fromKeys, toKeys, false, nil)
+	for _, op := range matcher.GetOpCodes() {
+		switch op.Tag {
+		case 'e':
+			for k := 0; k < op.I2-op.I1; k++ {
+				chapters = append(chapters, compareChapter(from, to, op.I1+k, op.J1+k))
+			}
+		case 'd':
+			for i := op.I1; i < op.I2; i++ {
+				removed(i)
+			}
+		case 'i':
+			for j := op.J1; j < op.J2; j++ {
+				added(j)
+			}
+		case 'r':
+			i, j := op.I1, op.J1
+			for ; i < op.I2 && j < op.J2; i, j = i+1, j+1 {
+				if similarity(from[i].Content, to[j].Content) < renamedChapterSimilarity {
+					removed(i)
+					added(j)
+					continue
+				}
+				chapter := compareChapter(from, to, i, j)
+				chapter.Status = ChapterModified
+				chapter.FromTitle = from[i].Title
+				chapters = append(chapters, chapter)
+			}
+			for ; i < op.I2; i++ {
+				removed(i)
+			}
+			for ; j < op.J2; j++ {
+				added(j)
+			}
+		}
+	}
+	return chapters
+}
+
+// compareChapter diffs chapter i of from against chapter j of to
+func compareChapter(from, to []pipeline.BookSection, i, j int) ChapterDiff {
+	chapter := ChapterDiff{
+		Status:    ChapterUnchanged,
+		Title:     to[j].Title,
+		FromIndex: i + 1,
+		ToIndex:   j + 1,
+	}
+	if from[i].Content == to[j].Content {
+		return chapter
+	}
+
+	chapter.Status = ChapterModified
+	chapter.Hunks = diffLines(splitLines(from[i].Content), splitLines(to[j].Content))
+	for _, hunk := range chapter.Hunks {
+		for _, line := range hunk.Lines {
+			switch line.Op {
+			case "+":
+				chapter.LinesAdded++
+			case "-":
+				chapter.LinesRemoved++
+			}
+		}
+	}
+	return chapter
+}
+
+// diffLines returns the hunks turning a into b
+func diffLines(a, b []string) []DiffHunk {
+	matcher := difflib.NewMatcherWithJunk(a, b, false, nil)
+
+	var hunks []DiffHunk
+	for _, group := range matcher.GetGroupedOpCodes(diffContextLines) {
+		first, last := group[0], group[len(group)-1]
+		hunk := DiffHunk{
+			FromLine:  first.I1 + 1,
+			FromLines: last.I2 - first.I1,
+			ToLine:    first.J1 + 1,
+			ToLines:   last.J2 - first.J1,
+		}
+		for _, op := range group {
+			if op.Tag == 'e' {
+				for _, text := range a[op.I1:op.I2] {
+					hunk.Lines = append(hunk.Lines, DiffLine{Op: " ", Text: text})
+				}
+				continue
+			}
+			for _, text := range a[op.I1:op.I2] {
+				hunk.Lines = append(hunk.Lines, DiffLine{Op: "-", Text: text})
+			}
+			for _, text := range b[op.J1:op.J2] {
+				hunk.Lines = append(hunk.Lines, DiffLine{Op: "+", Text: text})
+			}
+		}
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
+
+// similarity is how alike two texts are, line by line, from 0 to 1
+func similarity(a, b string) float64 {
+	return difflib.NewMatcherWithJunk(splitLines(a), splitLines(b), false, nil).Ratio()
+}
+
+// chapterKey normalizes a chapter title for matching
+func chapterKey(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// splitLines splits a chapter into lines; an empty chapter has none
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/repository"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+	"github.com/JuanCS-Dev/typecraft/pkg/pipeline"
+)
+
+// mockRevisionStore numbers revisions per project, like RevisionRepository
+type mockRevisionStore struct {
+	revisions []*domain.Revision
+}
+
+func (m *mockRevisionStore) Create(revision *domain.Revision) error {
+	revision.Number = 1
+	for _, existing := range m.revisions {
+		if existing.ProjectID == revision.ProjectID && existing.Number >= revision.Number {
+			revision.Number = existing.Number + 1
+		}
+	}
+	m.revisions = append(m.revisions, revision)
+	return nil
+}
+
+func (m *mockRevisionStore) GetByID(id string) (*domain.Revision, error) {
+	for _, revision := range m.revisions {
+		if revision.ID == id {
+			return revision, nil
+		}
+	}
+	return nil, domain.ErrRevisionNotFound
+}
+
+func (m *mockRevisionStore) GetByNumber(projectID uint, number int) (*domain.Revision, error) {
+	for _, revision := range m.revisions {
+		if revision.ProjectID == projectID && revision.Number == number {
+			return revision, nil
+		}
+	}
+	return nil, domain.ErrRevisionNotFound
+}
+
+func (m *mockRevisionStore) ListByProject(projectID uint) ([]*domain.Revision, error) {
+	var revisions []*domain.Revision
+	for i := len(m.revisions) - 1; i >= 0; i-- {
+		if m.revisions[i].ProjectID == projectID {
+			revisions = append(revisions, m.revisions[i])
+		}
+	}
+	return revisions, nil
+}
+
+func (m *mockRevisionStore) DeleteByProjectID(projectID uint) error {
+	kept := m.revisions[:0]
+	for _, revision := range m.revisions {
+		if revision.ProjectID != projectID {
+			kept = append(kept, revision)
+		}
+	}
+	m.revisions = kept
+	return nil
+}
+
+const (
+	firstDraft = `# Chapter One
+
+It was a dark night.
+The wind howled.
+
+# Chapter Two
+
+The hero came back home.
+
+# Chapter Three
+
+This chapter will be cut.
+`
+	secondDraft = `# Chapter One
+
+It was a dark and stormy night.
+The wind howled.
+
+# The Return
+
+The hero came back home.
+
+# Epilogue
+
+Everyone lived happily.
+`
+)
+
+// revisionFixture is a project owned by alice with two uploaded drafts
+type revisionFixture struct {
+	projects  *repository.MemoryProjectRepository
+	revisions *mockRevisionStore
+	store     storage.Storage
+	project   *domain.Project
+	workDir   string
+}
+
+func newRevisionFixture(t *testing.T) *revisionFixture {
+	tmpDir := t.TempDir()
+	store, err := storage.NewLocalStorage(filepath.Join(tmpDir, "storage"))
+	if err != nil {
+		t.Fatalf("NewLocalStorage failed: %v", err)
+	}
+	f := &revisionFixture{
+		projects:  repository.NewMemoryProjectRepository(),
+		revisions: &mockRevisionStore{},
+		store:     store,
+		workDir:   filepath.Join(tmpDir, "work"),
+	}
+
+	project := &domain.Project{UserID: "alice", Title: "Book", Author: "Alice"}
+	if err := f.projects.Create(project); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	projectID := strconv.FormatUint(uint64(project.ID), 10)
+
+	projects := NewProjectService(f.projects, repository.NewMemoryJobRepository(),
+		WithProjectStorage(store), WithProjectRevisions(f.revisions))
+	for i, draft := range []string{firstDraft, secondDraft} {
+		f.project, err = projects.UploadManuscript(context.Background(), projectID, ManuscriptFile{
+			Filename:   "book.md",
+			Content:    strings.NewReader(draft),
+			Size:       int64(len(draft)),
+			UploadedBy: "alice",
+			Note:       "draft " + strconv.Itoa(i+1),
+		})
+		if err != nil {
+			t.Fatalf("UploadManuscript failed: %v", err)
+		}
+	}
+	return f
+}
+
+func (f *revisionFixture) service() *RevisionService {
+	return NewRevisionService(f.revisions, f.projects, f.store, f.workDir)
+}
+
+func TestProjectService_UploadKeepsRevisions(t *testing.T) {
+	f := newRevisionFixture(t)
+
+	if len(f.revisions.revisions) != 2 {
+		t.Fatalf("Expected one revision per upload, got %d", len(f.revisions.revisions))
+	}
+	first, second := f.revisions.revisions[0], f.revisions.revisions[1]
+	if first.Number != 1 || second.Number != 2 {
+		t.Errorf("Expected revisions 1 and 2, got %d and %d", first.Number, second.Number)
+	}
+	sum := sha256.Sum256([]byte(firstDraft))
+	if first.Checksum != hex.EncodeToString(sum[:]) || first.Size != int64(len(firstDraft)) {
+		t.Errorf("Expected the checksum and size of the first draft, got %s (%d bytes)", first.Checksum, first.Size)
+	}
+	if first.UploadedBy != "alice" || first.Note != "draft 1" {
+		t.Errorf("Expected uploader and note on the revision, got %q, %q", first.UploadedBy, first.Note)
+	}
+
+	if f.project.RevisionID != second.ID || f.project.ManuscriptURL != second.ManuscriptURL {
+		t.Errorf("Expected the project at revision 2, got %s (%s)", f.project.RevisionID, f.project.ManuscriptURL)
+	}
+	// The earlier draft is kept
+	key, ok := f.store.Key(first.ManuscriptURL)
+	if !ok || first.ManuscriptURL == second.ManuscriptURL {
+		t.Fatalf("Expected each revision stored apart, got %s and %s", first.ManuscriptURL, second.ManuscriptURL)
+	}
+	if _, err := f.store.Stat(context.Background(), key); err != nil {
+		t.Errorf("Expected revision 1 to stay in storage: %v", err)
+	}
+}
+
+func TestProjectPipeline_ConvertUsesJobRevision(t *testing.T) {
+	f := newRevisionFixture(t)
+	first := f.revisions.revisions[0]
+
+	orchestrator := NewBookOrchestrator(newMockProjectRepository(), NewLocalAnalysisClient(), t.TempDir(), WithStorage(f.store))
+	job := &domain.Job{
+		ID:         "convert-1",
+		RevisionID: first.ID,
+		Payload:    &map[string]interface{}{"manuscript_url": first.ManuscriptURL},
+	}
+	result, err := NewProjectPipeline(orchestrator, f.workDir).Convert(context.Background(), job, f.project)
+	if err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	converted, _ := os.ReadFile(result["markdown_path"].(string))
+	if !strings.Contains(string(converted), "It was a dark night.") {
+		t.Errorf("Expected the draft of the job's revision, got %q", converted)
+	}
+	if result["revision_id"] != first.ID {
+		t.Errorf("Expected the revision in the result, got %v", result["revision_id"])
+	}
+}
+
+func TestRevisionService_Diff(t *testing.T) {
+	f := newRevisionFixture(t)
+	revisions := f.service()
+	projectID := strconv.FormatUint(uint64(f.project.ID), 10)
+
+	diff, err := revisions.Diff(context.Background(), "alice", projectID, 1, 2)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff.From.Number != 1 || diff.To.Number != 2 {
+		t.Errorf("Expected revisions 1 → 2, got %d → %d", diff.From.Number, diff.To.Number)
+	}
+
+	var statuses []string
+	for _, chapter := range diff.Chapters {
+		statuses = append(statuses, string(chapter.Status)+":"+chapter.Title)
+	}
+	want := []string{"modified:Chapter One", "modified:The Return", "removed:Chapter Three", "added:Epilogue"}
+	if strings.Join(statuses, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("Expected chapters %v, got %v", want, statuses)
+	}
+
+	edited := diff.Chapters[0]
+	if edited.LinesAdded != 1 || edited.LinesRemoved != 1 || len(edited.Hunks) != 1 {
+		t.Fatalf("Expected one changed line in one hunk, got %+v", edited)
+	}
+	var lines []string
+	for _, line := range edited.Hunks[0].Lines {
+		lines = append(lines, line.Op+line.Text)
+	}
+	if strings.Join(lines, "\n") != "-It was a dark night.\n+It was a dark and stormy night.\n The wind howled." {
+		t.Errorf("Unexpected hunk:\n%s", strings.Join(lines, "\n"))
+	}
+
+	if renamed := diff.Chapters[1]; renamed.FromTitle != "Chapter Two" || len(renamed.Hunks) != 0 {
+		t.Errorf("Expected a renamed chapter with the same text, got %+v", renamed)
+	}
+	if diff.Stats.ChaptersAdded != 1 || diff.Stats.ChaptersRemoved != 1 || diff.Stats.ChaptersModified != 2 {
+		t.Errorf("Unexpected stats: %+v", diff.Stats)
+	}
+
+	// The extracted text is reused
+	again, err := revisions.Diff(context.Background(), "alice", projectID, 2, 2)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if again.Stats.ChaptersUnchanged != 3 || again.Stats.LinesAdded != 0 {
+		t.Errorf("Expected a revision to equal itself, got %+v", again.Stats)
+	}
+}
+
+func TestRevisionService_Access(t *testing.T) {
+	f := newRevisionFixture(t)
+	revisions := f.service()
+	projectID := strconv.FormatUint(uint64(f.project.ID), 10)
+
+	listed, err := revisions.List("alice", projectID)
+	if err != nil || len(listed) != 2 || listed[0].Number != 2 {
+		t.Errorf("Expected revisions newest first, got %v (%v)", listed, err)
+	}
+	if _, err := revisions.List("bob", projectID); !errors.Is(err, ErrProjectAccessDenied) {
+		t.Errorf("Expected ErrProjectAccessDenied, got %v", err)
+	}
+	if _, err := revisions.Diff(context.Background(), "alice", projectID, 1, 3); !errors.Is(err, domain.ErrRevisionNotFound) {
+		t.Errorf("Expected ErrRevisionNotFound, got %v", err)
+	}
+}
+
+func TestGenerationJobs_EnqueueRecordsRevision(t *testing.T) {
+	projectRepo := newMockProjectRepository()
+	projectRepo.projects[1] = &domain.Project{ID: 1, UserID: "alice", ManuscriptURL: "file:///books/v2.md", RevisionID: "rev-2"}
+	revisions := &mockRevisionStore{}
+	revisions.Create(&domain.Revision{ID: "rev-1", ProjectID: 1, ManuscriptURL: "file:///books/v1.md"})
+
+	jobs := newMockJobStore()
+	runner := NewGenerationJobs(NewBookOrchestrator(projectRepo, &mockAnalysisClient{}, t.TempDir()), jobs,
+		WithGenerationRevisions(revisions))
+
+	tests := []struct {
+		name     string
+		req      GenerationRequest
+		revision string
+		content  string
+	}{
+		{"current manuscript", GenerationRequest{ProjectID: 1}, "rev-2", "file:///books/v2.md"},
+		{"numbered revision", GenerationRequest{ProjectID: 1, Revision: 1}, "rev-1", "file:///books/v1.md"},
+		{"other content", GenerationRequest{ProjectID: 1, ContentPath: "/tmp/other.md"}, "", "/tmp/other.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := runner.Enqueue(context.Background(), "alice", &tt.req)
+			if err != nil {
+				t.Fatalf("Enqueue failed: %v", err)
+			}
+			req, err := generationRequestFromJob(job)
+			if err != nil {
+				t.Fatalf("generationRequestFromJob failed: %v", err)
+			}
+			if job.RevisionID != tt.revision || req.RevisionID != tt.revision || req.ContentPath != tt.content {
+				t.Errorf("Expected %q from %s, got %q from %s", tt.revision, tt.content, job.RevisionID, req.ContentPath)
+			}
+		})
+	}
+
+	if _, err := runner.Enqueue(context.Background(), "alice", &GenerationRequest{ProjectID: 1, Revision: 9}); !errors.Is(err, domain.ErrRevisionNotFound) {
+		t.Errorf("Expected ErrRevisionNotFound, got %v", err)
+	}
+}
+
+func TestDiffChapters_InsertedChapterKeepsOrder(t *testing.T) {
+	from := []pipeline.BookSection{
+		{Title: "One", Content: "a"},
+		{Title: "Two", Content: "b"},
+	}
+	to := []pipeline.BookSection{
+		{Title: "One", Content: "a"},
+		{Title: "Interlude", Content: "new\ntext"},
+		{Title: "two", Content: "b"},
+	}
+
+	chapters := diffChapters(from, to)
+	if len(chapters) != 3 {
+		t.Fatalf("Expected 3 chapters, got %+v", chapters)
+	}
+	if chapters[0].Status != ChapterUnchanged || chapters[2].Status != ChapterUnchanged {
+		t.Errorf("Expected titles matched regardless of case, got %+v", chapters)
+	}
+	if added := chapters[1]; added.Status != ChapterAdded || added.ToIndex != 2 || added.LinesAdded != 2 {
+		t.Errorf("Expected the interlude added at position 2, got %+v", added)
+	}
+	if chapters[2].FromIndex != 2 || chapters[2].ToIndex != 3 {
+		t.Errorf("Expected chapter two moved from 2 to 3, got %+v", chapters[2])
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultTrashPurgeInterval = time.Hour
+	trashPurgeBatchSize       = 50
+)
+
+// TrashedProjects is the part of the project repository the purger uses
+type TrashedProjects interface {
+	GetExpired(before time.Time, limit int) ([]*domain.Project, error)
+	Delete(id string) error
+}
+
+// ProjectJobs deletes the jobs of a project
+type ProjectJobs interface {
+	GetByProjectID(projectID string) ([]*domain.Job, error)
+	DeleteByProjectID(projectID string) error
+}
+
+// ProjectJobLogs deletes the tool runs recorded by jobs
+type ProjectJobLogs interface {
+	DeleteByJobIDs(jobIDs []string) error
+}
+
+// ProjectAnalyses deletes the AI analyses of a project
+type ProjectAnalyses interface {
+	DeleteByProjectID(projectID string) error
+}
+
+// ProjectRevisions deletes the manuscript revisions of a project
+type ProjectRevisions interface {
+	ListByProject(projectID uint) ([]*domain.Revision, error)
+	DeleteByProjectID(projectID uint) error
+}
+
+// ProjectRecords deletes rows keyed by the numeric project ID (artifacts,
+// uploads)
+type ProjectRecords interface {
+	DeleteByProjectID(projectID uint) error
+}
+
+// ProjectData holds the repositories with rows that belong to a project.
+// Nil repositories are skipped.
+type ProjectData struct {
+	Jobs      ProjectJobs
+	JobLogs   ProjectJobLogs
+	Analyses  ProjectAnalyses
+	Revisions ProjectRevisions
+	Artifacts ProjectRecords
+	Uploads   ProjectRecords
+}
+
+// TrashPurger permanently deletes projects that stayed in the trash longer
+// than the retention period, together with their jobs, analyses, revisions,
+// artifacts and stored files. The project row goes last, so a purge that
+// fails halfway is simply retried on the next run.
+type TrashPurger struct {
+	projects  TrashedProjects
+	data      ProjectData
+	storage   storage.Storage
+	workDir   string
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// TrashPurgerOption configures a TrashPurger
+type TrashPurgerOption func(*TrashPurger)
+
+// WithTrashPurgeInterval sets how often the trash is checked for expired projects
+func WithTrashPurgeInterval(interval time.Duration) TrashPurgerOption {
+	return func(p *TrashPurger) {
+		p.interval = interval
+	}
+}
+
+// NewTrashPurger creates a purger for projects trashed more than retention
+// ago. store holds the project files (nil skips them) and workDir the
+// cached revision Markdown (see RevisionService).
+func NewTrashPurger(projects TrashedProjects, data ProjectData, store storage.Storage, workDir string, retention time.Duration, opts ...TrashPurgerOption) *TrashPurger {
+	p := &TrashPurger{
+		projects:  projects,
+		data:      data,
+		storage:   store,
+		workDir:   workDir,
+		retention: retention,
+		interval:  defaultTrashPurgeInterval,
+		now:       time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Run purges expired projects every interval until ctx is cancelled
+func (p *TrashPurger) Run(ctx context.Context) error {
+	for {
+		purged, err := p.PurgeExpired(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to purge trash")
+		} else if purged > 0 {
+			log.Info().Int("projects", purged).Msg("purged expired projects from trash")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(p.interval):
+		}
+	}
+}
+
+// PurgeExpired purges every project whose retention is over and returns how
+// many were purged
+func (p *TrashPurger) PurgeExpired(ctx context.Context) (int, error) {
+	cutoff := p.now().Add(-p.retention)
+	purged := 0
+	for {
+		projects, err := p.projects.GetExpired(cutoff, trashPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		for _, project := range projects {
+			if err := ctx.Err(); err != nil {
+				return purged, nil
+			}
+			if err := p.Purge(ctx, project); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(projects) < trashPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// Purge deletes a project and everything that belongs to it
+func (p *TrashPurger) Purge(ctx context.Context, project *domain.Project) error {
+	id := strconv.FormatUint(uint64(project.ID), 10)
+
+	if p.data.Jobs != nil {
+		jobs, err := p.data.Jobs.GetByProjectID(id)
+		if err != nil {
+			return fmt.Errorf("failed to purge project %s: %w", id, err)
+		}
+		if p.data.JobLogs != nil {
+			jobIDs := make([]string, 0, len(jobs))
+			for _, job := range jobs {
+				jobIDs = append(jobIDs, job.ID)
+			}
+			if err := p.data.JobLogs.DeleteByJobIDs(jobIDs); err != nil {
+				return fmt.Errorf("failed to purge project %s: %w", id, err)
+			}
+		}
+		if err := p.data.Jobs.DeleteByProjectID(id); err != nil {
+			return fmt.Errorf("failed to purge project %s: %w", id, err)
+		}
+	}
+
+	if p.data.Analyses != nil {
+		if err := p.data.Analyses.DeleteByProjectID(id); err != nil {
+			return fmt.Errorf("failed to purge project %s: %w", id, err)
+		}
+	}
+
+	if p.data.Revisions != nil {
+		revisions, err := p.data.Revisions.ListByProject(project.ID)
+		if err != nil {
+			return fmt.Errorf("failed to purge project %s: %w", id, err)
+		}
+		for _, revision := range revisions {
+			if err := os.RemoveAll(filepath.Join(p.workDir, "revisions", revision.ID)); err != nil {
+				return fmt.Errorf("failed to purge project %s: %w", id, err)
+			}
+		}
+		if err := p.data.Revisions.DeleteByProjectID(project.ID); err != nil {
+			return fmt.Errorf("failed to purge project %s: %w", id, err)
+		}
+	}
+
+	for _, records := range []ProjectRecords{p.data.Artifacts, p.data.Uploads} {
+		if records == nil {
+			continue
+		}
+		if err := records.DeleteByProjectID(project.ID); err != nil {
+			return fmt.Errorf("failed to purge project %s: %w", id, err)
+		}
+	}
+
+	// Manuscripts, revisions, intermediate files and generated books
+	if p.storage != nil {
+		if err := p.storage.DeletePrefix(ctx, storage.ProjectKey(project.ID)); err != nil {
+			return fmt.Errorf("failed to purge files of project %s: %w", id, err)
+		}
+	}
+
+	if err := p.projects.Delete(id); err != nil {
+		return fmt.Errorf("failed to purge project %s: %w", id, err)
+	}
+	return nil
+}
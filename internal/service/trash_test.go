@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/repository"
+	"github.com/JuanCS-Dev/typecraft/internal/storage"
+)
+
+func TestProjectService_TrashAndRestore(t *testing.T) {
+	projects := repository.NewMemoryProjectRepository()
+	jobs := repository.NewMemoryJobRepository()
+	svc := NewProjectService(projects, jobs, WithTrashRetention(30*24*time.Hour))
+
+	project, err := svc.CreateProject("alice", CreateProjectRequest{Title: "Book", Author: "Alice"})
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	id := strconv.FormatUint(uint64(project.ID), 10)
+
+	pending := &domain.Job{ID: "job-pending", ProjectID: id, Type: domain.JobTypeRender, Status: domain.JobStatusPending}
+	done := &domain.Job{ID: "job-done", ProjectID: id, Type: domain.JobTypeConvert, Status: domain.JobStatusCompleted}
+	for _, job := range []*domain.Job{pending, done} {
+		if err := jobs.Create(job); err != nil {
+			t.Fatalf("Create job failed: %v", err)
+		}
+	}
+
+	if err := svc.DeleteProject("alice", id); err != nil {
+		t.Fatalf("DeleteProject failed: %v", err)
+	}
+	if _, err := svc.GetProject("alice", id); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Errorf("Expected a trashed project to be hidden, got %v", err)
+	}
+	if err := svc.DeleteProject("alice", id); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Errorf("Expected deleting a trashed project to fail with ErrProjectNotFound, got %v", err)
+	}
+
+	// Nothing is deleted yet: pending work is cancelled, finished work kept
+	if job, _ := jobs.GetByID("job-pending"); job == nil || job.Status != domain.JobStatusCancelled {
+		t.Errorf("Expected the pending job to be cancelled, got %+v", job)
+	}
+	if job, _ := jobs.GetByID("job-done"); job == nil || job.Status != domain.JobStatusCompleted {
+		t.Errorf("Expected the completed job to be kept, got %+v", job)
+	}
+
+	trash, total, err := svc.ListTrash("alice", 1, 20)
+	if err != nil {
+		t.Fatalf("ListTrash failed: %v", err)
+	}
+	if total != 1 || len(trash) != 1 || trash[0].ID != project.ID {
+		t.Fatalf("Expected the project in the trash, got %d: %+v", total, trash)
+	}
+	if trash[0].PurgeAt == nil || !trash[0].PurgeAt.Equal(trash[0].DeletedAt.Add(30*24*time.Hour)) {
+		t.Errorf("Expected purge_at 30 days after deletion, got %v (deleted %v)", trash[0].PurgeAt, trash[0].DeletedAt)
+	}
+	if _, total, _ := svc.ListTrash("bob", 1, 20); total != 0 {
+		t.Errorf("Expected another user's trash to be empty, got %d", total)
+	}
+
+	restored, err := svc.RestoreProject("alice", id)
+	if err != nil {
+		t.Fatalf("RestoreProject failed: %v", err)
+	}
+	if restored.IsTrashed() || restored.Title != "Book" {
+		t.Errorf("Unexpected restored project: %+v", restored)
+	}
+	if _, err := svc.RestoreProject("alice", id); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Errorf("Expected restoring a project outside the trash to fail, got %v", err)
+	}
+	if _, total, _ := svc.ListTrash("alice", 1, 20); total != 0 {
+		t.Errorf("Expected an empty trash after restoring, got %d", total)
+	}
+}
+
+func TestTrashPurger_PurgesExpiredProjects(t *testing.T) {
+	f := newRevisionFixture(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	// A second project, trashed recently, must survive the purge
+	kept := &domain.Project{UserID: "alice", Title: "Kept", Author: "Alice"}
+	if err := f.projects.Create(kept); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	expiredID := strconv.FormatUint(uint64(f.project.ID), 10)
+	keptID := strconv.FormatUint(uint64(kept.ID), 10)
+
+	jobs := repository.NewMemoryJobRepository()
+	analyses := repository.NewMemoryAnalysisRepository()
+	artifacts := &mockArtifactStore{}
+	logs := newMockJobLogStore()
+	for _, project := range []*domain.Project{f.project, kept} {
+		id := strconv.FormatUint(uint64(project.ID), 10)
+		if err := jobs.Create(&domain.Job{ID: "render-" + id, ProjectID: id, Type: domain.JobTypeRender, Status: domain.JobStatusCompleted}); err != nil {
+			t.Fatalf("Create job failed: %v", err)
+		}
+		logs.Create(&domain.JobLog{ID: "log-" + id, JobID: "render-" + id, Tool: "pandoc"})
+		if err := analyses.Save(&domain.AIAnalysis{ProjectID: id, Genre: "fiction"}); err != nil {
+			t.Fatalf("Save analysis failed: %v", err)
+		}
+		key := storage.ProjectKey(project.ID, "output", "g1", "book.pdf")
+		if _, err := f.store.Put(ctx, key, strings.NewReader("%PDF"), 4, ""); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		artifacts.Create(&domain.Artifact{ID: "pdf-" + id, ProjectID: project.ID, Format: "pdf", Key: key})
+	}
+
+	// Markdown cached by a revision diff
+	revisions, _ := f.revisions.ListByProject(f.project.ID)
+	cacheDir := filepath.Join(f.workDir, "revisions", revisions[0].ID)
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	if err := f.projects.Trash(expiredID, now.Add(-31*24*time.Hour)); err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+	if err := f.projects.Trash(keptID, now.Add(-24*time.Hour)); err != nil {
+		t.Fatalf("Trash failed: %v", err)
+	}
+
+	purger := NewTrashPurger(f.projects, ProjectData{
+		Jobs:      jobs,
+		JobLogs:   logs,
+		Analyses:  analyses,
+		Revisions: f.revisions,
+		Artifacts: artifacts,
+	}, f.store, f.workDir, 30*24*time.Hour)
+
+	purged, err := purger.PurgeExpired(ctx)
+	if err != nil {
+		t.Fatalf("PurgeExpired failed: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("Expected 1 project purged, got %d", purged)
+	}
+
+	if _, err := f.projects.GetTrashed(expiredID); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Errorf("Expected the expired project to be gone, got %v", err)
+	}
+	if remaining, _ := jobs.GetByProjectID(expiredID); len(remaining) != 0 {
+		t.Errorf("Expected the project's jobs to be deleted, got %d", len(remaining))
+	}
+	if remaining, _ := logs.GetByJobID("render-" + expiredID); len(remaining) != 0 {
+		t.Errorf("Expected the logs of the project's jobs to be deleted, got %d", len(remaining))
+	}
+	if remaining, _ := logs.GetByJobID("render-" + keptID); len(remaining) != 1 {
+		t.Errorf("Expected the kept project's job logs to survive, got %d", len(remaining))
+	}
+	if count, _ := analyses.CountByProject(expiredID); count != 0 {
+		t.Errorf("Expected the project's analyses to be deleted, got %d", count)
+	}
+	if remaining, _ := f.revisions.ListByProject(f.project.ID); len(remaining) != 0 {
+		t.Errorf("Expected the project's revisions to be deleted, got %d", len(remaining))
+	}
+	if remaining, _ := artifacts.ListByProject(f.project.ID); len(remaining) != 0 {
+		t.Errorf("Expected the project's artifacts to be deleted, got %d", len(remaining))
+	}
+	if _, err := f.store.Stat(ctx, storage.ProjectKey(f.project.ID, "output", "g1", "book.pdf")); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the generated book to be deleted, got %v", err)
+	}
+	if _, err := f.store.Stat(ctx, mustKey(t, f.store, revisions[0].ManuscriptURL)); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Expected the revision files to be deleted, got %v", err)
+	}
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the revision cache to be removed, got %v", err)
+	}
+
+	// The recently trashed project still has everything
+	if _, err := f.projects.GetTrashed(keptID); err != nil {
+		t.Errorf("Expected the recently trashed project to stay in the trash, got %v", err)
+	}
+	if remaining, _ := jobs.GetByProjectID(keptID); len(remaining) != 1 {
+		t.Errorf("Expected the kept project's job to survive, got %d", len(remaining))
+	}
+	if count, _ := analyses.CountByProject(keptID); count != 1 {
+		t.Errorf("Expected the kept project's analysis to survive, got %d", count)
+	}
+	if _, err := f.store.Stat(ctx, storage.ProjectKey(kept.ID, "output", "g1", "book.pdf")); err != nil {
+		t.Errorf("Expected the kept project's files to survive, got %v", err)
+	}
+
+	// Nothing else has expired
+	if purged, err := purger.PurgeExpired(ctx); err != nil || purged != 0 {
+		t.Errorf("Expected a second run to purge nothing, got %d (%v)", purged, err)
+	}
+}
+
+// mustKey maps a storage URL back to its key
+func mustKey(t *testing.T, store storage.Storage, url string) string {
+	t.Helper()
+	key, ok := store.Key(url)
+	if !ok {
+		t.Fatalf("URL %q is not in the storage", url)
+	}
+	return key
+}
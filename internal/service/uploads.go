@@ -0,0 +1,385 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/google/uuid"
+)
+
+// defaultMaxUploadSize applies when no limit is configured (config.MaxFileSizeMB)
+const defaultMaxUploadSize = 100 << 20
+
+var (
+	// ErrUploadTooLarge means a manuscript exceeds the size limit, or a chunk
+	// goes past the declared size
+	ErrUploadTooLarge = errors.New("upload too large")
+	// ErrUploadOffsetMismatch means a chunk does not start where the upload
+	// stopped; the client should ask for the current offset and resume there
+	ErrUploadOffsetMismatch = errors.New("upload offset mismatch")
+	// ErrUploadClosed means the upload already completed or failed
+	ErrUploadClosed = errors.New("upload is closed")
+	// ErrInvalidUpload means the upload request is malformed
+	ErrInvalidUpload = errors.New("invalid upload")
+)
+
+// UploadStore persists uploads (implemented by repository.UploadRepository)
+type UploadStore interface {
+	Create(upload *domain.Upload) error
+	GetByID(id string) (*domain.Upload, error)
+	Update(upload *domain.Upload) error
+}
+
+// ManuscriptSaver stores a complete manuscript as the project's manuscript
+// (implemented by ProjectService)
+type ManuscriptSaver interface {
+	UploadManuscript(ctx context.Context, projectID string, file ManuscriptFile) (*domain.Project, error)
+}
+
+// CreateUploadRequest starts a chunked upload. Note is kept on the manuscript
+// revision the upload creates.
+type CreateUploadRequest struct {
+	Filename string `json:"filename" binding:"required"`
+	Size     int64  `json:"size" binding:"required"`
+	Note     string `json:"note,omitempty"`
+}
+
+// UploadService receives manuscripts in chunks that can be resumed after a
+// dropped connection (tus-style: every chunk starts at the upload's current
+// offset). Chunks are staged on local disk; the first one is sniffed so
+// files of the wrong type are refused early, and the complete file is
+// verified and checksummed before it reaches storage.
+type UploadService struct {
+	uploads      UploadStore
+	projects     ProjectLookup
+	manuscripts  ManuscriptSaver
+	stagingDir   string
+	maxSize      int64
+	inputFormats func() ([]string, error)
+
+	formatsOnce sync.Once
+	formats     map[string]bool
+
+	locksMu sync.Mutex
+	locks   map[string]*uploadLock // uploads with a chunk being written
+}
+
+// uploadLock serializes the chunks of one upload; refs counts the requests
+// holding or waiting for it
+type uploadLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// UploadServiceOption configures optional UploadService settings
+type UploadServiceOption func(*UploadService)
+
+// WithMaxUploadSize sets the largest manuscript accepted, in bytes
+func WithMaxUploadSize(size int64) UploadServiceOption {
+	return func(s *UploadService) {
+		if size > 0 {
+			s.maxSize = size
+		}
+	}
+}
+
+// WithInputFormats sets where the converter's input formats come from
+// (PandocConverter.ListInputFormats). Without it, or when listing fails,
+// every format the pipeline converts is accepted.
+func WithInputFormats(list func() ([]string, error)) UploadServiceOption {
+	return func(s *UploadService) {
+		s.inputFormats = list
+	}
+}
+
+// NewUploadService creates the service. Partial uploads are kept in stagingDir.
+func NewUploadService(uploads UploadStore, projects ProjectLookup, manuscripts ManuscriptSaver, stagingDir string, opts ...UploadServiceOption) *UploadService {
+	s := &UploadService{
+		uploads:     uploads,
+		projects:    projects,
+		manuscripts: manuscripts,
+		stagingDir:  stagingDir,
+		maxSize:     defaultMaxUploadSize,
+		locks:       make(map[string]*uploadLock),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// MaxSize returns the largest manuscript accepted, in bytes
+func (s *UploadService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Create starts an upload after checking the project owner, the size limit
+// and the file format
+func (s *UploadService) Create(userID, projectID string, req CreateUploadRequest) (*domain.Upload, error) {
+	project, err := ownedProject(s.projects, Caller{UserID: userID}, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(filepath.Clean("/" + filepath.ToSlash(req.Filename)))
+	if name == "/" || name == "." {
+		return nil, fmt.Errorf("%w: filename is required", ErrInvalidUpload)
+	}
+	if req.Size <= 0 {
+		return nil, fmt.Errorf("%w: size must be positive", ErrInvalidUpload)
+	}
+	if req.Size > s.maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the %d MB limit", ErrUploadTooLarge, req.Size, s.maxSize>>20)
+	}
+	if err := supportedManuscript(name, s.supportedFormats()); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(s.stagingDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload dir: %w", err)
+	}
+
+	now := time.Now()
+	upload := &domain.Upload{
+		ID:        uuid.New().String(),
+		ProjectID: project.ID,
+		UserID:    userID,
+		Filename:  name,
+		Format:    filepath.Ext(name)[1:],
+		Size:      req.Size,
+		Note:      req.Note,
+		Status:    domain.UploadStatusUploading,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.uploads.Create(upload); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// Get returns an upload of a project owned by userID
+func (s *UploadService) Get(userID, projectID, uploadID string) (*domain.Upload, error) {
+	project, err := ownedProject(s.projects, Caller{UserID: userID}, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := s.uploads.GetByID(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.ProjectID != project.ID {
+		return nil, domain.ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// WriteChunk appends a chunk starting at offset. Bytes received before a
+// read error are kept, so the client can resume from the returned offset.
+// The chunk carrying the last byte completes the upload: the file is
+// verified, checksummed and stored as the project's manuscript.
+func (s *UploadService) WriteChunk(ctx context.Context, userID, projectID, uploadID string, offset int64, r io.Reader) (*domain.Upload, error) {
+	unlock := s.lock(uploadID)
+	defer unlock()
+
+	upload, err := s.Get(userID, projectID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if !upload.IsOpen() {
+		return upload, ErrUploadClosed
+	}
+	if offset != upload.Offset {
+		return upload, fmt.Errorf("%w: upload is at %d, chunk starts at %d", ErrUploadOffsetMismatch, upload.Offset, offset)
+	}
+
+	body := bufio.NewReaderSize(r, sniffLen)
+	if offset == 0 {
+		head, _ := body.Peek(sniffLen)
+		if len(head) == 0 {
+			return upload, nil
+		}
+		contentType, err := sniffManuscript(upload.Filename, head)
+		if err != nil {
+			return upload, s.fail(upload, err)
+		}
+		upload.ContentType = contentType
+	}
+
+	written, copyErr := s.stage(upload, body)
+	upload.Offset += written
+	upload.UpdatedAt = time.Now()
+
+	// Anything beyond the declared size is refused
+	if copyErr == nil && upload.Remaining() == 0 {
+		if n, _ := body.Read(make([]byte, 1)); n > 0 {
+			copyErr = fmt.Errorf("%w: chunk goes past the declared size of %d bytes", ErrUploadTooLarge, upload.Size)
+		}
+	}
+
+	if err := s.uploads.Update(upload); err != nil {
+		return nil, err
+	}
+	if copyErr != nil {
+		return upload, copyErr
+	}
+
+	if upload.Remaining() == 0 {
+		return upload, s.complete(ctx, upload)
+	}
+	return upload, nil
+}
+
+// Upload receives a whole manuscript in one request
+func (s *UploadService) Upload(ctx context.Context, userID, projectID string, req CreateUploadRequest, r io.Reader) (*domain.Upload, error) {
+	upload, err := s.Create(userID, projectID, req)
+	if err != nil {
+		return nil, err
+	}
+	upload, err = s.WriteChunk(ctx, userID, projectID, upload.ID, 0, r)
+	if err == nil && upload.IsOpen() {
+		err = fmt.Errorf("%w: received %d of %d bytes", ErrInvalidUpload, upload.Offset, upload.Size)
+	}
+	return upload, err
+}
+
+// stage writes the chunk at the upload's offset, dropping bytes a crashed
+// earlier write may have left past it
+func (s *UploadService) stage(upload *domain.Upload, r io.Reader) (int64, error) {
+	f, err := os.OpenFile(s.stagingPath(upload), os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if err := f.Truncate(upload.Offset); err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(upload.Offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	written, err := io.Copy(f, io.LimitReader(r, upload.Remaining()))
+	if syncErr := f.Sync(); err == nil {
+		err = syncErr
+	}
+	return written, err
+}
+
+// complete verifies the staged file and hands it over as the manuscript
+func (s *UploadService) complete(ctx context.Context, upload *domain.Upload) error {
+	path := s.stagingPath(upload)
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := verifyManuscript(upload.Filename, f, upload.Size); err != nil {
+		return s.fail(upload, err)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	project, err := s.manuscripts.UploadManuscript(ctx, strconv.FormatUint(uint64(upload.ProjectID), 10), ManuscriptFile{
+		Filename:   upload.Filename,
+		Content:    f,
+		Size:       upload.Size,
+		UploadedBy: upload.UserID,
+		Note:       upload.Note,
+	})
+	if err != nil {
+		// The staged file stays, so completing can be retried
+		return err
+	}
+
+	upload.Checksum = hex.EncodeToString(hash.Sum(nil))
+	upload.ManuscriptURL = project.ManuscriptURL
+	upload.RevisionID = project.RevisionID
+	upload.Status = domain.UploadStatusCompleted
+	upload.UpdatedAt = time.Now()
+	if err := s.uploads.Update(upload); err != nil {
+		return err
+	}
+
+	os.Remove(path)
+	return nil
+}
+
+// fail closes the upload with reason and drops what was received
+func (s *UploadService) fail(upload *domain.Upload, reason error) error {
+	upload.Status = domain.UploadStatusFailed
+	upload.Error = reason.Error()
+	upload.UpdatedAt = time.Now()
+	os.Remove(s.stagingPath(upload))
+
+	if err := s.uploads.Update(upload); err != nil {
+		return errors.Join(reason, err)
+	}
+	return reason
+}
+
+// supportedFormats loads the converter's input formats once
+func (s *UploadService) supportedFormats() map[string]bool {
+	s.formatsOnce.Do(func() {
+		s.formats = make(map[string]bool)
+		if s.inputFormats != nil {
+			if formats, err := s.inputFormats(); err == nil {
+				for _, format := range formats {
+					s.formats[format] = true
+				}
+				return
+			}
+		}
+		for _, reader := range manuscriptFormats {
+			s.formats[reader] = true
+		}
+	})
+	return s.formats
+}
+
+// lock serializes the chunks of one upload. The lock is dropped with its last
+// holder, so completed, failed and abandoned uploads leave nothing behind.
+func (s *UploadService) lock(uploadID string) func() {
+	s.locksMu.Lock()
+	l, ok := s.locks[uploadID]
+	if !ok {
+		l = &uploadLock{}
+		s.locks[uploadID] = l
+	}
+	l.refs++
+	s.locksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		s.locksMu.Lock()
+		defer s.locksMu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(s.locks, uploadID)
+		}
+	}
+}
+
+func (s *UploadService) stagingPath(upload *domain.Upload) string {
+	return filepath.Join(s.stagingDir, upload.ID+".part")
+}
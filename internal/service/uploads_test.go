@@ -0,0 +1,255 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+// mockUploadStore keeps uploads in memory
+type mockUploadStore struct {
+	uploads map[string]domain.Upload
+}
+
+func (m *mockUploadStore) Create(upload *domain.Upload) error {
+	m.uploads[upload.ID] = *upload
+	return nil
+}
+
+func (m *mockUploadStore) GetByID(id string) (*domain.Upload, error) {
+	upload, ok := m.uploads[id]
+	if !ok {
+		return nil, domain.ErrUploadNotFound
+	}
+	return &upload, nil
+}
+
+func (m *mockUploadStore) Update(upload *domain.Upload) error {
+	m.uploads[upload.ID] = *upload
+	return nil
+}
+
+// recordingSaver keeps the manuscripts handed over by the upload service
+type recordingSaver struct {
+	files map[string][]byte
+}
+
+func (r *recordingSaver) UploadManuscript(ctx context.Context, projectID string, file ManuscriptFile) (*domain.Project, error) {
+	data, err := io.ReadAll(file.Content)
+	if err != nil {
+		return nil, err
+	}
+	r.files[file.Filename] = data
+	return &domain.Project{ManuscriptURL: "file:///storage/projects/" + projectID + "/manuscript/" + file.Filename}, nil
+}
+
+// newTestUploads returns an upload service for project 1 (owned by alice)
+func newTestUploads(t *testing.T, opts ...UploadServiceOption) (*UploadService, *mockUploadStore, *recordingSaver) {
+	store := &mockUploadStore{uploads: make(map[string]domain.Upload)}
+	saver := &recordingSaver{files: make(map[string][]byte)}
+	projects := webhookFixtures{projects: map[string]*domain.Project{
+		"1": {ID: 1, UserID: "alice"},
+	}}
+	return NewUploadService(store, projects, saver, t.TempDir(), opts...), store, saver
+}
+
+// docxFile builds a minimal Word package
+func docxFile(t *testing.T, entries ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("zip Create failed: %v", err)
+		}
+		w.Write([]byte("<xml/>"))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestUploadService_ResumableChunks(t *testing.T) {
+	uploads, store, saver := newTestUploads(t)
+	ctx := context.Background()
+	content := []byte(strings.Repeat("# Capítulo 1\n\nEra uma vez...\n", 40))
+
+	upload, err := uploads.Create("alice", "1", CreateUploadRequest{Filename: "book.md", Size: int64(len(content))})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// First chunk, then a dropped connection halfway through the second
+	first := content[:300]
+	upload, err = uploads.WriteChunk(ctx, "alice", "1", upload.ID, 0, bytes.NewReader(first))
+	if err != nil || upload.Offset != 300 || upload.ContentType != "text/markdown; charset=utf-8" {
+		t.Fatalf("Unexpected first chunk result %+v, %v", upload, err)
+	}
+	broken := io.MultiReader(bytes.NewReader(content[300:400]), iotest.ErrReader(io.ErrUnexpectedEOF))
+	upload, err = uploads.WriteChunk(ctx, "alice", "1", upload.ID, 300, broken)
+	if err == nil || upload.Offset != 400 {
+		t.Fatalf("Expected the received bytes kept after a read error, got %+v, %v", upload, err)
+	}
+
+	// Resuming from a stale offset is refused
+	if _, err := uploads.WriteChunk(ctx, "alice", "1", upload.ID, 300, bytes.NewReader(content[300:])); !errors.Is(err, ErrUploadOffsetMismatch) {
+		t.Errorf("Expected ErrUploadOffsetMismatch, got %v", err)
+	}
+
+	stored, _ := store.GetByID(upload.ID)
+	upload, err = uploads.WriteChunk(ctx, "alice", "1", upload.ID, stored.Offset, bytes.NewReader(content[stored.Offset:]))
+	if err != nil {
+		t.Fatalf("Last chunk failed: %v", err)
+	}
+
+	sum := sha256.Sum256(content)
+	if upload.Status != domain.UploadStatusCompleted || upload.Checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected a completed upload with its checksum, got %+v", upload)
+	}
+	if upload.ManuscriptURL == "" {
+		t.Error("Expected the manuscript URL")
+	}
+	if !bytes.Equal(saver.files["book.md"], content) {
+		t.Errorf("Stored manuscript differs from the upload (%d bytes)", len(saver.files["book.md"]))
+	}
+
+	if _, err := uploads.WriteChunk(ctx, "alice", "1", upload.ID, upload.Offset, strings.NewReader("x")); !errors.Is(err, ErrUploadClosed) {
+		t.Errorf("Expected ErrUploadClosed after completion, got %v", err)
+	}
+	if len(uploads.locks) != 0 {
+		t.Errorf("Expected no chunk locks left, got %d", len(uploads.locks))
+	}
+}
+
+func TestUploadService_LockSerializesChunks(t *testing.T) {
+	uploads, _, _ := newTestUploads(t)
+
+	unlock := uploads.lock("u1")
+	locked := make(chan struct{})
+	go func() {
+		defer close(locked)
+		uploads.lock("u1")()
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("Expected the second chunk to wait for the first")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+
+	if len(uploads.locks) != 0 {
+		t.Errorf("Expected the lock dropped with its last holder, got %d", len(uploads.locks))
+	}
+}
+
+func TestUploadService_CreateValidates(t *testing.T) {
+	uploads, _, _ := newTestUploads(t, WithMaxUploadSize(1<<20), WithInputFormats(func() ([]string, error) {
+		return []string{"docx", "markdown"}, nil
+	}))
+
+	tests := []struct {
+		name   string
+		userID string
+		req    CreateUploadRequest
+		want   error
+	}{
+		{"too large", "alice", CreateUploadRequest{Filename: "book.md", Size: 2 << 20}, ErrUploadTooLarge},
+		{"unknown extension", "alice", CreateUploadRequest{Filename: "book.exe", Size: 10}, ErrUnsupportedFormat},
+		{"reader not in pandoc", "alice", CreateUploadRequest{Filename: "book.odt", Size: 10}, ErrUnsupportedFormat},
+		{"empty", "alice", CreateUploadRequest{Filename: "book.md"}, ErrInvalidUpload},
+		{"other user", "bob", CreateUploadRequest{Filename: "book.md", Size: 10}, ErrProjectAccessDenied},
+	}
+	for _, tt := range tests {
+		if _, err := uploads.Create(tt.userID, "1", tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	upload, err := uploads.Create("alice", "1", CreateUploadRequest{Filename: "../../etc/Book.DOCX", Size: 10})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if upload.Filename != "Book.DOCX" {
+		t.Errorf("Expected the base name only, got %q", upload.Filename)
+	}
+}
+
+func TestUploadService_RejectsWrongContent(t *testing.T) {
+	uploads, store, saver := newTestUploads(t)
+	ctx := context.Background()
+
+	// A PDF renamed to .docx is refused on the first chunk
+	pdf := []byte("%PDF-1.7\n%âãÏÓ\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	_, err := uploads.Upload(ctx, "alice", "1", CreateUploadRequest{Filename: "book.docx", Size: int64(len(pdf))}, bytes.NewReader(pdf))
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat for a PDF named .docx, got %v", err)
+	}
+
+	// A zip without a Word document passes sniffing but not verification
+	notWord := docxFile(t, "content.xml")
+	upload, err := uploads.Upload(ctx, "alice", "1", CreateUploadRequest{Filename: "book.docx", Size: int64(len(notWord))}, bytes.NewReader(notWord))
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat for a zip without word/document.xml, got %v", err)
+	}
+	if stored, _ := store.GetByID(upload.ID); stored.Status != domain.UploadStatusFailed || stored.Error == "" {
+		t.Errorf("Expected the upload marked failed, got %+v", stored)
+	}
+	if len(saver.files) != 0 {
+		t.Errorf("Expected nothing stored, got %d files", len(saver.files))
+	}
+
+	docx := docxFile(t, "[Content_Types].xml", "word/document.xml")
+	upload, err = uploads.Upload(ctx, "alice", "1", CreateUploadRequest{Filename: "book.docx", Size: int64(len(docx))}, bytes.NewReader(docx))
+	if err != nil || upload.Status != domain.UploadStatusCompleted {
+		t.Errorf("Expected a valid docx accepted, got %+v, %v", upload, err)
+	}
+}
+
+func TestUploadService_RejectsExtraBytes(t *testing.T) {
+	uploads, _, saver := newTestUploads(t)
+
+	upload, err := uploads.Create("alice", "1", CreateUploadRequest{Filename: "book.txt", Size: 5})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := uploads.WriteChunk(context.Background(), "alice", "1", upload.ID, 0, strings.NewReader("hello world")); !errors.Is(err, ErrUploadTooLarge) {
+		t.Errorf("Expected ErrUploadTooLarge for bytes past the declared size, got %v", err)
+	}
+	if len(saver.files) != 0 {
+		t.Error("Expected nothing stored")
+	}
+}
+
+func TestSniffManuscript(t *testing.T) {
+	tests := []struct {
+		filename string
+		head     string
+		ok       bool
+	}{
+		{"book.md", "# Título\n\nTexto em português", true},
+		{"book.md", "\x00\x01\x02binary", false},
+		{"book.txt", "ação" + string([]byte{0xc3}), true}, // truncated last rune
+		{"book.rtf", `{\rtf1\ansi hello}`, true},
+		{"book.rtf", "plain text", false},
+		{"book.html", "<!DOCTYPE html><html><body>Hi</body></html>", true},
+		{"book.epub", "PK\x03\x04mimetypeapplication/epub+zip", true},
+		{"book.odt", "%PDF-1.7", false},
+	}
+	for _, tt := range tests {
+		_, err := sniffManuscript(tt.filename, []byte(tt.head))
+		if (err == nil) != tt.ok {
+			t.Errorf("sniffManuscript(%q, %q): expected ok=%v, got %v", tt.filename, tt.head, tt.ok, err)
+		}
+	}
+}
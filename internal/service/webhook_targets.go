@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// webhookLookupTimeout bounds resolving a webhook host on registration
+const webhookLookupTimeout = 5 * time.Second
+
+// ErrWebhookTargetForbidden means a webhook URL points at a loopback, private,
+// link-local or otherwise internal address
+var ErrWebhookTargetForbidden = errors.New("webhook target is not a public address")
+
+// blockedWebhookPrefixes are the non-public ranges netip does not classify:
+// "this network", carrier-grade NAT, IETF protocol assignments, benchmarking
+// and the reserved class E
+var blockedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// hostLookup resolves a host name to its addresses
+type hostLookup func(ctx context.Context, host string) ([]netip.Addr, error)
+
+// lookupHost resolves with the system resolver
+func lookupHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+// isPublicAddr reports whether webhooks may be delivered to addr.
+// IPv4-mapped IPv6 addresses are judged by their IPv4 address.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookHost rejects a host that is, or resolves to, a non-public
+// address. Every address counts: one internal record is enough to reject it.
+func checkWebhookHost(ctx context.Context, lookup hostLookup, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !isPublicAddr(addr) {
+			return fmt.Errorf("%w: %s", ErrWebhookTargetForbidden, host)
+		}
+		return nil
+	}
+
+	addrs, err := lookup(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("failed to resolve %s: no addresses", host)
+	}
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrWebhookTargetForbidden, host, addr)
+		}
+	}
+	return nil
+}
+
+// newWebhookClient creates the delivery client. Its dialer checks the address
+// it actually connects to with allowed, after DNS resolution, so a host that
+// resolves elsewhere at delivery time than on registration still cannot reach
+// internal services. Redirects are returned as the response instead of being
+// followed, and environment proxies are ignored (the dialer must see the
+// subscriber's address).
+func newWebhookClient(timeout time.Duration, allowed func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !allowed(addr) {
+				return fmt.Errorf("%w: %s", ErrWebhookTargetForbidden, addr)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::1":   true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"0.0.0.0":              false,
+		"::":                   false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.0.1":          false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"100.64.0.1":           false,
+		"224.0.0.1":            false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:93.184.216.34": true,
+	}
+	for address, public := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(address)); got != public {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", address, got, public)
+		}
+	}
+}
+
+// newDueDelivery stores a webhook for url with one delivery due now
+func newDueDelivery(url string) (*mockWebhookStore, *domain.WebhookDelivery) {
+	store := &mockWebhookStore{}
+	store.Create(&domain.Webhook{ID: "h", URL: url, Secret: "s", Active: true})
+	now := time.Now()
+	delivery := &domain.WebhookDelivery{ID: "d", WebhookID: "h", Event: domain.WebhookEventJobFailed, Status: domain.WebhookDeliveryPending, MaxAttempts: 3, NextAttemptAt: &now}
+	store.CreateDelivery(delivery)
+	return store, delivery
+}
+
+func TestWebhookDispatcher_RefusesPrivateTargets(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer server.Close()
+
+	// A stored URL that now points at loopback, e.g. after a DNS change
+	store, delivery := newDueDelivery(server.URL)
+	NewWebhookDispatcher(store).DispatchDue(context.Background())
+
+	if hits != 0 {
+		t.Errorf("Expected no request to reach the loopback server, got %d", hits)
+	}
+	if delivery.Status != domain.WebhookDeliveryPending || !strings.Contains(delivery.Error, ErrWebhookTargetForbidden.Error()) {
+		t.Errorf("Expected a failed attempt refusing the address, got %+v", delivery)
+	}
+}
+
+func TestWebhookDispatcher_DoesNotFollowRedirects(t *testing.T) {
+	internalHits := 0
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalHits++
+	}))
+	defer internal.Close()
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	}))
+	defer subscriber.Close()
+
+	// Loopback allowed, to reach the test servers
+	client := newWebhookClient(time.Second, func(netip.Addr) bool { return true })
+	store, delivery := newDueDelivery(subscriber.URL)
+	NewWebhookDispatcher(store, WithWebhookClient(client)).DispatchDue(context.Background())
+
+	if internalHits != 0 {
+		t.Errorf("Expected the redirect not to be followed")
+	}
+	if delivery.Status == domain.WebhookDeliverySucceeded || delivery.ResponseStatus != http.StatusFound {
+		t.Errorf("Expected the redirect to count as a failed attempt, got %+v", delivery)
+	}
+}
+
+func TestCheckWebhookHost(t *testing.T) {
+	failing := func(ctx context.Context, host string) ([]netip.Addr, error) {
+		return nil, errors.New("no such host")
+	}
+	if err := checkWebhookHost(context.Background(), failing, "missing.example"); err == nil || errors.Is(err, ErrWebhookTargetForbidden) {
+		t.Errorf("Expected a resolution error, got %v", err)
+	}
+	if err := checkWebhookHost(context.Background(), fakeLookup, "hooks.internal"); !errors.Is(err, ErrWebhookTargetForbidden) {
+		t.Errorf("Expected a host with one private record to be refused, got %v", err)
+	}
+	if err := checkWebhookHost(context.Background(), fakeLookup, "example.com"); err != nil {
+		t.Errorf("Expected a public host to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,508 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Headers sent with every webhook delivery.
+// The signature is "sha256=" followed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook secret.
+const (
+	WebhookHeaderEvent     = "X-Typecraft-Event"
+	WebhookHeaderDelivery  = "X-Typecraft-Delivery"
+	WebhookHeaderTimestamp = "X-Typecraft-Timestamp"
+	WebhookHeaderSignature = "X-Typecraft-Signature"
+)
+
+const (
+	defaultWebhookMaxAttempts  = 8
+	defaultWebhookBackoffBase  = 30 * time.Second
+	defaultWebhookBackoffMax   = time.Hour
+	defaultWebhookTimeout      = 10 * time.Second
+	defaultWebhookPollInterval = 5 * time.Second
+	webhookBatchSize           = 20
+
+	// webhookResponseLimit bounds how much of a subscriber response is logged
+	webhookResponseLimit = 4096
+)
+
+var (
+	// ErrInvalidWebhook is returned for a subscription with a bad URL, event or project
+	ErrInvalidWebhook = errors.New("invalid webhook")
+	// ErrWebhookAccessDenied means the subscription belongs to someone else
+	ErrWebhookAccessDenied = errors.New("webhook access denied")
+)
+
+// WebhookStore persists subscriptions and their delivery log
+// (implemented by repository.WebhookRepository)
+type WebhookStore interface {
+	Create(hook *domain.Webhook) error
+	GetByID(id string) (*domain.Webhook, error)
+	List(filter domain.WebhookFilter) ([]*domain.Webhook, error)
+	Subscribers(userID, projectID string) ([]*domain.Webhook, error)
+	Delete(id string) error
+	CreateDelivery(delivery *domain.WebhookDelivery) error
+	ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error)
+	UpdateDelivery(delivery *domain.WebhookDelivery) error
+	ListDeliveries(webhookID string, limit, offset int) ([]*domain.WebhookDelivery, int64, error)
+}
+
+// ProjectLookup loads projects by ID (implemented by repository.ProjectRepository)
+type ProjectLookup interface {
+	GetByID(id string) (*domain.Project, error)
+}
+
+// JobLookup loads jobs by ID (implemented by repository.JobRepository)
+type JobLookup interface {
+	GetByID(id string) (*domain.Job, error)
+}
+
+// WebhookPayload is the JSON body of a delivery
+type WebhookPayload struct {
+	ID             string               `json:"id"` // delivery ID, the same on every retry
+	Event          domain.WebhookEvent  `json:"event"`
+	CreatedAt      time.Time            `json:"created_at"`
+	Project        *domain.Project      `json:"project"`
+	PreviousStatus domain.ProjectStatus `json:"previous_status,omitempty"`
+	Job            *domain.Job          `json:"job,omitempty"`
+	Generation     *GenerationJobResult `json:"generation,omitempty"`
+	Warnings       []string             `json:"warnings,omitempty"`
+}
+
+// CreateWebhookRequest subscribes a URL to the events of a project, or of
+// every project of the user when ProjectID is empty
+type CreateWebhookRequest struct {
+	URL       string                `json:"url" binding:"required"`
+	ProjectID string                `json:"project_id"`
+	Events    []domain.WebhookEvent `json:"events"` // empty subscribes to every event
+	Secret    string                `json:"secret"` // generated when empty
+}
+
+// WebhookCreated is a new subscription along with its signing secret,
+// which is only ever returned here
+type WebhookCreated struct {
+	*domain.Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookDeliveryList is a page of the delivery log of a webhook
+type WebhookDeliveryList struct {
+	Deliveries []*domain.WebhookDelivery `json:"deliveries"`
+	Total      int64                     `json:"total"`
+	Limit      int                       `json:"limit"`
+	Offset     int                       `json:"offset"`
+}
+
+// WebhookService manages webhook subscriptions and turns project events into
+// deliveries. It is an EventPublisher: plug it next to the event store and
+// every status change, completed generation or failed job is queued for the
+// matching subscribers; WebhookDispatcher sends them.
+type WebhookService struct {
+	store    WebhookStore
+	projects ProjectLookup
+	jobs     JobLookup
+	now      func() time.Time
+	lookup   hostLookup
+}
+
+// NewWebhookService creates the webhook service
+func NewWebhookService(store WebhookStore, projects ProjectLookup, jobs JobLookup) *WebhookService {
+	return &WebhookService{store: store, projects: projects, jobs: jobs, now: time.Now, lookup: lookupHost}
+}
+
+// owned loads a subscription, checking that userID owns it
+func (s *WebhookService) owned(userID, id string) (*domain.Webhook, error) {
+	hook, err := s.store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if hook.UserID != userID {
+		return nil, ErrWebhookAccessDenied
+	}
+	return hook, nil
+}
+
+// Create validates and stores a subscription of userID. The URL must point
+// at a public address (see checkWebhookHost); deliveries check it again when
+// connecting.
+func (s *WebhookService) Create(userID string, req CreateWebhookRequest) (*WebhookCreated, error) {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookLookupTimeout)
+	defer cancel()
+	if err := checkWebhookHost(ctx, s.lookup, target.Hostname()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	for _, event := range req.Events {
+		if !event.IsValid() {
+			return nil, fmt.Errorf("%w: unknown event %q (available: %v)", ErrInvalidWebhook, event, domain.WebhookEvents)
+		}
+	}
+	if req.ProjectID != "" {
+		project, err := s.projects.GetByID(req.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: project %s: %v", ErrInvalidWebhook, req.ProjectID, err)
+		}
+		if project.UserID != userID {
+			return nil, fmt.Errorf("%w: project %s belongs to another user", ErrInvalidWebhook, req.ProjectID)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	hook := &domain.Webhook{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		ProjectID: req.ProjectID,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    req.Events,
+		Active:    true,
+	}
+	if err := s.store.Create(hook); err != nil {
+		return nil, err
+	}
+	return &WebhookCreated{Webhook: hook, Secret: secret}, nil
+}
+
+// List returns the subscriptions matching filter
+func (s *WebhookService) List(filter domain.WebhookFilter) ([]*domain.Webhook, error) {
+	return s.store.List(filter)
+}
+
+// Get returns a subscription of userID
+func (s *WebhookService) Get(userID, id string) (*domain.Webhook, error) {
+	return s.owned(userID, id)
+}
+
+// Delete removes a subscription of userID and its delivery log
+func (s *WebhookService) Delete(userID, id string) error {
+	if _, err := s.owned(userID, id); err != nil {
+		return err
+	}
+	return s.store.Delete(id)
+}
+
+// Deliveries returns the delivery log of a webhook of userID, newest first
+func (s *WebhookService) Deliveries(userID, webhookID string, limit, offset int) (*WebhookDeliveryList, error) {
+	if _, err := s.owned(userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	filter := normalizeJobFilter(domain.JobFilter{Limit: limit, Offset: offset})
+	deliveries, total, err := s.store.ListDeliveries(webhookID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	if deliveries == nil {
+		deliveries = []*domain.WebhookDelivery{}
+	}
+	return &WebhookDeliveryList{Deliveries: deliveries, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// Publish queues deliveries for the project events webhooks care about:
+// status transitions, completed generations (and their warnings) and jobs
+// that failed for good. Other events are ignored.
+func (s *WebhookService) Publish(ctx context.Context, projectID, eventType string, data interface{}) error {
+	var payloads []WebhookPayload
+
+	switch event := data.(type) {
+	case ProjectStatusEvent:
+		if eventType != EventProjectStatus || event.Status == event.Previous {
+			return nil
+		}
+		payloads = append(payloads, WebhookPayload{Event: domain.WebhookEventStatusChanged, PreviousStatus: event.Previous})
+
+	case JobStatusEvent:
+		if eventType != EventJobStatus || event.Status != domain.JobStatusFailed {
+			return nil
+		}
+		job, err := s.jobs.GetByID(event.JobID)
+		if err != nil {
+			return s.logError(projectID, eventType, err)
+		}
+		payloads = append(payloads, WebhookPayload{Event: domain.WebhookEventJobFailed, Job: job})
+
+	case GenerationJobResult:
+		if eventType != EventGenerationCompleted {
+			return nil
+		}
+		generation := event
+		payloads = append(payloads, WebhookPayload{Event: domain.WebhookEventGenerationCompleted, Generation: &generation})
+		if len(event.Warnings) > 0 {
+			payloads = append(payloads, WebhookPayload{Event: domain.WebhookEventValidationWarnings, Warnings: event.Warnings})
+		}
+
+	default:
+		return nil
+	}
+
+	project, err := s.projects.GetByID(projectID)
+	if err != nil {
+		return s.logError(projectID, eventType, err)
+	}
+	hooks, err := s.store.Subscribers(project.UserID, projectID)
+	if err != nil {
+		return s.logError(projectID, eventType, err)
+	}
+
+	for _, payload := range payloads {
+		payload.Project = project
+		for _, hook := range hooks {
+			if !hook.Subscribes(payload.Event, project) {
+				continue
+			}
+			if err := s.enqueue(hook, payload); err != nil {
+				return s.logError(projectID, eventType, err)
+			}
+		}
+	}
+	return nil
+}
+
+// enqueue stores a delivery of payload to hook, due right away
+func (s *WebhookService) enqueue(hook *domain.Webhook, payload WebhookPayload) error {
+	now := s.now()
+	payload.ID = uuid.New().String()
+	payload.CreatedAt = now
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	return s.store.CreateDelivery(&domain.WebhookDelivery{
+		ID:            payload.ID,
+		WebhookID:     hook.ID,
+		Event:         payload.Event,
+		Payload:       string(body),
+		Status:        domain.WebhookDeliveryPending,
+		MaxAttempts:   defaultWebhookMaxAttempts,
+		NextAttemptAt: &now,
+	})
+}
+
+// logError logs a failure to queue deliveries; publishers never fail the
+// work that produced the event
+func (s *WebhookService) logError(projectID, eventType string, err error) error {
+	log.Error().Err(err).Str("project_id", projectID).Str("event", eventType).Msg("failed to queue webhook deliveries")
+	return err
+}
+
+// SignWebhook returns the signature header value of a delivery body sent at
+// timestamp (Unix seconds). Receivers recompute it with their secret and
+// compare with hmac.Equal.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookSecret generates a random signing secret
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// WebhookDispatcher sends due deliveries, retrying failures with exponential
+// backoff until MaxAttempts. Several dispatchers can share the store: claimed
+// deliveries are hidden from the others while being sent.
+type WebhookDispatcher struct {
+	store        WebhookStore
+	client       *http.Client
+	pollInterval time.Duration
+	backoffBase  time.Duration
+	backoffMax   time.Duration
+	now          func() time.Time
+}
+
+// WebhookDispatcherOption configures a WebhookDispatcher
+type WebhookDispatcherOption func(*WebhookDispatcher)
+
+// WithWebhookClient sets the HTTP client used for deliveries (its Timeout
+// bounds each attempt). It replaces the default client, and with it the
+// refusal to connect to non-public addresses and to follow redirects.
+func WithWebhookClient(client *http.Client) WebhookDispatcherOption {
+	return func(d *WebhookDispatcher) {
+		d.client = client
+	}
+}
+
+// WithWebhookBackoff sets the delay before the first retry and its upper bound
+func WithWebhookBackoff(base, max time.Duration) WebhookDispatcherOption {
+	return func(d *WebhookDispatcher) {
+		d.backoffBase = base
+		d.backoffMax = max
+	}
+}
+
+// WithWebhookPollInterval sets how long an idle dispatcher waits before looking again
+func WithWebhookPollInterval(interval time.Duration) WebhookDispatcherOption {
+	return func(d *WebhookDispatcher) {
+		d.pollInterval = interval
+	}
+}
+
+// NewWebhookDispatcher creates a dispatcher for the deliveries in store
+func NewWebhookDispatcher(store WebhookStore, opts ...WebhookDispatcherOption) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		store:        store,
+		client:       newWebhookClient(defaultWebhookTimeout, isPublicAddr),
+		pollInterval: defaultWebhookPollInterval,
+		backoffBase:  defaultWebhookBackoffBase,
+		backoffMax:   defaultWebhookBackoffMax,
+		now:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Run dispatches due deliveries until ctx is cancelled
+func (d *WebhookDispatcher) Run(ctx context.Context) error {
+	for {
+		sent, err := d.DispatchDue(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to dispatch webhooks")
+		}
+		if sent > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
+
+// DispatchDue sends one batch of due deliveries and returns how many were attempted
+func (d *WebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	// Claimed for as long as sending the whole batch can take
+	deliveries, err := d.store.ClaimDeliveries(d.now(), webhookBatchSize*d.attemptTimeout(), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			// Left claimed: they are retried once the claim expires
+			return 0, nil
+		}
+		d.deliver(ctx, delivery)
+	}
+	return len(deliveries), nil
+}
+
+// deliver makes one attempt and records its outcome
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *domain.WebhookDelivery) {
+	logger := log.With().Str("delivery_id", delivery.ID).Str("webhook_id", delivery.WebhookID).
+		Str("event", string(delivery.Event)).Logger()
+
+	delivery.Attempts++
+	start := d.now()
+
+	status, body, err := d.send(ctx, delivery)
+	delivery.DurationMs = d.now().Sub(start).Milliseconds()
+	if err == nil {
+		delivery.MarkSucceeded(status, body, d.now())
+	} else {
+		delivery.MarkAttemptFailed(status, body, err, d.now().Add(d.backoff(delivery.Attempts)))
+		logger.Warn().Err(err).Int("attempt", delivery.Attempts).Str("status", string(delivery.Status)).Msg("webhook delivery failed")
+	}
+
+	if err := d.store.UpdateDelivery(delivery); err != nil {
+		logger.Error().Err(err).Msg("failed to save webhook delivery")
+	}
+}
+
+// send posts the signed payload and returns the response status and body.
+// A redirect counts as a failure: it is not followed.
+func (d *WebhookDispatcher) send(ctx context.Context, delivery *domain.WebhookDelivery) (int, string, error) {
+	hook, err := d.store.GetByID(delivery.WebhookID)
+	if err != nil {
+		return 0, "", err
+	}
+	if !hook.Active {
+		return 0, "", errors.New("webhook is inactive")
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := d.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Typecraft-Webhooks/1.0")
+	req.Header.Set(WebhookHeaderEvent, string(delivery.Event))
+	req.Header.Set(WebhookHeaderDelivery, delivery.ID)
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookHeaderSignature, SignWebhook(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(response), fmt.Errorf("subscriber responded %s", resp.Status)
+	}
+	return resp.StatusCode, string(response), nil
+}
+
+// backoff doubles the delay after every failed attempt, up to backoffMax
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	delay := d.backoffBase
+	for i := 1; i < attempt && delay < d.backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > d.backoffMax {
+		return d.backoffMax
+	}
+	return delay
+}
+
+// attemptTimeout is how long a single delivery attempt may take
+func (d *WebhookDispatcher) attemptTimeout() time.Duration {
+	if d.client.Timeout > 0 {
+		return d.client.Timeout
+	}
+	return defaultWebhookTimeout
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+)
+
+// mockWebhookStore implements WebhookStore in memory
+type mockWebhookStore struct {
+	mu         sync.Mutex
+	hooks      []*domain.Webhook
+	deliveries []*domain.WebhookDelivery
+}
+
+func (m *mockWebhookStore) Create(hook *domain.Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+	return nil
+}
+
+func (m *mockWebhookStore) GetByID(id string) (*domain.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, hook := range m.hooks {
+		if hook.ID == id {
+			return hook, nil
+		}
+	}
+	return nil, domain.ErrWebhookNotFound
+}
+
+func (m *mockWebhookStore) List(filter domain.WebhookFilter) ([]*domain.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var hooks []*domain.Webhook
+	for _, hook := range m.hooks {
+		if (filter.UserID == "" || hook.UserID == filter.UserID) && (filter.ProjectID == "" || hook.ProjectID == filter.ProjectID) {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+func (m *mockWebhookStore) Subscribers(userID, projectID string) ([]*domain.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var hooks []*domain.Webhook
+	for _, hook := range m.hooks {
+		if hook.Active && (hook.ProjectID == projectID || (hook.ProjectID == "" && hook.UserID == userID)) {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+func (m *mockWebhookStore) Delete(id string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockWebhookStore) CreateDelivery(delivery *domain.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries = append(m.deliveries, delivery)
+	return nil
+}
+
+func (m *mockWebhookStore) ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []*domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.Status == domain.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) && len(due) < limit {
+			until := now.Add(lease)
+			delivery.NextAttemptAt = &until
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+func (m *mockWebhookStore) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	return nil
+}
+
+func (m *mockWebhookStore) ListDeliveries(webhookID string, limit, offset int) ([]*domain.WebhookDelivery, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deliveries []*domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, int64(len(deliveries)), nil
+}
+
+// webhookFixtures implements ProjectLookup
+type webhookFixtures struct {
+	projects map[string]*domain.Project
+}
+
+func (f webhookFixtures) GetByID(id string) (*domain.Project, error) {
+	if project, ok := f.projects[id]; ok {
+		return project, nil
+	}
+	return nil, domain.ErrProjectNotFound
+}
+
+// webhookJobs implements JobLookup
+type webhookJobs map[string]*domain.Job
+
+func (j webhookJobs) GetByID(id string) (*domain.Job, error) {
+	if job, ok := j[id]; ok {
+		return job, nil
+	}
+	return nil, domain.ErrJobNotFound
+}
+
+func newTestWebhookService() (*WebhookService, *mockWebhookStore) {
+	store := &mockWebhookStore{}
+	projects := webhookFixtures{projects: map[string]*domain.Project{
+		"1": {ID: 1, UserID: "alice", Title: "Book One", Status: domain.StatusRendering},
+		"2": {ID: 2, UserID: "bob", Title: "Book Two"},
+	}}
+	jobs := webhookJobs{"j1": {ID: "j1", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusFailed, ErrorMsg: "lualatex failed"}}
+	webhooks := NewWebhookService(store, projects, jobs)
+	webhooks.lookup = fakeLookup
+	return webhooks, store
+}
+
+// fakeLookup resolves "localhost" and *.internal to private addresses and
+// every other host to a public one, without DNS
+func fakeLookup(ctx context.Context, host string) ([]netip.Addr, error) {
+	switch {
+	case host == "localhost":
+		return []netip.Addr{netip.MustParseAddr("127.0.0.1")}, nil
+	case strings.HasSuffix(host, ".internal"):
+		return []netip.Addr{netip.MustParseAddr("93.184.216.34"), netip.MustParseAddr("10.0.0.5")}, nil
+	}
+	return []netip.Addr{netip.MustParseAddr("93.184.216.34")}, nil
+}
+
+func TestWebhookService_CreateValidates(t *testing.T) {
+	webhooks, _ := newTestWebhookService()
+
+	tests := []struct {
+		name string
+		req  CreateWebhookRequest
+	}{
+		{"relative URL", CreateWebhookRequest{URL: "/hooks"}},
+		{"unsupported scheme", CreateWebhookRequest{URL: "ftp://example.com/hook"}},
+		{"unknown event", CreateWebhookRequest{URL: "https://example.com/hook", Events: []domain.WebhookEvent{"book.sold"}}},
+		{"missing project", CreateWebhookRequest{URL: "https://example.com/hook", ProjectID: "99"}},
+		{"project of another user", CreateWebhookRequest{URL: "https://example.com/hook", ProjectID: "2"}},
+		{"loopback", CreateWebhookRequest{URL: "http://127.0.0.1:8000/hook"}},
+		{"localhost", CreateWebhookRequest{URL: "http://localhost/hook"}},
+		{"IPv6 loopback", CreateWebhookRequest{URL: "http://[::1]/hook"}},
+		{"cloud metadata", CreateWebhookRequest{URL: "http://169.254.169.254/latest/meta-data"}},
+		{"private range", CreateWebhookRequest{URL: "https://192.168.1.10/hook"}},
+		{"IPv4-mapped private", CreateWebhookRequest{URL: "http://[::ffff:10.0.0.1]/hook"}},
+		{"name resolving to a private address", CreateWebhookRequest{URL: "https://hooks.internal/hook"}},
+	}
+	for _, tt := range tests {
+		if _, err := webhooks.Create("alice", tt.req); !errors.Is(err, ErrInvalidWebhook) {
+			t.Errorf("%s: expected ErrInvalidWebhook, got %v", tt.name, err)
+		}
+	}
+
+	created, err := webhooks.Create("alice", CreateWebhookRequest{URL: "https://example.com/hook", ProjectID: "1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(created.Secret) != 64 || created.Webhook.Secret != created.Secret || !created.Active {
+		t.Errorf("Expected an active webhook with a generated secret, got %+v", created)
+	}
+
+	// Only the owner sees or deletes it
+	if _, err := webhooks.Get("bob", created.Webhook.ID); !errors.Is(err, ErrWebhookAccessDenied) {
+		t.Errorf("Expected Get by another user to be denied, got %v", err)
+	}
+	if _, err := webhooks.Deliveries("bob", created.Webhook.ID, 10, 0); !errors.Is(err, ErrWebhookAccessDenied) {
+		t.Errorf("Expected Deliveries by another user to be denied, got %v", err)
+	}
+	if err := webhooks.Delete("bob", created.Webhook.ID); !errors.Is(err, ErrWebhookAccessDenied) {
+		t.Errorf("Expected Delete by another user to be denied, got %v", err)
+	}
+	if _, err := webhooks.Get("alice", created.Webhook.ID); err != nil {
+		t.Errorf("Get failed: %v", err)
+	}
+}
+
+func TestWebhookService_PublishQueuesMatchingDeliveries(t *testing.T) {
+	webhooks, store := newTestWebhookService()
+	ctx := context.Background()
+
+	projectHook, _ := webhooks.Create("alice", CreateWebhookRequest{URL: "https://example.com/a", ProjectID: "1"})
+	userHook, _ := webhooks.Create("alice", CreateWebhookRequest{URL: "https://example.com/b", Events: []domain.WebhookEvent{domain.WebhookEventJobFailed}})
+	webhooks.Create("bob", CreateWebhookRequest{URL: "https://example.com/c"})
+
+	// Same status again is not a transition
+	webhooks.Publish(ctx, "1", EventProjectStatus, ProjectStatusEvent{Status: domain.StatusRendering, Previous: domain.StatusRendering})
+	// Progress and non-failed jobs are not webhook events
+	webhooks.Publish(ctx, "1", EventGenerationProgress, GenerationProgress{ProjectID: 1})
+	webhooks.Publish(ctx, "1", EventJobStatus, JobStatusEvent{JobID: "j1", Status: domain.JobStatusRunning})
+	if len(store.deliveries) != 0 {
+		t.Fatalf("Expected no deliveries, got %d", len(store.deliveries))
+	}
+
+	webhooks.Publish(ctx, "1", EventProjectStatus, ProjectStatusEvent{Status: domain.StatusRendering, Previous: domain.StatusDesigning})
+	webhooks.Publish(ctx, "1", EventJobStatus, JobStatusEvent{JobID: "j1", Status: domain.JobStatusFailed})
+	webhooks.Publish(ctx, "1", EventGenerationCompleted, GenerationJobResult{Pipeline: "latex", Warnings: []string{"Overfull \\hbox"}})
+
+	got := map[string][]domain.WebhookEvent{}
+	for _, delivery := range store.deliveries {
+		got[delivery.WebhookID] = append(got[delivery.WebhookID], delivery.Event)
+	}
+	expectedProject := []domain.WebhookEvent{
+		domain.WebhookEventStatusChanged,
+		domain.WebhookEventJobFailed,
+		domain.WebhookEventGenerationCompleted,
+		domain.WebhookEventValidationWarnings,
+	}
+	if len(got[projectHook.ID]) != len(expectedProject) {
+		t.Errorf("Expected %v for the project webhook, got %v", expectedProject, got[projectHook.ID])
+	}
+	for i, event := range expectedProject {
+		if i < len(got[projectHook.ID]) && got[projectHook.ID][i] != event {
+			t.Errorf("Expected %v for the project webhook, got %v", expectedProject, got[projectHook.ID])
+			break
+		}
+	}
+	if len(got[userHook.ID]) != 1 || got[userHook.ID][0] != domain.WebhookEventJobFailed {
+		t.Errorf("Expected only job.failed for the filtered user webhook, got %v", got[userHook.ID])
+	}
+	if len(got) != 2 {
+		t.Errorf("Expected no delivery for another user's webhook, got %v", got)
+	}
+
+	var payload WebhookPayload
+	for _, delivery := range store.deliveries {
+		if delivery.Event == domain.WebhookEventJobFailed {
+			json.Unmarshal([]byte(delivery.Payload), &payload)
+			break
+		}
+	}
+	if payload.Project == nil || payload.Project.Title != "Book One" || payload.Job == nil || payload.Job.ErrorMsg != "lualatex failed" {
+		t.Errorf("Expected payload with project and job, got %+v", payload)
+	}
+}
+
+func TestWebhookDispatcher_SignsAndRetries(t *testing.T) {
+	webhooks, store := newTestWebhookService()
+	hook, _ := webhooks.Create("alice", CreateWebhookRequest{URL: "http://placeholder", ProjectID: "1", Secret: "s3cret"})
+
+	var mu sync.Mutex
+	failures := 1
+	var received []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, body)
+		if failures > 0 {
+			failures--
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	hook.URL = server.URL
+
+	webhooks.Publish(context.Background(), "1", EventProjectStatus, ProjectStatusEvent{Status: domain.StatusFailed, Previous: domain.StatusRendering})
+	if len(store.deliveries) != 1 {
+		t.Fatalf("Expected one delivery, got %d", len(store.deliveries))
+	}
+	delivery := store.deliveries[0]
+
+	now := time.Now()
+	dispatcher := NewWebhookDispatcher(store, WithWebhookBackoff(time.Minute, time.Hour), WithWebhookClient(server.Client()))
+	dispatcher.now = func() time.Time { return now }
+
+	if sent, err := dispatcher.DispatchDue(context.Background()); err != nil || sent != 1 {
+		t.Fatalf("Expected one attempt, got %d, %v", sent, err)
+	}
+	if delivery.Status != domain.WebhookDeliveryPending || delivery.ResponseStatus != http.StatusServiceUnavailable ||
+		delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("Expected a retry in 1m after the 503, got %+v", delivery)
+	}
+
+	// Not due yet
+	if sent, _ := dispatcher.DispatchDue(context.Background()); sent != 0 {
+		t.Errorf("Expected nothing due before the backoff, got %d", sent)
+	}
+
+	now = now.Add(time.Minute)
+	dispatcher.DispatchDue(context.Background())
+	if delivery.Status != domain.WebhookDeliverySucceeded || delivery.Attempts != 2 || delivery.DeliveredAt == nil {
+		t.Fatalf("Expected delivery to succeed on the second attempt, got %+v", delivery)
+	}
+
+	req, body := received[1], bodies[1]
+	if req.Header.Get(WebhookHeaderEvent) != string(domain.WebhookEventStatusChanged) || req.Header.Get(WebhookHeaderDelivery) != delivery.ID {
+		t.Errorf("Unexpected delivery headers: %v", req.Header)
+	}
+	timestamp, _ := strconv.ParseInt(req.Header.Get(WebhookHeaderTimestamp), 10, 64)
+	if req.Header.Get(WebhookHeaderSignature) != SignWebhook("s3cret", timestamp, body) {
+		t.Errorf("Signature does not match the body")
+	}
+	if string(body) != delivery.Payload {
+		t.Errorf("Expected the stored payload to be sent as is")
+	}
+}
+
+func TestWebhookDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	store := &mockWebhookStore{}
+	store.Create(&domain.Webhook{ID: "h", URL: "http://127.0.0.1:1", Secret: "s", Active: true})
+	now := time.Now()
+	delivery := &domain.WebhookDelivery{ID: "d", WebhookID: "h", Event: domain.WebhookEventJobFailed, Status: domain.WebhookDeliveryPending, MaxAttempts: 2, NextAttemptAt: &now}
+	store.CreateDelivery(delivery)
+
+	dispatcher := NewWebhookDispatcher(store, WithWebhookBackoff(time.Second, time.Second))
+	dispatcher.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		dispatcher.DispatchDue(context.Background())
+		now = now.Add(time.Second)
+	}
+
+	if delivery.Status != domain.WebhookDeliveryFailed || delivery.Attempts != 2 || delivery.Error == "" || delivery.NextAttemptAt != nil {
+		t.Errorf("Expected a failed delivery after 2 attempts, got %+v", delivery)
+	}
+}
+
+func TestWebhookDispatcher_Backoff(t *testing.T) {
+	dispatcher := NewWebhookDispatcher(&mockWebhookStore{}, WithWebhookBackoff(30*time.Second, 5*time.Minute))
+
+	expected := map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute, 5: 5 * time.Minute, 40: 5 * time.Minute}
+	for attempt, delay := range expected {
+		if got := dispatcher.backoff(attempt); got != delay {
+			t.Errorf("backoff(%d) = %v, expected %v", attempt, got, delay)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage keeps objects as files under a root directory. It suits a
+// single machine, or several processes sharing a volume.
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage creates the storage, creating root if needed
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	if root == "" {
+		return nil, fmt.Errorf("local storage needs a directory")
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage dir: %w", err)
+	}
+	return &LocalStorage{root: abs}, nil
+}
+
+// Put writes the object through a temporary file, so readers never see a
+// partial object
+func (l *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error) {
+	filename, key, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, contextReader{ctx: ctx, r: r})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	if size >= 0 && written != size {
+		return nil, fmt.Errorf("failed to store %s: expected %d bytes, got %d", key, size, written)
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return nil, err
+	}
+	return l.Stat(ctx, key)
+}
+
+// Get opens the object's file
+func (l *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	filename, key, err := l.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, notFound(key, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+
+	return f, l.object(key, info), nil
+}
+
+// GetRange opens the object's file at offset
+func (l *LocalStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	r, _, err := l.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	f := r.(*os.File)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return limitedReadCloser{Reader: io.LimitReader(f, length), Closer: f}, nil
+}
+
+// Stat describes the object's file
+func (l *LocalStorage) Stat(ctx context.Context, key string) (*Object, error) {
+	filename, key, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, notFound(key, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return l.object(key, info), nil
+}
+
+// Delete removes the object's file
+func (l *LocalStorage) Delete(ctx context.Context, key string) error {
+	filename, _, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DeletePrefix removes the directory of the prefix
+func (l *LocalStorage) DeletePrefix(ctx context.Context, prefix string) error {
+	dir, _, err := l.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// URL returns the file:// URL of the object
+func (l *LocalStorage) URL(key string) string {
+	filename, _, err := l.path(key)
+	if err != nil {
+		return ""
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filename)}).String()
+}
+
+// Key maps a file:// URL (or plain path) under the root back to its key
+func (l *LocalStorage) Key(rawURL string) (string, bool) {
+	filename := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Scheme != "" {
+		if u.Scheme != "file" {
+			return "", false
+		}
+		filename = filepath.FromSlash(u.Path)
+	}
+
+	rel, err := filepath.Rel(l.root, filepath.Clean(filename))
+	if err != nil {
+		return "", false
+	}
+	key, err := cleanKey(filepath.ToSlash(rel))
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+// path resolves a key to its file
+func (l *LocalStorage) path(key string) (string, string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), key, nil
+}
+
+func (l *LocalStorage) object(key string, info os.FileInfo) *Object {
+	return &Object{
+		Key:         key,
+		Size:        info.Size(),
+		ContentType: ContentType(key),
+		ModTime:     info.ModTime(),
+		ETag:        fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size()),
+	}
+}
+
+// notFound maps a missing file to ErrNotFound
+func notFound(key string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return err
+}
+
+// limitedReadCloser closes the file behind a limited reader
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// contextReader stops a copy once the context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JuanCS-Dev/typecraft/internal/config"
+)
+
+func TestLocalStorage_RoundTrip(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage failed: %v", err)
+	}
+	ctx := context.Background()
+	key := ProjectKey(3, "output", "book.pdf")
+
+	obj, err := store.Put(ctx, key, strings.NewReader("%PDF-1.7"), 8, "application/pdf")
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if obj.Key != "projects/3/output/book.pdf" || obj.Size != 8 || obj.ContentType != "application/pdf" {
+		t.Errorf("Unexpected object: %+v", obj)
+	}
+
+	r, _, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "%PDF-1.7" {
+		t.Errorf("Unexpected content: %q", data)
+	}
+
+	// Overwrites replace the object
+	if _, err := store.Put(ctx, key, strings.NewReader("%PDF-2.0 longer"), -1, ""); err != nil {
+		t.Fatalf("Overwrite failed: %v", err)
+	}
+	if obj, _ := store.Stat(ctx, key); obj == nil || obj.Size != 15 {
+		t.Errorf("Expected the overwritten size, got %+v", obj)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Deleting a missing object should succeed, got %v", err)
+	}
+	if _, err := store.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestLocalStorage_DeletePrefix(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{
+		ProjectKey(4, "manuscript", "livro.md"),
+		ProjectKey(4, "output", "g1", "book.pdf"),
+		ProjectKey(42, "manuscript", "outro.md"),
+	} {
+		if _, err := store.Put(ctx, key, strings.NewReader("x"), 1, ""); err != nil {
+			t.Fatalf("Put %s failed: %v", key, err)
+		}
+	}
+
+	if err := store.DeletePrefix(ctx, ProjectKey(4)); err != nil {
+		t.Fatalf("DeletePrefix failed: %v", err)
+	}
+	if _, err := store.Stat(ctx, ProjectKey(4, "output", "g1", "book.pdf")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected project 4's files to be gone, got %v", err)
+	}
+	if _, err := store.Stat(ctx, ProjectKey(42, "manuscript", "outro.md")); err != nil {
+		t.Errorf("Expected project 42's files to survive, got %v", err)
+	}
+	// Nothing left to delete is not an error
+	if err := store.DeletePrefix(ctx, ProjectKey(4)); err != nil {
+		t.Errorf("Deleting a missing prefix should succeed, got %v", err)
+	}
+}
+
+func TestLocalStorage_ShortBodyLeavesNoObject(t *testing.T) {
+	store, _ := NewLocalStorage(t.TempDir())
+	ctx := context.Background()
+
+	if _, err := store.Put(ctx, "a.txt", strings.NewReader("abc"), 10, ""); err == nil {
+		t.Fatalf("Expected an error for a truncated body")
+	}
+	if _, err := store.Stat(ctx, "a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected no object after a failed put, got %v", err)
+	}
+}
+
+func TestLocalStorage_InvalidKeys(t *testing.T) {
+	store, _ := NewLocalStorage(t.TempDir())
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../b", "."} {
+		if _, err := store.Put(context.Background(), key, strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
+
+func TestLocalStorage_URLAndKey(t *testing.T) {
+	root := t.TempDir()
+	store, _ := NewLocalStorage(root)
+
+	url := store.URL("projects/1/manuscript/livro.docx")
+	if !strings.HasPrefix(url, "file://") {
+		t.Fatalf("Expected a file URL, got %s", url)
+	}
+	if key, ok := store.Key(url); !ok || key != "projects/1/manuscript/livro.docx" {
+		t.Errorf("Key(%s) = %q, %v", url, key, ok)
+	}
+	// Plain paths under the root are recognised too
+	if key, ok := store.Key(filepath.Join(root, "projects", "1", "a.md")); !ok || key != "projects/1/a.md" {
+		t.Errorf("Expected plain path recognised, got %q, %v", key, ok)
+	}
+
+	for _, other := range []string{"s3://typecraft-files/a.pdf", "/somewhere/else.md", "file:///somewhere/else.md"} {
+		if key, ok := store.Key(other); ok {
+			t.Errorf("Expected %s outside the storage, got key %q", other, key)
+		}
+	}
+}
+
+func TestPutFileAndDownload(t *testing.T) {
+	store, _ := NewLocalStorage(t.TempDir())
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	source := filepath.Join(dir, "manuscript.md")
+	os.WriteFile(source, []byte("# Title"), 0644)
+
+	obj, err := PutFile(ctx, store, "projects/1/intermediate/manuscript.md", source)
+	if err != nil {
+		t.Fatalf("PutFile failed: %v", err)
+	}
+	if obj.ContentType != "text/markdown; charset=utf-8" {
+		t.Errorf("Unexpected content type: %s", obj.ContentType)
+	}
+
+	target := filepath.Join(dir, "copy", "manuscript.md")
+	if err := Download(ctx, store, obj.Key, target); err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "# Title" {
+		t.Errorf("Unexpected downloaded content: %q", data)
+	}
+
+	if err := Download(ctx, store, "projects/1/missing.md", target); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := New(context.Background(), &config.Config{StorageBackend: "local", StorageDir: dir})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, ok := store.(*LocalStorage); !ok {
+		t.Errorf("Expected local storage, got %T", store)
+	}
+
+	_, server := newFakeS3(t)
+	store, err = New(context.Background(), &config.Config{
+		StorageBackend: "s3",
+		S3Endpoint:     server.URL,
+		S3AccessKey:    "minioadmin",
+		S3SecretKey:    "minioadmin",
+		S3Bucket:       "typecraft-files",
+		S3Region:       "us-east-1",
+	})
+	if err != nil {
+		t.Fatalf("New(s3) failed: %v", err)
+	}
+	if _, ok := store.(*S3Storage); !ok {
+		t.Errorf("Expected S3 storage, got %T", store)
+	}
+
+	if _, err := New(context.Background(), &config.Config{StorageBackend: "ftp"}); err == nil {
+		t.Errorf("Expected an error for an unknown backend")
+	}
+}
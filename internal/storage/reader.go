@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ObjectReader reads an object as an io.ReadSeekCloser, so it can be served
+// with http.ServeContent (Range requests, conditional GETs). Each seek that
+// moves the position reopens the object with GetRange, so only the bytes
+// actually read are transferred.
+type ObjectReader struct {
+	ctx     context.Context
+	storage Storage
+	object  *Object
+	offset  int64
+	body    io.ReadCloser
+}
+
+// NewObjectReader reads obj from s
+func NewObjectReader(ctx context.Context, s Storage, obj *Object) *ObjectReader {
+	return &ObjectReader{ctx: ctx, storage: s, object: obj}
+}
+
+// Read reads from the current position
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	if r.offset >= r.object.Size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.storage.GetRange(r.ctx, r.object.Key, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+// Seek moves the position; the next Read reopens the object there
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.object.Size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if offset != r.offset {
+		r.closeBody()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+// Close releases the open object, if any
+func (r *ObjectReader) Close() error {
+	return r.closeBody()
+}
+
+func (r *ObjectReader) closeBody() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObjectReader_ServeContentRanges(t *testing.T) {
+	store, _ := NewLocalStorage(t.TempDir())
+	ctx := context.Background()
+	content := strings.Repeat("0123456789", 100)
+	obj, err := store.Put(ctx, "projects/1/output/book.pdf", strings.NewReader(content), -1, "")
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	serve := func(rangeHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/download", nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		rec := httptest.NewRecorder()
+		reader := NewObjectReader(ctx, store, obj)
+		defer reader.Close()
+		rec.Header().Set("Content-Type", obj.ContentType)
+		http.ServeContent(rec, req, "book.pdf", time.Time{}, reader)
+		return rec
+	}
+
+	full := serve("")
+	if full.Code != http.StatusOK || full.Body.String() != content {
+		t.Errorf("Expected the full object, got %d (%d bytes)", full.Code, full.Body.Len())
+	}
+
+	partial := serve("bytes=995-")
+	if partial.Code != http.StatusPartialContent || partial.Body.String() != "56789" {
+		t.Errorf("Expected the last 5 bytes, got %d %q", partial.Code, partial.Body.String())
+	}
+	if got := partial.Header().Get("Content-Range"); got != "bytes 995-999/1000" {
+		t.Errorf("Unexpected Content-Range: %s", got)
+	}
+
+	middle := serve("bytes=10-14")
+	if middle.Code != http.StatusPartialContent || middle.Body.String() != "01234" {
+		t.Errorf("Expected bytes 10-14, got %d %q", middle.Code, middle.Body.String())
+	}
+
+	if rec := serve("bytes=5000-"); rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("Expected 416 for a range past the end, got %d", rec.Code)
+	}
+}
+
+func TestObjectReader_SeekAndRead(t *testing.T) {
+	store, _ := NewLocalStorage(t.TempDir())
+	ctx := context.Background()
+	obj, _ := store.Put(ctx, "a.txt", strings.NewReader("hello world"), -1, "")
+
+	r := NewObjectReader(ctx, store, obj)
+	defer r.Close()
+
+	buf := make([]byte, 5)
+	io.ReadFull(r, buf)
+	if string(buf) != "hello" {
+		t.Errorf("Unexpected first read: %q", buf)
+	}
+
+	if pos, _ := r.Seek(-5, io.SeekEnd); pos != 6 {
+		t.Errorf("Expected position 6, got %d", pos)
+	}
+	rest, _ := io.ReadAll(r)
+	if !bytes.Equal(rest, []byte("world")) {
+		t.Errorf("Unexpected read after seek: %q", rest)
+	}
+
+	if _, err := r.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("Expected an error for a negative position")
+	}
+}
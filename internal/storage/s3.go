@@ -0,0 +1,531 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// unsignedPayload skips hashing request bodies, so uploads can stream
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// emptyPayloadHash is the SHA-256 of an empty body
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	sigV4Service   = "s3"
+	amzDateFormat  = "20060102T150405Z"
+)
+
+// S3Config holds the connection settings of an S3-compatible store
+// (config.S3*)
+type S3Config struct {
+	Endpoint  string // e.g. http://localhost:9000 for MinIO
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Region    string
+}
+
+// S3Storage keeps objects in a bucket of an S3-compatible store (AWS S3,
+// MinIO). Requests use path-style addressing and Signature Version 4.
+type S3Storage struct {
+	endpoint *url.URL
+	bucket   string
+	signer   sigV4Signer
+	client   *http.Client
+	now      func() time.Time
+}
+
+// S3Option configures optional S3Storage settings
+type S3Option func(*S3Storage)
+
+// WithHTTPClient sets the client used to reach the store
+func WithHTTPClient(client *http.Client) S3Option {
+	return func(s *S3Storage) {
+		s.client = client
+	}
+}
+
+// NewS3Storage creates the storage. It does not contact the store; see
+// EnsureBucket.
+func NewS3Storage(cfg S3Config, opts ...S3Option) (*S3Storage, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint: %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	s := &S3Storage{
+		endpoint: endpoint,
+		bucket:   cfg.Bucket,
+		signer: sigV4Signer{
+			accessKey: cfg.AccessKey,
+			secretKey: cfg.SecretKey,
+			region:    region,
+			service:   sigV4Service,
+		},
+		client: &http.Client{Timeout: 5 * time.Minute},
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// EnsureBucket creates the bucket if it does not exist
+func (s *S3Storage) EnsureBucket(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, "", nil, -1, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reach S3 bucket %s: %w", s.bucket, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to reach S3 bucket %s: %s", s.bucket, resp.Status)
+	}
+
+	var body []byte
+	if s.signer.region != "us-east-1" {
+		body = []byte(fmt.Sprintf(
+			`<CreateBucketConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><LocationConstraint>%s</LocationConstraint></CreateBucketConfiguration>`,
+			s.signer.region))
+	}
+	resp, err = s.do(ctx, http.MethodPut, "", bytes.NewReader(body), int64(len(body)), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create S3 bucket %s: %w", s.bucket, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		if e := responseError(resp); e.Code != "BucketAlreadyOwnedByYou" {
+			return fmt.Errorf("failed to create S3 bucket %s: %w", s.bucket, e)
+		}
+	}
+	return nil
+}
+
+// Put uploads the object. Bodies of unknown size are buffered, since S3
+// needs the length up front.
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store %s: %w", key, err)
+		}
+		r, size = bytes.NewReader(data), int64(len(data))
+	}
+	if contentType == "" {
+		contentType = ContentType(key)
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, r, size, http.Header{"Content-Type": {contentType}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to store %s: %w", key, responseError(resp))
+	}
+
+	return &Object{
+		Key:         key,
+		Size:        size,
+		ContentType: contentType,
+		ModTime:     s.now(),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}, nil
+}
+
+// Get downloads the object
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, key, nil, -1, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, nil, s.objectError(key, resp)
+	}
+	return resp.Body, responseObject(key, resp), nil
+}
+
+// GetRange downloads part of the object with a Range request
+func (s *S3Storage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 || length == 0 {
+		return nil, fmt.Errorf("invalid range %d+%d for %s", offset, length, key)
+	}
+
+	byteRange := fmt.Sprintf("bytes=%d-", offset)
+	if length > 0 {
+		byteRange += strconv.FormatInt(offset+length-1, 10)
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, -1, http.Header{"Range": {byteRange}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp.Body, nil
+	case http.StatusOK:
+		// Ranges are optional for servers: skip to the offset ourselves
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to get %s: %w", key, err)
+		}
+		if length < 0 {
+			return resp.Body, nil
+		}
+		return limitedReadCloser{Reader: io.LimitReader(resp.Body, length), Closer: resp.Body}, nil
+	default:
+		defer resp.Body.Close()
+		return nil, s.objectError(key, resp)
+	}
+}
+
+// Stat reads the object's metadata
+func (s *S3Storage) Stat(ctx context.Context, key string) (*Object, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(ctx, http.MethodHead, key, nil, -1, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, s.objectError(key, resp)
+	}
+	return responseObject(key, resp), nil
+}
+
+// Delete removes the object
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, -1, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete %s: %w", key, responseError(resp))
+	}
+	return nil
+}
+
+// DeletePrefix lists the objects under prefix and removes them one by one
+func (s *S3Storage) DeletePrefix(ctx context.Context, prefix string) error {
+	prefix, err := cleanKey(prefix)
+	if err != nil {
+		return err
+	}
+
+	token := ""
+	for {
+		page, err := s.list(ctx, prefix+"/", token)
+		if err != nil {
+			return err
+		}
+		for _, obj := range page.Contents {
+			if err := s.Delete(ctx, obj.Key); err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// listBucketResult is one page of a ListObjectsV2 response
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// list fetches one page of the keys starting with prefix
+func (s *S3Storage) list(ctx context.Context, prefix, token string) (*listBucketResult, error) {
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	if token != "" {
+		query.Set("continuation-token", token)
+	}
+
+	resp, err := s.doQuery(ctx, http.MethodGet, "", query, nil, -1, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, responseError(resp))
+	}
+
+	page := &listBucketResult{}
+	if err := xml.NewDecoder(resp.Body).Decode(page); err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	return page, nil
+}
+
+// URL returns the s3://bucket/key URL of the object
+func (s *S3Storage) URL(key string) string {
+	key, err := cleanKey(key)
+	if err != nil {
+		return ""
+	}
+	return "s3://" + s.bucket + "/" + key
+}
+
+// Key maps an s3:// URL of this bucket back to its key
+func (s *S3Storage) Key(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "s3" || u.Host != s.bucket {
+		return "", false
+	}
+	key, err := cleanKey(strings.TrimPrefix(u.Path, "/"))
+	if err != nil {
+		return "", false
+	}
+	return key, true
+}
+
+// do sends a signed request for the bucket (key "") or one of its objects
+func (s *S3Storage) do(ctx context.Context, method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	return s.doQuery(ctx, method, key, nil, body, size, header)
+}
+
+// doQuery is do with a query string
+func (s *S3Storage) doQuery(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	target := *s.endpoint
+	target.Path = strings.TrimSuffix(s.endpoint.Path, "/") + "/" + s.bucket
+	if key != "" {
+		target.Path += "/" + key
+	}
+	target.RawPath = awsURIEscape(target.Path, false)
+	target.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if size >= 0 {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+
+	payloadHash := unsignedPayload
+	if body == nil {
+		payloadHash = emptyPayloadHash
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	s.signer.sign(req, payloadHash, s.now())
+
+	return s.client.Do(req)
+}
+
+// objectError maps an error response about an object
+func (s *S3Storage) objectError(key string, resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return fmt.Errorf("request for %s failed: %w", key, responseError(resp))
+}
+
+// responseObject reads the object metadata from response headers
+func responseObject(key string, resp *http.Response) *Object {
+	obj := &Object{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if length, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		obj.Size = length
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		obj.ModTime = modified
+	}
+	return obj
+}
+
+// S3Error is an error response of the store
+type S3Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("S3 status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("S3 %s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// responseError decodes the XML error body of a response
+func responseError(resp *http.Response) *S3Error {
+	e := &S3Error{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	xml.Unmarshal(data, e)
+	return e
+}
+
+// sigV4Signer signs requests with AWS Signature Version 4
+type sigV4Signer struct {
+	accessKey string
+	secretKey string
+	region    string
+	service   string
+}
+
+// sign sets X-Amz-Date and Authorization. The host, Content-Type and every
+// X-Amz-* header are signed.
+func (s sigV4Signer) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+
+	signedHeaders := []string{"host"}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			signedHeaders = append(signedHeaders, lower)
+		}
+	}
+	sort.Strings(signedHeaders)
+
+	signature := s.signature(req, signedHeaders, payloadHash, now)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.accessKey, s.scope(now), strings.Join(signedHeaders, ";"), signature))
+}
+
+// signature computes the request signature over the given headers
+func (s sigV4Signer) signature(req *http.Request, signedHeaders []string, payloadHash string, now time.Time) string {
+	canonical := canonicalRequest(req, signedHeaders, payloadHash)
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.UTC().Format(amzDateFormat),
+		s.scope(now),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.UTC().Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s sigV4Signer) scope(now time.Time) string {
+	return now.UTC().Format("20060102") + "/" + s.region + "/" + s.service + "/aws4_request"
+}
+
+// canonicalRequest builds the SigV4 canonical form of a request, as seen by
+// either the client or the server
+func canonicalRequest(req *http.Request, signedHeaders []string, payloadHash string) string {
+	uri := req.URL.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, awsURIEscape(name, true)+"="+awsURIEscape(value, true))
+		}
+	}
+	sort.Strings(params)
+
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		}
+		headers.WriteString(name + ":" + strings.Join(strings.Fields(value), " ") + "\n")
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		uri,
+		strings.Join(params, "&"),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// awsURIEscape percent-encodes everything but unreserved characters (and
+// "/" unless encodeSlash), as SigV4 requires
+func awsURIEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
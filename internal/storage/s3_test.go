@@ -0,0 +1,360 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a MinIO stand-in: it verifies SigV4 signatures and keeps
+// objects of one bucket in memory
+type fakeS3 struct {
+	t         *testing.T
+	secretKey string
+	region    string
+
+	mu      sync.Mutex
+	buckets map[string]bool
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{
+		t:         t,
+		secretKey: "minioadmin",
+		region:    "us-east-1",
+		buckets:   make(map[string]bool),
+		objects:   make(map[string]fakeObject),
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.verify(r) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `<Error><Code>SignatureDoesNotMatch</Code><Message>bad signature</Message></Error>`)
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if key == "" {
+		switch r.Method {
+		case http.MethodHead:
+			if !f.buckets[bucket] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			f.buckets[bucket] = true
+		case http.MethodGet:
+			f.list(w, r)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if !f.buckets[bucket] {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `<Error><Code>NoSuchBucket</Code><Message>missing bucket</Message></Error>`)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+		w.Header().Set("ETag", `"etag-`+key+`"`)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		http.ServeContent(w, r, key, time.Now(), bytes.NewReader(obj.data))
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// fakeListPageSize is small so listings are paginated
+const fakeListPageSize = 2
+
+// list answers ListObjectsV2, using the last key of a page as its
+// continuation token
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("list-type") != "2" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, query.Get("prefix")) && key > query.Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	truncated := len(keys) > fakeListPageSize
+	if truncated {
+		keys = keys[:fakeListPageSize]
+	}
+	io.WriteString(w, "<ListBucketResult>")
+	for _, key := range keys {
+		fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", key)
+	}
+	if truncated {
+		fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>", keys[len(keys)-1])
+	}
+	io.WriteString(w, "</ListBucketResult>")
+}
+
+// verify recomputes the signature from the request as received
+func (f *fakeS3) verify(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	var credential, signedHeaders, signature string
+	for _, part := range strings.Split(strings.TrimPrefix(auth, sigV4Algorithm+" "), ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		switch name {
+		case "Credential":
+			credential = value
+		case "SignedHeaders":
+			signedHeaders = value
+		case "Signature":
+			signature = value
+		}
+	}
+	accessKey, _, _ := strings.Cut(credential, "/")
+	now, err := time.Parse(amzDateFormat, r.Header.Get("X-Amz-Date"))
+	if err != nil || accessKey != "minioadmin" {
+		return false
+	}
+
+	signer := sigV4Signer{accessKey: accessKey, secretKey: f.secretKey, region: f.region, service: sigV4Service}
+	expected := signer.signature(r, strings.Split(signedHeaders, ";"), r.Header.Get("X-Amz-Content-Sha256"), now)
+	if expected != signature {
+		f.t.Logf("signature mismatch for %s %s", r.Method, r.URL)
+		return false
+	}
+	return true
+}
+
+func newTestS3(t *testing.T) (*S3Storage, *fakeS3) {
+	fake, server := newFakeS3(t)
+	s3, err := NewS3Storage(S3Config{
+		Endpoint:  server.URL,
+		AccessKey: "minioadmin",
+		SecretKey: "minioadmin",
+		Bucket:    "typecraft-files",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage failed: %v", err)
+	}
+	return s3, fake
+}
+
+func TestSigV4Signer_KnownAnswer(t *testing.T) {
+	// "get-vanilla" from the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	signer := sigV4Signer{
+		accessKey: "AKIDEXAMPLE",
+		secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		region:    "us-east-1",
+		service:   "service",
+	}
+
+	signer.sign(req, emptyPayloadHash, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("Unexpected Authorization:\n got %s\nwant %s", got, expected)
+	}
+}
+
+func TestS3Storage_RoundTrip(t *testing.T) {
+	s3, fake := newTestS3(t)
+	ctx := context.Background()
+
+	if err := s3.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket failed: %v", err)
+	}
+	if !fake.buckets["typecraft-files"] {
+		t.Fatalf("Expected the bucket to be created")
+	}
+	// Idempotent once the bucket exists
+	if err := s3.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket on an existing bucket failed: %v", err)
+	}
+
+	// Keys with spaces and accents must survive signing
+	key := ProjectKey(7, "manuscript", "Meu Livro – versão 2.md")
+	obj, err := s3.Put(ctx, key, strings.NewReader("# Capítulo 1"), -1, "")
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if obj.Size != int64(len("# Capítulo 1")) || obj.ETag == "" {
+		t.Errorf("Unexpected object: %+v", obj)
+	}
+	if stored := fake.objects[key]; string(stored.data) != "# Capítulo 1" || stored.contentType != "text/markdown; charset=utf-8" {
+		t.Errorf("Unexpected stored object: %q %q", stored.data, stored.contentType)
+	}
+
+	r, info, err := s3.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "# Capítulo 1" || info.Size != int64(len(data)) {
+		t.Errorf("Unexpected content %q (%+v)", data, info)
+	}
+
+	if _, err := s3.Stat(ctx, key); err != nil {
+		t.Errorf("Stat failed: %v", err)
+	}
+
+	if err := s3.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s3.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if _, _, err := s3.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from Get, got %v", err)
+	}
+}
+
+func TestS3Storage_DeletePrefix(t *testing.T) {
+	s3, fake := newTestS3(t)
+	ctx := context.Background()
+	if err := s3.EnsureBucket(ctx); err != nil {
+		t.Fatalf("EnsureBucket failed: %v", err)
+	}
+
+	keys := []string{
+		ProjectKey(4, "manuscript", "livro.md"),
+		ProjectKey(4, "intermediate", "manuscript.md"),
+		ProjectKey(4, "output", "g1", "book.pdf"),
+		ProjectKey(4, "output", "g1", "book.epub"),
+		ProjectKey(42, "manuscript", "outro.md"),
+	}
+	for _, key := range keys {
+		if _, err := s3.Put(ctx, key, strings.NewReader("x"), 1, ""); err != nil {
+			t.Fatalf("Put %s failed: %v", key, err)
+		}
+	}
+
+	// Four objects span two pages of the fake listing
+	if err := s3.DeletePrefix(ctx, ProjectKey(4)); err != nil {
+		t.Fatalf("DeletePrefix failed: %v", err)
+	}
+	if len(fake.objects) != 1 {
+		t.Errorf("Expected only project 42's object to remain, got %d objects", len(fake.objects))
+	}
+	if _, ok := fake.objects[ProjectKey(42, "manuscript", "outro.md")]; !ok {
+		t.Errorf("Expected projects/42 to survive deleting projects/4")
+	}
+
+	if err := s3.DeletePrefix(ctx, "../etc"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestS3Storage_GetRange(t *testing.T) {
+	s3, _ := newTestS3(t)
+	ctx := context.Background()
+	s3.EnsureBucket(ctx)
+	s3.Put(ctx, "book.pdf", strings.NewReader("0123456789"), 10, "application/pdf")
+
+	for _, tt := range []struct {
+		offset, length int64
+		expected       string
+	}{
+		{0, -1, "0123456789"},
+		{3, 4, "3456"},
+		{7, -1, "789"},
+	} {
+		r, err := s3.GetRange(ctx, "book.pdf", tt.offset, tt.length)
+		if err != nil {
+			t.Fatalf("GetRange(%d, %d) failed: %v", tt.offset, tt.length, err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		if string(data) != tt.expected {
+			t.Errorf("GetRange(%d, %d) = %q, want %q", tt.offset, tt.length, data, tt.expected)
+		}
+	}
+
+	if _, err := s3.GetRange(ctx, "missing.pdf", 0, -1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestS3Storage_RejectsBadCredentials(t *testing.T) {
+	s3, fake := newTestS3(t)
+	fake.secretKey = "other"
+
+	err := s3.EnsureBucket(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected a 403 error, got %v", err)
+	}
+
+	fake.buckets["typecraft-files"] = true
+	_, err = s3.Put(context.Background(), "a.txt", strings.NewReader("x"), 1, "text/plain")
+	var s3Err *S3Error
+	if !errors.As(err, &s3Err) || s3Err.Code != "SignatureDoesNotMatch" {
+		t.Errorf("Expected SignatureDoesNotMatch, got %v", err)
+	}
+}
+
+func TestS3Storage_URLAndKey(t *testing.T) {
+	s3, _ := newTestS3(t)
+
+	url := s3.URL("projects/7/output/book.pdf")
+	if url != "s3://typecraft-files/projects/7/output/book.pdf" {
+		t.Errorf("Unexpected URL: %s", url)
+	}
+	if key, ok := s3.Key(url); !ok || key != "projects/7/output/book.pdf" {
+		t.Errorf("Key(%s) = %q, %v", url, key, ok)
+	}
+
+	for _, other := range []string{"s3://other-bucket/a.pdf", "file:///tmp/a.pdf", "/tmp/a.pdf", "s3://typecraft-files/../a"} {
+		if key, ok := s3.Key(other); ok {
+			t.Errorf("Expected %s outside the storage, got key %q", other, key)
+		}
+	}
+}
+
+func TestNewS3Storage_Validation(t *testing.T) {
+	if _, err := NewS3Storage(S3Config{Endpoint: "localhost:9000", Bucket: "b"}); err == nil {
+		t.Errorf("Expected an error for an endpoint without scheme")
+	}
+	if _, err := NewS3Storage(S3Config{Endpoint: "http://localhost:9000"}); err == nil {
+		t.Errorf("Expected an error without bucket")
+	}
+}
@@ -0,0 +1,174 @@
+// Package storage keeps the files of a project (uploaded manuscripts,
+// intermediate Markdown and rendered books) behind one interface, backed by
+// the local filesystem or an S3-compatible object store such as MinIO.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/config"
+)
+
+// Backends selectable with STORAGE_BACKEND
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+var (
+	// ErrNotFound means no object is stored under the key
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey means the key is empty or escapes the storage root
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Object describes a stored file
+type Object struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ModTime     time.Time `json:"mod_time"`
+	ETag        string    `json:"etag,omitempty"`
+}
+
+// Storage stores objects under slash-separated keys such as
+// "projects/42/output/book.pdf" (see ProjectKey).
+type Storage interface {
+	// Put stores r under key. size is the length of r, or -1 if unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (*Object, error)
+	// Get opens the object; the caller closes the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// GetRange opens length bytes of the object starting at offset
+	// (length -1 reads to the end)
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Stat describes the object without reading it
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes every object under the key directory prefix,
+	// e.g. "projects/42" (but not "projects/420")
+	DeletePrefix(ctx context.Context, prefix string) error
+	// URL is the location recorded on projects (file:// or s3://)
+	URL(key string) string
+	// Key maps a URL returned by URL back to its key; ok is false for
+	// locations outside this storage
+	Key(rawURL string) (key string, ok bool)
+}
+
+// New creates the storage selected by cfg.StorageBackend. The S3 bucket is
+// created if it does not exist yet.
+func New(ctx context.Context, cfg *config.Config) (Storage, error) {
+	switch strings.ToLower(cfg.StorageBackend) {
+	case "", BackendLocal:
+		return NewLocalStorage(cfg.StorageDir)
+	case BackendS3, "minio":
+		s3, err := NewS3Storage(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := s3.EnsureBucket(ctx); err != nil {
+			return nil, err
+		}
+		return s3, nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.StorageBackend)
+	}
+}
+
+// ProjectKey builds the key of a project file, e.g.
+// ProjectKey(42, "output", "book.pdf") = "projects/42/output/book.pdf"
+func ProjectKey(projectID uint, elem ...string) string {
+	return path.Join(append([]string{"projects", fmt.Sprintf("%d", projectID)}, elem...)...)
+}
+
+// contentTypes covers the manuscript, bundle and book formats, whose types are
+// missing from many systems' MIME tables
+var contentTypes = map[string]string{
+	".md":       "text/markdown; charset=utf-8",
+	".markdown": "text/markdown; charset=utf-8",
+	".txt":      "text/plain; charset=utf-8",
+	".docx":     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".odt":      "application/vnd.oasis.opendocument.text",
+	".rtf":      "application/rtf",
+	".epub":     "application/epub+zip",
+	".pdf":      "application/pdf",
+	".zip":      "application/zip",
+}
+
+// ContentType guesses the content type of a file from its name
+func ContentType(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if ct, ok := contentTypes[ext]; ok {
+		return ct
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// PutFile uploads a local file
+func PutFile(ctx context.Context, s Storage, key, filename string) (*Object, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Put(ctx, key, f, info.Size(), ContentType(filename))
+}
+
+// Download copies an object into a local file, creating its directory
+func Download(ctx context.Context, s Storage, key, filename string) error {
+	r, _, err := s.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(filename)
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return f.Close()
+}
+
+// cleanKey validates a key and normalizes it to "a/b/c"
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return cleaned, nil
+}
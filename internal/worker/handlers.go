@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+)
+
+// projectStage adapts a ProjectPipeline stage to a job Handler
+func projectStage(run func(context.Context, *domain.Project) (map[string]interface{}, error)) Handler {
+	return func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		return run(ctx, project)
+	}
+}
+
+// RegisterServices wires every job type to the service that runs it:
+// the staged project pipeline for convert/analyze/design/render jobs (convert
+// and render need the job: the manuscript revision and the artifacts) and the
+// orchestrator for export jobs queued by the generation API.
+func RegisterServices(w *Worker, pipeline *service.ProjectPipeline, generations *service.GenerationJobs) {
+	w.Handle(domain.JobTypeConvert, pipeline.Convert)
+	w.Handle(domain.JobTypeAnalyze, projectStage(pipeline.Analyze))
+	w.Handle(domain.JobTypeDesign, projectStage(pipeline.Design))
+	w.Handle(domain.JobTypeRender, pipeline.Render)
+	w.Handle(domain.JobTypeExport, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		result, err := generations.Execute(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		// Stored outputs; older results only have local files
+		outputs, ok := result["output_urls"].(map[string]interface{})
+		if !ok {
+			outputs, ok = result["output_files"].(map[string]interface{})
+		}
+		if ok {
+			if pdf, ok := outputs["pdf"].(string); ok {
+				project.PDFKdpURL = pdf
+			}
+			if epub, ok := outputs["epub"].(string); ok {
+				project.EpubURL = epub
+			}
+		}
+		return result, nil
+	})
+}
@@ -0,0 +1,558 @@
+// Package worker processes the asynchronous jobs queued in the jobs table.
+// Jobs are claimed by priority once their parents completed, dispatched to a
+// handler per domain.JobType and retried with exponential backoff until
+// MaxAttempts is reached. A job that fails or is cancelled cancels the jobs
+// depending on it.
+//
+// Claimed jobs are leased to the worker that claimed them. The worker renews
+// the lease with heartbeats while the job runs; a reaper returns jobs whose
+// lease expired (their worker crashed) to the queue, or fails them once
+// they ran out of attempts. A job cancelled while it runs (see
+// service.JobService.Cancel) loses its lease: the worker stops it and puts its
+// project back in the status it had before the job.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultPollInterval = 2 * time.Second
+	defaultBackoffBase  = 30 * time.Second
+	defaultBackoffMax   = 30 * time.Minute
+	defaultLeaseTTL     = time.Minute
+)
+
+// JobSource claims and saves jobs (implemented by repository.JobRepository).
+// Claim must only return jobs whose parents (Job.DependsOn) have completed,
+// leased to owner for ttl. Heartbeat and Release return domain.ErrJobLeaseLost
+// once owner no longer holds the job.
+type JobSource interface {
+	Claim(owner string, ttl time.Duration) (*domain.Job, error)
+	GetByID(id string) (*domain.Job, error)
+	Heartbeat(id, owner string, ttl time.Duration) error
+	Release(job *domain.Job, owner string) error
+	ReapExpired(now time.Time) ([]*domain.Job, error)
+	Update(job *domain.Job) error
+	GetByProjectID(projectID string) ([]*domain.Job, error)
+}
+
+// ProjectStore loads and saves the project a job belongs to
+type ProjectStore interface {
+	GetByID(id string) (*domain.Project, error)
+	Update(project *domain.Project) error
+}
+
+// Handler runs one job for its project and returns what to store in Job.Result.
+// Handlers may update the project; the worker saves it with the new status.
+type Handler func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error)
+
+// stage describes how a job type moves its project forward
+type stage struct {
+	running  domain.ProjectStatus // status while the job runs
+	progress int                  // progress once the job completes
+}
+
+// stages maps each job type to its place in the project pipeline.
+// Render and export produce the final books and complete the project.
+var stages = map[domain.JobType]stage{
+	domain.JobTypeConvert: {domain.StatusAnalyzing, 25},
+	domain.JobTypeAnalyze: {domain.StatusAnalyzing, 40},
+	domain.JobTypeDesign:  {domain.StatusDesigning, 60},
+	domain.JobTypeRefine:  {domain.StatusRefining, 80},
+	domain.JobTypeRender:  {domain.StatusRendering, 100},
+	domain.JobTypeExport:  {domain.StatusRendering, 100},
+}
+
+// Worker claims pending jobs and runs up to Concurrency of them at once
+type Worker struct {
+	jobs     JobSource
+	projects ProjectStore
+	logs     service.JobLogStore
+	events   service.EventPublisher
+	watch    service.EventStore
+	handlers map[domain.JobType]Handler
+
+	id           string // lease owner
+	concurrency  int
+	leaseTTL     time.Duration
+	pollInterval time.Duration
+	backoffBase  time.Duration
+	backoffMax   time.Duration
+	now          func() time.Time
+}
+
+// Option configures a Worker
+type Option func(*Worker)
+
+// WithPollInterval sets how long an idle worker waits before claiming again
+func WithPollInterval(interval time.Duration) Option {
+	return func(w *Worker) {
+		w.pollInterval = interval
+	}
+}
+
+// WithID sets the lease owner recorded on claimed jobs (defaults to host, pid and a random suffix)
+func WithID(id string) Option {
+	return func(w *Worker) {
+		w.id = id
+	}
+}
+
+// WithLease sets how long a claimed job stays leased without a heartbeat.
+// Heartbeats are sent every third of it and the reaper runs every half.
+func WithLease(ttl time.Duration) Option {
+	return func(w *Worker) {
+		w.leaseTTL = ttl
+	}
+}
+
+// WithJobLogs records every external tool run (pandoc, lualatex, pagedjs-cli,
+// ...) of a job in logs, so its output can be inspected after the fact
+func WithJobLogs(logs service.JobLogStore) Option {
+	return func(w *Worker) {
+		w.logs = logs
+	}
+}
+
+// WithEvents publishes job state changes and project status transitions as
+// project events (see GET /projects/:id/events)
+func WithEvents(events service.EventPublisher) Option {
+	return func(w *Worker) {
+		w.events = events
+	}
+}
+
+// WithCancellations stops a running job as soon as a job.status event reports
+// it cancelled, instead of on its next heartbeat. events must be the store the
+// API publishes to (see service.NewEventStore).
+func WithCancellations(events service.EventStore) Option {
+	return func(w *Worker) {
+		w.watch = events
+	}
+}
+
+// WithBackoff sets the delay before the first retry and its upper bound
+func WithBackoff(base, max time.Duration) Option {
+	return func(w *Worker) {
+		w.backoffBase = base
+		w.backoffMax = max
+	}
+}
+
+// New creates a worker running at most concurrency jobs at a time
+func New(jobs JobSource, projects ProjectStore, concurrency int, opts ...Option) *Worker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	w := &Worker{
+		jobs:         jobs,
+		projects:     projects,
+		handlers:     make(map[domain.JobType]Handler),
+		id:           defaultID(),
+		concurrency:  concurrency,
+		leaseTTL:     defaultLeaseTTL,
+		pollInterval: defaultPollInterval,
+		backoffBase:  defaultBackoffBase,
+		backoffMax:   defaultBackoffMax,
+		now:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Handle registers the handler for a job type
+func (w *Worker) Handle(jobType domain.JobType, handler Handler) {
+	w.handlers[jobType] = handler
+}
+
+// ID returns the lease owner this worker records on the jobs it claims
+func (w *Worker) ID() string {
+	return w.id
+}
+
+// Run claims and processes jobs until ctx is cancelled, then waits for the
+// jobs in flight. Cancelled jobs are put back in the queue for the next worker.
+// The reaper runs alongside, recovering jobs abandoned by crashed workers.
+func (w *Worker) Run(ctx context.Context) error {
+	slots := make(chan struct{}, w.concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.runReaper(ctx)
+	}()
+
+	for {
+		// Wait for a free slot before claiming, so claimed jobs never wait
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+
+		job, err := w.jobs.Claim(w.id, w.leaseTTL)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to claim job")
+		}
+		if job == nil {
+			<-slots
+			select {
+			case <-time.After(w.pollInterval):
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			w.Process(ctx, job)
+		}()
+	}
+}
+
+// Process runs a claimed job and records the outcome on the job and its project
+func (w *Worker) Process(ctx context.Context, job *domain.Job) {
+	logger := log.With().Str("job_id", job.ID).Str("type", string(job.Type)).
+		Str("project_id", job.ProjectID).Int("attempt", job.Attempts).Logger()
+
+	service.PublishJobStatus(ctx, w.events, job)
+
+	project, err := w.projects.GetByID(job.ProjectID)
+	if err != nil {
+		w.finish(ctx, job, nil, nil, fmt.Errorf("failed to load project: %w", err))
+		return
+	}
+
+	// Restored if the job is cancelled
+	previous := project.Status
+	if st, ok := stages[job.Type]; ok && !project.IsCompleted() {
+		project.Status = st.running
+		w.saveProject(project, previous)
+	}
+	running := project.Status
+
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		// Retrying cannot help a job nobody knows how to run
+		job.Attempts = job.MaxAttempts
+		w.finish(ctx, job, project, nil, fmt.Errorf("no handler for job type %q", job.Type))
+		return
+	}
+
+	logger.Info().Msg("job started")
+	start := w.now()
+
+	// The handler stops if the lease is lost: the job now belongs to someone else
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	if w.logs != nil {
+		jobCtx = process.WithRecorder(jobCtx, service.JobLogRecorder(w.logs, job))
+	}
+	stopHeartbeat := w.heartbeat(jobCtx, job, cancel)
+	stopWatch := w.watchCancellation(jobCtx, job, cancel)
+	result, err := handler(jobCtx, job, project)
+	stopWatch()
+	stopHeartbeat()
+	if errors.Is(context.Cause(jobCtx), domain.ErrJobLeaseLost) {
+		cancel(nil)
+		logger.Warn().Msg("job lease lost or job cancelled, dropping its outcome")
+		if current, err := w.jobs.GetByID(job.ID); err != nil {
+			logger.Error().Err(err).Msg("failed to reload job")
+		} else if current.Status == domain.JobStatusCancelled {
+			w.restoreProject(job.ProjectID, running, previous)
+		}
+		return
+	}
+	cancel(nil)
+	w.finish(ctx, job, project, result, err)
+	if job.Status == domain.JobStatusCancelled {
+		w.restoreProject(job.ProjectID, running, previous)
+	}
+
+	logger.Info().Str("status", string(job.Status)).Dur("duration", w.now().Sub(start)).Msg("job finished")
+}
+
+// finish saves the job outcome: completed, retried with backoff, cancelled or failed
+func (w *Worker) finish(ctx context.Context, job *domain.Job, project *domain.Project, result map[string]interface{}, err error) {
+	switch {
+	case err == nil:
+		job.MarkCompleted(result)
+		w.advance(job, project)
+
+	case ctx.Err() != nil:
+		// Worker shutting down: give the attempt back and requeue right away
+		job.Attempts--
+		job.ScheduleRetry(err, w.now())
+
+	case errors.Is(err, service.ErrGenerationCancelled):
+		// Stopped mid-run by its own context, not by a lost lease: never retried
+		job.MarkCancelled(err.Error())
+		defer w.cancelDependents(job)
+
+	case job.Attempts < job.MaxAttempts:
+		job.ScheduleRetry(err, w.now().Add(Backoff(job.Attempts, w.backoffBase, w.backoffMax)))
+
+	default:
+		job.MarkFailed(err)
+		if result != nil {
+			// Kept for the dead-letter view (e.g. tool output)
+			job.Result = &result
+		}
+		defer w.cancelDependents(job)
+		if project != nil {
+			previous := project.Status
+			project.AddError(fmt.Sprintf("%s: %v", job.Type, err))
+			project.SetStatus(domain.StatusFailed)
+			w.saveProject(project, previous)
+		}
+	}
+
+	if err := w.jobs.Release(job, w.id); errors.Is(err, domain.ErrJobLeaseLost) {
+		log.Warn().Str("job_id", job.ID).Msg("job lease lost before saving its outcome")
+	} else if err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("failed to save job")
+	} else {
+		service.PublishJobStatus(ctx, w.events, job)
+	}
+}
+
+// heartbeat renews the job lease until the returned stop function is called.
+// Losing the lease cancels the job with domain.ErrJobLeaseLost.
+func (w *Worker) heartbeat(ctx context.Context, job *domain.Job, cancel context.CancelCauseFunc) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(w.leaseTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				err := w.jobs.Heartbeat(job.ID, w.id, w.leaseTTL)
+				if errors.Is(err, domain.ErrJobLeaseLost) {
+					cancel(err)
+					return
+				}
+				if err != nil {
+					log.Error().Err(err).Str("job_id", job.ID).Msg("failed to renew job lease")
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// watchCancellation follows the job.status events of the job's project until
+// the returned stop function is called, cancelling the job with
+// domain.ErrJobLeaseLost as soon as it is reported cancelled. The job is read
+// once after the watch starts, so a cancellation published just before is not
+// missed. Without an event store only heartbeats notice cancellations.
+func (w *Worker) watchCancellation(ctx context.Context, job *domain.Job, cancel context.CancelCauseFunc) (stop func()) {
+	if w.watch == nil {
+		return func() {}
+	}
+
+	ctx, stopWatch := context.WithCancel(ctx)
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		afterID, err := w.watch.LastID(ctx, job.ProjectID)
+		if err == nil {
+			var current *domain.Job
+			if current, err = w.jobs.GetByID(job.ID); err == nil && current.Status == domain.JobStatusCancelled {
+				cancel(domain.ErrJobLeaseLost)
+				return
+			}
+		}
+		for err == nil {
+			var events []service.ProjectEvent
+			events, err = w.watch.Read(ctx, job.ProjectID, afterID, w.leaseTTL/3)
+			for _, event := range events {
+				afterID = event.ID
+				if event.Type != service.EventJobStatus {
+					continue
+				}
+				var data service.JobStatusEvent
+				if json.Unmarshal(event.Data, &data) == nil && data.JobID == job.ID && data.Status == domain.JobStatusCancelled {
+					cancel(domain.ErrJobLeaseLost)
+					return
+				}
+			}
+		}
+		if ctx.Err() == nil {
+			log.Error().Err(err).Str("job_id", job.ID).Msg("failed to watch job cancellation")
+		}
+	}()
+
+	return func() {
+		stopWatch()
+		<-finished
+	}
+}
+
+// runReaper recovers expired leases every half lease until ctx is cancelled
+func (w *Worker) runReaper(ctx context.Context) {
+	ticker := time.NewTicker(w.leaseTTL / 2)
+	defer ticker.Stop()
+
+	for {
+		w.Reap()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Reap returns jobs whose lease expired to the queue, or fails them when they
+// cannot be retried, cancelling the jobs that depend on them
+func (w *Worker) Reap() {
+	jobs, err := w.jobs.ReapExpired(w.now())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to reap expired jobs")
+		return
+	}
+
+	for _, job := range jobs {
+		log.Warn().Str("job_id", job.ID).Str("type", string(job.Type)).
+			Str("status", string(job.Status)).Msg("recovered job with expired lease")
+		service.PublishJobStatus(context.Background(), w.events, job)
+		if job.Status == domain.JobStatusFailed {
+			w.cancelDependents(job)
+		}
+	}
+}
+
+// cancelDependents cancels the unfinished jobs that depend, directly or not,
+// on a job that will never complete
+func (w *Worker) cancelDependents(parent *domain.Job) {
+	jobs, err := w.jobs.GetByProjectID(parent.ProjectID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", parent.ID).Msg("failed to load dependent jobs")
+		return
+	}
+
+	reason := fmt.Sprintf("dependency %s job %s %s", parent.Type, parent.ID, parent.Status)
+	if parent.ErrorMsg != "" {
+		reason += ": " + parent.ErrorMsg
+	}
+
+	for _, child := range domain.Descendants(jobs, parent.ID) {
+		if child.IsTerminal() {
+			continue
+		}
+		child.MarkCancelled(reason)
+		if err := w.jobs.Update(child); err != nil {
+			log.Error().Err(err).Str("job_id", child.ID).Msg("failed to cancel dependent job")
+			continue
+		}
+		service.PublishJobStatus(context.Background(), w.events, child)
+	}
+}
+
+// advance moves the project forward after a job completes
+func (w *Worker) advance(job *domain.Job, project *domain.Project) {
+	if project == nil {
+		return
+	}
+
+	previous := project.Status
+	if st, ok := stages[job.Type]; ok {
+		if st.progress >= 100 {
+			project.SetStatus(domain.StatusCompleted)
+		} else if st.progress > project.Progress {
+			project.Progress = st.progress
+		}
+	}
+	w.saveProject(project, previous)
+}
+
+// restoreProject puts the project of a cancelled job back in the status it had
+// before the job ran, unless it moved on from running meanwhile
+func (w *Worker) restoreProject(projectID string, running, previous domain.ProjectStatus) {
+	if running == previous {
+		return
+	}
+	project, err := w.projects.GetByID(projectID)
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID).Msg("failed to load project of cancelled job")
+		return
+	}
+	if project.Status != running {
+		return
+	}
+	project.Status = previous
+	w.saveProject(project, running)
+}
+
+// saveProject saves the project and publishes its status, changed from previous
+func (w *Worker) saveProject(project *domain.Project, previous domain.ProjectStatus) {
+	project.UpdatedAt = w.now()
+	if err := w.projects.Update(project); err != nil {
+		log.Error().Err(err).Uint("project_id", project.ID).Msg("failed to save project")
+		return
+	}
+	service.PublishProjectStatus(context.Background(), w.events, project, previous)
+}
+
+// defaultID identifies this process as a lease owner
+func defaultID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.New().String()[:8])
+}
+
+// Backoff returns the delay before retrying after the given attempt:
+// base, 2×base, 4×base, ... capped at max
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
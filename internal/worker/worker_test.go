@@ -0,0 +1,739 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JuanCS-Dev/typecraft/internal/domain"
+	"github.com/JuanCS-Dev/typecraft/internal/service"
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
+)
+
+// testWorkerID is the lease owner of the workers built by newTestWorker
+const testWorkerID = "test-worker"
+
+// memoryJobs implements JobSource, claiming by priority like the repository
+type memoryJobs struct {
+	mu         sync.Mutex
+	jobs       []*domain.Job
+	heartbeats int
+}
+
+func (m *memoryJobs) Claim(owner string, ttl time.Duration) (*domain.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make(map[string]domain.JobStatus, len(m.jobs))
+	for _, job := range m.jobs {
+		statuses[job.ID] = job.Status
+	}
+
+	var next *domain.Job
+	for _, job := range m.jobs {
+		if job.Status != domain.JobStatusPending || (job.RunAfter != nil && job.RunAfter.After(time.Now())) {
+			continue
+		}
+		if !job.DependenciesMet(statuses) {
+			continue
+		}
+		if next == nil || job.Priority > next.Priority {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	next.MarkStarted()
+	next.Lease(owner, time.Now().Add(ttl))
+	copied := *next
+	return &copied, nil
+}
+
+func (m *memoryJobs) GetByID(id string) (*domain.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.ID == id {
+			copied := *job
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrJobNotFound
+}
+
+// cancel cancels a stored job the way JobService.Cancel does
+func (m *memoryJobs) cancel(id string) *domain.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.ID == id {
+			job.MarkCancelled("generation cancelled by user")
+			job.ReleaseLease()
+			copied := *job
+			return &copied
+		}
+	}
+	return nil
+}
+
+// leased returns the stored job if owner still holds its lease
+func (m *memoryJobs) leased(id, owner string) (int, bool) {
+	for i, job := range m.jobs {
+		if job.ID == id {
+			return i, job.LeaseOwner == owner && job.Status == domain.JobStatusRunning
+		}
+	}
+	return -1, false
+}
+
+func (m *memoryJobs) Heartbeat(id, owner string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.leased(id, owner)
+	if !ok {
+		return domain.ErrJobLeaseLost
+	}
+	m.heartbeats++
+	expires := time.Now().Add(ttl)
+	m.jobs[i].LeaseExpiresAt = &expires
+	return nil
+}
+
+func (m *memoryJobs) Release(job *domain.Job, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job.ReleaseLease()
+	i, ok := m.leased(job.ID, owner)
+	if !ok {
+		return domain.ErrJobLeaseLost
+	}
+	copied := *job
+	m.jobs[i] = &copied
+	return nil
+}
+
+func (m *memoryJobs) ReapExpired(now time.Time) ([]*domain.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var reaped []*domain.Job
+	for _, job := range m.jobs {
+		if job.LeaseExpired(now) {
+			job.RecoverExpiredLease(now)
+			copied := *job
+			reaped = append(reaped, &copied)
+		}
+	}
+	return reaped, nil
+}
+
+func (m *memoryJobs) Update(job *domain.Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, stored := range m.jobs {
+		if stored.ID == job.ID {
+			copied := *job
+			m.jobs[i] = &copied
+			return nil
+		}
+	}
+	return errors.New("job not found")
+}
+
+func (m *memoryJobs) GetByProjectID(projectID string) ([]*domain.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var jobs []*domain.Job
+	for _, job := range m.jobs {
+		if job.ProjectID == projectID {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	return jobs, nil
+}
+
+func (m *memoryJobs) get(id string) domain.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.ID == id {
+			return *job
+		}
+	}
+	return domain.Job{}
+}
+
+// memoryProjects implements ProjectStore
+type memoryProjects struct {
+	mu       sync.Mutex
+	projects map[string]domain.Project
+}
+
+func (m *memoryProjects) GetByID(id string) (*domain.Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	project, ok := m.projects[id]
+	if !ok {
+		return nil, domain.ErrProjectNotFound
+	}
+	return &project, nil
+}
+
+func (m *memoryProjects) Update(project *domain.Project) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.projects[strconv.FormatUint(uint64(project.ID), 10)] = *project
+	return nil
+}
+
+func newTestWorker(jobs ...*domain.Job) (*Worker, *memoryJobs, *memoryProjects) {
+	source := &memoryJobs{jobs: jobs}
+	projects := &memoryProjects{projects: map[string]domain.Project{
+		"1": {ID: 1, Status: domain.StatusAnalyzing, Progress: 10},
+	}}
+	w := New(source, projects, 1, WithID(testWorkerID), WithBackoff(time.Minute, 10*time.Minute))
+	return w, source, projects
+}
+
+func claim(t *testing.T, source *memoryJobs) *domain.Job {
+	t.Helper()
+	job, err := source.Claim(testWorkerID, time.Minute)
+	if err != nil || job == nil {
+		t.Fatalf("Expected a job to claim, got %v, %v", job, err)
+	}
+	return job
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt, time.Second, 10*time.Second); got != tt.expected {
+			t.Errorf("Backoff(%d) = %v, expected %v", tt.attempt, got, tt.expected)
+		}
+	}
+}
+
+func TestWorker_CompletedJobAdvancesProject(t *testing.T) {
+	w, source, projects := newTestWorker(&domain.Job{ID: "a", ProjectID: "1", Type: domain.JobTypeAnalyze, Status: domain.JobStatusPending, MaxAttempts: 3})
+
+	var runningStatus domain.ProjectStatus
+	w.Handle(domain.JobTypeAnalyze, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		stored, _ := projects.GetByID("1")
+		runningStatus = stored.Status
+		project.Genre = "Fiction"
+		return map[string]interface{}{"genre": "Fiction"}, nil
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	job := source.get("a")
+	if job.Status != domain.JobStatusCompleted || job.Result == nil || (*job.Result)["genre"] != "Fiction" {
+		t.Errorf("Expected completed job with result, got %s %v", job.Status, job.Result)
+	}
+	if runningStatus != domain.StatusAnalyzing {
+		t.Errorf("Expected analyzing status while running, got %s", runningStatus)
+	}
+
+	project, _ := projects.GetByID("1")
+	if project.Progress != 40 || project.Genre != "Fiction" {
+		t.Errorf("Expected progress 40 and handler changes saved, got %d %q", project.Progress, project.Genre)
+	}
+}
+
+func TestWorker_FinalStageCompletesProject(t *testing.T) {
+	w, source, projects := newTestWorker(&domain.Job{ID: "r", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusPending, MaxAttempts: 3})
+	w.Handle(domain.JobTypeRender, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		return nil, nil
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	project, _ := projects.GetByID("1")
+	if project.Status != domain.StatusCompleted || project.Progress != 100 || project.CompletedAt == nil {
+		t.Errorf("Expected completed project, got %s %d", project.Status, project.Progress)
+	}
+}
+
+func TestWorker_RetriesWithBackoffThenFails(t *testing.T) {
+	w, source, projects := newTestWorker(&domain.Job{ID: "c", ProjectID: "1", Type: domain.JobTypeConvert, Status: domain.JobStatusPending, MaxAttempts: 2})
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+	w.Handle(domain.JobTypeConvert, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		return nil, errors.New("pandoc crashed")
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	job := source.get("c")
+	if job.Status != domain.JobStatusPending || job.Attempts != 1 {
+		t.Fatalf("Expected job requeued after first attempt, got %s (attempts %d)", job.Status, job.Attempts)
+	}
+	if job.RunAfter == nil || !job.RunAfter.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected retry after 1m backoff, got %v", job.RunAfter)
+	}
+
+	// Backoff elapsed: the last attempt fails for good
+	source.jobs[0].RunAfter = nil
+	w.Process(context.Background(), claim(t, source))
+
+	job = source.get("c")
+	if job.Status != domain.JobStatusFailed || job.Attempts != 2 || job.ErrorMsg != "pandoc crashed" {
+		t.Errorf("Expected failed job after max attempts, got %s (attempts %d, %q)", job.Status, job.Attempts, job.ErrorMsg)
+	}
+
+	project, _ := projects.GetByID("1")
+	if project.Status != domain.StatusFailed || project.ErrorLog == nil || !strings.Contains((*project.ErrorLog)[0], "pandoc crashed") {
+		t.Errorf("Expected failed project with error log, got %s %v", project.Status, project.ErrorLog)
+	}
+}
+
+func TestWorker_CancelledJobIsNotRetried(t *testing.T) {
+	w, source, projects := newTestWorker(&domain.Job{ID: "e", ProjectID: "1", Type: domain.JobTypeExport, Status: domain.JobStatusPending, MaxAttempts: 3})
+	w.Handle(domain.JobTypeExport, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		return nil, service.ErrGenerationCancelled
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	if job := source.get("e"); job.Status != domain.JobStatusCancelled {
+		t.Errorf("Expected cancelled job, got %s", job.Status)
+	}
+	if project, _ := projects.GetByID("1"); project.Status != domain.StatusAnalyzing {
+		t.Errorf("Expected project status restored, got %s", project.Status)
+	}
+}
+
+func TestWorker_CancelledJobRestoresProject(t *testing.T) {
+	tests := []struct {
+		name     string
+		leaseTTL time.Duration
+		watch    bool
+	}{
+		// The job.status event stops the job long before any heartbeat
+		{"event", time.Hour, true},
+		{"heartbeat", 30 * time.Millisecond, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, source, projects := newTestWorker(&domain.Job{ID: "e", ProjectID: "1", Type: domain.JobTypeExport, Status: domain.JobStatusPending, MaxAttempts: 3})
+			w.leaseTTL = tt.leaseTTL
+			events := service.NewMemoryEventStore()
+			if tt.watch {
+				WithCancellations(events)(w)
+			}
+
+			var runningStatus domain.ProjectStatus
+			w.Handle(domain.JobTypeExport, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+				stored, _ := projects.GetByID("1")
+				runningStatus = stored.Status
+
+				service.PublishJobStatus(ctx, events, source.cancel(job.ID))
+				select {
+				case <-ctx.Done():
+					return nil, service.ErrGenerationCancelled
+				case <-time.After(5 * time.Second):
+					return nil, errors.New("job not stopped")
+				}
+			})
+
+			w.Process(context.Background(), claim(t, source))
+
+			if runningStatus != domain.StatusRendering {
+				t.Errorf("Expected rendering status while running, got %s", runningStatus)
+			}
+			if job := source.get("e"); job.Status != domain.JobStatusCancelled {
+				t.Errorf("Expected cancelled job, got %s", job.Status)
+			}
+			if project, _ := projects.GetByID("1"); project.Status != domain.StatusAnalyzing {
+				t.Errorf("Expected project status restored to analyzing, got %s", project.Status)
+			}
+		})
+	}
+}
+
+func TestWorker_UnknownTypeFailsImmediately(t *testing.T) {
+	w, source, _ := newTestWorker(&domain.Job{ID: "x", ProjectID: "1", Type: domain.JobTypeRefine, Status: domain.JobStatusPending, MaxAttempts: 3})
+
+	w.Process(context.Background(), claim(t, source))
+
+	if job := source.get("x"); job.Status != domain.JobStatusFailed {
+		t.Errorf("Expected failed job without handler, got %s", job.Status)
+	}
+}
+
+func TestWorker_RunRespectsConcurrency(t *testing.T) {
+	var jobs []*domain.Job
+	for _, id := range []string{"1", "2", "3", "4", "5", "6"} {
+		jobs = append(jobs, &domain.Job{ID: id, ProjectID: "1", Type: domain.JobTypeDesign, Status: domain.JobStatusPending, MaxAttempts: 1})
+	}
+	source := &memoryJobs{jobs: jobs}
+	projects := &memoryProjects{projects: map[string]domain.Project{"1": {ID: 1}}}
+	w := New(source, projects, 2, WithPollInterval(5*time.Millisecond))
+
+	var mu sync.Mutex
+	running, peak, done := 0, 0, 0
+	w.Handle(domain.JobTypeDesign, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		done++
+		mu.Unlock()
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(finished)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := done
+		mu.Unlock()
+		if n == len(jobs) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-finished
+
+	if done != len(jobs) {
+		t.Fatalf("Expected %d jobs processed, got %d", len(jobs), done)
+	}
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent jobs, got %d", peak)
+	}
+}
+
+func TestWorker_ShutdownRequeuesJob(t *testing.T) {
+	w, source, _ := newTestWorker(&domain.Job{ID: "s", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusPending, MaxAttempts: 3})
+	ctx, cancel := context.WithCancel(context.Background())
+	w.Handle(domain.JobTypeRender, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		cancel()
+		return nil, ctx.Err()
+	})
+
+	w.Process(ctx, claim(t, source))
+
+	if job := source.get("s"); job.Status != domain.JobStatusPending || job.Attempts != 0 {
+		t.Errorf("Expected job back in the queue without using an attempt, got %s (attempts %d)", job.Status, job.Attempts)
+	}
+}
+
+// chain builds pending jobs for project 1 where each depends on the previous one
+func chain(types ...domain.JobType) []*domain.Job {
+	jobs := make([]*domain.Job, len(types))
+	for i, jobType := range types {
+		jobs[i] = &domain.Job{ID: string(jobType), ProjectID: "1", Type: jobType, Status: domain.JobStatusPending, Priority: i, MaxAttempts: 1}
+		if i > 0 {
+			jobs[i].AddDependency(jobs[i-1])
+		}
+	}
+	return jobs
+}
+
+func TestWorker_ChildWaitsForParent(t *testing.T) {
+	// The child has the higher priority but must wait for its parent
+	w, source, _ := newTestWorker(chain(domain.JobTypeConvert, domain.JobTypeAnalyze)...)
+	var ran []domain.JobType
+	handler := func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		ran = append(ran, job.Type)
+		return nil, nil
+	}
+	w.Handle(domain.JobTypeConvert, handler)
+	w.Handle(domain.JobTypeAnalyze, handler)
+
+	w.Process(context.Background(), claim(t, source))
+	w.Process(context.Background(), claim(t, source))
+
+	if len(ran) != 2 || ran[0] != domain.JobTypeConvert || ran[1] != domain.JobTypeAnalyze {
+		t.Errorf("Expected convert before analyze, got %v", ran)
+	}
+}
+
+func TestWorker_FailureCancelsDependents(t *testing.T) {
+	w, source, _ := newTestWorker(chain(domain.JobTypeConvert, domain.JobTypeAnalyze, domain.JobTypeDesign)...)
+	w.Handle(domain.JobTypeConvert, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		return nil, errors.New("unsupported manuscript format: .pages")
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	if job := source.get("convert"); job.Status != domain.JobStatusFailed {
+		t.Fatalf("Expected failed parent, got %s", job.Status)
+	}
+	for _, id := range []string{"analyze", "design"} {
+		job := source.get(id)
+		if job.Status != domain.JobStatusCancelled {
+			t.Errorf("Expected %s cancelled, got %s", id, job.Status)
+		}
+		if !strings.Contains(job.ErrorMsg, "dependency convert job convert failed") ||
+			!strings.Contains(job.ErrorMsg, "unsupported manuscript format") {
+			t.Errorf("Expected cancellation reason naming the failed parent, got %q", job.ErrorMsg)
+		}
+	}
+
+	if job, _ := source.Claim(testWorkerID, time.Minute); job != nil {
+		t.Errorf("Expected nothing left to claim, got %s", job.ID)
+	}
+}
+
+func TestWorker_CancellationCancelsDependents(t *testing.T) {
+	w, source, _ := newTestWorker(chain(domain.JobTypeRender, domain.JobTypeExport)...)
+	w.Handle(domain.JobTypeRender, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		return nil, service.ErrGenerationCancelled
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	job := source.get("export")
+	if job.Status != domain.JobStatusCancelled || !strings.Contains(job.ErrorMsg, "dependency render job render cancelled") {
+		t.Errorf("Expected export cancelled by its parent, got %s %q", job.Status, job.ErrorMsg)
+	}
+}
+
+func TestWorker_RetryKeepsDependentsPending(t *testing.T) {
+	jobs := chain(domain.JobTypeConvert, domain.JobTypeAnalyze)
+	jobs[0].MaxAttempts = 2
+	w, source, _ := newTestWorker(jobs...)
+	w.Handle(domain.JobTypeConvert, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		return nil, errors.New("pandoc crashed")
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	if job := source.get("analyze"); job.Status != domain.JobStatusPending {
+		t.Errorf("Expected dependent pending while its parent retries, got %s", job.Status)
+	}
+}
+
+func TestJobGraph(t *testing.T) {
+	jobs := chain(domain.JobTypeConvert, domain.JobTypeAnalyze, domain.JobTypeDesign)
+	jobs[0].Status = domain.JobStatusCompleted
+
+	if err := domain.ValidateJobGraph(jobs); err != nil {
+		t.Fatalf("Expected valid chain, got %v", err)
+	}
+
+	graph := domain.NewJobGraph(jobs)
+	if len(graph.Edges) != 2 || graph.Edges[0] != (domain.JobEdge{From: "convert", To: "analyze"}) {
+		t.Errorf("Unexpected edges: %v", graph.Edges)
+	}
+	if len(graph.Runnable) != 1 || graph.Runnable[0] != "analyze" {
+		t.Errorf("Expected only analyze runnable, got %v", graph.Runnable)
+	}
+
+	// Closing the loop must be rejected
+	jobs[0].AddDependency(jobs[2])
+	if err := domain.ValidateJobGraph(jobs); err == nil {
+		t.Error("Expected cycle to be rejected")
+	}
+
+	orphan := &domain.Job{ID: "orphan", DependsOn: &[]string{"missing"}}
+	if err := domain.ValidateJobGraph([]*domain.Job{orphan}); err == nil {
+		t.Error("Expected unknown parent to be rejected")
+	}
+}
+
+func TestWorker_HeartbeatKeepsLease(t *testing.T) {
+	w, source, _ := newTestWorker(&domain.Job{ID: "r", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusPending, MaxAttempts: 1})
+	w.leaseTTL = 30 * time.Millisecond
+	w.Handle(domain.JobTypeRender, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		time.Sleep(100 * time.Millisecond)
+		return nil, ctx.Err()
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	job := source.get("r")
+	if job.Status != domain.JobStatusCompleted {
+		t.Fatalf("Expected job completed under a renewed lease, got %s: %s", job.Status, job.ErrorMsg)
+	}
+	if source.heartbeats == 0 {
+		t.Error("Expected heartbeats while the job ran")
+	}
+	if job.LeaseOwner != "" || job.LeaseExpiresAt != nil {
+		t.Errorf("Expected lease released, got %q %v", job.LeaseOwner, job.LeaseExpiresAt)
+	}
+}
+
+func TestWorker_LostLeaseStopsJob(t *testing.T) {
+	w, source, _ := newTestWorker(&domain.Job{ID: "r", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusPending, MaxAttempts: 3})
+	w.leaseTTL = 30 * time.Millisecond
+	w.Handle(domain.JobTypeRender, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		// Another worker takes over the job, as after a reap
+		source.mu.Lock()
+		source.jobs[0].LeaseOwner = "other-worker"
+		source.mu.Unlock()
+
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	job := source.get("r")
+	if job.Status != domain.JobStatusRunning || job.LeaseOwner != "other-worker" {
+		t.Errorf("Expected the new owner's job untouched, got %s owned by %q", job.Status, job.LeaseOwner)
+	}
+}
+
+func TestWorker_ReapRecoversExpiredJobs(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	jobs := chain(domain.JobTypeConvert, domain.JobTypeAnalyze)
+	jobs[0].Status, jobs[0].Attempts, jobs[0].MaxAttempts = domain.JobStatusRunning, 1, 1
+	jobs[0].Lease("crashed-worker", expired)
+	retryable := &domain.Job{ID: "retry", ProjectID: "1", Type: domain.JobTypeDesign, Status: domain.JobStatusRunning, Attempts: 1, MaxAttempts: 3}
+	retryable.Lease("crashed-worker", expired)
+	healthy := &domain.Job{ID: "healthy", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusRunning, Attempts: 1, MaxAttempts: 3}
+	healthy.Lease("live-worker", time.Now().Add(time.Minute))
+
+	w, source, _ := newTestWorker(append(jobs, retryable, healthy)...)
+	w.Reap()
+
+	if job := source.get("retry"); job.Status != domain.JobStatusPending || job.LeaseOwner != "" || !strings.Contains(job.ErrorMsg, "crashed-worker") {
+		t.Errorf("Expected retryable job back in the queue, got %s owned by %q (%q)", job.Status, job.LeaseOwner, job.ErrorMsg)
+	}
+	if job := source.get("convert"); job.Status != domain.JobStatusFailed {
+		t.Errorf("Expected job without attempts left to fail, got %s", job.Status)
+	}
+	if job := source.get("analyze"); job.Status != domain.JobStatusCancelled {
+		t.Errorf("Expected dependent of the failed job cancelled, got %s", job.Status)
+	}
+	if job := source.get("healthy"); job.Status != domain.JobStatusRunning || job.LeaseOwner != "live-worker" {
+		t.Errorf("Expected job with a live lease untouched, got %s owned by %q", job.Status, job.LeaseOwner)
+	}
+}
+
+// memoryLogs implements service.JobLogStore
+type memoryLogs struct {
+	mu   sync.Mutex
+	logs []*domain.JobLog
+}
+
+func (m *memoryLogs) Create(log *domain.JobLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logs = append(m.logs, log)
+	return nil
+}
+
+func (m *memoryLogs) GetByJobID(jobID string) ([]*domain.JobLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var logs []*domain.JobLog
+	for _, log := range m.logs {
+		if log.JobID == jobID {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (m *memoryLogs) GetByID(jobID, id string) (*domain.JobLog, error) {
+	return nil, domain.ErrJobLogNotFound
+}
+
+func TestWorker_RecordsToolRuns(t *testing.T) {
+	w, source, _ := newTestWorker(&domain.Job{ID: "r", ProjectID: "1", Type: domain.JobTypeRender, Status: domain.JobStatusPending, MaxAttempts: 1})
+	logs := &memoryLogs{}
+	WithJobLogs(logs)(w)
+	w.Handle(domain.JobTypeRender, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		_, err := process.CombinedOutput(ctx, process.CommandContext(ctx, "sh", "-c", "echo 'Error: font not found' >&2; exit 2"))
+		return nil, err
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	runs, _ := logs.GetByJobID("r")
+	if len(runs) != 1 {
+		t.Fatalf("Expected the tool run recorded, got %d", len(runs))
+	}
+	if runs[0].Attempt != 1 || runs[0].ExitCode != 2 || runs[0].Stderr != "Error: font not found\n" {
+		t.Errorf("Unexpected tool run: %+v", runs[0])
+	}
+	if job := source.get("r"); job.Status != domain.JobStatusFailed {
+		t.Errorf("Expected failed job, got %s", job.Status)
+	}
+}
+
+func TestWorker_PublishesEvents(t *testing.T) {
+	w, source, _ := newTestWorker(chain(domain.JobTypeConvert, domain.JobTypeAnalyze)...)
+	events := service.NewMemoryEventStore()
+	WithEvents(events)(w)
+	w.Handle(domain.JobTypeConvert, func(ctx context.Context, job *domain.Job, project *domain.Project) (map[string]interface{}, error) {
+		return nil, errors.New("unsupported manuscript format: .pages")
+	})
+
+	w.Process(context.Background(), claim(t, source))
+
+	published, err := events.Read(context.Background(), "1", "0", 0)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	var got []string
+	for _, event := range published {
+		switch event.Type {
+		case service.EventJobStatus:
+			var data service.JobStatusEvent
+			json.Unmarshal(event.Data, &data)
+			got = append(got, data.JobID+":"+string(data.Status))
+		case service.EventProjectStatus:
+			var data service.ProjectStatusEvent
+			json.Unmarshal(event.Data, &data)
+			got = append(got, "project:"+string(data.Status))
+		}
+	}
+
+	expected := []string{
+		"convert:running",
+		"project:analyzing",
+		"project:failed",
+		"convert:failed",
+		"analyze:cancelled",
+	}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected events %v, got %v", expected, got)
+	}
+}
@@ -2,11 +2,14 @@ package converter
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
 )
 
 // PandocConverter lida com conversões usando Pandoc
@@ -38,6 +41,11 @@ type ConvertRequest struct {
 
 // Convert executa a conversão usando pandoc
 func (c *PandocConverter) Convert(req ConvertRequest) error {
+	return c.ConvertContext(context.Background(), req)
+}
+
+// ConvertContext executa a conversão, matando o pandoc se o contexto for cancelado
+func (c *PandocConverter) ConvertContext(ctx context.Context, req ConvertRequest) error {
 	// Verificar se arquivo de entrada existe
 	if _, err := os.Stat(req.InputFile); os.IsNotExist(err) {
 		return fmt.Errorf("arquivo de entrada não existe: %s", req.InputFile)
@@ -67,13 +75,16 @@ func (c *PandocConverter) Convert(req ConvertRequest) error {
 	args = append(args, req.Options...)
 	
 	// Executar comando
-	cmd := exec.Command(c.pandocPath, args...)
+	cmd := process.CommandContext(ctx, c.pandocPath, args...)
 	
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	
-	if err := cmd.Run(); err != nil {
+	if err := process.Exec(ctx, cmd); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("conversão cancelada: %w", ctx.Err())
+		}
 		return fmt.Errorf("erro ao executar pandoc: %w\nStderr: %s", err, stderr.String())
 	}
 	
@@ -34,23 +34,23 @@ type DesignRequest struct {
 
 // DesignResult contains the generated design
 type DesignResult struct {
-	Fonts   Fonts
-	Colors  []string
-	Margins Margins
+	Fonts   Fonts    `json:"fonts"`
+	Colors  []string `json:"colors"`
+	Margins Margins  `json:"margins"`
 }
 
 // Fonts contains font selections
 type Fonts struct {
-	Body    string
-	Heading string
+	Body    string `json:"body"`
+	Heading string `json:"heading"`
 }
 
 // Margins contains page margins in millimeters
 type Margins struct {
-	Top    float64
-	Bottom float64
-	Left   float64
-	Right  float64
+	Top    float64 `json:"top"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+	Right  float64 `json:"right"`
 }
 
 // GenerateDesign generates a complete design based on content analysis
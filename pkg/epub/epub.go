@@ -37,6 +37,7 @@ type Chapter struct {
 	Title    string
 	Content  string // HTML content
 	FileName string // e.g., "chapter1.xhtml"
+	Type     string // epub:type semântico (chapter, part, preface, appendix...)
 }
 
 // EPub representa um livro ePub
@@ -64,6 +65,15 @@ type ImageFile struct {
 	ID       string
 	Path     string
 	MimeType string
+	FileName string // nome em OEBPS/Images (padrão: o nome do arquivo em Path)
+}
+
+// href retorna o nome da imagem dentro de OEBPS/Images
+func (img ImageFile) href() string {
+	if img.FileName != "" {
+		return img.FileName
+	}
+	return filepath.Base(img.Path)
 }
 
 // NewEPub cria um novo ePub
@@ -248,6 +258,11 @@ func (e *EPub) writeChapters() error {
 
 // wrapChapterHTML envolve o conteúdo do capítulo em HTML válido
 func (e *EPub) wrapChapterHTML(chapter Chapter) string {
+	sectionType := chapter.Type
+	if sectionType == "" {
+		sectionType = "chapter"
+	}
+
 	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE html>
 <html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
@@ -257,12 +272,12 @@ func (e *EPub) wrapChapterHTML(chapter Chapter) string {
   <link rel="stylesheet" type="text/css" href="../Styles/style.css"/>
 </head>
 <body>
-  <section epub:type="chapter">
+  <section epub:type="%s">
     <h1>%s</h1>
     %s
   </section>
 </body>
-</html>`, chapter.Title, chapter.Title, chapter.Content)
+</html>`, escapeXML(chapter.Title), sectionType, escapeXML(chapter.Title), chapter.Content)
 }
 
 // writeCSS escreve o CSS
@@ -298,7 +313,7 @@ func (e *EPub) copyImages() error {
 			continue // Skip if image doesn't exist
 		}
 		
-		dest := filepath.Join(e.tempDir, "OEBPS", "Images", filepath.Base(img.Path))
+		dest := filepath.Join(e.tempDir, "OEBPS", "Images", img.href())
 		if err := copyFile(img.Path, dest); err != nil {
 			return fmt.Errorf("failed to copy image %s: %w", img.ID, err)
 		}
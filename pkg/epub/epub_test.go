@@ -236,6 +236,24 @@ func TestEPub_ChapterWrapping(t *testing.T) {
 	assert.Contains(t, wrapped, "epub:type=\"chapter\"")
 }
 
+func TestEPub_ChapterWrapping_Type(t *testing.T) {
+	epub := NewEPub(EPub3)
+
+	wrapped := epub.wrapChapterHTML(Chapter{Title: "Prefácio", Type: "preface"})
+
+	assert.Contains(t, wrapped, "epub:type=\"preface\"")
+	assert.NotContains(t, wrapped, "epub:type=\"chapter\"")
+}
+
+func TestEPub_ChapterWrapping_EscapesTitle(t *testing.T) {
+	epub := NewEPub(EPub3)
+
+	wrapped := epub.wrapChapterHTML(Chapter{Title: "Rock & Roll <Live>"})
+
+	assert.Contains(t, wrapped, "<title>Rock &amp; Roll &lt;Live&gt;</title>")
+	assert.Contains(t, wrapped, "<h1>Rock &amp; Roll &lt;Live&gt;</h1>")
+}
+
 func TestOPFGenerator(t *testing.T) {
 	epub := NewEPub(EPub3)
 	epub.Metadata = Metadata{
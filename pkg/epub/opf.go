@@ -145,7 +145,7 @@ func (o *OPFGenerator) generateManifest() string {
 			properties = " properties=\"cover-image\""
 		}
 		sb.WriteString(fmt.Sprintf("    <item id=\"%s\" href=\"Images/%s\" media-type=\"%s\"%s/>\n",
-			img.ID, img.Path, mimeType, properties))
+			img.ID, img.href(), mimeType, properties))
 	}
 	
 	sb.WriteString("  </manifest>\n")
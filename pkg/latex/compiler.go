@@ -2,6 +2,8 @@ package latex
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,6 +11,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
 )
 
 // Compiler compila documentos LaTeX em PDF
@@ -79,20 +83,27 @@ type CompileResult struct {
 	LogPath    string
 	Errors     []CompileError
 	Warnings   []string
+	Pages      int
 	Duration   time.Duration
 	TempFiles  []string
 }
 
 // CompileError erro de compilação LaTeX
 type CompileError struct {
-	Line    int
-	File    string
-	Message string
-	Type    string // error, warning, fatal
+	Line    int    `json:"line,omitempty"`
+	File    string `json:"file,omitempty"`
+	Message string `json:"message"`
+	Type    string `json:"type"` // error, warning, fatal
 }
 
 // Compile compila documento LaTeX para PDF
 func (c *Compiler) Compile(latexContent string) (*CompileResult, error) {
+	return c.CompileContext(context.Background(), latexContent)
+}
+
+// CompileContext compila documento LaTeX para PDF, abortando a compilação
+// (e todos os processos do motor) quando o contexto é cancelado
+func (c *Compiler) CompileContext(ctx context.Context, latexContent string) (*CompileResult, error) {
 	start := time.Now()
 	
 	// Cria arquivo .tex
@@ -107,7 +118,7 @@ func (c *Compiler) Compile(latexContent string) (*CompileResult, error) {
 
 	// Compila (2 passes para resolver referências)
 	for i := 0; i < 2; i++ {
-		if err := c.runCompiler(texPath); err != nil {
+		if err := c.runCompiler(ctx, texPath); err != nil {
 			result.Success = false
 			result.Duration = time.Since(start)
 			
@@ -144,13 +155,14 @@ func (c *Compiler) Compile(latexContent string) (*CompileResult, error) {
 	if logData, err := os.ReadFile(logPath); err == nil {
 		result.LogPath = logPath
 		result.Warnings = c.parseWarnings(string(logData))
+		result.Pages = c.parsePageCount(string(logData))
 	}
 
 	return result, nil
 }
 
 // runCompiler executa o compilador LaTeX
-func (c *Compiler) runCompiler(texPath string) error {
+func (c *Compiler) runCompiler(ctx context.Context, texPath string) error {
 	args := []string{
 		"-interaction=nonstopmode",
 		"-halt-on-error",
@@ -161,30 +173,29 @@ func (c *Compiler) runCompiler(texPath string) error {
 	args = append(args, c.extraArgs...)
 	args = append(args, texPath)
 
-	cmd := exec.Command(c.engine, args...)
+	// Timeout handling
+	runCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	cmd := process.CommandContext(runCtx, c.engine, args...)
 	cmd.Dir = c.workDir
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start %s: %w", c.engine, err)
-	}
-
-	// Timeout handling
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Wait()
-	}()
-
-	select {
-	case <-time.After(c.timeout):
-		cmd.Process.Kill()
-		return fmt.Errorf("compilation timeout after %v", c.timeout)
-	case err := <-done:
-		if err != nil {
-			return fmt.Errorf("compilation error: %w\n%s", err, stderr.String())
+	// O document.log sobrevive no registro da execução, mesmo após o workDir ser removido
+	logPath := strings.TrimSuffix(texPath, filepath.Ext(texPath)) + ".log"
+	if err := process.Exec(runCtx, cmd, process.WithLogFile(logPath)); err != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case ctx.Err() != nil:
+			return fmt.Errorf("compilation cancelled: %w", ctx.Err())
+		case runCtx.Err() == context.DeadlineExceeded:
+			return fmt.Errorf("compilation timeout after %v", c.timeout)
+		case !errors.As(err, &exitErr):
+			return fmt.Errorf("failed to start %s: %w", c.engine, err)
 		}
+		return fmt.Errorf("compilation error: %w\n%s", err, stderr.String())
 	}
 
 	return nil
@@ -255,6 +266,20 @@ func (c *Compiler) parseWarnings(log string) []string {
 	return warnings
 }
 
+// parsePageCount extrai o número de páginas do log LaTeX
+func (c *Compiler) parsePageCount(log string) int {
+	// Pattern: Output written on document.pdf (12 pages, 34567 bytes).
+	rePages := regexp.MustCompile(`Output written on .*?\((\d+) pages?`)
+	match := rePages.FindStringSubmatch(log)
+	if len(match) < 2 {
+		return 0
+	}
+
+	pages := 0
+	fmt.Sscanf(match[1], "%d", &pages)
+	return pages
+}
+
 // CopyPDF copia PDF gerado para destino
 func (c *Compiler) CopyPDF(result *CompileResult, destPath string) error {
 	if !result.Success {
@@ -185,6 +185,16 @@ Package hyperref Warning: Token not allowed in a PDF string.`
 	assert.Contains(t, warnings[1], "Token not allowed")
 }
 
+func TestCompiler_ParsePageCount(t *testing.T) {
+	c, err := NewCompiler()
+	require.NoError(t, err)
+	defer c.Cleanup()
+
+	assert.Equal(t, 12, c.parsePageCount("Output written on document.pdf (12 pages, 34567 bytes)."))
+	assert.Equal(t, 1, c.parsePageCount("Output written on document.pdf (1 page, 1234 bytes)."))
+	assert.Equal(t, 0, c.parsePageCount("No pages of output."))
+}
+
 func TestCompiler_CopyPDF(t *testing.T) {
 	c, err := NewCompiler()
 	require.NoError(t, err)
@@ -20,6 +20,7 @@ type Document struct {
 	Class    DocumentClass
 	Options  []string
 	Packages []Package
+	Preamble []string
 	Metadata DocumentMetadata
 	Content  []string
 }
@@ -44,6 +45,7 @@ func NewDocument(class DocumentClass) *Document {
 		Class:    class,
 		Options:  make([]string, 0),
 		Packages: make([]Package, 0),
+		Preamble: make([]string, 0),
 		Content:  make([]string, 0),
 	}
 }
@@ -63,6 +65,12 @@ func (d *Document) AddPackage(name string, options ...string) *Document {
 	return d
 }
 
+// AddPreamble adiciona comandos ao preâmbulo (após os pacotes)
+func (d *Document) AddPreamble(command string) *Document {
+	d.Preamble = append(d.Preamble, command)
+	return d
+}
+
 // SetMetadata define metadados
 func (d *Document) SetMetadata(metadata DocumentMetadata) *Document {
 	d.Metadata = metadata
@@ -147,6 +155,16 @@ func (d *Document) Generate() string {
 		sb.WriteString("\n")
 	}
 	
+	// Preamble
+	for _, command := range d.Preamble {
+		sb.WriteString(command)
+		sb.WriteString("\n")
+	}
+	
+	if len(d.Preamble) > 0 {
+		sb.WriteString("\n")
+	}
+	
 	// Metadata
 	if d.Metadata.Title != "" {
 		sb.WriteString(fmt.Sprintf("\\title{%s}\n", d.Metadata.Title))
@@ -206,6 +224,14 @@ func (db *DocumentBuilder) WithPackage(name string, options ...string) *Document
 	return db
 }
 
+// WithPreamble adiciona comandos ao preâmbulo
+func (db *DocumentBuilder) WithPreamble(commands ...string) *DocumentBuilder {
+	for _, command := range commands {
+		db.document.AddPreamble(command)
+	}
+	return db
+}
+
 // WithMetadata adiciona metadados
 func (db *DocumentBuilder) WithMetadata(metadata DocumentMetadata) *DocumentBuilder {
 	db.document.SetMetadata(metadata)
@@ -199,6 +199,27 @@ func TestDocument_Generate_Complete(t *testing.T) {
 	assert.True(t, introIdx < endIdx)
 }
 
+func TestDocument_Generate_WithPreamble(t *testing.T) {
+	doc := NewDocumentBuilder(ClassBook).
+		WithPackage("fontspec").
+		WithPreamble("\\setmainfont{Garamond}", "\\definecolor{primary}{HTML}{2C3E50}").
+		WithMetadata(DocumentMetadata{Title: "My Book"}).
+		Build()
+
+	result := doc.Generate()
+
+	assert.Contains(t, result, "\\setmainfont{Garamond}")
+	assert.Contains(t, result, "\\definecolor{primary}{HTML}{2C3E50}")
+
+	// Preâmbulo vem depois dos pacotes e antes do corpo
+	packageIdx := strings.Index(result, "\\usepackage{fontspec}")
+	fontIdx := strings.Index(result, "\\setmainfont{Garamond}")
+	beginIdx := strings.Index(result, "\\begin{document}")
+
+	assert.True(t, packageIdx < fontIdx)
+	assert.True(t, fontIdx < beginIdx)
+}
+
 func TestDocumentBuilder(t *testing.T) {
 	doc := NewDocumentBuilder(ClassBook).
 		WithOptions("11pt", "twoside").
@@ -1,10 +1,12 @@
 package pipeline
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+
+	"github.com/JuanCS-Dev/typecraft/pkg/process"
 )
 
 // PDFGenerator gera PDFs usando Paged.js CLI
@@ -26,6 +28,12 @@ func NewPDFGenerator() (*PDFGenerator, error) {
 
 // GeneratePDF converte HTML em PDF usando pagedjs-cli
 func (p *PDFGenerator) GeneratePDF(htmlContent string, outputPath string) error {
+	return p.GeneratePDFContext(context.Background(), htmlContent, outputPath)
+}
+
+// GeneratePDFContext converte HTML em PDF, encerrando o pagedjs-cli (e o
+// navegador que ele inicia) se o contexto for cancelado
+func (p *PDFGenerator) GeneratePDFContext(ctx context.Context, htmlContent string, outputPath string) error {
 	// Cria arquivo HTML temporário
 	htmlPath := filepath.Join(p.tempDir, "input.html")
 	if err := os.WriteFile(htmlPath, []byte(htmlContent), 0644); err != nil {
@@ -33,14 +41,17 @@ func (p *PDFGenerator) GeneratePDF(htmlContent string, outputPath string) error
 	}
 
 	// Executa pagedjs-cli
-	cmd := exec.Command("pagedjs-cli",
+	cmd := process.CommandContext(ctx, "pagedjs-cli",
 		htmlPath,
 		"-o", outputPath,
 		"--timeout", "120000",
 	)
 
-	output, err := cmd.CombinedOutput()
+	output, err := process.CombinedOutput(ctx, cmd)
 	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("geração de PDF cancelada: %w", ctx.Err())
+		}
 		return fmt.Errorf("erro ao executar pagedjs-cli: %w\nOutput: %s", err, string(output))
 	}
 
@@ -49,6 +60,12 @@ func (p *PDFGenerator) GeneratePDF(htmlContent string, outputPath string) error
 
 // GeneratePDFWithOptions gera PDF com opções customizadas
 func (p *PDFGenerator) GeneratePDFWithOptions(htmlContent string, outputPath string, opts PDFOptions) error {
+	return p.GeneratePDFWithOptionsContext(context.Background(), htmlContent, outputPath, opts)
+}
+
+// GeneratePDFWithOptionsContext gera PDF com opções customizadas, cancelável
+// pelo contexto
+func (p *PDFGenerator) GeneratePDFWithOptionsContext(ctx context.Context, htmlContent string, outputPath string, opts PDFOptions) error {
 	htmlPath := filepath.Join(p.tempDir, "input.html")
 	if err := os.WriteFile(htmlPath, []byte(htmlContent), 0644); err != nil {
 		return fmt.Errorf("erro ao escrever HTML temporário: %w", err)
@@ -79,9 +96,12 @@ func (p *PDFGenerator) GeneratePDFWithOptions(htmlContent string, outputPath str
 		}
 	}
 
-	cmd := exec.Command("pagedjs-cli", args...)
-	output, err := cmd.CombinedOutput()
+	cmd := process.CommandContext(ctx, "pagedjs-cli", args...)
+	output, err := process.CombinedOutput(ctx, cmd)
 	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("geração de PDF cancelada: %w", ctx.Err())
+		}
 		return fmt.Errorf("erro ao executar pagedjs-cli: %w\nOutput: %s", err, string(output))
 	}
 
@@ -0,0 +1,353 @@
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Tipos de seção reconhecidos pelo SplitSections
+const (
+	SectionPart             = "part"
+	SectionChapter          = "chapter"
+	SectionPreface          = "preface"
+	SectionForeword         = "foreword"
+	SectionAcknowledgements = "acknowledgements"
+	SectionAppendix         = "appendix"
+	SectionGlossary         = "glossary"
+)
+
+// numberPattern aceita algarismos, romanos (só maiúsculos, validados por
+// isRomanNumeral) e números por extenso (até vinte)
+const numberPattern = `(\d+|(?-i:[IVXLCDM]+)|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve|thirteen|fourteen|fifteen|sixteen|seventeen|eighteen|nineteen|twenty|um|uma|dois|duas|três|tres|quatro|cinco|seis|sete|oito|nove|dez|onze|doze|treze|catorze|quatorze|quinze|dezesseis|dezessete|dezoito|dezenove|vinte|first|second|third|fourth|fifth|primeira|segunda|terceira|quarta|quinta)`
+
+var (
+	// chapterHeading casa "Chapter 3", "Capítulo IV: O Início", "CAPÍTULO UM"
+	chapterHeading = regexp.MustCompile(`(?i)^(chapter|cap[íi]tulo)\s+` + numberPattern + `\b`)
+	// partHeading casa "Part One", "Parte II", "Livro 1"
+	partHeading = regexp.MustCompile(`(?i)^(part|parte|book|livro)\s+` + numberPattern + `\b`)
+	// romanHeading casa títulos formados só por algarismos romanos ("IV", "XII.")
+	romanHeading = regexp.MustCompile(`^([IVXLCDM]+)\.?$`)
+	// romanNumeral valida um numeral romano (até MMMCMXCIX); aceita a string
+	// vazia, que isRomanNumeral recusa
+	romanNumeral = regexp.MustCompile(`^M{0,3}(CM|CD|D?C{0,3})(XC|XL|L?X{0,3})(IX|IV|V?I{0,3})$`)
+
+	atxHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	setextH1       = regexp.MustCompile(`^=+\s*$`)
+	setextH2       = regexp.MustCompile(`^-+\s*$`)
+	headingAttrs   = regexp.MustCompile(`\s*\{[^}]*\}$`)
+	fenceDelimiter = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// matterKeywords classifica front e back matter pelo título da seção
+var matterKeywords = []struct {
+	sectionType string
+	keywords    []string
+}{
+	{SectionPreface, []string{"preface", "prefácio", "prefacio"}},
+	{SectionForeword, []string{"foreword", "apresentação", "apresentacao"}},
+	{SectionAcknowledgements, []string{"acknowledgements", "acknowledgments", "agradecimentos"}},
+	{SectionAppendix, []string{"appendix", "apêndice", "apendice", "anexo"}},
+	{SectionGlossary, []string{"glossary", "glossário", "glossario"}},
+}
+
+// IsChapterHeading indica se o título já traz o rótulo de capítulo
+// ("Capítulo 1", "Chapter IV" ou apenas um numeral romano). Um numeral
+// sozinho só é título quando ocupa a linha inteira: o chamador garante isso.
+func IsChapterHeading(title string) bool {
+	title = strings.TrimSpace(title)
+	if labeledHeading(chapterHeading, title) {
+		return true
+	}
+	m := romanHeading.FindStringSubmatch(title)
+	return m != nil && isRomanNumeral(m[1])
+}
+
+// IsPartHeading indica se o título é um divisor de parte ("Parte I", "Part Two")
+func IsPartHeading(title string) bool {
+	return labeledHeading(partHeading, strings.TrimSpace(title))
+}
+
+// labeledHeading indica se o título casa com um rótulo seguido de número
+// (chapterHeading ou partHeading) e, se o número for romano, se é válido:
+// "Chapter XII" sim, "BOOK DID" ou "Part IIII" não
+func labeledHeading(pattern *regexp.Regexp, title string) bool {
+	m := pattern.FindStringSubmatch(title)
+	if m == nil {
+		return false
+	}
+	number := m[2]
+	if strings.Trim(number, "IVXLCDM") == "" {
+		return isRomanNumeral(number)
+	}
+	return true
+}
+
+// isRomanNumeral indica se s é um numeral romano válido em maiúsculas
+func isRomanNumeral(s string) bool {
+	return s != "" && romanNumeral.MatchString(s)
+}
+
+// IsFrontMatter indica se a seção pertence aos elementos pré-textuais
+func (s BookSection) IsFrontMatter() bool {
+	switch s.Type {
+	case SectionPreface, SectionForeword, SectionAcknowledgements:
+		return true
+	}
+	return false
+}
+
+// IsBackMatter indica se a seção pertence aos elementos pós-textuais
+func (s BookSection) IsBackMatter() bool {
+	switch s.Type {
+	case SectionAppendix, SectionGlossary:
+		return true
+	}
+	return false
+}
+
+// classifySection determina o tipo da seção a partir do título
+func classifySection(title string) string {
+	if IsPartHeading(title) {
+		return SectionPart
+	}
+	if IsChapterHeading(title) {
+		return SectionChapter
+	}
+
+	lower := strings.ToLower(strings.TrimSpace(title))
+	for _, matter := range matterKeywords {
+		for _, keyword := range matter.keywords {
+			if strings.HasPrefix(lower, keyword) {
+				return matter.sectionType
+			}
+		}
+	}
+
+	return SectionChapter
+}
+
+// markdownLine é uma linha do manuscrito já classificada
+type markdownLine struct {
+	text    string
+	level   int    // nível do título (0 = texto)
+	title   string // texto do título
+	bare    bool   // título estrutural sem marcação ("Capítulo 1" sozinho)
+	skipped bool   // linha de sublinhado setext já consumida
+}
+
+// SplitSections divide um manuscrito Markdown em seções estruturais.
+//
+// Reconhece títulos ATX (#) e setext, rótulos "Chapter N" / "Capítulo N",
+// numerais romanos e divisores de parte, mesmo sem marcação Markdown.
+// Prefácio, apresentação, agradecimentos, apêndices e glossário são
+// classificados em BookSection.Type. O título da seção sai do conteúdo, e os
+// subtítulos internos são renivelados para começar em "##".
+func SplitSections(markdown string) []BookSection {
+	lines := scanMarkdown(stripFrontMatter(strings.ReplaceAll(markdown, "\r\n", "\n")))
+	chapterLevel, titleIndex := detectChapterLevel(lines)
+
+	var sections []BookSection
+	var current *BookSection
+	var body strings.Builder
+	chapters, appendices, parts := 0, 0, 0
+
+	flush := func() {
+		content := strings.Trim(body.String(), "\n")
+		body.Reset()
+
+		if current == nil {
+			if strings.TrimSpace(content) == "" {
+				return
+			}
+			// Texto antes do primeiro título vira uma seção sem título
+			current = &BookSection{Type: SectionChapter}
+		}
+		current.Content = content
+		sections = append(sections, *current)
+		current = nil
+	}
+
+	for i, line := range lines {
+		if line.skipped || i == titleIndex {
+			continue
+		}
+
+		structural := line.bare || (line.level > 0 && line.level <= chapterLevel)
+		if !structural {
+			if line.level > 0 {
+				// Subtítulo interno: renivela para começar em "##"
+				level := line.level - chapterLevel + 1
+				if level < 2 {
+					level = 2
+				}
+				fmt.Fprintf(&body, "%s %s\n", strings.Repeat("#", level), line.title)
+				continue
+			}
+			body.WriteString(line.text)
+			body.WriteString("\n")
+			continue
+		}
+
+		flush()
+
+		section := BookSection{Title: line.title, Type: classifySection(line.title)}
+		switch section.Type {
+		case SectionPart:
+			parts++
+			section.Number = parts
+		case SectionChapter:
+			chapters++
+			section.Number = chapters
+		case SectionAppendix:
+			appendices++
+			section.Number = appendices
+		}
+		current = &section
+	}
+	flush()
+
+	if len(sections) == 0 {
+		return []BookSection{{Type: SectionChapter, Number: 1, Content: strings.TrimSpace(markdown)}}
+	}
+	return sections
+}
+
+// scanMarkdown classifica as linhas do manuscrito, ignorando blocos de código
+func scanMarkdown(markdown string) []markdownLine {
+	raw := strings.Split(markdown, "\n")
+	lines := make([]markdownLine, len(raw))
+	inFence := false
+
+	blank := func(i int) bool {
+		return i < 0 || i >= len(raw) || strings.TrimSpace(raw[i]) == ""
+	}
+
+	for i, text := range raw {
+		lines[i].text = text
+		if lines[i].skipped {
+			continue
+		}
+
+		if fenceDelimiter.MatchString(text) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" {
+			continue
+		}
+
+		if m := atxHeading.FindStringSubmatch(text); m != nil {
+			lines[i].level = len(m[1])
+			lines[i].title = cleanHeading(m[2])
+			continue
+		}
+
+		// Setext: parágrafo de uma linha sublinhado por === ou ---
+		if blank(i-1) && i+1 < len(raw) && !strings.HasPrefix(text, "    ") {
+			if setextH1.MatchString(raw[i+1]) {
+				lines[i].level, lines[i].title = 1, cleanHeading(trimmed)
+				lines[i+1].skipped = true
+				continue
+			}
+			if setextH2.MatchString(raw[i+1]) && !strings.HasPrefix(trimmed, "-") {
+				lines[i].level, lines[i].title = 2, cleanHeading(trimmed)
+				lines[i+1].skipped = true
+				continue
+			}
+		}
+
+		// Rótulo estrutural isolado ("Capítulo 1", "PARTE II", "IV")
+		if blank(i-1) && blank(i+1) && len(trimmed) <= 80 {
+			label := strings.Trim(trimmed, "*_")
+			if IsChapterHeading(label) || IsPartHeading(label) {
+				lines[i].bare = true
+				lines[i].title = label
+			}
+		}
+	}
+
+	return lines
+}
+
+// detectChapterLevel escolhe o nível de título que abre capítulos.
+// Retorna também o índice de um título de livro a descartar (-1 se não houver):
+// um único título no nível mais alto, no início do texto, seguido de títulos
+// mais baixos, é o título da obra e não um capítulo.
+func detectChapterLevel(lines []markdownLine) (int, int) {
+	minLevel, labeledLevel := 7, 7
+	counts := make(map[int]int)
+	first := -1
+
+	for i, line := range lines {
+		if line.level == 0 {
+			if first == -1 && strings.TrimSpace(line.text) != "" && !line.skipped {
+				first = -2 // texto antes de qualquer título
+			}
+			continue
+		}
+		if first == -1 {
+			first = i
+		}
+		counts[line.level]++
+		if line.level < minLevel {
+			minLevel = line.level
+		}
+		if IsChapterHeading(line.title) && line.level < labeledLevel {
+			labeledLevel = line.level
+		}
+	}
+
+	if minLevel == 7 {
+		return 1, -1
+	}
+
+	// Título de obra: único no seu nível, no início e acima dos capítulos
+	isBookTitle := func(chapterLevel int) bool {
+		return first >= 0 && lines[first].level < chapterLevel &&
+			counts[lines[first].level] == 1 && !IsPartHeading(lines[first].title)
+	}
+
+	if labeledLevel < 7 {
+		if isBookTitle(labeledLevel) {
+			return labeledLevel, first
+		}
+		return labeledLevel, -1
+	}
+
+	for level := minLevel + 1; level <= 6; level++ {
+		if counts[level] > 0 {
+			if isBookTitle(level) {
+				return level, first
+			}
+			break
+		}
+	}
+
+	return minLevel, -1
+}
+
+// stripFrontMatter remove o bloco de metadados YAML do início do manuscrito
+func stripFrontMatter(markdown string) string {
+	if !strings.HasPrefix(markdown, "---\n") {
+		return markdown
+	}
+
+	rest := markdown[len("---\n"):]
+	for _, closing := range []string{"\n---\n", "\n...\n"} {
+		if i := strings.Index(rest, closing); i >= 0 {
+			return rest[i+len(closing):]
+		}
+	}
+	return markdown
+}
+
+// cleanHeading remove atributos Pandoc ("{#id .class}") e espaços
+func cleanHeading(title string) string {
+	return strings.TrimSpace(headingAttrs.ReplaceAllString(strings.TrimSpace(title), ""))
+}
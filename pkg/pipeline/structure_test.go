@@ -0,0 +1,179 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitSections_MarkdownHeadings(t *testing.T) {
+	markdown := "# Um\n\nTexto um.\n\n## Seção\n\nMais.\n\n# Dois\n\nTexto dois.\n"
+
+	sections := SplitSections(markdown)
+
+	if len(sections) != 2 {
+		t.Fatalf("Esperado 2 seções, obtido %d", len(sections))
+	}
+	if sections[0].Title != "Um" || sections[0].Type != SectionChapter || sections[0].Number != 1 {
+		t.Errorf("Primeira seção inesperada: %+v", sections[0])
+	}
+	if !strings.Contains(sections[0].Content, "## Seção") {
+		t.Errorf("Subtítulo deveria permanecer no conteúdo: %q", sections[0].Content)
+	}
+	if strings.Contains(sections[0].Content, "# Um") {
+		t.Error("Título do capítulo não deveria estar no conteúdo")
+	}
+	if sections[1].Number != 2 {
+		t.Errorf("Esperado capítulo 2, obtido %d", sections[1].Number)
+	}
+}
+
+func TestSplitSections_BookTitleAndShiftedLevels(t *testing.T) {
+	markdown := "# Meu Livro\n\n## Capítulo 1\n\nTexto.\n\n### Detalhe\n\nMais.\n\n## Capítulo 2\n\nFim.\n"
+
+	sections := SplitSections(markdown)
+
+	if len(sections) != 2 {
+		t.Fatalf("Esperado 2 seções, obtido %d: %+v", len(sections), sections)
+	}
+	if sections[0].Title != "Capítulo 1" {
+		t.Errorf("Título do livro não deveria virar capítulo: %q", sections[0].Title)
+	}
+	if !strings.Contains(sections[0].Content, "## Detalhe") {
+		t.Errorf("Subtítulo deveria ser renivelado para ##: %q", sections[0].Content)
+	}
+}
+
+func TestSplitSections_BareLabelsAndParts(t *testing.T) {
+	markdown := "PARTE I\n\nCapítulo 1\n\nEra uma vez.\n\nCapítulo 2\n\nContinua.\n\nPart Two\n\nIII\n\nFim.\n"
+
+	sections := SplitSections(markdown)
+
+	expected := []struct {
+		title       string
+		sectionType string
+	}{
+		{"PARTE I", SectionPart},
+		{"Capítulo 1", SectionChapter},
+		{"Capítulo 2", SectionChapter},
+		{"Part Two", SectionPart},
+		{"III", SectionChapter},
+	}
+
+	if len(sections) != len(expected) {
+		t.Fatalf("Esperado %d seções, obtido %d: %+v", len(expected), len(sections), sections)
+	}
+	for i, want := range expected {
+		if sections[i].Title != want.title || sections[i].Type != want.sectionType {
+			t.Errorf("Seção %d: obtido (%q, %s), esperado (%q, %s)",
+				i, sections[i].Title, sections[i].Type, want.title, want.sectionType)
+		}
+	}
+	if sections[3].Number != 2 || sections[4].Number != 3 {
+		t.Errorf("Numeração inesperada: parte %d, capítulo %d", sections[3].Number, sections[4].Number)
+	}
+}
+
+func TestSplitSections_FrontAndBackMatter(t *testing.T) {
+	markdown := "# Prefácio\n\nTexto.\n\n# Agradecimentos\n\nObrigado.\n\n# Chapter One\n\nStory.\n\n# Appendix A: Tables\n\nData.\n\n# Glossário\n\nTermos.\n"
+
+	sections := SplitSections(markdown)
+
+	types := []string{SectionPreface, SectionAcknowledgements, SectionChapter, SectionAppendix, SectionGlossary}
+	if len(sections) != len(types) {
+		t.Fatalf("Esperado %d seções, obtido %d", len(types), len(sections))
+	}
+	for i, want := range types {
+		if sections[i].Type != want {
+			t.Errorf("Seção %d: tipo %s, esperado %s", i, sections[i].Type, want)
+		}
+	}
+
+	if !sections[0].IsFrontMatter() || sections[2].IsFrontMatter() {
+		t.Error("Classificação de front matter incorreta")
+	}
+	if !sections[3].IsBackMatter() || !sections[4].IsBackMatter() {
+		t.Error("Classificação de back matter incorreta")
+	}
+}
+
+func TestSplitSections_IgnoresCodeAndFrontMatter(t *testing.T) {
+	markdown := "---\ntitle: Livro\n---\n\n# Um\n\n```\n# não é título\n```\n\nSetext\n======\n\nTexto.\n"
+
+	sections := SplitSections(markdown)
+
+	if len(sections) != 2 {
+		t.Fatalf("Esperado 2 seções, obtido %d: %+v", len(sections), sections)
+	}
+	if !strings.Contains(sections[0].Content, "# não é título") {
+		t.Error("Conteúdo de bloco de código deveria ser preservado")
+	}
+	if sections[1].Title != "Setext" {
+		t.Errorf("Título setext não reconhecido: %q", sections[1].Title)
+	}
+	if strings.Contains(sections[0].Content, "title: Livro") {
+		t.Error("Metadados YAML deveriam ser removidos")
+	}
+}
+
+func TestSplitSections_NoHeadings(t *testing.T) {
+	sections := SplitSections("Apenas um parágrafo.\n\nE outro.")
+
+	if len(sections) != 1 {
+		t.Fatalf("Esperado 1 seção, obtido %d", len(sections))
+	}
+	if sections[0].Type != SectionChapter || sections[0].Title != "" {
+		t.Errorf("Seção inesperada: %+v", sections[0])
+	}
+}
+
+func TestSplitSections_IgnoresWordsThatLookRoman(t *testing.T) {
+	markdown := "Capítulo 1\n\nQuem fez isso?\nI.\nFui eu.\n\nDid\n\nmix\n\nCIVIL\n\nIIII\n\nBook did well that year.\n\nPart mix of both.\n\nO fim.\n"
+
+	sections := SplitSections(markdown)
+
+	if len(sections) != 1 || sections[0].Title != "Capítulo 1" {
+		t.Fatalf("Esperado apenas o Capítulo 1, obtido %d seções: %+v", len(sections), sections)
+	}
+	for _, line := range []string{"I.", "Did", "mix", "CIVIL", "IIII", "Book did well", "Part mix"} {
+		if !strings.Contains(sections[0].Content, line) {
+			t.Errorf("%q deveria continuar no texto do capítulo", line)
+		}
+	}
+}
+
+func TestIsChapterHeading_RomanNumerals(t *testing.T) {
+	tests := []struct {
+		title string
+		want  bool
+	}{
+		{"IV", true},
+		{"XII.", true},
+		{"MCMXCIX", true},
+		{"Chapter XIV", true},
+		{"CAPÍTULO IX: O Retorno", true},
+		{"I", true},
+		{"iv", false},
+		{"Did", false},
+		{"mix", false},
+		{"CIVIL", false},
+		{"IIII", false},
+		{"VX", false},
+		{"Chapter did", false},
+		{"Chapter MIM", false},
+		{"Capítulo civil", false},
+	}
+	for _, tt := range tests {
+		if got := IsChapterHeading(tt.title); got != tt.want {
+			t.Errorf("IsChapterHeading(%q) = %v, esperado %v", tt.title, got, tt.want)
+		}
+	}
+
+	for _, title := range []string{"Book did", "Part mix", "Livro DID", "Parte IIII"} {
+		if IsPartHeading(title) {
+			t.Errorf("IsPartHeading(%q) deveria ser falso", title)
+		}
+	}
+	if !IsPartHeading("Parte II") {
+		t.Error("IsPartHeading(\"Parte II\") deveria ser verdadeiro")
+	}
+}
@@ -0,0 +1,28 @@
+// Package process executa ferramentas externas (lualatex, pandoc, pagedjs-cli)
+// de forma cancelável: ao cancelar o contexto, toda a árvore de processos é
+// encerrada, não apenas o processo filho direto. Exec e CombinedOutput
+// registram cada execução (comando, duração, exit code e saídas) no Recorder
+// do contexto, quando houver.
+package process
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// waitDelay é o tempo máximo aguardando os pipes de saída após o cancelamento
+const waitDelay = 5 * time.Second
+
+// CommandContext cria um comando vinculado ao contexto.
+// O processo roda em um grupo próprio; quando o contexto é cancelado o grupo
+// inteiro é morto (npx → node → chromium, lualatex → luaotfload, ...).
+func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package process
+
+import "os/exec"
+
+// setProcessGroup não tem equivalente portátil fora de sistemas unix
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup mata apenas o processo filho direto
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
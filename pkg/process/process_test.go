@@ -0,0 +1,63 @@
+//go:build unix
+
+package process
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCommandContext_KillsProcessTree(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// O shell inicia um neto em segundo plano e espera por ele
+	cmd := CommandContext(ctx, "sh", "-c", "sleep 60 & echo $! > "+pidFile+"; wait")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Falha iniciar comando: %v", err)
+	}
+
+	var grandchild int
+	for i := 0; i < 50 && grandchild == 0; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if data, err := os.ReadFile(pidFile); err == nil {
+			grandchild, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+		}
+	}
+	if grandchild == 0 {
+		t.Fatal("Processo neto não iniciou")
+	}
+
+	cancel()
+	if err := cmd.Wait(); err == nil {
+		t.Error("Esperado erro após cancelamento")
+	}
+
+	// O neto deve ter sido morto junto com o grupo
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if err := syscall.Kill(grandchild, 0); errors.Is(err, syscall.ESRCH) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	syscall.Kill(grandchild, syscall.SIGKILL)
+	t.Errorf("Processo neto %d sobreviveu ao cancelamento", grandchild)
+}
+
+func TestCommandContext_CompletesNormally(t *testing.T) {
+	output, err := CommandContext(context.Background(), "echo", "ok").Output()
+	if err != nil {
+		t.Fatalf("Falha executar comando: %v", err)
+	}
+	if strings.TrimSpace(string(output)) != "ok" {
+		t.Errorf("Saída inesperada: %q", output)
+	}
+}
@@ -0,0 +1,200 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MaxCapturedOutput limita quanto de stdout/stderr é guardado por execução.
+// Quando excedido, mantém-se o final da saída, onde as ferramentas costumam
+// relatar o erro.
+const MaxCapturedOutput = 1 << 20
+
+// Run descreve uma execução de ferramenta externa
+type Run struct {
+	Command   []string          `json:"command"`
+	Dir       string            `json:"dir,omitempty"`
+	StartedAt time.Time         `json:"started_at"`
+	Duration  time.Duration     `json:"duration"`
+	ExitCode  int               `json:"exit_code"` // -1 se o processo não terminou sozinho
+	Error     string            `json:"error,omitempty"`
+	Stdout    string            `json:"stdout"`
+	Stderr    string            `json:"stderr"`
+	Files     map[string]string `json:"files,omitempty"` // arquivos de log gerados pela ferramenta
+	Truncated bool              `json:"truncated,omitempty"`
+}
+
+// Recorder recebe cada execução concluída
+type Recorder interface {
+	Record(run *Run)
+}
+
+// RecorderFunc adapta uma função a Recorder
+type RecorderFunc func(run *Run)
+
+// Record chama f(run)
+func (f RecorderFunc) Record(run *Run) {
+	f(run)
+}
+
+type recorderKey struct{}
+
+// WithRecorder retorna um contexto cujas execuções (Exec, CombinedOutput)
+// são registradas em recorder
+func WithRecorder(ctx context.Context, recorder Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// RecorderFrom retorna o Recorder do contexto, ou nil
+func RecorderFrom(ctx context.Context) Recorder {
+	recorder, _ := ctx.Value(recorderKey{}).(Recorder)
+	return recorder
+}
+
+// ExecOption configura Exec e CombinedOutput
+type ExecOption func(*execConfig)
+
+type execConfig struct {
+	logFiles []string
+}
+
+// WithLogFile anexa ao registro o conteúdo de um arquivo de log escrito pela
+// ferramenta (ex.: document.log do LaTeX), lido ao fim da execução
+func WithLogFile(path string) ExecOption {
+	return func(c *execConfig) {
+		c.logFiles = append(c.logFiles, path)
+	}
+}
+
+// Exec executa o comando como cmd.Run e, se o contexto tiver um Recorder,
+// registra linha de comando, duração, exit code, stdout e stderr.
+// Stdout e Stderr já configurados no comando continuam recebendo a saída.
+func Exec(ctx context.Context, cmd *exec.Cmd, opts ...ExecOption) error {
+	recorder := RecorderFrom(ctx)
+	if recorder == nil {
+		return cmd.Run()
+	}
+
+	var config execConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	stdout := &tailBuffer{limit: MaxCapturedOutput}
+	stderr := &tailBuffer{limit: MaxCapturedOutput}
+	cmd.Stdout = teeWriter(cmd.Stdout, stdout)
+	cmd.Stderr = teeWriter(cmd.Stderr, stderr)
+
+	start := time.Now()
+	err := cmd.Run()
+
+	run := &Run{
+		Command:   cmd.Args,
+		Dir:       cmd.Dir,
+		StartedAt: start,
+		Duration:  time.Since(start),
+		ExitCode:  exitCode(cmd, err),
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	for _, path := range config.logFiles {
+		if data, readErr := os.ReadFile(path); readErr == nil {
+			if run.Files == nil {
+				run.Files = make(map[string]string)
+			}
+			run.Files[filepath.Base(path)] = string(tail(data, MaxCapturedOutput))
+		}
+	}
+
+	recorder.Record(run)
+	return err
+}
+
+// CombinedOutput executa o comando como cmd.CombinedOutput, registrando a
+// execução como Exec (com stdout e stderr separados)
+func CombinedOutput(ctx context.Context, cmd *exec.Cmd, opts ...ExecOption) ([]byte, error) {
+	// Com o tee, stdout e stderr escrevem de goroutines diferentes
+	combined := &lockedBuffer{}
+	cmd.Stdout = combined
+	cmd.Stderr = combined
+	err := Exec(ctx, cmd, opts...)
+	return combined.Bytes(), err
+}
+
+// lockedBuffer é um bytes.Buffer seguro para escritas concorrentes
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
+
+// exitCode retorna o código de saída, ou -1 se o processo não terminou
+// normalmente (não iniciou, foi morto por sinal ou cancelado)
+func exitCode(cmd *exec.Cmd, err error) int {
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return -1
+	}
+	if cmd.ProcessState == nil {
+		return -1
+	}
+	return cmd.ProcessState.ExitCode()
+}
+
+// teeWriter acrescenta capture a um destino já configurado
+func teeWriter(existing io.Writer, capture io.Writer) io.Writer {
+	if existing == nil {
+		return capture
+	}
+	return io.MultiWriter(existing, capture)
+}
+
+// tailBuffer guarda os últimos limit bytes escritos
+type tailBuffer struct {
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = append([]byte(nil), tail(b.buf, b.limit)...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
+
+// tail retorna os últimos limit bytes de data
+func tail(data []byte, limit int) []byte {
+	if len(data) <= limit {
+		return data
+	}
+	return data[len(data)-limit:]
+}
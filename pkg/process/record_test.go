@@ -0,0 +1,101 @@
+//go:build unix
+
+package process
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runs coleta as execuções registradas
+type runs []*Run
+
+func (r *runs) Record(run *Run) {
+	*r = append(*r, run)
+}
+
+func TestExec_RecordsRun(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "document.log")
+
+	var recorded runs
+	ctx := WithRecorder(context.Background(), &recorded)
+
+	var stderr strings.Builder
+	cmd := CommandContext(ctx, "sh", "-c", "echo saida; echo '! Undefined control sequence.' >&2; echo log > document.log; exit 3")
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+
+	if err := Exec(ctx, cmd, WithLogFile(logPath)); err == nil {
+		t.Fatal("Esperado erro com exit 3")
+	}
+
+	if len(recorded) != 1 {
+		t.Fatalf("Esperada 1 execução registrada, obtido %d", len(recorded))
+	}
+	run := recorded[0]
+	if run.ExitCode != 3 || run.Error == "" || run.Dir != dir {
+		t.Errorf("Execução inesperada: %+v", run)
+	}
+	if strings.Join(run.Command, " ") != "sh -c "+cmd.Args[2] {
+		t.Errorf("Linha de comando inesperada: %v", run.Command)
+	}
+	if run.Stdout != "saida\n" || run.Stderr != "! Undefined control sequence.\n" {
+		t.Errorf("Saídas inesperadas: stdout=%q stderr=%q", run.Stdout, run.Stderr)
+	}
+	if run.Files["document.log"] != "log\n" {
+		t.Errorf("Esperado document.log anexado, obtido %v", run.Files)
+	}
+	// O Stderr configurado pelo chamador continua recebendo a saída
+	if stderr.String() != run.Stderr {
+		t.Errorf("Stderr do chamador perdeu a saída: %q", stderr.String())
+	}
+}
+
+func TestExec_WithoutRecorder(t *testing.T) {
+	cmd := CommandContext(context.Background(), "true")
+	if err := Exec(context.Background(), cmd); err != nil {
+		t.Fatalf("Falha executar comando: %v", err)
+	}
+}
+
+func TestExec_StartFailure(t *testing.T) {
+	var recorded runs
+	ctx := WithRecorder(context.Background(), &recorded)
+
+	if err := Exec(ctx, CommandContext(ctx, filepath.Join(t.TempDir(), "inexistente"))); err == nil {
+		t.Fatal("Esperado erro para comando inexistente")
+	}
+	if len(recorded) != 1 || recorded[0].ExitCode != -1 {
+		t.Errorf("Esperada execução com exit code -1, obtido %+v", recorded)
+	}
+}
+
+func TestCombinedOutput_RecordsStreamsSeparately(t *testing.T) {
+	var recorded runs
+	ctx := WithRecorder(context.Background(), &recorded)
+
+	output, err := CombinedOutput(ctx, CommandContext(ctx, "sh", "-c", "echo out; echo err >&2"))
+	if err != nil {
+		t.Fatalf("Falha executar comando: %v", err)
+	}
+
+	if !strings.Contains(string(output), "out") || !strings.Contains(string(output), "err") {
+		t.Errorf("Saída combinada incompleta: %q", output)
+	}
+	if len(recorded) != 1 || recorded[0].Stdout != "out\n" || recorded[0].Stderr != "err\n" || recorded[0].ExitCode != 0 {
+		t.Errorf("Execução inesperada: %+v", recorded)
+	}
+}
+
+func TestTailBuffer_KeepsEnd(t *testing.T) {
+	buf := &tailBuffer{limit: 8}
+	buf.Write([]byte("inicio-"))
+	buf.Write([]byte("erro final"))
+
+	if buf.String() != "ro final" || !buf.truncated {
+		t.Errorf("Esperado final da saída, obtido %q (truncated %v)", buf.String(), buf.truncated)
+	}
+}
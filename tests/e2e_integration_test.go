@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 	"time"
@@ -20,6 +21,7 @@ func TestE2E_CompleteBookGeneration(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping E2E test in short mode")
 	}
+	requireRenderTools(t, "pandoc", "lualatex")
 
 	ctx := context.Background()
 	
@@ -95,6 +97,7 @@ func TestE2E_LaTeXPipeline(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping E2E test in short mode")
 	}
+	requireRenderTools(t, "pandoc", "lualatex")
 
 	ctx := context.Background()
 	tmpDir := t.TempDir()
@@ -191,6 +194,7 @@ func TestE2E_CustomDesign(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping E2E test in short mode")
 	}
+	requireRenderTools(t, "pandoc", "lualatex")
 
 	ctx := context.Background()
 	tmpDir := t.TempDir()
@@ -254,6 +258,7 @@ func TestE2E_MultipleFormats(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping E2E test in short mode")
 	}
+	requireRenderTools(t, "pandoc", "lualatex")
 
 	ctx := context.Background()
 	tmpDir := t.TempDir()
@@ -309,6 +314,16 @@ func TestE2E_MultipleFormats(t *testing.T) {
 
 // Helper functions
 
+// requireRenderTools skips the test when an external renderer is not installed
+func requireRenderTools(t *testing.T, tools ...string) {
+	t.Helper()
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not available on system", tool)
+		}
+	}
+}
+
 func validateGenerationResult(t *testing.T, result *service.GenerationResult, tmpDir string) {
 	t.Helper()
 